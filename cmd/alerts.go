@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type suggestAlertsOptions struct {
+	Options
+	Job      string
+	Headroom float64
+	Top      int
+	For      time.Duration
+	Severity string
+}
+
+func (o *suggestAlertsOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("job", "Job label of the target in Prometheus").
+		Required().
+		StringVar(&o.Job)
+
+	app.Flag("headroom", "Growth over the observed baseline tolerated before alerting, in percent").
+		Default("50").
+		Float64Var(&o.Headroom)
+
+	app.Flag("top", "Number of metric families, the largest first, that get a cardinality alert of their own").
+		Default("5").
+		IntVar(&o.Top)
+
+	app.Flag("for", "How long a condition must hold before the alert fires").
+		Default("15m").
+		DurationVar(&o.For)
+
+	app.Flag("severity", "Severity label of the alerts, none when empty").
+		Default("warning").
+		StringVar(&o.Severity)
+}
+
+func registerSuggestAlertsCommand(app *extkingpin.App) {
+	cmd := app.Command("suggest-alerts", "Generate Prometheus alerting rules on the cardinality of a target, "+
+		"calibrated from its observed scrape.")
+	opts := &suggestAlertsOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		if opts.Headroom < 0 || opts.Top < 0 {
+			return errors.New("--headroom and --top must not be negative")
+		}
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			rules := scrape.SuggestAlerts(result, scrape.AlertOptions{
+				Job:         opts.Job,
+				Headroom:    opts.Headroom / 100,
+				TopFamilies: opts.Top,
+				For:         opts.For,
+				Severity:    opts.Severity,
+			})
+			enc := yaml.NewEncoder(os.Stdout)
+			enc.SetIndent(2)
+			if err := enc.Encode(rules); err != nil {
+				return errors.Wrap(err, "failed to write the rules")
+			}
+			return enc.Close()
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type bandwidthOptions struct {
+	Options
+	ScrapeInterval    time.Duration
+	MaxSamplesPerSend int
+	Top               int
+}
+
+func (o *bandwidthOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("scrape-interval", "Interval the target is scraped at by the server writing to the remote storage").
+		Default("1m").
+		DurationVar(&o.ScrapeInterval)
+
+	app.Flag("max-samples-per-send", "max_samples_per_send of the remote write queue, the batch size of requests").
+		Default(fmt.Sprint(scrape.DefaultMaxSamplesPerSend)).
+		IntVar(&o.MaxSamplesPerSend)
+
+	app.Flag("top", "Number of metric families to list, the largest first, 0 for all").
+		Default("20").
+		IntVar(&o.Top)
+}
+
+func registerBandwidthCommand(app *extkingpin.App) {
+	cmd := app.Command("bandwidth", "Estimate the compressed remote write traffic the series of a target generate, "+
+		"by building and snappy compressing the write requests.")
+	opts := &bandwidthOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		if opts.ScrapeInterval <= 0 {
+			return errors.New("--scrape-interval must be positive")
+		}
+		if opts.MaxSamplesPerSend < 1 {
+			return errors.New("--max-samples-per-send must be at least 1")
+		}
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			report := scrape.EstimateRemoteWrite(result.Series, result.Time, opts.ScrapeInterval,
+				opts.MaxSamplesPerSend)
+			return printBandwidthReport(os.Stdout, report, opts.Top)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printBandwidthReport(out io.Writer, report scrape.RemoteWriteReport, top int) error {
+	total := report.Total
+	fmt.Fprintf(out, "%d series scraped every %s, %d write request(s) of up to %d samples per scrape\n",
+		total.Series, report.Interval, report.Requests, report.MaxSamplesPerSend)
+	fmt.Fprintf(out, "%s per scrape, %s uncompressed, %s/s, %s a day\n\n",
+		units.BytesSize(float64(total.CompressedBytes)), units.BytesSize(float64(total.UncompressedBytes)),
+		units.BytesSize(total.BytesPerSecond), units.BytesSize(total.BytesPerSecond*(24*time.Hour).Seconds()))
+
+	families := report.Families
+	if top > 0 && len(families) > top {
+		families = families[:top]
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tSERIES\tCOMPRESSED\tUNCOMPRESSED\tRATE\tBYTES/SERIES")
+	for _, f := range families {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s/s\t%.1f\n", f.Name, f.Series,
+			units.BytesSize(float64(f.CompressedBytes)), units.BytesSize(float64(f.UncompressedBytes)),
+			units.BytesSize(f.BytesPerSecond), float64(f.CompressedBytes)/float64(max(f.Series, 1)))
+	}
+	return tw.Flush()
+}
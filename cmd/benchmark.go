@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type benchmarkOptions struct {
+	Options
+	Scrapes     int
+	Concurrency int
+}
+
+func (o *benchmarkOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+
+	app.Flag("scrapes", "Number of scrapes to perform").
+		Default("20").
+		IntVar(&o.Scrapes)
+
+	app.Flag("concurrency", "Number of scrapes in flight at once").
+		Default("1").
+		IntVar(&o.Concurrency)
+}
+
+func registerBenchmarkCommand(app *extkingpin.App) {
+	cmd := app.Command("benchmark", "Scrape the target repeatedly and report response time, body size and parse time.")
+	opts := &benchmarkOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		if opts.Scrapes < 1 {
+			return errors.New("--scrapes must be at least 1")
+		}
+		scraper, err := opts.NewScraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			start := time.Now()
+			samples := scrape.Benchmark(ctx, scraper, opts.Scrapes, opts.Concurrency)
+			report := scrape.SummarizeBenchmark(samples)
+			if err := printBenchmark(os.Stdout, report, time.Since(start)); err != nil {
+				return err
+			}
+			if report.Failures > 0 && report.Failures == report.Scrapes {
+				return errors.Wrap(samples[0].Err, "all scrapes failed")
+			}
+			return nil
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printBenchmark(out io.Writer, report scrape.BenchmarkReport, elapsed time.Duration) error {
+	fmt.Fprintf(out, "%d scrapes, %d failed, in %s (%.1f scrapes/s)\n\n",
+		report.Scrapes, report.Failures, elapsed.Round(time.Millisecond),
+		float64(report.Scrapes)/elapsed.Seconds())
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PHASE\tMIN\tMEAN\tP50\tP90\tP99\tMAX")
+	for _, phase := range []struct {
+		name    string
+		summary scrape.LatencySummary
+	}{
+		{name: "response", summary: report.Response},
+		{name: "parse", summary: report.Parse},
+	} {
+		s := phase.summary
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", phase.name,
+			formatLatency(s.Min), formatLatency(s.Mean), formatLatency(s.P50),
+			formatLatency(s.P90), formatLatency(s.P99), formatLatency(s.Max))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTENT TYPE\tSCRAPES\tBODY SIZE\tTRANSFERRED\tCOMPRESSION")
+	for _, ct := range report.ContentTypes {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.1fx\n", ct.ContentType, ct.Scrapes,
+			units.BytesSize(float64(ct.AvgBodyBytes)), units.BytesSize(float64(ct.AvgWireBytes)),
+			ct.CompressionRatio())
+	}
+	return tw.Flush()
+}
+
+// formatLatency rounds durations to a readable precision.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime/pprof"
+	"text/tabwriter"
+
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type benchParseOptions struct {
+	File        string
+	InputFormat string
+	Iterations  int
+	CPUProfile  string
+	MemProfile  string
+}
+
+func (o *benchParseOptions) addFlags(app extkingpin.AppClause) {
+	app.Flag("file", "Exposition to parse, a file path or - for stdin").
+		Required().
+		StringVar(&o.File)
+
+	app.Flag("input-format", "Format of the file, auto detects it from the content").
+		Default(formatAuto).
+		EnumVar(&o.InputFormat, formatAuto, formatText, formatOpenMetrics, formatProtobuf)
+
+	app.Flag("iterations", "Number of times the exposition is parsed with each parser").
+		Default("10").
+		IntVar(&o.Iterations)
+
+	app.Flag("cpu-profile", "File to write a CPU profile of the parsing to, for go tool pprof").
+		StringVar(&o.CPUProfile)
+
+	app.Flag("mem-profile", "File to write a heap profile to once every parser ran, for go tool pprof").
+		StringVar(&o.MemProfile)
+}
+
+// registerBenchParseCommand registers bench-parse, hidden as it measures the analyzer itself rather than a target,
+// e.g. to compare the parsers across upgrades of the Prometheus libraries.
+func registerBenchParseCommand(app *extkingpin.App, kp *kingpin.Application) {
+	cmd := app.Command("bench-parse", "Parse an exposition repeatedly with each parser and report the time and "+
+		"allocations per series and the peak RSS of the analyzer.")
+	// extkingpin does not expose hiding commands.
+	kp.GetCommand("bench-parse").Hidden()
+	opts := &benchParseOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		_ *prometheus.Registry,
+		_ opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		if opts.Iterations < 1 {
+			return errors.New("--iterations must be at least 1")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			var (
+				body []byte
+				err  error
+			)
+			if opts.File == "-" {
+				body, err = io.ReadAll(os.Stdin)
+			} else {
+				body, err = os.ReadFile(opts.File)
+			}
+			if err != nil {
+				return errors.Wrap(err, "failed to read exposition")
+			}
+			contentType := inputContentType(opts.InputFormat, "", body)
+
+			if opts.CPUProfile != "" {
+				f, err := os.Create(opts.CPUProfile)
+				if err != nil {
+					return errors.Wrap(err, "failed to create CPU profile")
+				}
+				defer f.Close()
+				if err := pprof.StartCPUProfile(f); err != nil {
+					return errors.Wrap(err, "failed to start CPU profile")
+				}
+				defer pprof.StopCPUProfile()
+			}
+
+			parser := scrape.NewPromScraper("", log.NewNopLogger())
+			benchmarks, err := scrape.BenchmarkParsers(ctx, parser, body, contentType, opts.Iterations)
+			if err != nil {
+				return err
+			}
+
+			if opts.MemProfile != "" {
+				if err := writeHeapProfile(opts.MemProfile); err != nil {
+					return err
+				}
+			}
+			level.Info(logger).Log("msg", "parsed exposition", "content_type", contentType, "bytes", len(body))
+			return printParseBenchmarks(os.Stdout, benchmarks)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to create heap profile")
+	}
+	defer f.Close()
+	return errors.Wrap(pprof.WriteHeapProfile(f), "failed to write heap profile")
+}
+
+func printParseBenchmarks(out io.Writer, benchmarks []scrape.ParseBenchmark) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTENT TYPE\tBODY SIZE\tSERIES\tITERATIONS\tNS/SERIES\tALLOCS/SERIES\tBYTES/SERIES")
+	for _, b := range benchmarks {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.0f\t%.1f\t%.0f\n", b.ContentType,
+			units.BytesSize(float64(b.BodyBytes)), b.Series, b.Iterations,
+			b.NsPerSeries, b.AllocsPerSeries, b.BytesPerSeries)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if rss, ok := peakRSS(); ok {
+		fmt.Fprintf(out, "\nPeak RSS: %s\n", units.BytesSize(float64(rss)))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// exploreRange is the time range the metric is graphed over.
+const exploreRange = "1h"
+
+// grafanaExploreURL returns the Grafana Explore URL querying expr, on the given datasource if any.
+func grafanaExploreURL(base, datasource, expr string) (string, error) {
+	type query struct {
+		RefID string `json:"refId"`
+		Expr  string `json:"expr"`
+	}
+	return grafanaExplore(base, datasource, query{RefID: "A", Expr: expr})
+}
+
+// grafanaTraceURL returns the Grafana Explore URL of a trace in the given Tempo datasource.
+func grafanaTraceURL(base, datasource, traceID string) (string, error) {
+	type query struct {
+		RefID     string `json:"refId"`
+		QueryType string `json:"queryType"`
+		Query     string `json:"query"`
+	}
+	return grafanaExplore(base, datasource, query{RefID: "A", QueryType: "traceql", Query: traceID})
+}
+
+// grafanaExplore returns the Grafana Explore URL running the query on the datasource.
+func grafanaExplore(base, datasource string, query any) (string, error) {
+	state := struct {
+		Datasource string            `json:"datasource,omitempty"`
+		Queries    []any             `json:"queries"`
+		Range      map[string]string `json:"range"`
+	}{
+		Datasource: datasource,
+		Queries:    []any{query},
+		Range:      map[string]string{"from": "now-" + exploreRange, "to": "now"},
+	}
+	left, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(base, "/") + "/explore?" + url.Values{"left": {string(left)}}.Encode(), nil
+}
+
+// prometheusGraphURL returns the URL of the Prometheus expression browser graphing expr.
+func prometheusGraphURL(base, expr string) string {
+	params := url.Values{
+		"g0.expr":        {expr},
+		"g0.tab":         {"0"},
+		"g0.range_input": {exploreRange},
+	}
+	return strings.TrimSuffix(base, "/") + "/graph?" + params.Encode()
+}
+
+// openBrowser opens the URL with the platform's default browser, without waiting for it.
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+// openSelected opens the selected metric in Grafana Explore, or in the Prometheus UI when prometheus
+// is set, and returns the opened URL.
+func (m *seriesTable) openSelected(prometheus bool) (string, error) {
+	name := rowName(m.table.SelectedRow())
+	set, ok := m.seriesMap[name]
+	if !ok {
+		return "", errors.New("no metric selected")
+	}
+	expr := set.Selector(name)
+
+	var (
+		u   string
+		err error
+	)
+	switch {
+	case prometheus && m.prometheusURL != "":
+		u = prometheusGraphURL(m.prometheusURL, expr)
+	case prometheus:
+		return "", errors.New("--prometheus.url is not set")
+	case m.grafanaURL != "":
+		u, err = grafanaExploreURL(m.grafanaURL, m.grafanaDatasource, expr)
+	case m.prometheusURL != "":
+		u = prometheusGraphURL(m.prometheusURL, expr)
+	default:
+		return "", errors.New("neither --grafana.url nor --prometheus.url is set")
+	}
+	if err != nil {
+		return "", err
+	}
+	return u, openBrowser(u)
+}
+
+// jaegerTraceURL returns the URL of a trace in the Jaeger UI.
+func jaegerTraceURL(base, traceID string) string {
+	return strings.TrimSuffix(base, "/") + "/trace/" + url.PathEscape(traceID)
+}
+
+// openTrace opens the trace of the latest exemplar of the selected series and returns the opened URL.
+func (m *seriesTable) openTrace() (string, error) {
+	row := m.table.SelectedRow()
+	if len(row) <= seriesTraceColumn || row[seriesTraceColumn] == noTraceID {
+		return "", errors.New("no exemplar with a trace ID on the selected series")
+	}
+	traceID := row[seriesTraceColumn]
+	var (
+		u   string
+		err error
+	)
+	switch {
+	case m.jaegerURL != "":
+		u = jaegerTraceURL(m.jaegerURL, traceID)
+	case m.tempoDatasource != "" && m.grafanaURL != "":
+		u, err = grafanaTraceURL(m.grafanaURL, m.tempoDatasource, traceID)
+	case m.tempoDatasource != "":
+		return "", errors.Errorf("--tempo.datasource needs --grafana.url, trace ID %s", traceID)
+	default:
+		return "", errors.Errorf("neither --tempo.datasource nor --jaeger.url is set, trace ID %s", traceID)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := openBrowser(u); err != nil {
+		return "", errors.Wrapf(err, "open %s", u)
+	}
+	return u, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaTraceURL(t *testing.T) {
+	t.Parallel()
+	const left = `%7B%22datasource%22%3A%22tempo%22%2C%22queries%22%3A%5B%7B%22refId%22%3A%22A%22%2C%22queryType%22%3A` +
+		`%22traceql%22%2C%22query%22%3A%224bf92f3577b34da6a3ce929d0e0e4736%22%7D%5D%2C%22range%22%3A%7B%22from%22%3A` +
+		`%22now-1h%22%2C%22to%22%3A%22now%22%7D%7D`
+	for base, want := range map[string]string{
+		"http://grafana:3000":              "http://grafana:3000/explore?left=" + left,
+		"http://grafana:3000/":             "http://grafana:3000/explore?left=" + left,
+		"https://ops.example.com/grafana/": "https://ops.example.com/grafana/explore?left=" + left,
+	} {
+		got, err := grafanaTraceURL(base, "tempo", "4bf92f3577b34da6a3ce929d0e0e4736")
+		require.NoError(t, err)
+		require.Equal(t, want, got, "--grafana.url=%s", base)
+	}
+}
+
+// The query is JSON encoded in the left param, itself URL encoded, whatever it holds.
+func TestGrafanaExplore_Escaping(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		url      func() (string, error)
+		wantLeft string
+	}{
+		"traceql": {
+			url: func() (string, error) {
+				return grafanaTraceURL("https://ops.example.com/grafana", "tempo-uid", `{ span.path = "/a&b" }`)
+			},
+			wantLeft: `{"datasource":"tempo-uid","queries":[{"refId":"A","queryType":"traceql",` +
+				`"query":"{ span.path = \"/a\u0026b\" }"}],"range":{"from":"now-1h","to":"now"}}`,
+		},
+		"promql": {
+			url: func() (string, error) {
+				return grafanaExploreURL("https://ops.example.com/grafana/", "", `http_requests_total{path="/a b",code=~"5.."}`)
+			},
+			wantLeft: `{"queries":[{"refId":"A","expr":"http_requests_total{path=\"/a b\",code=~\"5..\"}"}],` +
+				`"range":{"from":"now-1h","to":"now"}}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tc.url()
+			require.NoError(t, err)
+			u, err := url.Parse(got)
+			require.NoError(t, err)
+			require.Equal(t, "/grafana/explore", u.Path)
+			require.Len(t, u.Query(), 1)
+			require.Equal(t, tc.wantLeft, u.Query().Get("left"))
+		})
+	}
+}
+
+func TestGrafanaExploreURL(t *testing.T) {
+	t.Parallel()
+	got, err := grafanaExploreURL("http://grafana:3000/", "prom", "up")
+	require.NoError(t, err)
+	require.Equal(t, "http://grafana:3000/explore?left=%7B%22datasource%22%3A%22prom%22%2C%22queries%22%3A%5B%7B"+
+		"%22refId%22%3A%22A%22%2C%22expr%22%3A%22up%22%7D%5D%2C%22range%22%3A%7B%22from%22%3A%22now-1h%22%2C%22to"+
+		"%22%3A%22now%22%7D%7D", got)
+}
+
+func TestJaegerTraceURL(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "http://jaeger:16686/trace/4bf92f3577b34da6",
+		jaegerTraceURL("http://jaeger:16686", "4bf92f3577b34da6"))
+	require.Equal(t, "https://ops.example.com/jaeger/trace/4bf92f3577b34da6",
+		jaegerTraceURL("https://ops.example.com/jaeger/", "4bf92f3577b34da6"))
+	require.Equal(t, "http://jaeger:16686/trace/a%2Fb", jaegerTraceURL("http://jaeger:16686/", "a/b"))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type capacityOptions struct {
+	Options
+	ScrapeIntervals     []time.Duration
+	Retention           time.Duration
+	Instances           int
+	BytesPerSample      float64
+	IndexBytesPerSeries float64
+	Top                 int
+}
+
+func (o *capacityOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("scrape-interval", "Interval the target is scraped at, repeat it to compare intervals, the metric "+
+		"families are listed for the first one").
+		Default("1m").
+		DurationListVar(&o.ScrapeIntervals)
+
+	app.Flag("retention", "Retention of the TSDB, e.g. 360h for 15 days").
+		Default(scrape.DefaultRetention.String()).
+		DurationVar(&o.Retention)
+
+	app.Flag("instances", "Number of instances exposing the same series as the target, e.g. replicas").
+		Default("1").
+		IntVar(&o.Instances)
+
+	app.Flag("bytes-per-sample", "Size of a sample once compressed in TSDB chunks").
+		Default(strconv.FormatFloat(scrape.DefaultBytesPerSample, 'g', -1, 64)).
+		Float64Var(&o.BytesPerSample)
+
+	app.Flag("index-bytes-per-series", "Size of a series in the index of every block").
+		Default(strconv.Itoa(scrape.DefaultIndexBytesPerSeries)).
+		Float64Var(&o.IndexBytesPerSeries)
+
+	app.Flag("top", "Number of metric families to list, the largest first, 0 for all").
+		Default("20").
+		IntVar(&o.Top)
+}
+
+// models returns the capacity model of every scrape interval.
+func (o *capacityOptions) models() ([]scrape.CapacityModel, error) {
+	if o.Retention <= 0 {
+		return nil, errors.New("--retention must be positive")
+	}
+	if o.BytesPerSample < 0 || o.IndexBytesPerSeries < 0 {
+		return nil, errors.New("--bytes-per-sample and --index-bytes-per-series must not be negative")
+	}
+	models := make([]scrape.CapacityModel, 0, len(o.ScrapeIntervals))
+	for _, interval := range o.ScrapeIntervals {
+		if interval <= 0 {
+			return nil, errors.Errorf("--scrape-interval must be positive, got %s", interval)
+		}
+		models = append(models, scrape.CapacityModel{
+			Interval:            interval,
+			Retention:           o.Retention,
+			Instances:           o.Instances,
+			BytesPerSample:      o.BytesPerSample,
+			IndexBytesPerSeries: o.IndexBytesPerSeries,
+		})
+	}
+	return models, nil
+}
+
+func registerCapacityCommand(app *extkingpin.App) {
+	cmd := app.Command("capacity", "Estimate the samples per day and the TSDB storage of the series of a target "+
+		"per metric family, for one or more scrape intervals and a retention.")
+	opts := &capacityOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		models, err := opts.models()
+		if err != nil {
+			return err
+		}
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			reports := make([]scrape.CapacityReport, 0, len(models))
+			for _, m := range models {
+				reports = append(reports, scrape.EstimateCapacity(result.Series, m))
+			}
+			return printCapacityReports(os.Stdout, reports, opts.Top)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printCapacityReports(out io.Writer, reports []scrape.CapacityReport, top int) error {
+	first := reports[0]
+	m := first.Model
+	fmt.Fprintf(out, "%d series from %d instance(s) retained %s, %g bytes per sample, %s of index per series in "+
+		"each of %d blocks\n\n", first.Total.Series, m.Instances, model.Duration(m.Retention), m.BytesPerSample,
+		units.BytesSize(m.IndexBytesPerSeries), m.Blocks())
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INTERVAL\tSAMPLES/S\tSAMPLES/DAY\tCHUNKS\tINDEX\tSTORAGE")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%.1f\t%.0f\t%s\t%s\t%s\n", r.Model.Interval,
+			r.Total.SamplesPerDay/(24*time.Hour).Seconds(), r.Total.SamplesPerDay,
+			units.BytesSize(r.Total.ChunkBytes), units.BytesSize(r.Total.IndexBytes),
+			units.BytesSize(r.Total.StorageBytes()))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	families := first.Families
+	if top > 0 && len(families) > top {
+		families = families[:top]
+	}
+	fmt.Fprintf(out, "\nMetric families scraped every %s:\n", m.Interval)
+	tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tSERIES\tSAMPLES/DAY\tCHUNKS\tINDEX\tSTORAGE\tSHARE")
+	for _, f := range families {
+		share := 0.0
+		if total := first.Total.StorageBytes(); total > 0 {
+			share = f.StorageBytes() / total * 100
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.0f\t%s\t%s\t%s\t%.1f%%\n", f.Name, f.Series, f.SamplesPerDay,
+			units.BytesSize(f.ChunkBytes), units.BytesSize(f.IndexBytes), units.BytesSize(f.StorageBytes()), share)
+	}
+	return tw.Flush()
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -14,44 +18,236 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/termenv"
 	"github.com/oklog/run"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	config_util "github.com/prometheus/common/config"
 	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
 
 	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
 )
 
 type cardinalityOptions struct {
 	Options
+	Refresh        time.Duration
+	HistorySize    int
+	TimelineSize   int
+	Type           string
+	MinCardinality int
+	AppOnly        bool
+	Columns        string
+	Pins           []string
+	MaxInfoLabels  int
+	// PushStaleAfter is the age of the last push after which a Pushgateway group is flagged as stale.
+	PushStaleAfter time.Duration
+	// GrafanaURL, GrafanaDatasource and PrometheusURL are where the selected metric can be opened.
+	GrafanaURL        string
+	GrafanaDatasource string
+	PrometheusURL     string
+	// TempoDatasource, in GrafanaURL, and JaegerURL are where the traces of exemplars can be opened.
+	TempoDatasource string
+	JaegerURL       string
+	// StateFile is where the session state is saved, empty to neither save nor restore it. It is keyed by
+	// stateKey, the target URL when empty.
+	StateFile string
+	stateKey  string
+	// Targets are scraped along the --scrape-url target, Concurrency of them at once. Families whose
+	// cardinality diverges by DivergenceRatio across them are flagged.
+	Targets         []string
+	Concurrency     int
+	DivergenceRatio float64
+	// Output is the format the analysis is written in, Template renders each metric family with
+	// --output=template.
+	Output   string
+	Template string
+	// PrometheusJob is the job whose targets are listed by the targets API of PrometheusURL and scraped. The
+	// Prometheus* credentials and TLS settings authenticate the targets API requests, the scrape ones are for
+	// the targets only.
+	PrometheusJob                   string
+	PrometheusBearerTokenFile       string
+	PrometheusBasicAuthUsername     string
+	PrometheusBasicAuthPasswordFile string
+	PrometheusTLSCAFile             string
+	PrometheusTLSInsecureSkipVerify bool
+	// Baseline is a saved scrape the TUI diffs the current scrape against.
+	Baseline string
 }
 
 func (o *cardinalityOptions) addFlags(app extkingpin.AppClause) {
-	o.AddFlags(app)
+	app.Flag("scrape-url", "URL to scrape metrics from, a file:// URL or a path reads them from a file, "+
+		"required unless --prometheus.job lists the targets").
+		StringVar(&o.ScrapeURL)
+
+	o.addScrapeFlags(app)
+	o.addCacheFlags(app)
+	o.addThrottleFlags(app)
+
+	app.Flag("refresh", "Interval to automatically re-scrape the target while the TUI is open, 0 to disable").
+		Default("0s").
+		DurationVar(&o.Refresh)
+
+	app.Flag("target", "Another target to scrape along --scrape-url, can be repeated. With several targets the TUI "+
+		"opens on a per-target report").
+		StringsVar(&o.Targets)
+
+	app.Flag("prometheus.job", "Job of the --prometheus.url server whose active targets, listed by its targets API, "+
+		"are scraped directly with the scheme, path and params Prometheus uses, along --scrape-url if set").
+		Default("").
+		StringVar(&o.PrometheusJob)
+
+	app.Flag("prometheus.bearer-token-file", "File with the bearer token of the --prometheus.url targets API").
+		Default("").
+		StringVar(&o.PrometheusBearerTokenFile)
+
+	app.Flag("prometheus.basic-auth.username", "Basic auth username of the --prometheus.url targets API").
+		Default("").
+		StringVar(&o.PrometheusBasicAuthUsername)
+
+	app.Flag("prometheus.basic-auth.password-file", "File with the basic auth password of the --prometheus.url "+
+		"targets API").
+		Default("").
+		StringVar(&o.PrometheusBasicAuthPasswordFile)
+
+	app.Flag("prometheus.tls.ca-file", "CA certificate to verify the --prometheus.url server with").
+		Default("").
+		StringVar(&o.PrometheusTLSCAFile)
+
+	app.Flag("prometheus.tls.insecure-skip-verify", "Do not verify the certificate of the --prometheus.url server").
+		Default("false").
+		BoolVar(&o.PrometheusTLSInsecureSkipVerify)
+
+	app.Flag("scrape.concurrency", "Number of targets scraped at once with --target").
+		Default("10").
+		IntVar(&o.Concurrency)
+
+	app.Flag("targets.divergence-ratio", "Flag metric families whose largest target has this many times the "+
+		"series of the median target").
+		Default(strconv.Itoa(defaultDivergenceRatio)).
+		Float64Var(&o.DivergenceRatio)
+
+	app.Flag("output", "Output format: the interactive "+outputTUI+", or to scrape once, "+outputTemplate+
+		" to render every metric family with --template, "+outputProm+" to write the analysis as Prometheus metrics, "+
+		outputMatrixCSV+" or "+outputMatrixJSON+" to export the distinct values of every label of every family, "+
+		"e.g. for a heatmap").
+		Default(outputTUI).
+		EnumVar(&o.Output, outputFormats...)
+
+	app.Flag("template", "Go template rendered for every metric family with --output=template, e.g. "+
+		"'{{.Name}} {{.Cardinality}}', fields: Name, Cardinality, Type, Labels, CreatedTS").
+		Default("").
+		StringVar(&o.Template)
+
+	o.addViewFlags(app)
 }
 
-var baseStyle = lipgloss.NewStyle().
-	BorderStyle(lipgloss.NormalBorder()).
-	BorderForeground(lipgloss.Color("240"))
+// addViewFlags registers the flags configuring the TUI, shared by the commands feeding it.
+func (o *cardinalityOptions) addViewFlags(app extkingpin.AppClause) {
+	app.Flag("history-size", "Number of scrapes kept per metric for the delta and trend columns").
+		Default("20").
+		IntVar(&o.HistorySize)
+
+	app.Flag("timeline-size", "Number of scrapes of the session kept in memory, with all their series, to step "+
+		"back and forth through with [ and ]").
+		Default(strconv.Itoa(defaultTimelineSize)).
+		IntVar(&o.TimelineSize)
+
+	app.Flag("type", "Only show metric families of the given type ("+strings.Join(metricTypes, ", ")+")").
+		Default("").
+		StringVar(&o.Type)
+
+	app.Flag("min-cardinality", "Hide metric families with fewer series than this").
+		Default("0").
+		IntVar(&o.MinCardinality)
+
+	app.Flag("app-only", "Hide the Go runtime, process, promhttp and build info metrics of the client libraries").
+		Default("false").
+		BoolVar(&o.AppOnly)
+
+	app.Flag("columns", "Comma separated list of columns to show, the name column is always shown").
+		Default(defaultColumns).
+		StringVar(&o.Columns)
+
+	app.Flag("max-info-labels", "Flag info metrics whose series carry more labels than this").
+		Default(strconv.Itoa(defaultMaxInfoLabels)).
+		IntVar(&o.MaxInfoLabels)
+
+	app.Flag("pushgateway.stale-after", "Flag the push groups of a Pushgateway target last pushed longer ago than "+
+		"this, abandoned groups are scraped until deleted").
+		Default(defaultPushStaleAfter.String()).
+		DurationVar(&o.PushStaleAfter)
+
+	app.Flag("pin", "Metric to pin to the top of the table, can be repeated").
+		StringsVar(&o.Pins)
+
+	app.Flag("grafana.url", "Base URL of Grafana, the selected metric can be opened in its Explore view").
+		Default("").
+		StringVar(&o.GrafanaURL)
+
+	app.Flag("grafana.datasource", "UID of the Grafana datasource to query, defaults to Grafana's default datasource").
+		Default("").
+		StringVar(&o.GrafanaDatasource)
+
+	app.Flag("prometheus.url", "Base URL of a Prometheus server, the selected metric can be opened in its graph UI").
+		Default("").
+		StringVar(&o.PrometheusURL)
+
+	app.Flag("tempo.datasource", "UID of the Tempo datasource of --grafana.url, the trace of the selected series' "+
+		"latest exemplar can be opened in its Explore view").
+		Default("").
+		StringVar(&o.TempoDatasource)
+
+	app.Flag("jaeger.url", "Base URL of the Jaeger UI, the trace of the selected series' latest exemplar can be opened").
+		Default("").
+		StringVar(&o.JaegerURL)
+
+	app.Flag("state-file", "File the search, pins, columns and filters are saved to on exit and restored from on "+
+		"the next run against the same target, empty to disable").
+		Default(defaultStateFile()).
+		StringVar(&o.StateFile)
+
+	app.Flag("baseline", "Snapshot, see the snapshot command, to diff the current scrape against, the TUI then "+
+		"lists the families added, removed or changed since, and the label values behind the changes").
+		Default("").
+		StringVar(&o.Baseline)
+}
+
+// baseStyle, hintStyle and warnStyle are built from the active theme, see applyTheme.
+var baseStyle lipgloss.Style
+
+// hintStyle matches the color of the help views.
+var hintStyle lipgloss.Style
+
+// warnStyle highlights analysis findings in the footer.
+var warnStyle lipgloss.Style
 
-var tableHelp = help.New().ShortHelpView([]key.Binding{
+var thresholdHelp = []key.Binding{
 	key.NewBinding(
-		key.WithKeys("up", "k"),
-		key.WithHelp("↑/k", "up"),
+		key.WithKeys("enter"),
+		key.WithHelp("enter:", "apply threshold"),
 	),
 	key.NewBinding(
-		key.WithKeys("down", "j"),
-		key.WithHelp("↓/j", "down"),
+		key.WithKeys("esc"),
+		key.WithHelp("esc:", "cancel"),
 	),
+}
+var exportHelp = []key.Binding{
 	key.NewBinding(
-		key.WithKeys("/"),
-		key.WithHelp("/", "search metrics"),
+		key.WithKeys("enter"),
+		key.WithHelp("enter:", "export (.csv, .json or .md)"),
 	),
-})
-var searchHelp = help.New().ShortHelpView([]key.Binding{
+	key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc:", "cancel"),
+	),
+}
+var searchHelp = []key.Binding{
 	key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter:", "explore table"),
@@ -60,7 +256,16 @@ var searchHelp = help.New().ShortHelpView([]key.Binding{
 		key.WithKeys("esc"),
 		key.WithHelp("esc:", "clear search"),
 	),
-})
+}
+
+const (
+	// defaultTableHeight is used until the terminal size is known.
+	defaultTableHeight = 20
+	// minTableHeight keeps a few rows visible on very small terminals.
+	minTableHeight = 5
+	// defaultMaxInfoLabels is the default of --max-info-labels.
+	defaultMaxInfoLabels = 10
+)
 
 var noFiltering func(info scrape.SeriesInfo) bool = nil
 
@@ -71,115 +276,560 @@ type seriesTable struct {
 	seriesMap        scrape.SeriesMap
 	loading          bool
 	searchingMetrics bool
-	err              error
 	infoTitle        string
+	// loaded is set once a scrape of the target succeeded.
+	loaded bool
+	// slowScrape describes a slow scrape from its timing, see Options.slowScrape.
+	slowScrape func(scrape.Timing) string
+	// failure is set while the first scrape fails, editingURL while urlInput prompts for another target
+	// scraped through newScrapeFn, nil when the target cannot be changed.
+	failure     scrapeFailure
+	editingURL  bool
+	urlInput    textinput.Model
+	newScrapeFn retargetFunc
+	// federated is set when the target is a federation endpoint, enabling the per-origin view.
+	federated     bool
+	groupByOrigin bool
+	// pushgateway is set when the target is a Pushgateway, the per-origin view then lists its push groups,
+	// pushStaleAfter is the age of the last push above which they are flagged.
+	pushgateway    bool
+	pushStaleAfter time.Duration
+	// replaying is set when the scrapes come from a recording rather than the target.
+	replaying bool
+	// treeView groups the metrics by name prefix, expanded holds the prefixes of the expanded nodes
+	// and treeNodes the node shown by each row.
+	treeView  bool
+	expanded  map[string]bool
+	treeRoot  *scrape.PrefixNode
+	treeNodes []*scrape.PrefixNode
+	tracer    opentracing.Tracer
+	// drillDown is the metric whose series, or labels when drillLabels is set, are listed. drillLabel is
+	// the label whose values are listed. metricSearch is the search to restore when going back to the metrics.
+	// aggregated are the labels of the metric toggled off in the label list, to see its cardinality without them.
+	drillDown    string
+	drillLabels  bool
+	drillLabel   string
+	aggregated   []string
+	metricSearch savedSearch
+	// scrapeFn re-scrapes the target, it is nil when refreshing is not supported.
+	scrapeFn   func() (*scrape.Result, error)
+	refreshing bool
+	// progress delivers the progress of the scrape in flight, lastProgress is the latest report of it.
+	progress     <-chan scrape.Progress
+	lastProgress *scrape.Progress
+	flash        string
+	flashID      int
+	// autoRefresh is the interval between automatic refreshes, they only happen while autoRefreshOn.
+	autoRefresh   time.Duration
+	autoRefreshOn bool
+	autoRefreshID int
+	// changes holds the cardinality delta of the metrics that changed in the last refresh.
+	changes map[string]int
+	history *scrape.History
+	// timeline keeps the last scrapes, the table shows the one travelBack scrapes before the latest.
+	timeline   *scrape.Timeline
+	travelBack int
+	// typeFilter restricts the metric table to families of the given type, empty for all types.
+	typeFilter string
+	// minCardinality hides rows with fewer series, it is edited through thresholdInput.
+	minCardinality   int
+	thresholdInput   textinput.Model
+	editingThreshold bool
+	// appOnly hides the standard instrumentation of the client libraries, see scrape.Classify.
+	appOnly bool
+	// exportInput holds the path the visible rows are exported to.
+	exportInput textinput.Model
+	exporting   bool
+	// columns holds the keys of the metric columns to show, width is the terminal width once known.
+	columns []string
+	width   int
+	// height is the terminal height, fixedHeight the table height forced by --output-height.
+	height      int
+	fixedHeight int
+	showHelp    bool
+	// pinned metrics are always shown at the top of the table, pinnedOnly hides every other metric.
+	pinned     map[string]struct{}
+	pinnedOnly bool
+	// maxInfoLabels is the number of labels above which info metrics are flagged in the footer.
+	maxInfoLabels int
+	// marked metrics are summed up in the footer.
+	marked map[string]struct{}
+	// grafanaURL, grafanaDatasource and prometheusURL are where the selected metric can be opened.
+	grafanaURL        string
+	grafanaDatasource string
+	prometheusURL     string
+	// tempoDatasource, in grafanaURL, and jaegerURL are where the traces of exemplars can be opened.
+	tempoDatasource string
+	jaegerURL       string
+	// target is the URL of the scraped target, stateFile where the session state is saved on exit, under
+	// stateKey.
+	target    string
+	stateFile string
+	stateKey  string
+	// targets are the targets of multi-target mode, nil otherwise. Their report is shown while targetView
+	// is set, the metric views show the target at targetIndex. Sending on rescrapeTargets scrapes them all.
+	targets         []targetState
+	targetView      bool
+	targetIndex     int
+	rescrapeTargets chan<- struct{}
+	// divergences compares the families of the targets that are up, divergenceTargets maps its scrapes
+	// back to the targets. The divergence view lists them, ratios of divergenceRatio or more are flagged.
+	divergences       []scrape.FamilyDivergence
+	divergenceTargets []int
+	divergenceView    bool
+	divergenceRatio   float64
+	// historySize is the number of scrapes kept by the histories of the targets, timelineSize by their timelines.
+	historySize  int
+	timelineSize int
+	// baseline is the saved scrape the diff view compares the current scrape to, nil when none is loaded.
+	// diffFamily is the family whose label value changes are listed.
+	baseline   *scrape.Result
+	diffView   bool
+	diffFamily string
+	// previousRun returns the result of the run of the tool before this one, nil when unknown or when the
+	// scrapes are not cached, see previousResult. runTrends is the change of every family since then.
+	previousRun func() *scrape.Result
+	runTrends   map[string]scrape.FamilyTrend
+	// heatmap shows the label matrix of the listed families in place of the table, nil when closed.
+	heatmap *heatmap
+	// rows caches the metric rows of seriesMap, search the matches of the last search among them.
+	rows   []metricRow
+	search searchCache
 }
 
+// newModel creates the table model, a zero height fits the table to the terminal.
 func newModel(sm map[string]scrape.SeriesSet, height int) *seriesTable {
+	tableHeight := height
+	if tableHeight <= 0 {
+		tableHeight = defaultTableHeight
+	}
 	tbl := table.New(
-		table.WithColumns([]table.Column{
-			{Title: "Name", Width: 60},
-			{Title: "Cardinality", Width: 16},
-			{Title: "Type", Width: 10},
-			{Title: "Labels", Width: 80},
-			{Title: "Created TS", Width: 50},
-		}),
 		table.WithFocused(true),
-		table.WithHeight(height),
+		table.WithHeight(tableHeight),
 	)
 
 	tblStyle := table.DefaultStyles()
 	tblStyle.Header = tblStyle.Header.
 		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
+		BorderForeground(lipgloss.Color(activeTheme.Border)).
 		BorderBottom(true).
 		Bold(false)
 	tblStyle.Selected = tblStyle.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
+		Foreground(lipgloss.Color(activeTheme.SelectedForeground)).
+		Background(lipgloss.Color(activeTheme.SelectedBackground)).
 		Bold(false)
+	if lipgloss.ColorProfile() == termenv.Ascii {
+		// Without colors, mark the selected row in the padding of its first cell instead.
+		tblStyle.Selected = tblStyle.Selected.Transform(func(row string) string {
+			if rest, ok := strings.CutPrefix(row, " "); ok {
+				return ">" + rest
+			}
+			return row
+		})
+	}
 	tbl.SetStyles(tblStyle)
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
-	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color(activeTheme.Accent))
 
 	ti := textinput.New()
 	ti.Placeholder = "Metric name"
 
+	thi := textinput.New()
+	thi.Prompt = "min cardinality > "
+	thi.Placeholder = "0"
+	thi.CharLimit = 10
+	thi.Validate = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		_, err := strconv.Atoi(s)
+		return err
+	}
+
+	ui := textinput.New()
+	ui.Prompt = "target URL > "
+
+	ei := textinput.New()
+	ei.Prompt = "export to > "
+	ei.Placeholder = "rows.csv"
+
 	m := &seriesTable{
 		table:            tbl,
 		seriesMap:        sm,
 		spinner:          sp,
 		searchInput:      ti,
+		thresholdInput:   thi,
+		exportInput:      ei,
+		urlInput:         ui,
 		loading:          true,
 		searchingMetrics: false,
+		tracer:           opentracing.NoopTracer{},
+		autoRefresh:      defaultAutoRefreshInterval,
+		history:          scrape.NewHistory(defaultHistorySize),
+		historySize:      defaultHistorySize,
+		timeline:         scrape.NewTimeline(defaultTimelineSize),
+		timelineSize:     defaultTimelineSize,
+		divergenceRatio:  defaultDivergenceRatio,
+		fixedHeight:      max(height, 0),
+		maxInfoLabels:    defaultMaxInfoLabels,
+		pushStaleAfter:   defaultPushStaleAfter,
+		pinned:           make(map[string]struct{}),
+		marked:           make(map[string]struct{}),
+		expanded:         make(map[string]bool),
 	}
+	m.columns, _ = parseColumns(defaultColumns)
+	m.resetColumns()
 
 	return m
 }
 
 func (m *seriesTable) setTableRows(filter func(info scrape.SeriesInfo) bool) {
-	var rows []table.Row
-	for _, r := range m.seriesMap.AsRows() {
-		if filter == nil || filter(r) {
-			rows = append(rows, table.Row{
-				r.Name,
-				strconv.Itoa(r.Cardinality),
-				r.Type,
-				r.Labels,
-				r.CreatedTS,
-			})
+	if m.diffView {
+		m.setDiffRows()
+		return
+	}
+	if m.targetView {
+		m.setTargetRows()
+		return
+	}
+	if m.drillDown != "" {
+		m.setDrillDownRows()
+		return
+	}
+	if m.groupByOrigin && m.pushgateway {
+		m.setPushGroupRows(filter)
+		return
+	}
+	if m.groupByOrigin {
+		m.setOriginRows(filter)
+		return
+	}
+	if m.treeView {
+		m.setTreeRows(filter)
+		return
+	}
+
+	cols := m.visibleMetricColumns()
+	widths := m.table.Columns()
+	var hits map[string]searchMatch
+	if query := m.searchQuery(); query != "" {
+		hits = m.searchHits(query)
+	}
+	var rows []scoredRow
+	for _, mr := range m.metricRows() {
+		r := mr.info
+		pinned := m.isPinned(r.Name)
+		if m.pinnedOnly && !pinned {
+			continue
+		}
+		// Pinned metrics stay visible whatever the filters.
+		if pinned || filter == nil || filter(r) {
+			match := hits[r.Name]
+			prefix := m.rowPrefix(r.Name)
+			row := make(table.Row, 0, len(cols))
+			for i, c := range cols {
+				width := widths[i].Width
+				if i == 0 {
+					width -= runewidth.StringWidth(prefix)
+				}
+				row = append(row, highlightMatches(c.cell(m, r), match.positions[c.key], width))
+			}
+			row[0] = prefix + row[0]
+			rows = append(rows, scoredRow{row: row, score: match.score, pinned: pinned})
 		}
 	}
 
-	m.table.SetRows(rows)
+	m.table.SetRows(rankRows(rows))
+}
+
+type scoredRow struct {
+	row    table.Row
+	score  int
+	pinned bool
+}
+
+// rankRows puts pinned rows first and orders the rest by their search score, rows scoring the same
+// keep their order.
+func rankRows(scored []scoredRow) []table.Row {
+	slices.SortStableFunc(scored, func(a, b scoredRow) int {
+		if a.pinned != b.pinned {
+			if a.pinned {
+				return -1
+			}
+			return 1
+		}
+		return b.score - a.score
+	})
+	rows := make([]table.Row, 0, len(scored))
+	for _, r := range scored {
+		rows = append(rows, r.row)
+	}
+	return rows
+}
+
+// rowPrefix returns the markers shown before the name of the metric.
+func (m *seriesTable) rowPrefix(name string) string {
+	var prefix string
+	if m.isMarked(name) {
+		prefix += markMarker
+	}
+	if m.isPinned(name) {
+		prefix += pinMarker
+	}
+	return prefix
+}
+
+// rowName returns the metric name, or job in the origin view, identifying a table row.
+func rowName(row table.Row) string {
+	if len(row) == 0 {
+		return ""
+	}
+	name := strings.TrimPrefix(ansi.Strip(row[0]), markMarker)
+	return strings.TrimPrefix(name, pinMarker)
+}
+
+// showTrend reports whether the delta and trend columns should be shown.
+func (m *seriesTable) showTrend() bool {
+	return m.history.Len() > 1
+}
+
+// setRunTrends compares the families of the scrape with the previous run of the tool, when it is known.
+func (m *seriesTable) setRunTrends(series scrape.SeriesMap) {
+	m.runTrends = nil
+	if m.previousRun == nil {
+		return
+	}
+	if previous := m.previousRun(); previous != nil {
+		m.runTrends, _ = scrape.CompareFamilies(previous.Series, series)
+	}
+}
+
+// setOriginRows fills the table with the per-origin breakdown of a federated scrape. The filter is
+// applied to the job name.
+func (m *seriesTable) setOriginRows(filter func(info scrape.SeriesInfo) bool) {
+	widths := m.table.Columns()
+	terms := m.searchTerms()
+	var rows []scoredRow
+	for _, o := range m.seriesMap.ByOrigin() {
+		info := scrape.SeriesInfo{Name: o.Job, Cardinality: o.Cardinality}
+		if filter == nil || filter(info) {
+			match, _ := matchSearch(terms, searchFields(info), &info)
+			rows = append(rows, scoredRow{score: match.score, row: table.Row{
+				highlightMatches(o.Job, match.positions["name"], widths[0].Width),
+				strconv.Itoa(o.Cardinality),
+				strconv.Itoa(o.Instances),
+				strconv.Itoa(o.Metrics),
+				fmt.Sprintf("%s (%d)", o.TopMetric, o.TopMetricCardinality),
+			}})
+		}
+	}
+
+	m.table.SetRows(rankRows(rows))
+}
+
+// setGroupByOrigin switches between the flat metric table and the per-origin table.
+func (m *seriesTable) setGroupByOrigin(group bool) {
+	m.groupByOrigin = group
+	if group {
+		m.treeView = false
+	}
+	m.resetColumns()
+	m.table.SetCursor(0)
+}
+
+// metricView reports whether the table lists metric families, as opposed to origins or prefixes.
+func (m *seriesTable) metricView() bool {
+	return !m.groupByOrigin && !m.treeView && !m.diffView && m.drillDown == ""
+}
+
+// totalRows returns the number of unfiltered rows of the current view.
+func (m *seriesTable) totalRows() int {
+	if m.drillDown != "" {
+		return m.drillDownTotal()
+	}
+	if m.groupByOrigin && m.pushgateway {
+		groups, _ := m.seriesMap.PushGroups()
+		return len(groups)
+	}
+	if m.groupByOrigin {
+		return len(m.seriesMap.ByOrigin())
+	}
+	return len(m.seriesMap)
 }
 
 func (m *seriesTable) View() string {
 	if m.loading {
-		return m.spinner.View() + "\nLoading..."
+		return m.spinner.View() + "\nLoading..." + m.progressView()
 	}
-	if m.err != nil {
-		return baseStyle.Render("Exiting with error: " + m.err.Error())
+	if m.failure.err != nil {
+		return m.failureView()
+	}
+	if m.showHelp {
+		return m.helpOverlay()
 	}
 
-	var view strings.Builder
-	if m.searchingMetrics {
-		view.WriteString(baseStyle.Render(m.searchInput.View()))
+	header := m.headerView()
+	footer := m.footerView()
+	if m.heatmap != nil {
+		return header + "\n" + m.heatmapView() + "\n" + footer
 	}
 
-	view.WriteString("\n")
-	view.WriteString(baseStyle.Render(m.table.View()))
+	return header + "\n" + baseStyle.Render(m.table.View()) + "\n" + footer
+}
+
+// headerView is the prompt above the table, if any.
+func (m *seriesTable) headerView() string {
+	switch {
+	case m.editingThreshold:
+		return baseStyle.Render(m.thresholdInput.View())
+	case m.exporting:
+		return baseStyle.Render(m.exportInput.View())
+	case m.searchingMetrics:
+		return baseStyle.Render(m.searchInput.View())
+	}
+	return ""
+}
 
-	view.WriteString("\n")
-	if m.searchInput.Focused() {
-		view.WriteString(searchHelp)
+func (m *seriesTable) footerView() string {
+	if m.targetView {
+		return m.targetFooterView()
+	}
+	if m.diffView {
+		return m.diffFooterView()
+	}
+	if m.heatmap != nil {
+		return m.heatmapFooterView()
+	}
+	var view strings.Builder
+	if m.editingThreshold {
+		view.WriteString(help.New().ShortHelpView(thresholdHelp))
+	} else if m.exporting {
+		view.WriteString(help.New().ShortHelpView(exportHelp))
+	} else if m.searchInput.Focused() {
+		view.WriteString(help.New().ShortHelpView(searchHelp))
 	} else {
-		view.WriteString(tableHelp)
+		view.WriteString(m.shortHelpView())
 	}
 
+	rowKind := "metrics"
+	switch {
+	case m.drillDown != "":
+		rowKind = m.drillDownKind()
+	case m.groupByOrigin && m.pushgateway:
+		rowKind = "push groups"
+	case m.groupByOrigin:
+		rowKind = "origin jobs"
+	}
 	if m.searchingMetrics {
-		total := len(m.seriesMap)
+		total := m.totalRows()
 		filtered := len(m.table.Rows())
+		if m.treeView {
+			filtered = m.treeRoot.Metrics
+		}
 		view.WriteString("\n")
-		view.WriteString(fmt.Sprintf("Showing %d out of %d metrics", filtered, total))
+		view.WriteString(fmt.Sprintf("Showing %d out of %d %s", filtered, total, rowKind))
 	} else {
-		total := len(m.seriesMap)
+		total := m.totalRows()
 		view.WriteString("\n")
-		view.WriteString(fmt.Sprintf("Total metrics: %d", total))
+		view.WriteString(fmt.Sprintf("Total %s: %d", rowKind, total))
+		if whatIf := m.whatIfSummary(); whatIf != "" {
+			view.WriteString("\n")
+			view.WriteString(whatIf)
+		}
+		if skew := m.skewSummary(); skew != "" {
+			view.WriteString("\n")
+			view.WriteString(skew)
+		}
+		if push := m.pushSummary(); push != "" {
+			view.WriteString("\n")
+			view.WriteString(push)
+		}
+		if usage := formatExemplarUsage(m.seriesMap.ExemplarUsage()); usage != "" && m.drillDown == "" {
+			view.WriteString("\n")
+			view.WriteString(usage)
+		}
+		if !m.groupByOrigin && m.drillDown == "" {
+			view.WriteString("\n")
+			view.WriteString(m.typeSummary())
+			view.WriteString("\n")
+			view.WriteString(m.categorySummary())
+			if info := m.infoSummary(); info != "" {
+				view.WriteString("\n")
+				view.WriteString(info)
+			}
+			if mixed := m.mixedTypeSummary(); mixed != "" {
+				view.WriteString("\n")
+				view.WriteString(mixed)
+			}
+			if shared := m.sharedLabelSummary(); shared != "" {
+				view.WriteString("\n")
+				view.WriteString(shared)
+			}
+		}
 		view.WriteString("\n")
 		view.WriteString(m.infoTitle)
+		if m.autoRefreshOn {
+			view.WriteString(fmt.Sprintf(" | Auto-refresh every %s", m.autoRefresh))
+		}
+		if timeline := m.timelineSummary(); timeline != "" {
+			view.WriteString("\n")
+			view.WriteString(timeline)
+		}
+	}
+
+	if pins := m.pinsSummary(); pins != "" && !m.groupByOrigin {
+		view.WriteString("\n")
+		view.WriteString(pins)
+	}
+	if marks := m.marksSummary(); marks != "" && !m.groupByOrigin {
+		view.WriteString("\n")
+		view.WriteString(marks)
+	}
+
+	if m.refreshing {
+		view.WriteString("\n")
+		view.WriteString(m.spinner.View() + " Refreshing..." + m.progressView())
+	} else if m.flash != "" {
+		view.WriteString("\n")
+		view.WriteString(m.flash)
 	}
 
 	return view.String()
 }
 
 func (m *seriesTable) Init() tea.Cmd {
-	return m.spinner.Tick
+	return tea.Batch(m.spinner.Tick, waitForProgress(m.progress))
+}
+
+// progressView describes the progress of the scrape in flight, if it takes long enough to be reported.
+func (m *seriesTable) progressView() string {
+	if m.lastProgress == nil {
+		return ""
+	}
+	return " " + formatProgress(*m.lastProgress)
 }
 
+// Update handles the message, then fits the table to the terminal as the message may have resized it or changed
+// the height of the header and footer around the table.
 func (m *seriesTable) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	m.fitHeight()
+	return model, cmd
+}
+
+// fitHeight gives the table whatever the header, the footer and the table border leave of the terminal, unless
+// the height of the table is fixed.
+func (m *seriesTable) fitHeight() {
+	if m.height <= 0 || m.fixedHeight != 0 {
+		return
+	}
+	height := m.height - lipgloss.Height(m.headerView()) - lipgloss.Height(m.footerView()) - 2
+	m.table.SetHeight(max(height, minTableHeight))
+}
+
+func (m *seriesTable) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -187,23 +837,95 @@ func (m *seriesTable) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c":
 			return m, tea.Quit
 		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.resetColumns()
+		return m, nil
 	case spinner.TickMsg:
-		if m.loading {
+		if m.loading || m.refreshing {
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
 		}
+	case progressMsg:
+		if m.loading || m.refreshing {
+			p := scrape.Progress(msg)
+			m.lastProgress = &p
+		}
+		return m, waitForProgress(m.progress)
 	case error:
-		m.loading = false
-		m.err = msg
-		return m, tea.Quit
+		return m, m.failScrape(msg)
+	case retryMsg:
+		if msg.id == m.failure.retryID && m.failure.err != nil {
+			return m, m.retryScrape()
+		}
+		return m, nil
+	case targetResultMsg:
+		m.applyTargetResult(msg)
+		return m, nil
 	case *scrape.Result:
+		m.failure = scrapeFailure{}
+		m.lastProgress = nil
+		span := m.tracer.StartSpan("analyze_series")
+		span.SetTag("metric_families", len(msg.Series))
 		m.loading = false
-		m.seriesMap = msg.Series
+		m.loaded = true
+		m.history.Add(msg.Series)
+		m.recordScrape(msg)
+		m.setSeriesMap(msg.Series)
+		m.detectPushgateway(msg.Series)
 		m.infoTitle = m.formatInfoTitle(msg)
-		m.setTableRows(noFiltering)
+		m.setRunTrends(msg.Series)
+		if m.federated || m.pushgateway {
+			m.setGroupByOrigin(true)
+		} else {
+			m.resetColumns()
+		}
+		span.Finish()
+		return m, m.scheduleAutoRefresh()
+	case refreshMsg:
+		return m, m.startRefresh(msg.reason)
+	case refreshResultMsg:
+		return m, tea.Batch(m.applyRefresh(msg), m.scheduleAutoRefresh())
+	case autoRefreshMsg:
+		if msg.id == m.autoRefreshID && m.autoRefreshOn {
+			return m, m.startRefresh("auto")
+		}
+		return m, nil
+	case clearFlashMsg:
+		if msg.id == m.flashID {
+			m.flash = ""
+		}
 		return m, nil
 	}
 
+	if m.failure.err != nil {
+		return m.updateWhileFailed(msg)
+	}
+	if m.showHelp {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.String() {
+			case "?", "esc", "q":
+				m.showHelp = false
+			}
+		}
+		return m, nil
+	}
+	if m.targetView {
+		return m.updateWhileBrowsingTargets(msg)
+	}
+	if m.diffView {
+		return m.updateWhileBrowsingDiff(msg)
+	}
+	if m.heatmap != nil {
+		return m.updateWhileBrowsingHeatmap(msg)
+	}
+	if m.editingThreshold {
+		return m.updateWhileEditingThreshold(msg)
+	}
+	if m.exporting {
+		return m.updateWhileExporting(msg)
+	}
 	if m.searchingMetrics {
 		return m.updateWhileSearchingMetrics(msg)
 	} else {
@@ -215,10 +937,25 @@ func (m *seriesTable) updateWhileBrowsingTable(msg tea.Msg) (tea.Model, tea.Cmd)
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if key.Matches(msg, keys.Columns) {
+			if m.metricView() {
+				n, _ := strconv.Atoi(msg.String())
+				m.toggleColumn(n)
+			}
+			return m, nil
+		}
 		switch msg.String() {
 		case "q":
 			return m, tea.Quit
 		case "esc":
+			if m.drillDown != "" {
+				m.drillDownBack()
+				return m, nil
+			}
+			if m.targets != nil {
+				m.showTargets()
+				return m, nil
+			}
 			if m.table.Focused() {
 				m.table.Blur()
 			} else {
@@ -232,6 +969,119 @@ func (m *seriesTable) updateWhileBrowsingTable(msg tea.Msg) (tea.Model, tea.Cmd)
 		case "up":
 			m.table, cmd = m.table.Update(msg)
 			return m, cmd
+		case "?":
+			m.showHelp = true
+			return m, nil
+		case "p":
+			if m.metricView() {
+				m.togglePin()
+			}
+			return m, nil
+		case "P":
+			if m.metricView() {
+				m.togglePinnedOnly()
+			}
+			return m, nil
+		case " ":
+			if m.metricView() {
+				m.toggleMark()
+			} else if m.drillLabels && m.drillLabel == "" {
+				m.toggleAggregated()
+			}
+			return m, nil
+		case "x":
+			m.clearMarks()
+			return m, nil
+		case "o", "O":
+			if !m.metricView() {
+				return m, nil
+			}
+			u, err := m.openSelected(msg.String() == "O")
+			if err != nil {
+				return m, m.setFlash("Failed to open browser: " + err.Error())
+			}
+			return m, m.setFlash("Opened " + u)
+		case "T":
+			if m.drillDown == "" || m.drillLabels {
+				return m, nil
+			}
+			u, err := m.openTrace()
+			if err != nil {
+				return m, m.setFlash("Failed to open trace: " + err.Error())
+			}
+			return m, m.setFlash("Opened " + u)
+		case "c", "C":
+			if !m.metricView() {
+				return m, nil
+			}
+			if text := m.copySelected(msg.String() == "C"); text != "" {
+				return m, m.setFlash("Copied " + text)
+			}
+			return m, nil
+		case "r":
+			return m, m.startRefresh("manual")
+		case "a":
+			return m, m.toggleAutoRefresh()
+		case "t":
+			m.cycleTypeFilter()
+			return m, nil
+		case "A":
+			m.toggleAppOnly()
+			return m, nil
+		case ">":
+			m.editingThreshold = true
+			m.thresholdInput.SetValue(strconv.Itoa(m.minCardinality))
+			m.thresholdInput.CursorEnd()
+			m.table.Blur()
+			return m, m.thresholdInput.Focus()
+		case "w":
+			return m, m.startExport()
+		case "g":
+			if (m.federated || m.pushgateway) && m.drillDown == "" {
+				m.setGroupByOrigin(!m.groupByOrigin)
+			}
+			return m, nil
+		case "L":
+			if m.metricView() {
+				m.openDrillDown(true)
+			} else if m.drillDown != "" && !m.drillLabels {
+				m.openLabels()
+			}
+			return m, nil
+		case "D":
+			if m.baseline != nil && m.metricView() {
+				m.toggleDiffView()
+			}
+			return m, nil
+		case "H":
+			if m.metricView() {
+				m.toggleHeatmap()
+			}
+			return m, nil
+		case "[", "]":
+			if m.drillDown == "" {
+				return m, m.stepTimeline(msg.String() == "[")
+			}
+			return m, nil
+		case "v":
+			if m.drillDown == "" {
+				m.setTreeView(!m.treeView)
+			}
+			return m, nil
+		case "enter", "right", "l":
+			if msg.String() == "enter" && m.metricView() {
+				m.openDrillDown(false)
+				return m, nil
+			}
+			if msg.String() == "enter" && m.drillLabels && m.drillLabel == "" {
+				m.openLabelValues()
+				return m, nil
+			}
+			m.setExpanded(true)
+			return m, nil
+		case "left", "h":
+			m.setExpanded(false)
+			return m, nil
 		case "/":
 			m.searchingMetrics = true
 			m.searchInput.SetCursor(int(cursor.CursorBlink))
@@ -251,6 +1101,10 @@ func (m *seriesTable) updateWhileSearchingMetrics(msg tea.Msg) (tea.Model, tea.C
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
+			if !m.searchInput.Focused() {
+				// Already exploring the filtered table, e.g. to drill down into the selected metric.
+				return m.updateWhileBrowsingTable(msg)
+			}
 			// Allow exploring the filtered table
 			m.searchInput.SetCursor(int(cursor.CursorHide))
 			m.searchInput.Blur()
@@ -260,10 +1114,10 @@ func (m *seriesTable) updateWhileSearchingMetrics(msg tea.Msg) (tea.Model, tea.C
 			// Reset the search input and table back to their initial state
 			m.searchInput.Reset()
 			m.searchInput.Blur()
-			m.setTableRows(noFiltering)
 
 			// Hide the search input and restore control to the table
 			m.searchingMetrics = false
+			m.setTableRows(m.currentFilter())
 			m.table.Focus()
 			return m, cmd
 		default:
@@ -272,15 +1126,7 @@ func (m *seriesTable) updateWhileSearchingMetrics(msg tea.Msg) (tea.Model, tea.C
 				m.searchInput, cmd = m.searchInput.Update(msg)
 
 				oldRowCount := len(m.table.Rows())
-				if len(m.searchInput.Value()) > 0 {
-					v := strings.ToLower(m.searchInput.Value())
-					m.setTableRows(func(info scrape.SeriesInfo) bool {
-						return strings.Contains(strings.ToLower(info.Name), v)
-					})
-				} else {
-					// Show all rows
-					m.setTableRows(noFiltering)
-				}
+				m.setTableRows(m.currentFilter())
 
 				if oldRowCount != len(m.table.Rows()) {
 					//Reset the selected row since the current index might exceed the filtered count
@@ -302,7 +1148,31 @@ func (m *seriesTable) updateWhileSearchingMetrics(msg tea.Msg) (tea.Model, tea.C
 }
 
 func (m *seriesTable) formatInfoTitle(sr *scrape.Result) string {
-	return "Scrape used content type: " + sr.UsedContentType
+	title := "Scrape used content type: " + sr.UsedContentType
+	if m.federated {
+		title += " (federation endpoint, series grouped by origin job)"
+	}
+	if m.pushgateway {
+		title += " (Pushgateway, series grouped by push group)"
+	}
+	if m.replaying {
+		title += ", replaying scrape recorded at " + sr.Time.Format(time.DateTime)
+	}
+	if sr.Truncated {
+		title += " | Partial result, parsing stopped at --max-series"
+	}
+	if sr.ParseFailed {
+		title += " | " + warnStyle.Render("Partial result, parsing stopped at an entry that failed to parse")
+	}
+	if sr.Timing.Total > 0 {
+		title += " | Scrape took " + sr.Timing.String()
+		if m.slowScrape != nil {
+			if slow := m.slowScrape(sr.Timing); slow != "" {
+				title += " | " + warnStyle.Render(slow)
+			}
+		}
+	}
+	return title
 }
 
 func registerCardinalityCommand(app *extkingpin.App) {
@@ -313,60 +1183,274 @@ func registerCardinalityCommand(app *extkingpin.App) {
 		g *run.Group,
 		logger log.Logger,
 		reg *prometheus.Registry,
-		_ opentracing.Tracer,
-		_ <-chan struct{},
+		tracer opentracing.Tracer,
+		reloadCh <-chan struct{},
 		_ bool,
 	) error {
+		ctx := tracing.ContextWithTracer(context.Background(), tracer)
+		if opts.PrometheusJob != "" {
+			if err := opts.discoverTargets(ctx, logger); err != nil {
+				return err
+			}
+		}
+		if opts.ScrapeURL == "" {
+			return errors.New("--scrape-url or --prometheus.job is required")
+		}
 		scrapeURL := opts.ScrapeURL
 		timeoutDuration := opts.Timeout
+		scrapeMetrics := scrape.NewMetrics(reg)
 
-		metricTable := newModel(nil, opts.OutputHeight)
-		p := tea.NewProgram(metricTable)
-
-		// Create a channel to signal when scraping is complete
-		scrapeDone := make(chan struct{})
+		maxSize, err := opts.MaxScrapeSizeBytes()
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse max scrape size")
+		}
+		progressOpt, progress := tableProgress()
+		if opts.Output != outputTUI {
+			return opts.runOutput(ctx, g, logger, scrapeMetrics)
+		}
+		// newScrapeFn builds the scrape function of a target, the TUI uses it to switch targets. previousRun
+		// follows the last target it was built for.
+		var previousRun func() *scrape.Result
+		newScrapeFn := func(scrapeURL string) (func() (*scrape.Result, error), error) {
+			o := opts.Options
+			o.ScrapeURL = scrapeURL
+			scraper, err := o.Scraper(logger, scrape.WithMetrics(scrapeMetrics), progressOpt)
+			if err != nil {
+				return nil, err
+			}
+			previousRun = func() *scrape.Result { return previousResult(scraper) }
+			return func() (*scrape.Result, error) {
+				level.Info(logger).Log(
+					"msg", "scraping",
+					"url", scrapeURL,
+					"timeout", timeoutDuration,
+					"max_size", maxSize,
+				)
 
-		g.Add(func() error {
-			_, err := p.Run()
+				t0 := time.Now()
+				result, err := scraper.Scrape(ctx)
+				if err != nil {
+					return nil, err
+				}
+				level.Info(logger).Log("msg", "scraping complete", "duration", time.Since(t0))
+				return result, nil
+			}, nil
+		}
+		scrapeFn, err := newScrapeFn(scrapeURL)
+		if err != nil {
 			return err
-		}, func(error) {
-			close(scrapeDone)
-		})
+		}
 
-		g.Add(func() error {
-			maxSize, err := opts.MaxScrapeSizeBytes()
+		metricTable, err := opts.newTable(logger, tracer, scrapeURL, scrapeFn)
+		if err != nil {
+			return err
+		}
+		if len(opts.Targets) > 0 {
+			targets, err := opts.scrapeTargets(logger, scrapeMetrics)
 			if err != nil {
-				err = errors.Wrapf(err, "failed to parse max scrape size")
-				p.Send(err)
 				return err
 			}
+			metricTable.divergenceRatio = opts.DivergenceRatio
+			manager := scrape.NewManager(opts.managerOptions()...)
+			runTargets(ctx, g, logger, metricTable, manager, targets, reloadCh)
+			return nil
+		}
+		metricTable.newScrapeFn = newScrapeFn
+		metricTable.previousRun = func() *scrape.Result { return previousRun() }
+		metricTable.progress = progress
+		if opts.Refresh > 0 {
+			metricTable.autoRefresh = opts.Refresh
+			metricTable.autoRefreshOn = true
+		}
+		runTable(g, logger, metricTable, reloadCh)
+		return nil
+	})
+}
 
-			level.Info(logger).Log(
-				"msg", "scraping",
-				"url", scrapeURL,
-				"timeout", timeoutDuration,
-				"max_size", maxSize,
-			)
-
-			t0 := time.Now()
-			scraper := scrape.NewPromScraper(
-				scrapeURL,
-				logger,
-				scrape.WithTimeout(timeoutDuration),
-				scrape.WithMaxBodySize(maxSize),
-			)
-			metrics, err := scraper.Scrape()
-			if err != nil {
-				p.Send(err)
-				return err
+// discoverTargets lists the active targets of --prometheus.job with the targets API of --prometheus.url, the first
+// one becomes the --scrape-url target when unset, the others are scraped along like --target.
+func (o *cardinalityOptions) discoverTargets(ctx context.Context, logger log.Logger) error {
+	if o.PrometheusURL == "" {
+		return errors.New("--prometheus.job needs --prometheus.url")
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+	client, err := o.prometheusClient()
+	if err != nil {
+		return err
+	}
+	active, err := scrape.JobTargets(ctx, client, o.PrometheusURL, o.PrometheusJob)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the targets of job %s", o.PrometheusJob)
+	}
+	if len(active) == 0 {
+		return errors.Errorf("job %s has no active target in %s", o.PrometheusJob, o.PrometheusURL)
+	}
+	for _, t := range active {
+		level.Info(logger).Log("msg", "discovered target", "job", o.PrometheusJob, "url", t.ScrapeURL,
+			"health", t.Health)
+		if o.ScrapeURL == "" {
+			o.ScrapeURL = t.ScrapeURL
+			continue
+		}
+		o.Targets = append(o.Targets, t.ScrapeURL)
+	}
+	return nil
+}
+
+// prometheusClient builds the HTTP client of the --prometheus.url targets API from the --prometheus.* flags.
+func (o *cardinalityOptions) prometheusClient() (*http.Client, error) {
+	cfg := config_util.DefaultHTTPClientConfig
+	if o.PrometheusBearerTokenFile != "" {
+		cfg.Authorization = &config_util.Authorization{CredentialsFile: o.PrometheusBearerTokenFile}
+	}
+	if o.PrometheusBasicAuthUsername != "" {
+		cfg.BasicAuth = &config_util.BasicAuth{
+			Username:     o.PrometheusBasicAuthUsername,
+			PasswordFile: o.PrometheusBasicAuthPasswordFile,
+		}
+	}
+	cfg.TLSConfig = config_util.TLSConfig{
+		CAFile:             o.PrometheusTLSCAFile,
+		InsecureSkipVerify: o.PrometheusTLSInsecureSkipVerify,
+	}
+	return newHTTPClient(cfg, "--prometheus.*")
+}
+
+// scrapeTargets builds a scraper for --scrape-url and every --target, each URL once.
+func (o *cardinalityOptions) scrapeTargets(logger log.Logger, metrics *scrape.Metrics) ([]scrape.Target, error) {
+	var targets []scrape.Target
+	for _, u := range append([]string{o.ScrapeURL}, o.Targets...) {
+		if slices.ContainsFunc(targets, func(t scrape.Target) bool { return t.Name == u }) {
+			continue
+		}
+		opts := o.Options
+		opts.ScrapeURL = u
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(metrics))
+		if err != nil {
+			return nil, errors.Wrapf(err, "target %s", u)
+		}
+		targets = append(targets, scrape.Target{Name: u, Scraper: scraper})
+	}
+	return targets, nil
+}
+
+// managerOptions configures the scrapes of the targets.
+func (o *cardinalityOptions) managerOptions() []scrape.ManagerOption {
+	opts := []scrape.ManagerOption{scrape.WithConcurrency(o.Concurrency)}
+	if throttle := o.throttle(); throttle != nil {
+		opts = append(opts, scrape.WithThrottle(throttle))
+	}
+	return opts
+}
+
+// newTable builds the TUI from the view flags and the saved session state of the target, scrapeFn provides
+// the scrapes of scrapeURL it displays.
+func (o *cardinalityOptions) newTable(
+	logger log.Logger,
+	tracer opentracing.Tracer,
+	scrapeURL string,
+	scrapeFn func() (*scrape.Result, error),
+) (*seriesTable, error) {
+	if o.Type != "" && !slices.Contains(metricTypes, o.Type) {
+		return nil, errors.Errorf("unknown metric type %q, expected one of: %s", o.Type, strings.Join(metricTypes, ", "))
+	}
+
+	columns, err := parseColumns(o.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	metricTable := newModel(nil, o.OutputHeight)
+	metricTable.federated = scrape.IsFederationURL(scrapeURL)
+	keys.Group.SetEnabled(metricTable.federated)
+	metricTable.tracer = tracer
+	metricTable.scrapeFn = scrapeFn
+	metricTable.history = scrape.NewHistory(o.HistorySize)
+	metricTable.historySize = o.HistorySize
+	metricTable.timeline = scrape.NewTimeline(o.TimelineSize)
+	metricTable.timelineSize = o.TimelineSize
+	metricTable.typeFilter = o.Type
+	metricTable.minCardinality = o.MinCardinality
+	metricTable.appOnly = o.AppOnly
+	metricTable.columns = columns
+	metricTable.maxInfoLabels = o.MaxInfoLabels
+	metricTable.pushStaleAfter = o.PushStaleAfter
+	metricTable.grafanaURL = o.GrafanaURL
+	metricTable.grafanaDatasource = o.GrafanaDatasource
+	metricTable.prometheusURL = o.PrometheusURL
+	metricTable.tempoDatasource = o.TempoDatasource
+	metricTable.jaegerURL = o.JaegerURL
+	for _, name := range o.Pins {
+		metricTable.pinned[name] = struct{}{}
+	}
+	metricTable.target = scrapeURL
+	metricTable.slowScrape = o.slowScrape
+	if o.Baseline != "" {
+		baseline, err := scrape.LoadResult(o.Baseline)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load the baseline %s", o.Baseline)
+		}
+		metricTable.baseline = baseline
+		keys.Diff.SetEnabled(true)
+	}
+	if o.StateFile != "" {
+		metricTable.stateFile = o.StateFile
+		metricTable.stateKey = cmp.Or(o.stateKey, scrapeURL)
+		state, ok, err := loadSessionState(o.StateFile, metricTable.stateKey)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to load the session state", "file", o.StateFile, "err", err)
+		} else if ok {
+			metricTable.restoreSessionState(state)
+		}
+	}
+	metricTable.resetColumns()
+	return metricTable, nil
+}
+
+// runTable runs the TUI fed by a first scrape, refreshing it on every reload event until it exits.
+func runTable(g *run.Group, logger log.Logger, metricTable *seriesTable, reloadCh <-chan struct{}) {
+	p := tea.NewProgram(metricTable)
+
+	// Create a channel to signal when the UI has exited
+	scrapeDone := make(chan struct{})
+
+	g.Add(func() error {
+		_, err := p.Run()
+		// A target that never loaded has no state worth saving.
+		if metricTable.loaded {
+			if err := metricTable.saveSessionState(); err != nil {
+				level.Warn(logger).Log("msg", "failed to save the session state", "err", err)
 			}
+		}
+		if err != nil {
+			return err
+		}
+		return metricTable.exitErr()
+	}, func(error) {
+		close(scrapeDone)
+	})
 
+	g.Add(func() error {
+		// The TUI retries a failed first scrape, see failScrape.
+		metrics, err := metricTable.scrapeFn()
+		if err != nil {
+			level.Warn(logger).Log("msg", "scrape failed", "err", err)
+			p.Send(err)
+		} else {
 			// Send the scraped data to the UI
-			level.Info(logger).Log("msg", "scraping complete", "duration", time.Since(t0))
 			p.Send(metrics)
-			return nil
-		}, func(error) {})
+		}
 
-		return nil
-	})
+		// Re-scrape on every reload event until the UI exits.
+		for {
+			select {
+			case <-reloadCh:
+				level.Info(logger).Log("msg", "reload requested, refreshing")
+				p.Send(refreshMsg{reason: "SIGHUP"})
+			case <-scrapeDone:
+				return nil
+			}
+		}
+	}, func(error) {})
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestCardinalityOptions_DiscoverTargets(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status": "error", "error": "unauthorized"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"activeTargets": [
+			{"scrapePool": "api", "scrapeUrl": "http://api-1:8080/metrics", "labels": {"job": "api"}},
+			{"scrapePool": "api", "scrapeUrl": "http://api-2:8080/metrics", "labels": {"job": "api"}}
+		]}}`))
+	}))
+	t.Cleanup(srv.Close)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret"), 0o600))
+
+	for name, tc := range map[string]struct {
+		args        []string
+		wantURL     string
+		wantTargets []string
+		wantErr     string
+	}{
+		"first target becomes --scrape-url": {
+			args:        []string{"--prometheus.bearer-token-file=" + tokenFile},
+			wantURL:     "http://api-1:8080/metrics",
+			wantTargets: []string{"http://api-2:8080/metrics"},
+		},
+		"--scrape-url is kept": {
+			args:        []string{"--prometheus.bearer-token-file=" + tokenFile, "--scrape-url=http://db:9104/metrics"},
+			wantURL:     "http://db:9104/metrics",
+			wantTargets: []string{"http://api-1:8080/metrics", "http://api-2:8080/metrics"},
+		},
+		"unauthenticated": {
+			wantErr: "unauthorized",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			kp := kingpin.New("test", "")
+			opts := &cardinalityOptions{}
+			opts.addFlags(extkingpin.NewApp(kp).Command("cardinality", ""))
+			args := append([]string{"cardinality", "--prometheus.url=" + srv.URL, "--prometheus.job=api"}, tc.args...)
+			_, err := kp.Parse(args)
+			require.NoError(t, err)
+
+			err = opts.discoverTargets(context.Background(), log.NewNopLogger())
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantURL, opts.ScrapeURL)
+			require.Equal(t, tc.wantTargets, opts.Targets)
+		})
+	}
+}
+
+// The targets API requests go through the proxy of the environment, like the scrapes.
+func TestCardinalityOptions_PrometheusClient_Proxy(t *testing.T) {
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+	}))
+	t.Cleanup(proxy.Close)
+	for _, name := range []string{"HTTP_PROXY", "http_proxy"} {
+		t.Setenv(name, proxy.URL)
+	}
+	for _, name := range []string{"NO_PROXY", "no_proxy"} {
+		t.Setenv(name, "")
+	}
+
+	client, err := (&cardinalityOptions{}).prometheusClient()
+	require.NoError(t, err)
+	resp, err := client.Get("http://prometheus.invalid:9090/api/v1/targets")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "http://prometheus.invalid:9090/api/v1/targets", <-proxied)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// output formats of the check command.
+const (
+	checkOutputText  = "text"
+	checkOutputJUnit = "junit"
+)
+
+type checkOptions struct {
+	Options
+	Lint      bool
+	LintRules string
+	// MaxLabelValueLength is the length above which the lint warns about label values.
+	MaxLabelValueLength int
+	Budget              scrape.Budget
+	IgnoreFile          string
+	Output              string
+	// Promtool compares the lint with promtool check metrics.
+	Promtool bool
+}
+
+func (o *checkOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+
+	app.Flag("lint", "Lint every metric family like promtool check metrics").
+		Default("true").
+		BoolVar(&o.Lint)
+
+	app.Flag("lint.rules", "YAML file of naming conventions to check the metric families against as well, e.g. "+
+		"required prefixes or forbidden label names").
+		Default("").
+		StringVar(&o.LintRules)
+
+	app.Flag("lint.max-label-value-length", "Warn about labels with values longer than this, e.g. full URLs "+
+		"or JSON blobs bloating the index, 0 to disable").
+		Default(strconv.Itoa(scrape.DefaultMaxLabelValueLength)).
+		IntVar(&o.MaxLabelValueLength)
+
+	app.Flag("lint.promtool", "Also lint the exposition the way promtool check metrics reads it, and warn where "+
+		"promtool and the lint disagree, e.g. when only one of them fails in CI").
+		Default("false").
+		BoolVar(&o.Promtool)
+
+	app.Flag("budget.max-series", "Fail when the target exposes more series than this, 0 to disable").
+		Default("0").
+		IntVar(&o.Budget.MaxSeries)
+
+	app.Flag("budget.max-family-series", "Fail for metric families with more series than this, 0 to disable").
+		Default("0").
+		IntVar(&o.Budget.MaxFamilySeries)
+
+	app.Flag("budget.max-label-values", "Fail for labels of a metric family with more values than this, "+
+		"0 to disable").
+		Default("0").
+		IntVar(&o.Budget.MaxLabelValues)
+
+	app.Flag("ignore-file", "File listing the known problems that should not fail the checks, skipped when the "+
+		"default file does not exist").
+		Default(scrape.IgnoreFile).
+		StringVar(&o.IgnoreFile)
+
+	app.Flag("output", "Output format: "+checkOutputText+", or "+checkOutputJUnit+" for a JUnit XML report "+
+		"with one test case per metric family and check").
+		Default(checkOutputText).
+		EnumVar(&o.Output, checkOutputText, checkOutputJUnit)
+}
+
+// check runs the enabled checks against the exposition of the target.
+func (o *checkOptions) check(
+	ctx context.Context,
+	scraper scrape.RawScraper,
+	conventions *scrape.ConventionRules,
+) ([]scrape.CheckResult, error) {
+	contentType, body, err := scraper.FetchWithContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scrape target")
+	}
+
+	var results []scrape.CheckResult
+	if o.Lint || conventions != nil {
+		families, err := scrape.DecodeFamilies(body, contentType)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode the exposition")
+		}
+		if o.Lint {
+			lint, err := scrape.Lint(families)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, lint...)
+			if o.Promtool {
+				promtool, err := scrape.ComparePromtool(body, lint)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, promtool...)
+			}
+		}
+		if conventions != nil {
+			results = append(results, scrape.CheckConventions(families, conventions)...)
+		}
+	}
+
+	result, err := scraper.Parse(body, contentType)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the exposition")
+	}
+	if o.Lint {
+		// One lint result per family, holding the problems of promlint, of the types and of the label values,
+		// and one for the labels shared by the whole scrape.
+		lint := scrape.CheckMixedTypes(result.Series)
+		if o.MaxLabelValueLength > 0 {
+			lint = append(lint, scrape.CheckLabelValueLengths(result.Series, o.MaxLabelValueLength)...)
+		}
+		results = scrape.MergeResults(append(results, lint...))
+		results = append(results, scrape.CheckSharedLabels(result.Series))
+	}
+	return append(results, scrape.CheckBudget(result.Series, o.Budget)...), nil
+}
+
+// ignoreList loads the ignore file, nil when the default one does not exist.
+func (o *checkOptions) ignoreList() (*scrape.IgnoreList, error) {
+	if !flagSet("ignore-file") {
+		if _, err := os.Stat(o.IgnoreFile); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+	}
+	return scrape.LoadIgnoreList(o.IgnoreFile)
+}
+
+func registerCheckCommand(app *extkingpin.App) {
+	cmd := app.Command("check", "Lint the metrics of a target and check its cardinality against a budget, "+
+		"failing when a check fails, e.g. in CI.")
+	opts := &checkOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		if opts.Promtool && !opts.Lint {
+			return errors.New("--lint.promtool needs --lint")
+		}
+		scraper, err := opts.RawScraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+		var conventions *scrape.ConventionRules
+		if opts.LintRules != "" {
+			if conventions, err = scrape.LoadConventionRules(opts.LintRules); err != nil {
+				return err
+			}
+		}
+		ignore, err := opts.ignoreList()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			results, err := opts.check(ctx, scraper, conventions)
+			if err != nil {
+				return err
+			}
+			if ignore != nil {
+				suppressed, expired := ignore.Apply(results, time.Now())
+				for _, e := range expired {
+					level.Warn(logger).Log("msg", "ignore entry expired, its problems are reported again",
+						"file", opts.IgnoreFile, "line", e.Line, "entry", e.Text)
+				}
+				level.Info(logger).Log("msg", "ignored known problems", "file", opts.IgnoreFile, "problems", suppressed)
+			}
+			if opts.Output == checkOutputJUnit {
+				err = writeJUnit(os.Stdout, opts.redactedScrapeURL(), results)
+			} else {
+				err = printCheckResults(os.Stdout, results)
+			}
+			if err != nil {
+				return err
+			}
+			if failed := countFailed(results); failed > 0 {
+				return errors.Errorf("%d of %d checks failed", failed, len(results))
+			}
+			return nil
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+// printCheckResults lists the problems found and sums up the results.
+func printCheckResults(out io.Writer, results []scrape.CheckResult) error {
+	for _, r := range results {
+		for _, p := range r.Problems {
+			status := "FAIL"
+			if p.Severity == scrape.SeverityWarning {
+				status = "WARN"
+			}
+			_, err := fmt.Fprintf(out, "%s %s %s [%s]: %s\n", status, r.Check, checkSubject(r), p.Rule, p.Text)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(out, "%d checks, %d failed\n", len(results), countFailed(results))
+	return err
+}
+
+func countFailed(results []scrape.CheckResult) int {
+	failed := 0
+	for _, r := range results {
+		if r.Failed() {
+			failed++
+		}
+	}
+	return failed
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+
+	"github.com/atotto/clipboard"
+	"github.com/muesli/termenv"
+)
+
+// copyToClipboard copies text to the system clipboard. Over SSH, or when no clipboard utility is
+// available, it falls back to the OSC52 escape sequence so the local terminal does the copy.
+func copyToClipboard(text string) {
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		if err := clipboard.WriteAll(text); err == nil {
+			return
+		}
+	}
+	termenv.Copy(text)
+}
+
+// copySelected copies the name, or a selector built from the label stats, of the selected metric.
+func (m *seriesTable) copySelected(selector bool) string {
+	name := rowName(m.table.SelectedRow())
+	set, ok := m.seriesMap[name]
+	if !ok {
+		return ""
+	}
+	text := name
+	if selector {
+		text = set.Selector(name)
+	}
+	copyToClipboard(text)
+	return text
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// maxCollisionFamilies is the number of families carrying a colliding label listed in the report.
+const maxCollisionFamilies = 3
+
+type collisionsOptions struct {
+	Options
+	Job            string
+	Instance       string
+	TargetLabels   []string
+	ExternalLabels []string
+}
+
+func (o *collisionsOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("job", "Job label Prometheus sets on the series of the target").
+		Required().
+		StringVar(&o.Job)
+
+	app.Flag("instance", "Instance label Prometheus sets on the series of the target, defaults to the host of "+
+		"--scrape-url").
+		Default("").
+		StringVar(&o.Instance)
+
+	app.Flag("target-label", "Other label Prometheus sets on the series of the target as 'name=value', e.g. "+
+		"from relabel_configs, can be repeated").
+		StringsVar(&o.TargetLabels)
+
+	app.Flag("external-label", "External label of the Prometheus as 'name=value', can be repeated").
+		StringsVar(&o.ExternalLabels)
+}
+
+// promLabels returns the target labels and the external labels of the flags.
+func (o *collisionsOptions) promLabels() (labels.Labels, labels.Labels, error) {
+	instance := o.Instance
+	if instance == "" {
+		u, err := url.Parse(o.ScrapeURL)
+		if err != nil || u.Host == "" {
+			return labels.EmptyLabels(), labels.EmptyLabels(), errors.Errorf(
+				"cannot derive the instance from %s, set --instance", o.ScrapeURL)
+		}
+		instance = u.Host
+	}
+	target := labels.NewBuilder(labels.FromStrings(
+		model.JobLabel, o.Job,
+		model.InstanceLabel, instance,
+	))
+	for _, l := range o.TargetLabels {
+		name, value, ok := strings.Cut(l, "=")
+		if !ok || name == "" {
+			return labels.EmptyLabels(), labels.EmptyLabels(), errors.Errorf(
+				"invalid target label %q, expected 'name=value'", l)
+		}
+		target.Set(name, value)
+	}
+	external := labels.NewBuilder(labels.EmptyLabels())
+	for _, l := range o.ExternalLabels {
+		name, value, ok := strings.Cut(l, "=")
+		if !ok || name == "" {
+			return labels.EmptyLabels(), labels.EmptyLabels(), errors.Errorf(
+				"invalid external label %q, expected 'name=value'", l)
+		}
+		external.Set(name, value)
+	}
+	return target.Labels(), external.Labels(), nil
+}
+
+func registerCollisionsCommand(app *extkingpin.App) {
+	cmd := app.Command("collisions", "Report the scraped labels colliding with the job, instance and external "+
+		"labels set by Prometheus, and what honor_labels: true and false would do with them.")
+	opts := &collisionsOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		target, external, err := opts.promLabels()
+		if err != nil {
+			return err
+		}
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			return printCollisions(os.Stdout, scrape.LabelCollisions(result.Series, target, external))
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printCollisions(out io.Writer, collisions []scrape.LabelCollision) error {
+	if len(collisions) == 0 {
+		_, err := fmt.Fprintln(out, "No scraped series carries a label set by Prometheus")
+		return err
+	}
+
+	fmt.Fprintln(out, "Labels set by Prometheus that the scraped series already carry:")
+	fmt.Fprintln(out)
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LABEL\tSET TO\tSOURCE\tSERIES\tSAME VALUE\tSCRAPED VALUES\tFAMILIES")
+	for _, c := range collisions {
+		source := "target"
+		if c.External {
+			source = "external"
+		}
+		families := strings.Join(c.Families[:min(len(c.Families), maxCollisionFamilies)], ", ")
+		if more := len(c.Families) - maxCollisionFamilies; more > 0 {
+			families += fmt.Sprintf(" and %d more", more)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+			c.Name, c.Value, source, c.Series, c.SameValue, c.Values, families)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var targetLines, honorLines, externalLines []string
+	for _, c := range collisions {
+		if c.External {
+			externalLines = append(externalLines, fmt.Sprintf(
+				"  %s: the scraped values are kept, %s=%q is not added to %d series",
+				c.Name, c.Name, c.Value, c.Series))
+			continue
+		}
+		targetLines = append(targetLines, fmt.Sprintf(
+			"  %s: the scraped values move to %s, a label with %d value(s) added to %d series",
+			c.Name, c.ExportedName(), c.Values, c.Series))
+		honorLines = append(honorLines, fmt.Sprintf(
+			"  %s: the scraped values are kept, %q is lost on %d series",
+			c.Name, c.Value, c.Series-c.SameValue))
+	}
+	if len(targetLines) > 0 {
+		fmt.Fprintln(out, "\nWith honor_labels: false, the default:")
+		fmt.Fprintln(out, strings.Join(targetLines, "\n"))
+		fmt.Fprintln(out, "\nWith honor_labels: true, series of targets exposing the same values collide:")
+		fmt.Fprintln(out, strings.Join(honorLines, "\n"))
+	}
+	if len(externalLines) > 0 {
+		fmt.Fprintln(out, "\nExternal labels, honor_labels or not:")
+		fmt.Fprintln(out, strings.Join(externalLines, "\n"))
+	}
+	return nil
+}
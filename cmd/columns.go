@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+const (
+	// minFlexWidth is the narrowest a flexible column gets on small terminals.
+	minFlexWidth = 10
+	// columnPadding is the horizontal padding the table styles add to every cell.
+	columnPadding = 2
+	// tableBorder is the width taken by the border around the table.
+	tableBorder = 2
+)
+
+type columnLayout struct {
+	title string
+	width int
+	// flex columns share the terminal width left over by the fixed ones.
+	flex bool
+}
+
+type metricColumn struct {
+	columnLayout
+	key string
+	// trend columns are only available once the session has more than one scrape, change columns once the
+	// previous run of the tool is known.
+	trend  bool
+	change bool
+	cell   func(m *seriesTable, r scrape.SeriesInfo) string
+}
+
+// metricColumnDefs are all the columns of the metric table, in display order. The name column must
+// stay first as rows are identified by it.
+var metricColumnDefs = []metricColumn{
+	{
+		key:          "name",
+		columnLayout: columnLayout{title: "Name", width: 60, flex: true},
+		cell:         func(_ *seriesTable, r scrape.SeriesInfo) string { return r.Name },
+	},
+	{
+		key:          "cardinality",
+		columnLayout: columnLayout{title: "Cardinality", width: 16},
+		cell: func(m *seriesTable, r scrape.SeriesInfo) string {
+			return m.formatCardinality(r.Name, r.Cardinality)
+		},
+	},
+	{
+		key:          "type",
+		columnLayout: columnLayout{title: "Type", width: 10},
+		cell:         func(_ *seriesTable, r scrape.SeriesInfo) string { return r.Type },
+	},
+	{
+		key:          "labels",
+		columnLayout: columnLayout{title: "Labels", width: 80, flex: true},
+		cell:         func(_ *seriesTable, r scrape.SeriesInfo) string { return r.Labels },
+	},
+	{
+		key:          "created",
+		columnLayout: columnLayout{title: "Created TS", width: 50, flex: true},
+		cell:         func(_ *seriesTable, r scrape.SeriesInfo) string { return r.CreatedTS },
+	},
+	{
+		key:          "delta",
+		columnLayout: columnLayout{title: "Δ", width: 8},
+		trend:        true,
+		cell: func(m *seriesTable, r scrape.SeriesInfo) string {
+			return formatDelta(m.history.Delta(r.Name))
+		},
+	},
+	{
+		key:          "trend",
+		columnLayout: columnLayout{title: "Trend", width: 20},
+		trend:        true,
+		cell: func(m *seriesTable, r scrape.SeriesInfo) string {
+			return sparkline(m.history.Cardinalities(r.Name))
+		},
+	},
+	{
+		key:          "change",
+		columnLayout: columnLayout{title: "Since last run", width: 16},
+		change:       true,
+		cell: func(m *seriesTable, r scrape.SeriesInfo) string {
+			return formatTrend(m.runTrends[r.Name])
+		},
+	},
+}
+
+var originColumnLayout = []columnLayout{
+	{title: "Job", width: 60, flex: true},
+	{title: "Cardinality", width: 16},
+	{title: "Instances", width: 10},
+	{title: "Metrics", width: 10},
+	{title: "Top metric", width: 80, flex: true},
+}
+
+// defaultColumns is the default value of the --columns flag.
+var defaultColumns = func() string {
+	keys := make([]string, 0, len(metricColumnDefs))
+	for _, c := range metricColumnDefs {
+		keys = append(keys, c.key)
+	}
+	return strings.Join(keys, ",")
+}()
+
+// columnToggleKeys returns the keys toggling the metric columns, 2 for the second column and so on, the name
+// column cannot be hidden.
+func columnToggleKeys() []string {
+	keys := make([]string, 0, len(metricColumnDefs)-1)
+	for n := 2; n <= len(metricColumnDefs); n++ {
+		keys = append(keys, strconv.Itoa(n))
+	}
+	return keys
+}
+
+// columnToggleNames returns the keys of the columns toggled by columnToggleKeys, in order.
+func columnToggleNames() string {
+	names := make([]string, 0, len(metricColumnDefs)-1)
+	for _, c := range metricColumnDefs[1:] {
+		names = append(names, c.key)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseColumns validates a comma separated list of column keys. The name column is always included.
+func parseColumns(s string) ([]string, error) {
+	keys := []string{metricColumnDefs[0].key}
+	for _, k := range strings.Split(s, ",") {
+		k = strings.TrimSpace(strings.ToLower(k))
+		if k == "" || slices.Contains(keys, k) {
+			continue
+		}
+		if !slices.ContainsFunc(metricColumnDefs, func(c metricColumn) bool { return c.key == k }) {
+			return nil, fmt.Errorf("unknown column %q, expected any of: %s", k, defaultColumns)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// visibleMetricColumns returns the metric columns currently displayed.
+func (m *seriesTable) visibleMetricColumns() []metricColumn {
+	var cols []metricColumn
+	for _, c := range metricColumnDefs {
+		if !slices.Contains(m.columns, c.key) {
+			continue
+		}
+		if c.trend && !m.showTrend() || c.change && m.runTrends == nil {
+			continue
+		}
+		cols = append(cols, c)
+	}
+	return cols
+}
+
+// toggleColumn shows or hides the n-th (1-based) metric column. The name column cannot be hidden.
+func (m *seriesTable) toggleColumn(n int) {
+	if n < 2 || n > len(metricColumnDefs) {
+		return
+	}
+	k := metricColumnDefs[n-1].key
+	if i := slices.Index(m.columns, k); i >= 0 {
+		m.columns = slices.Delete(m.columns, i, i+1)
+	} else {
+		m.columns = append(m.columns, k)
+	}
+	m.resetColumns()
+}
+
+// resetColumns recomputes the columns of the current view, e.g. after a resize or a column toggle.
+func (m *seriesTable) resetColumns() {
+	// Clear the rows first so they never get rendered against a different set of columns.
+	m.table.SetRows(nil)
+	switch {
+	case m.diffView:
+		m.table.SetColumns(fitColumns(m.diffLayout(), m.width))
+	case m.targetView && m.divergenceView:
+		m.table.SetColumns(fitColumns(divergenceColumnLayout, m.width))
+	case m.targetView:
+		m.table.SetColumns(fitColumns(targetColumnLayout, m.width))
+	case m.drillDown != "":
+		m.table.SetColumns(fitColumns(m.drillDownLayout(), m.width))
+	case m.groupByOrigin && m.pushgateway:
+		m.table.SetColumns(fitColumns(pushGroupColumnLayout, m.width))
+	case m.groupByOrigin:
+		m.table.SetColumns(fitColumns(originColumnLayout, m.width))
+	case m.treeView:
+		m.table.SetColumns(fitColumns(treeColumnLayout, m.width))
+	default:
+		cols := m.visibleMetricColumns()
+		layout := make([]columnLayout, 0, len(cols))
+		for _, c := range cols {
+			layout = append(layout, c.columnLayout)
+		}
+		m.table.SetColumns(fitColumns(layout, m.width))
+	}
+	m.setTableRows(m.currentFilter())
+}
+
+// fitColumns sizes the columns to the terminal width. Fixed columns keep their width while flexible
+// columns share what is left in proportion to their preferred width. A zero width keeps the preferred widths.
+func fitColumns(layout []columnLayout, width int) []table.Column {
+	cols := make([]table.Column, 0, len(layout))
+	if width <= 0 {
+		for _, l := range layout {
+			cols = append(cols, table.Column{Title: l.title, Width: l.width})
+		}
+		return cols
+	}
+
+	available := width - tableBorder - columnPadding*len(layout)
+	flexPreferred := 0
+	for _, l := range layout {
+		if l.flex {
+			flexPreferred += l.width
+		} else {
+			available -= l.width
+		}
+	}
+
+	for _, l := range layout {
+		w := l.width
+		if l.flex && flexPreferred > 0 {
+			w = max(available*l.width/flexPreferred, minFlexWidth)
+		}
+		cols = append(cols, table.Column{Title: l.title, Width: w})
+	}
+	return cols
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type compressionOptions struct {
+	Options
+	Encodings []string
+}
+
+func (o *compressionOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+
+	app.Flag("encoding", "Content encoding to compare, can be repeated").
+		Default(scrape.Encodings...).
+		EnumsVar(&o.Encodings, scrape.Encodings...)
+}
+
+func registerCompressionCommand(app *extkingpin.App) {
+	cmd := app.Command("compression", "Compare the size of the body of a target per content encoding, as served "+
+		"or as it would be if the handler compressed it.")
+	opts := &compressionOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.NewScraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			sizes, err := scraper.MeasureEncodings(ctx, opts.Encodings...)
+			if err != nil {
+				return err
+			}
+			return printEncodingSizes(os.Stdout, sizes)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printEncodingSizes(out io.Writer, sizes []scrape.EncodingSize) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENCODING\tSERVED\tWIRE SIZE\tBODY SIZE\tRATIO\tDURATION")
+	for _, s := range sizes {
+		served := "yes"
+		if !s.Served {
+			served = "no, compressed locally"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.1fx\t%s\n", s.Encoding, served, units.BytesSize(float64(s.WireBytes)),
+			units.BytesSize(float64(s.BodyBytes)), s.Ratio(), s.Duration.Round(time.Millisecond))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Prometheus asks for gzip, the handler is worth compressing when it does not serve it.
+	for _, s := range sizes {
+		if s.Encoding != scrape.EncodingGzip {
+			continue
+		}
+		if s.Served {
+			fmt.Fprintf(out, "\nThe target serves gzip, Prometheus transfers %s per scrape\n",
+				units.BytesSize(float64(s.WireBytes)))
+		} else {
+			fmt.Fprintf(out, "\nThe target ignores gzip, Prometheus transfers %s per scrape where compressing "+
+				"the response would transfer %s\n", units.BytesSize(float64(s.BodyBytes)),
+				units.BytesSize(float64(s.WireBytes)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is looked up in the working directory when --config is not given.
+const defaultConfigFile = "scrape-analyzer.yaml"
+
+// toolConfig is the configuration file of the tool. Profiles map flag names, without the leading
+// dashes, to their values, themes define the colors selectable with --theme, e.g.:
+//
+//	profiles:
+//	  payments-api:
+//	    scrape-url: https://payments:8443/metrics
+//	    scrape.bearer-token-file: /var/run/secrets/token
+//	    max-scrape-size: 500MB
+//	    columns: name,cardinality,labels
+//	    pin: [http_requests_total, up]
+//	themes:
+//	  solarized:
+//	    base: light
+//	    selected-background: "#268bd2"
+type toolConfig struct {
+	Profiles map[string]map[string]any `yaml:"profiles"`
+	// Themes are decoded over their base theme, see lookupTheme.
+	Themes map[string]yaml.Node `yaml:"themes"`
+}
+
+func readToolConfig(path string) (toolConfig, error) {
+	var cfg toolConfig
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, errors.Wrap(err, "failed to read config file")
+	}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return cfg, errors.Wrapf(err, "failed to parse config file %s", path)
+	}
+	return cfg, nil
+}
+
+// argValue returns the value of a flag in the raw command line, given as --flag value or --flag=value, or
+// else the value of its environment variable.
+func argValue(args []string, flag string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if v, ok := strings.CutPrefix(arg, "--"+flag+"="); ok {
+			return v
+		}
+		if arg == "--"+flag && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return os.Getenv(envVar(flag))
+}
+
+// profileArgs returns the flags setting the values of a profile for the command selected in args, to be
+// inserted into them with withProfileArgs. Flags given in args or through their environment variable are
+// skipped, so they take precedence over the profile, as are the flags the selected command does not define.
+func profileArgs(app *kingpin.Application, args []string, path, profile string) ([]string, error) {
+	cfg, err := readToolConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := cfg.Profiles[profile]
+	if !ok {
+		names := make([]string, 0, len(cfg.Profiles))
+		for name := range cfg.Profiles {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, errors.Errorf("profile %q not found in %s, available profiles: %s",
+			profile, path, strings.Join(names, ", "))
+	}
+
+	flags := commandFlags(app, args)
+	var command string
+	if cmd, _ := selectedCommand(app, args); cmd != nil {
+		command = cmd.FullCommand
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	// Keep the generated command line stable.
+	slices.Sort(names)
+
+	var extra []string
+	for _, name := range names {
+		flag, ok := flags[name]
+		switch {
+		case !ok && !definesFlag(app, name):
+			return nil, errors.Errorf("unknown flag %q in profile %q", name, profile)
+		case !ok, flagGiven(args, name), os.Getenv(flagEnvVar(command, name)) != "":
+			continue
+		}
+
+		vals, err := flagValues(values[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value of %s in profile %q", name, profile)
+		}
+		for _, v := range vals {
+			switch {
+			case !flag.IsBoolFlag():
+				extra = append(extra, "--"+name+"="+v)
+			case v == "true":
+				extra = append(extra, "--"+name)
+			default:
+				extra = append(extra, "--no-"+name)
+			}
+		}
+	}
+	return extra, nil
+}
+
+// withProfileArgs inserts the flags of a profile into args right after the selected command, before the flags
+// of the user and before a -- ending them.
+func withProfileArgs(app *kingpin.Application, args, extra []string) []string {
+	i := 0
+	if cmd, pos := selectedCommand(app, args); cmd != nil {
+		i = pos + 1
+	}
+	return slices.Concat(args[:i], extra, args[i:])
+}
+
+// commandFlags returns the application flags and the flags of the command selected in args, by name.
+func commandFlags(app *kingpin.Application, args []string) map[string]*kingpin.FlagModel {
+	flags := make(map[string]*kingpin.FlagModel)
+	for _, f := range app.Model().Flags {
+		flags[f.Name] = f
+	}
+	if cmd, _ := selectedCommand(app, args); cmd != nil {
+		for _, f := range cmd.Flags {
+			flags[f.Name] = f
+		}
+	}
+	return flags
+}
+
+// selectedCommand returns the command selected in args and its position in them, nil when there is none. The
+// values of the flags given as --flag value are skipped, they may well be a command name.
+func selectedCommand(app *kingpin.Application, args []string) (*kingpin.CmdModel, int) {
+	model := app.Model()
+	commands := model.FlattenedCommands()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			if takesValue(model, arg) {
+				i++
+			}
+			continue
+		}
+		if j := slices.IndexFunc(commands, func(cmd *kingpin.CmdModel) bool { return cmd.Name == arg }); j >= 0 {
+			return commands[j], i
+		}
+	}
+	return nil, -1
+}
+
+// takesValue reports whether arg is a flag of the application given without its value, the value being the
+// next argument then.
+func takesValue(model *kingpin.ApplicationModel, arg string) bool {
+	if strings.Contains(arg, "=") {
+		return false
+	}
+	name, long := strings.CutPrefix(arg, "--")
+	if !long {
+		name = strings.TrimPrefix(arg, "-")
+	}
+	flags := slices.Clone(model.Flags)
+	for _, cmd := range model.FlattenedCommands() {
+		flags = append(flags, cmd.Flags...)
+	}
+	i := slices.IndexFunc(flags, func(f *kingpin.FlagModel) bool {
+		if long {
+			return f.Name == name
+		}
+		return f.Short != 0 && name == string(f.Short)
+	})
+	return i >= 0 && !flags[i].IsBoolFlag()
+}
+
+// definesFlag reports whether any command of the application defines the flag.
+func definesFlag(app *kingpin.Application, name string) bool {
+	return slices.ContainsFunc(app.Model().FlattenedCommands(), func(cmd *kingpin.CmdModel) bool {
+		return slices.ContainsFunc(cmd.Flags, func(f *kingpin.FlagModel) bool { return f.Name == name })
+	})
+}
+
+// flagGiven reports whether the flag is set in the raw command line, before a -- ending the flags.
+func flagGiven(args []string, name string) bool {
+	if i := slices.Index(args, "--"); i >= 0 {
+		args = args[:i]
+	}
+	return slices.ContainsFunc(args, func(arg string) bool {
+		return arg == "--"+name || arg == "--no-"+name || strings.HasPrefix(arg, "--"+name+"=")
+	})
+}
+
+// flagValues converts a YAML value to flag values, lists set repeatable flags.
+func flagValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case int, float64:
+		return []string{fmt.Sprint(v)}, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := flagValues(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s...)
+		}
+		return values, nil
+	default:
+		return nil, errors.Errorf("unsupported value %v, expected a string, number, boolean or list", value)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// The environment variables of the cases are process wide, the tests of this file are not parallel.
+
+func TestProfileArgs_Precedence(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "scrape-analyzer.yaml")
+	require.NoError(t, os.WriteFile(config, []byte(`profiles:
+  api:
+    scrape-url: http://profile:8080/metrics
+    limit: 5
+    max-series: 50
+`), 0o600))
+
+	for _, tc := range []struct {
+		name          string
+		args          []string
+		env           map[string]string
+		profile       string
+		wantScrapeURL string
+		wantLimit     int
+		wantMaxSeries int
+	}{
+		{
+			name:          "defaults",
+			args:          []string{"top", "--scrape-url=http://flag:8080/metrics"},
+			wantScrapeURL: "http://flag:8080/metrics",
+			wantLimit:     20,
+		},
+		{
+			name:          "profile over defaults",
+			args:          []string{"top"},
+			profile:       "api",
+			wantScrapeURL: "http://profile:8080/metrics",
+			wantLimit:     5,
+			wantMaxSeries: 50,
+		},
+		{
+			name: "environment over profile",
+			args: []string{"top"},
+			env: map[string]string{
+				"PROM_SCRAPE_ANALYZER_TOP_LIMIT":  "7",
+				"PROM_SCRAPE_ANALYZER_MAX_SERIES": "70",
+			},
+			profile:       "api",
+			wantScrapeURL: "http://profile:8080/metrics",
+			wantLimit:     7,
+			wantMaxSeries: 70,
+		},
+		{
+			name:          "flags over environment and profile",
+			args:          []string{"top", "--limit=9", "--max-series", "90", "--scrape-url=http://flag:8080/metrics"},
+			env:           map[string]string{"PROM_SCRAPE_ANALYZER_TOP_LIMIT": "7"},
+			profile:       "api",
+			wantScrapeURL: "http://flag:8080/metrics",
+			wantLimit:     9,
+			wantMaxSeries: 90,
+		},
+		{
+			name:          "flag value equal to the command",
+			args:          []string{"--profile", "top", "top", "--limit", "9"},
+			profile:       "api",
+			wantScrapeURL: "http://profile:8080/metrics",
+			wantLimit:     9,
+			wantMaxSeries: 50,
+		},
+		{
+			name:          "short flag value equal to the command",
+			args:          []string{"-p", "top", "--log.debug", "top"},
+			profile:       "api",
+			wantScrapeURL: "http://profile:8080/metrics",
+			wantLimit:     5,
+			wantMaxSeries: 50,
+		},
+		{
+			name:          "profile before the end of the flags",
+			args:          []string{"top", "--limit=9", "--"},
+			profile:       "api",
+			wantScrapeURL: "http://profile:8080/metrics",
+			wantLimit:     9,
+			wantMaxSeries: 50,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			kp := kingpin.New("test", "")
+			kp.Flag("profile", "").Short('p').String()
+			kp.Flag("log.debug", "").Bool()
+			opts := &topOptions{}
+			opts.addFlags(extkingpin.NewApp(kp).Command("top", ""))
+			bindEnvVars(kp)
+
+			args := tc.args
+			if tc.profile != "" {
+				extra, err := profileArgs(kp, args, config, tc.profile)
+				require.NoError(t, err)
+				args = withProfileArgs(kp, args, extra)
+			}
+			_, err := kp.Parse(args)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantScrapeURL, opts.ScrapeURL)
+			require.Equal(t, tc.wantLimit, opts.Limit)
+			require.Equal(t, tc.wantMaxSeries, opts.MaxSeries)
+		})
+	}
+}
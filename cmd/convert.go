@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/prometheus/config"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// exposition formats accepted by the convert command, auto only applies to the input.
+const (
+	formatAuto        = "auto"
+	formatText        = "text"
+	formatOpenMetrics = "openmetrics"
+	formatProtobuf    = "protobuf"
+)
+
+type convertOptions struct {
+	Options
+	Input        string
+	InputFormat  string
+	Output       string
+	OutputFormat string
+}
+
+func (o *convertOptions) addFlags(app extkingpin.AppClause) {
+	app.Flag("input", "Exposition to convert: a file path, - for stdin or an http(s) URL to scrape").
+		Required().
+		StringVar(&o.Input)
+
+	app.Flag("input-format", "Format of the input, auto detects it from the content or the response headers").
+		Default(formatAuto).
+		EnumVar(&o.InputFormat, formatAuto, formatText, formatOpenMetrics, formatProtobuf)
+
+	app.Flag("output", "File to write the converted exposition to, - for stdout").
+		Default("-").
+		StringVar(&o.Output)
+
+	app.Flag("output-format", "Format of the output").
+		Default(formatOpenMetrics).
+		EnumVar(&o.OutputFormat, formatText, formatOpenMetrics, formatProtobuf)
+
+	app.Flag("timeout", "Timeout for the scrape request when the input is a URL").
+		Default("10s").
+		DurationVar(&o.Timeout)
+
+	app.Flag("max-scrape-size", "Maximum size of the scrape response body (e.g. 10MB, 1GB)").
+		Default("100MB").
+		StringVar(&o.MaxScrapeSize)
+}
+
+// read returns the input exposition along with its content type.
+func (o *convertOptions) read(
+	ctx context.Context,
+	logger log.Logger,
+	reg *prometheus.Registry,
+) (string, []byte, error) {
+	var (
+		contentType string
+		body        []byte
+		err         error
+	)
+	switch {
+	case strings.HasPrefix(o.Input, "http://") || strings.HasPrefix(o.Input, "https://"):
+		o.ScrapeURL = o.Input
+		scraper, serr := o.NewScraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if serr != nil {
+			return "", nil, serr
+		}
+		contentType, body, err = scraper.FetchWithContext(ctx)
+		err = errors.Wrap(err, "failed to scrape input")
+	case o.Input == "-":
+		body, err = io.ReadAll(os.Stdin)
+		err = errors.Wrap(err, "failed to read stdin")
+	default:
+		body, err = os.ReadFile(o.Input)
+		err = errors.Wrap(err, "failed to read input")
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	return inputContentType(o.InputFormat, contentType, body), body, nil
+}
+
+// inputContentType returns the content type of an input exposition in the given format. With formatAuto it is
+// the content type it was served with, or the one detected from the body when read from a file.
+func inputContentType(format, contentType string, body []byte) string {
+	switch format {
+	case formatText:
+		return config.ScrapeProtocolsHeaders[config.PrometheusText0_0_4]
+	case formatOpenMetrics:
+		return config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0]
+	case formatProtobuf:
+		return config.ScrapeProtocolsHeaders[config.PrometheusProto]
+	default:
+		if contentType == "" {
+			return scrape.DetectContentType(body)
+		}
+		return contentType
+	}
+}
+
+// outputFormat maps the --output-format flag to the encoder format.
+func (o *convertOptions) outputFormat() expfmt.Format {
+	switch o.OutputFormat {
+	case formatText:
+		return expfmt.NewFormat(expfmt.TypeTextPlain)
+	case formatProtobuf:
+		return expfmt.NewFormat(expfmt.TypeProtoDelim)
+	default:
+		return expfmt.NewFormat(expfmt.TypeOpenMetrics)
+	}
+}
+
+func registerConvertCommand(app *extkingpin.App) {
+	cmd := app.Command("convert", "Convert an exposition between the Prometheus text, OpenMetrics and protobuf formats.")
+	opts := &convertOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			contentType, body, err := opts.read(ctx, logger, reg)
+			if err != nil {
+				return err
+			}
+			families, err := scrape.DecodeFamilies(body, contentType)
+			if err != nil {
+				return err
+			}
+
+			out := io.Writer(os.Stdout)
+			if opts.Output != "-" {
+				f, err := os.Create(opts.Output)
+				if err != nil {
+					return errors.Wrap(err, "failed to create output")
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := scrape.EncodeFamilies(out, families, opts.outputFormat()); err != nil {
+				return err
+			}
+			level.Info(logger).Log("msg", "conversion complete", "metric_families", len(families),
+				"input_content_type", contentType, "output_format", opts.OutputFormat)
+			return nil
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
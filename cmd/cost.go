@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// customPricing is the pricing model defined by the --pricing.* flags alone.
+const customPricing = "custom"
+
+type costOptions struct {
+	Options
+	ScrapeInterval time.Duration
+	Instances      int
+	Pricing        string
+	UnitSeries     int
+	PricePerUnit   float64
+	IncludedDPM    float64
+	Top            int
+}
+
+func (o *costOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("scrape-interval", "Interval the target is scraped at by the server shipping to the vendor").
+		Default("1m").
+		DurationVar(&o.ScrapeInterval)
+
+	app.Flag("instances", "Number of instances exposing the same series as the target, e.g. replicas").
+		Default("1").
+		IntVar(&o.Instances)
+
+	pricings := append(slices.Sorted(maps.Keys(scrape.Pricings)), customPricing)
+	app.Flag("pricing", "Pricing model, the --pricing.* flags override its settings").
+		Default("grafana-cloud").
+		EnumVar(&o.Pricing, pricings...)
+
+	app.Flag("pricing.unit-series", "Number of active series a unit of the price covers").
+		Default("0").
+		IntVar(&o.UnitSeries)
+
+	app.Flag("pricing.price", "Monthly price of a unit of series, in dollars").
+		Default("0").
+		Float64Var(&o.PricePerUnit)
+
+	app.Flag("pricing.included-dpm", "Data points per minute of a series covered by the price, 0 when the price "+
+		"does not depend on the scrape interval").
+		Default("0").
+		Float64Var(&o.IncludedDPM)
+
+	app.Flag("top", "Number of metric families to list, the most expensive first, 0 for all").
+		Default("20").
+		IntVar(&o.Top)
+}
+
+// pricing returns the selected pricing model with the overrides of the --pricing.* flags.
+func (o *costOptions) pricing() (scrape.Pricing, error) {
+	p := scrape.Pricing{Name: customPricing}
+	if o.Pricing != customPricing {
+		p = scrape.Pricings[o.Pricing]
+	}
+	if o.UnitSeries > 0 {
+		p.UnitSeries = o.UnitSeries
+	}
+	if o.PricePerUnit > 0 {
+		p.PricePerUnit = o.PricePerUnit
+	}
+	if flagSet("pricing.included-dpm") {
+		p.IncludedDPM = o.IncludedDPM
+	}
+	if p.UnitSeries <= 0 || p.PricePerUnit <= 0 {
+		return p, errors.New("the custom pricing requires --pricing.unit-series and --pricing.price")
+	}
+	return p, nil
+}
+
+func registerCostCommand(app *extkingpin.App) {
+	cmd := app.Command("cost", "Estimate the data points per minute and the monthly cost of the series of a target "+
+		"at a hosted metrics vendor.")
+	opts := &costOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		if opts.ScrapeInterval <= 0 {
+			return errors.New("--scrape-interval must be positive")
+		}
+		pricing, err := opts.pricing()
+		if err != nil {
+			return err
+		}
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			report := scrape.EstimateCost(result.Series, opts.ScrapeInterval, opts.Instances, pricing)
+			return printCostReport(os.Stdout, report, opts.Top)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printCostReport(out io.Writer, report scrape.CostReport, top int) error {
+	p := report.Pricing
+	fmt.Fprintf(out, "Pricing %s: $%.2f per %d series a month", p.Name, p.PricePerUnit, p.UnitSeries)
+	if p.IncludedDPM > 0 {
+		fmt.Fprintf(out, " at %g DPM", p.IncludedDPM)
+	}
+	fmt.Fprintf(out, ", scraped every %s by %d instance(s)\n", report.Interval, report.Instances)
+	fmt.Fprintf(out, "%d active series, %.0f DPM, %.0f billable series, about $%.2f a month\n\n",
+		report.Total.Series, report.Total.DPM, report.Total.BillableSeries, report.Total.MonthlyCost)
+
+	families := report.Families
+	if top > 0 && len(families) > top {
+		families = families[:top]
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tSERIES\tDPM\tBILLABLE\tMONTHLY\tSHARE")
+	for _, f := range families {
+		fmt.Fprintf(tw, "%s\t%d\t%.0f\t%.0f\t$%.2f\t%s\n", f.Name, f.Series, f.DPM, f.BillableSeries,
+			f.MonthlyCost, formatShare(f.Series, report.Total.Series))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+var diffColumnLayout = []columnLayout{
+	{title: "Family", width: 60, flex: true},
+	{title: "Change", width: 10},
+	{title: "Baseline", width: 10},
+	{title: "Current", width: 10},
+	{title: "Δ", width: 8},
+}
+
+var labelDiffColumnLayout = []columnLayout{
+	{title: "Label", width: 30, flex: true},
+	{title: "Value", width: 60, flex: true},
+	{title: "Change", width: 12},
+	{title: "Baseline series", width: 16},
+	{title: "Current series", width: 16},
+}
+
+var diffHelp = []key.Binding{
+	key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter:", "label values"),
+	),
+	key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc:", "back"),
+	),
+	key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r:", "refresh"),
+	),
+	key.NewBinding(
+		key.WithKeys("q"),
+		key.WithHelp("q:", "quit"),
+	),
+}
+
+// Changes of the families and label values in the diff view.
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+	diffGrew    = "grew"
+	diffShrank  = "shrank"
+)
+
+// addedStyle and removedStyle color what appeared and disappeared since the baseline, see applyTheme.
+var (
+	addedStyle   lipgloss.Style
+	removedStyle lipgloss.Style
+)
+
+// familyDiff is a family whose cardinality differs between the baseline and the current scrape.
+type familyDiff struct {
+	name     string
+	change   string
+	baseline int
+	current  int
+}
+
+// size is the number of series the family gained or lost since the baseline.
+func (d familyDiff) size() int {
+	if d.current < d.baseline {
+		return d.baseline - d.current
+	}
+	return d.current - d.baseline
+}
+
+// diffFamilies compares the families of the current scrape to the baseline, the largest changes first. The
+// families of the same cardinality in both are left out.
+func (m *seriesTable) diffFamilies() []familyDiff {
+	trends, gone := scrape.CompareFamilies(m.baseline.Series, m.seriesMap)
+	diffs := make([]familyDiff, 0, len(trends)+len(gone))
+	for name, t := range trends {
+		d := familyDiff{name: name, baseline: t.Previous, current: t.Previous + t.Delta}
+		switch t.Trend {
+		case scrape.TrendNew:
+			d.change = diffAdded
+		case scrape.TrendGrew:
+			d.change = diffGrew
+		case scrape.TrendShrank:
+			d.change = diffShrank
+		default:
+			continue
+		}
+		diffs = append(diffs, d)
+	}
+	for _, name := range gone {
+		baseline := m.baseline.Series[name].Cardinality()
+		diffs = append(diffs, familyDiff{name: name, change: diffRemoved, baseline: baseline})
+	}
+	slices.SortFunc(diffs, func(a, b familyDiff) int {
+		return cmp.Or(cmp.Compare(b.size(), a.size()), strings.Compare(a.name, b.name))
+	})
+	return diffs
+}
+
+// renderChange colors s after the change: green when added, red when removed, as a warning when changed.
+func renderChange(change, s string, width int) string {
+	style := warnStyle
+	switch change {
+	case diffAdded:
+		style = addedStyle
+	case diffRemoved:
+		style = removedStyle
+	}
+	return styleCell(style, s, width)
+}
+
+// toggleDiffView switches between the metric table and its diff against the baseline.
+func (m *seriesTable) toggleDiffView() {
+	m.diffView = !m.diffView
+	m.diffFamily = ""
+	m.resetColumns()
+	m.table.SetCursor(0)
+}
+
+// openLabelDiff lists the label values that appeared or disappeared from the family selected in the diff view.
+func (m *seriesTable) openLabelDiff() {
+	row := m.table.SelectedRow()
+	if len(row) == 0 {
+		return
+	}
+	m.diffFamily = rowName(row)
+	m.resetColumns()
+	m.table.SetCursor(0)
+}
+
+// diffBack goes back from the label values to the families, or from the families to the metric table,
+// with the cursor on the family that was open.
+func (m *seriesTable) diffBack() {
+	if m.diffFamily == "" {
+		m.toggleDiffView()
+		return
+	}
+	family := m.diffFamily
+	m.diffFamily = ""
+	m.resetColumns()
+	m.followRow(family)
+}
+
+// diffLayout returns the columns of the diff view.
+func (m *seriesTable) diffLayout() []columnLayout {
+	if m.diffFamily != "" {
+		return labelDiffColumnLayout
+	}
+	return diffColumnLayout
+}
+
+// setDiffRows fills the table with the families that changed since the baseline, or the label values of the
+// open family that did.
+func (m *seriesTable) setDiffRows() {
+	widths := m.table.Columns()
+	if m.diffFamily != "" {
+		changes := scrape.CompareLabelValues(m.baseline.Series[m.diffFamily], m.seriesMap[m.diffFamily])
+		rows := make([]table.Row, 0, len(changes))
+		for _, c := range changes {
+			change := diffRemoved
+			if c.Appeared() {
+				change = diffAdded
+			}
+			rows = append(rows, table.Row{
+				c.Label,
+				renderChange(change, c.Value, widths[1].Width),
+				change,
+				strconv.Itoa(c.Previous),
+				strconv.Itoa(c.Current),
+			})
+		}
+		m.table.SetRows(rows)
+		return
+	}
+
+	diffs := m.diffFamilies()
+	rows := make([]table.Row, 0, len(diffs))
+	for _, d := range diffs {
+		rows = append(rows, table.Row{
+			renderChange(d.change, d.name, widths[0].Width),
+			d.change,
+			strconv.Itoa(d.baseline),
+			strconv.Itoa(d.current),
+			formatDelta(d.current - d.baseline),
+		})
+	}
+	m.table.SetRows(rows)
+}
+
+// diffFooterView sums up the changes since the baseline below the diff.
+func (m *seriesTable) diffFooterView() string {
+	var view strings.Builder
+	view.WriteString(help.New().ShortHelpView(diffHelp))
+	view.WriteString("\nDiff against the baseline scraped at " + m.baseline.Time.Format(time.DateTime))
+
+	if m.diffFamily != "" {
+		var appeared, disappeared int
+		for _, c := range scrape.CompareLabelValues(m.baseline.Series[m.diffFamily], m.seriesMap[m.diffFamily]) {
+			if c.Appeared() {
+				appeared++
+			} else {
+				disappeared++
+			}
+		}
+		view.WriteString(fmt.Sprintf("\n%s: %s, %s", m.diffFamily,
+			addedStyle.Render(fmt.Sprintf("%d label values appeared", appeared)),
+			removedStyle.Render(fmt.Sprintf("%d disappeared", disappeared))))
+	} else {
+		counts := make(map[string]int)
+		var delta int
+		for _, d := range m.diffFamilies() {
+			counts[d.change]++
+			delta += d.current - d.baseline
+		}
+		view.WriteString(fmt.Sprintf("\nFamilies: %s, %s, %s, series %s",
+			addedStyle.Render(fmt.Sprintf("%d added", counts[diffAdded])),
+			removedStyle.Render(fmt.Sprintf("%d removed", counts[diffRemoved])),
+			warnStyle.Render(fmt.Sprintf("%d changed", counts[diffGrew]+counts[diffShrank])),
+			formatDelta(delta)))
+	}
+
+	if m.refreshing {
+		view.WriteString("\n")
+		view.WriteString(m.spinner.View() + " Refreshing..." + m.progressView())
+	} else if m.flash != "" {
+		view.WriteString("\n")
+		view.WriteString(m.flash)
+	}
+	return view.String()
+}
+
+func (m *seriesTable) updateWhileBrowsingDiff(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "?":
+			m.showHelp = true
+			return m, nil
+		case "enter":
+			if m.diffFamily == "" {
+				m.openLabelDiff()
+			}
+			return m, nil
+		case "esc":
+			m.diffBack()
+			return m, nil
+		case "D":
+			m.toggleDiffView()
+			return m, nil
+		case "r":
+			return m, m.startRefresh("manual")
+		}
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+var seriesColumnLayout = []columnLayout{
+	{title: "Series", width: 80, flex: true},
+	{title: "Value", width: 16},
+	{title: "Created TS", width: 20},
+	{title: "Exemplars", width: 10},
+	{title: "Trace ID", width: 34},
+}
+
+// seriesTraceColumn is the column of the series list holding the trace ID of the latest exemplar.
+const seriesTraceColumn = 4
+
+const noTraceID = "-"
+
+var labelColumnLayout = []columnLayout{
+	{title: "Label", width: 40, flex: true},
+	{title: "Values", width: 10},
+	{title: "Series", width: 10},
+	{title: "Avg len", width: 8},
+	{title: "Max len", width: 8},
+	{title: "Top value", width: 60, flex: true},
+	{title: "Without", width: 12},
+}
+
+var labelValueColumnLayout = []columnLayout{
+	{title: "Value", width: 80, flex: true},
+	{title: "Series", width: 10},
+	{title: "Share", width: 8},
+	{title: "Cumulative", width: 10},
+	{title: "Distribution", width: distributionWidth},
+}
+
+// distributionWidth is the width of the bar showing the share of a label value.
+const distributionWidth = 20
+
+// savedSearch is the metric search put aside while drilling down into a metric.
+type savedSearch struct {
+	query  string
+	active bool
+}
+
+// openDrillDown lists the series, or the labels, of the selected metric in place of the metric table. The
+// drill-down has its own search, the metric search is restored when going back.
+func (m *seriesTable) openDrillDown(labels bool) {
+	row := m.table.SelectedRow()
+	if len(row) == 0 {
+		return
+	}
+	m.drillDown = rowName(row)
+	m.drillLabels = labels
+	m.metricSearch = savedSearch{query: m.searchInput.Value(), active: m.searchingMetrics}
+	m.searchInput.Reset()
+	m.searchingMetrics = false
+	m.resetColumns()
+	m.table.SetCursor(0)
+}
+
+// openLabels switches the series list to the labels of the metric.
+func (m *seriesTable) openLabels() {
+	m.drillLabels = true
+	m.clearDrillDownSearch()
+	m.resetColumns()
+	m.table.SetCursor(0)
+}
+
+// openLabelValues ranks the values of the selected label by the number of series carrying them.
+func (m *seriesTable) openLabelValues() {
+	row := m.table.SelectedRow()
+	if len(row) == 0 {
+		return
+	}
+	m.drillLabel = rowName(row)
+	m.clearDrillDownSearch()
+	m.resetColumns()
+	m.table.SetCursor(0)
+}
+
+// drillDownBack goes back up one level, from the label values to the labels and from the labels or the
+// series to the metric table.
+func (m *seriesTable) drillDownBack() {
+	if m.drillLabel == "" {
+		m.closeDrillDown()
+		return
+	}
+	label := m.drillLabel
+	m.drillLabel = ""
+	m.clearDrillDownSearch()
+	m.resetColumns()
+	if !m.followRow(label) {
+		m.table.SetCursor(0)
+	}
+}
+
+// closeDrillDown goes back to the metric table, with the cursor on the metric that was drilled into.
+func (m *seriesTable) closeDrillDown() {
+	name := m.drillDown
+	m.drillDown = ""
+	m.drillLabels = false
+	m.drillLabel = ""
+	m.aggregated = nil
+	m.searchInput.Reset()
+	m.searchInput.SetValue(m.metricSearch.query)
+	m.searchingMetrics = m.metricSearch.active
+	m.resetColumns()
+	if !m.followRow(name) {
+		m.table.SetCursor(0)
+	}
+}
+
+// toggleAggregated toggles the selected label in the what-if aggregation of the label list.
+func (m *seriesTable) toggleAggregated() {
+	row := m.table.SelectedRow()
+	if len(row) == 0 {
+		return
+	}
+	name := rowName(row)
+	if i := slices.Index(m.aggregated, name); i >= 0 {
+		m.aggregated = slices.Delete(m.aggregated, i, i+1)
+	} else {
+		m.aggregated = append(m.aggregated, name)
+	}
+	m.setDrillDownRows()
+}
+
+// whatIfSummary describes the cardinality of the drilled down metric without the aggregated labels, empty when
+// none is.
+func (m *seriesTable) whatIfSummary() string {
+	if m.drillDown == "" || len(m.aggregated) == 0 {
+		return ""
+	}
+	set := m.seriesMap[m.drillDown]
+	left := set.CardinalityWithout(m.aggregated...)
+	return fmt.Sprintf("Without %s: %d of %d series (%s)", strings.Join(m.aggregated, ", "), left,
+		set.Cardinality(), formatShare(left, set.Cardinality()))
+}
+
+// skewSummary describes how the series of the drilled down label are spread across its values, with a histogram
+// of the values by their number of series. It is empty outside of the label values.
+func (m *seriesTable) skewSummary() string {
+	if m.drillDown == "" || m.drillLabel == "" {
+		return ""
+	}
+	skew := scrape.LabelValueSkew(m.seriesMap[m.drillDown].LabelValues(m.drillLabel))
+	if skew.Series == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	// The 90% coverage tells a skewed label apart from a spread one.
+	c := skew.Coverage[min(1, len(skew.Coverage)-1)]
+	fmt.Fprintf(&sb, "%s of the series share %d of %d values", formatShare(c.Series, skew.Series), c.Values,
+		skew.Values)
+	if rest := skew.Values - c.Values; rest > 0 {
+		fmt.Fprintf(&sb, ", the other %s spread over %d values", formatShare(skew.Series-c.Series, skew.Series), rest)
+	}
+	sb.WriteString("\nSeries per value:")
+	maxValues := 0
+	for _, b := range skew.Buckets {
+		maxValues = max(maxValues, b.Values)
+	}
+	from := 1
+	for _, b := range skew.Buckets {
+		bucket := strconv.Itoa(b.UpTo)
+		if b.UpTo > from {
+			bucket = strconv.Itoa(from) + "-" + bucket
+		}
+		width := b.Values * distributionWidth / max(maxValues, 1)
+		if b.Values > 0 {
+			width = max(width, 1)
+		}
+		fmt.Fprintf(&sb, "\n  %-12s %-*s %d values", bucket, distributionWidth, strings.Repeat("█", width), b.Values)
+		from = b.UpTo + 1
+	}
+	return sb.String()
+}
+
+func (m *seriesTable) clearDrillDownSearch() {
+	m.searchInput.Reset()
+	m.searchingMetrics = false
+}
+
+// drillDownLayout returns the columns of the current drill-down level.
+func (m *seriesTable) drillDownLayout() []columnLayout {
+	switch {
+	case m.drillLabel != "":
+		return labelValueColumnLayout
+	case m.drillLabels:
+		return labelColumnLayout
+	default:
+		return seriesColumnLayout
+	}
+}
+
+// drillDownKind describes the rows of the current drill-down level, e.g. "labels of up".
+func (m *seriesTable) drillDownKind() string {
+	switch {
+	case m.drillLabel != "":
+		return "values of " + m.drillLabel + " in " + m.drillDown
+	case m.drillLabels:
+		return "labels of " + m.drillDown
+	default:
+		return "series of " + m.drillDown
+	}
+}
+
+// drillDownTotal returns the number of unfiltered rows of the current drill-down level.
+func (m *seriesTable) drillDownTotal() int {
+	set := m.seriesMap[m.drillDown]
+	switch {
+	case m.drillLabel != "":
+		return len(set.LabelValues(m.drillLabel))
+	case m.drillLabels:
+		return len(set.LabelStats())
+	default:
+		return set.Cardinality()
+	}
+}
+
+// setDrillDownRows fills the table with the rows of the current drill-down level matching the search.
+func (m *seriesTable) setDrillDownRows() {
+	switch {
+	case m.drillLabel != "":
+		m.setLabelValueRows()
+	case m.drillLabels:
+		m.setLabelRows()
+	default:
+		m.setSeriesRows()
+	}
+}
+
+// setSeriesRows fills the table with the series of the drilled down metric matching the search.
+func (m *seriesTable) setSeriesRows() {
+	widths := m.table.Columns()
+	terms := m.searchTerms()
+	var rows []scoredRow
+	for _, s := range m.seriesMap[m.drillDown].Sorted() {
+		lbls := seriesLabels(s)
+		var match searchMatch
+		if len(terms) > 0 {
+			var ok bool
+			if match, ok = matchSearch(terms, map[string]string{"series": strings.ToLower(lbls)}, nil); !ok {
+				continue
+			}
+		}
+		rows = append(rows, scoredRow{score: match.score, row: table.Row{
+			highlightMatches(lbls, match.positions["series"], widths[0].Width),
+			formatSeriesValue(s),
+			formatCreatedTimestamp(s.CreatedTimestamp),
+			strconv.Itoa(s.Exemplars),
+			latestTraceID(s),
+		}})
+	}
+
+	m.table.SetRows(rankRows(rows))
+}
+
+// setLabelRows fills the table with the labels of the drilled down metric, the most diverse first.
+func (m *seriesTable) setLabelRows() {
+	set := m.seriesMap[m.drillDown]
+	stats := set.LabelStats()
+	slices.SortFunc(stats, func(a, b scrape.LabelStats) int {
+		if a.DistinctValues != b.DistinctValues {
+			return int(b.DistinctValues) - int(a.DistinctValues)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	lengths := make(map[string]scrape.LabelValueLength, len(stats))
+	for _, l := range set.LabelValueLengths() {
+		lengths[l.Name] = l
+	}
+
+	widths := m.table.Columns()
+	terms := m.searchTerms()
+	var rows []scoredRow
+	for _, l := range stats {
+		var match searchMatch
+		if len(terms) > 0 {
+			var ok bool
+			if match, ok = matchSearch(terms, map[string]string{"label": strings.ToLower(l.Name)}, nil); !ok {
+				continue
+			}
+		}
+		values := set.LabelValues(l.Name)
+		series := 0
+		for _, v := range values {
+			series += v.Series
+		}
+		// The series left when the label is aggregated away on top of the ones already toggled off.
+		without := "aggregated"
+		if !slices.Contains(m.aggregated, l.Name) {
+			without = strconv.Itoa(set.CardinalityWithout(append(slices.Clone(m.aggregated), l.Name)...))
+		}
+		top := "-"
+		if len(values) > 0 {
+			top = fmt.Sprintf("%s (%s)", values[0].Value, formatShare(values[0].Series, set.Cardinality()))
+		}
+		rows = append(rows, scoredRow{score: match.score, row: table.Row{
+			highlightMatches(l.Name, match.positions["label"], widths[0].Width),
+			strconv.Itoa(int(l.DistinctValues)),
+			strconv.Itoa(series),
+			strconv.FormatFloat(lengths[l.Name].AvgLength, 'f', 0, 64),
+			formatMaxLength(lengths[l.Name].MaxLength),
+			top,
+			without,
+		}})
+	}
+
+	m.table.SetRows(rankRows(rows))
+}
+
+// setLabelValueRows fills the table with the values of the drilled down label, ranked by the number of
+// series carrying them, with their share of the series of the metric.
+func (m *seriesTable) setLabelValueRows() {
+	total := m.seriesMap[m.drillDown].Cardinality()
+	widths := m.table.Columns()
+	terms := m.searchTerms()
+	var rows []scoredRow
+	cumulative := 0
+	for _, v := range m.seriesMap[m.drillDown].LabelValues(m.drillLabel) {
+		cumulative += v.Series
+		var match searchMatch
+		if len(terms) > 0 {
+			var ok bool
+			if match, ok = matchSearch(terms, map[string]string{"value": strings.ToLower(v.Value)}, nil); !ok {
+				continue
+			}
+		}
+		rows = append(rows, scoredRow{score: match.score, row: table.Row{
+			highlightMatches(v.Value, match.positions["value"], widths[0].Width),
+			strconv.Itoa(v.Series),
+			formatShare(v.Series, total),
+			formatShare(cumulative, total),
+			strings.Repeat("█", max(v.Series*distributionWidth/max(total, 1), 1)),
+		}})
+	}
+
+	m.table.SetRows(rankRows(rows))
+}
+
+// formatMaxLength formats the length of the longest value of a label, flagging the long ones.
+func formatMaxLength(n int) string {
+	if n > scrape.DefaultMaxLabelValueLength {
+		return strconv.Itoa(n) + " !"
+	}
+	return strconv.Itoa(n)
+}
+
+// seriesLabels renders the labels of a series without its metric name, e.g. {code="200", job="api"}.
+func seriesLabels(s scrape.Series) string {
+	return labels.NewBuilder(s.Labels).Del(labels.MetricName).Labels().String()
+}
+
+func latestTraceID(s scrape.Series) string {
+	if len(s.TraceIDs) == 0 {
+		return noTraceID
+	}
+	return s.TraceIDs[len(s.TraceIDs)-1]
+}
+
+func formatSeriesValue(s scrape.Series) string {
+	if s.Type == "native_histogram" {
+		return "-"
+	}
+	return strconv.FormatFloat(s.Value, 'g', -1, 64)
+}
+
+func formatCreatedTimestamp(ms int64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Format(time.DateTime)
+}
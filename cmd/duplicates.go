@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type duplicatesOptions struct {
+	Options
+	Top int
+}
+
+func (o *duplicatesOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("top", "Number of duplicate labels to list, the most redundant series first, 0 for all").
+		Default("20").
+		IntVar(&o.Top)
+}
+
+func registerDuplicatesCommand(app *extkingpin.App) {
+	cmd := app.Command("duplicates", "Find the series of a metric family that differ only by a label without adding "+
+		"information, or by the same value under two label names like pod and pod_name, to consolidate.")
+	opts := &duplicatesOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			total := 0
+			for _, set := range result.Series {
+				total += set.Cardinality()
+			}
+			return printDuplicates(os.Stdout, scrape.Duplicates(result.Series), total, opts.Top)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printDuplicates(out io.Writer, duplicates []scrape.LabelDuplicates, total, top int) error {
+	if len(duplicates) == 0 {
+		_, err := fmt.Fprintln(out, "No duplicate series found")
+		return err
+	}
+	redundant := 0
+	for _, d := range duplicates {
+		redundant += d.Redundant
+	}
+	fmt.Fprintf(out, "%d labels duplicate series, consolidating them would remove up to %d of the %d series (%s)\n\n",
+		len(duplicates), redundant, total, formatShare(redundant, total))
+
+	if top > 0 && len(duplicates) > top {
+		duplicates = duplicates[:top]
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tLABEL\tREASON\tGROUPS\tSERIES\tREDUNDANT\tEXAMPLE")
+	for _, d := range duplicates {
+		label, reason := d.Label, "same values"
+		if d.Other != "" {
+			label, reason = d.Label+" / "+d.Other, "same value, two names"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+			d.Family, label, reason, d.Groups, d.Series, d.Redundant, d.Example)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// output formats of the exemplar-stats command.
+const (
+	exemplarOutputText = "text"
+	exemplarOutputJSON = "json"
+)
+
+type exemplarStatsOptions struct {
+	Options
+	Top    int
+	Output string
+}
+
+func (o *exemplarStatsOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+
+	app.Flag("top", "Number of metric families to list, the most exemplars first, 0 for all").
+		Default("20").
+		IntVar(&o.Top)
+
+	app.Flag("output", "Output format: "+exemplarOutputText+", or "+exemplarOutputJSON+" to export the report").
+		Default(exemplarOutputText).
+		EnumVar(&o.Output, exemplarOutputText, exemplarOutputJSON)
+}
+
+func registerExemplarStatsCommand(app *extkingpin.App) {
+	cmd := app.Command("exemplar-stats", "Summarize the exemplars of a target: counts per family, distinct "+
+		"trace IDs, age distribution and exemplars over the label limit.")
+	opts := &exemplarStatsOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.RawScraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			contentType, body, err := scraper.FetchWithContext(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			now := time.Now()
+			families, err := scrape.DecodeFamilies(body, contentType)
+			if err != nil {
+				return errors.Wrap(err, "failed to decode the exposition")
+			}
+			report := scrape.ExemplarStats(families, now)
+
+			if opts.Output == exemplarOutputJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			return printExemplarReport(os.Stdout, report, contentType, opts.Top)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+// formatExemplarUsage describes the memory the exemplars of the series take, empty when there are none.
+func formatExemplarUsage(u scrape.ExemplarUsage) string {
+	if u.Exemplars == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Exemplars: %d on %d series, est. %s of the %s the analyzer holds for the series (%s), "+
+		"--skip-exemplars drops them", u.Exemplars, u.Series, units.BytesSize(float64(u.Bytes)),
+		units.BytesSize(float64(u.TotalBytes)), formatShare(u.Bytes, u.TotalBytes))
+}
+
+func printExemplarReport(out io.Writer, report scrape.ExemplarReport, contentType string, top int) error {
+	if report.Exemplars == 0 {
+		_, err := fmt.Fprintf(out, "No exemplars in the %s scrape, only OpenMetrics and protobuf carry them\n",
+			contentType)
+		return err
+	}
+	fmt.Fprintf(out, "%d exemplars on %d series, %d distinct trace IDs\n",
+		report.Exemplars, report.Series, report.TraceIDs)
+	fmt.Fprintf(out, "%d without a trace ID, %d without a timestamp\n\n",
+		report.WithoutTraceID, report.WithoutTimestamp)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AGE\tEXEMPLARS")
+	for _, a := range report.Ages {
+		age := "older"
+		if a.UpTo > 0 {
+			age = "<= " + a.UpTo.String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", age, a.Count)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	families := report.Families
+	if top > 0 && len(families) > top {
+		families = families[:top]
+	}
+	fmt.Fprintln(out)
+	tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tSERIES\tEXEMPLARS\tTRACE IDS")
+	for _, f := range families {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", f.Name, f.Series, f.Exemplars, f.TraceIDs)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.Oversized) == 0 {
+		return nil
+	}
+	fmt.Fprintf(out, "\n%d exemplars exceed the limit of %d characters of labels and are dropped by Prometheus\n",
+		len(report.Oversized), scrape.MaxExemplarLabelsLength)
+	tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERIES\tLENGTH\tLABELS")
+	for _, o := range report.Oversized {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", o.Series, o.Length, o.Labels)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/pkg/errors"
+)
+
+// exportWriter writes the header and rows of the table to w.
+type exportWriter func(w io.Writer, header []string, rows [][]string) error
+
+// exportFormats maps the file extensions the visible rows can be exported to to their writers.
+var exportFormats = map[string]exportWriter{
+	".csv":  writeCSV,
+	".json": writeJSON,
+	".md":   writeMarkdown,
+}
+
+// startExport prompts for the file to write the visible rows to.
+func (m *seriesTable) startExport() tea.Cmd {
+	m.exporting = true
+	m.exportInput.SetCursor(int(cursor.CursorBlink))
+	m.exportInput.CursorEnd()
+	m.table.Blur()
+	return m.exportInput.Focus()
+}
+
+func (m *seriesTable) updateWhileExporting(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			path := strings.TrimSpace(m.exportInput.Value())
+			if path == "" {
+				return m, m.setFlash("Export path is empty")
+			}
+			n, err := m.exportRows(path)
+			if err != nil {
+				return m, m.setFlash("Failed to export: " + err.Error())
+			}
+			m.exporting = false
+			m.exportInput.Blur()
+			m.table.Focus()
+			return m, m.setFlash(fmt.Sprintf("Exported %d rows to %s", n, path))
+		case "esc":
+			m.exporting = false
+			m.exportInput.Blur()
+			m.table.Focus()
+			return m, nil
+		}
+	}
+
+	m.exportInput, cmd = m.exportInput.Update(msg)
+	return m, cmd
+}
+
+// exportRows writes the rows of the table, as filtered and sorted on screen, to path in the format of its
+// extension. It returns the number of rows written.
+func (m *seriesTable) exportRows(path string) (int, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	write, ok := exportFormats[ext]
+	if !ok {
+		return 0, errors.Errorf("unsupported file extension %q, use .csv, .json or .md", ext)
+	}
+
+	header, rows := m.visibleRows()
+	if r := m.timeline.At(m.travelBack); r != nil {
+		header, rows = markTruncated(header, rows, r.Truncated)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := write(f, header, rows); err != nil {
+		_ = f.Close()
+		return 0, errors.Wrapf(err, "write %s", path)
+	}
+	return len(rows), f.Close()
+}
+
+// visibleRows returns the column titles and the cells of the table rows, without styling and markers.
+func (m *seriesTable) visibleRows() ([]string, [][]string) {
+	cols := m.table.Columns()
+	header := make([]string, 0, len(cols))
+	for _, c := range cols {
+		header = append(header, c.Title)
+	}
+
+	rows := make([][]string, 0, len(m.table.Rows()))
+	for _, row := range m.table.Rows() {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			if i == 0 {
+				cell = rowName(row)
+			}
+			cells[i] = strings.TrimSpace(ansi.Strip(cell))
+		}
+		rows = append(rows, cells)
+	}
+	return header, rows
+}
+
+// markTruncated adds a truncated column, last, to the rows of a scrape whose parsing stopped at --max-series, so
+// that an export of a partial scrape never passes for a complete one. The rows of a complete scrape are kept as is.
+func markTruncated(header []string, rows [][]string, truncated bool) ([]string, [][]string) {
+	if !truncated {
+		return header, rows
+	}
+	header = append(slices.Clone(header), "truncated")
+	for i, row := range rows {
+		rows[i] = append(row, "true")
+	}
+	return header, rows
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// writeJSON writes the rows as an array of objects keyed by column title.
+func writeJSON(w io.Writer, header []string, rows [][]string) error {
+	objects := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		obj := make(map[string]string, len(header))
+		for i, title := range header {
+			if i < len(row) {
+				obj[title] = row[i]
+			}
+		}
+		objects = append(objects, obj)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objects)
+}
+
+func writeMarkdown(w io.Writer, header []string, rows [][]string) error {
+	// Pipes would split the cell and line breaks the row.
+	escape := strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+	line := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for i, c := range cells {
+			escaped[i] = escape.Replace(c)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(line(header))
+	sb.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		sb.WriteString(line(row))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/stretchr/testify/require"
+)
+
+// exportHeader and exportRows hold cells with the separators and quotes of every export format.
+var (
+	exportHeader = []string{"Name", "Labels"}
+	exportRows   = [][]string{
+		{"http_requests_total", `path="/a|b"`},
+		{"up", "a,b\nc"},
+		{"build_info", `say "hi"`},
+	}
+)
+
+func TestExportWriters(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		write  exportWriter
+		header []string
+		rows   [][]string
+		want   string
+	}{
+		"csv": {
+			write:  writeCSV,
+			header: exportHeader,
+			rows:   exportRows,
+			want: "Name,Labels\n" +
+				"http_requests_total,\"path=\"\"/a|b\"\"\"\n" +
+				"up,\"a,b\nc\"\n" +
+				"build_info,\"say \"\"hi\"\"\"\n",
+		},
+		"json": {
+			write:  writeJSON,
+			header: exportHeader,
+			rows:   exportRows,
+			want: `[
+  {
+    "Labels": "path=\"/a|b\"",
+    "Name": "http_requests_total"
+  },
+  {
+    "Labels": "a,b\nc",
+    "Name": "up"
+  },
+  {
+    "Labels": "say \"hi\"",
+    "Name": "build_info"
+  }
+]
+`,
+		},
+		"json with short rows": {
+			write:  writeJSON,
+			header: exportHeader,
+			rows:   [][]string{{"up"}},
+			want:   "[\n  {\n    \"Name\": \"up\"\n  }\n]\n",
+		},
+		"json without rows": {
+			write:  writeJSON,
+			header: exportHeader,
+			want:   "[]\n",
+		},
+		"markdown": {
+			write:  writeMarkdown,
+			header: exportHeader,
+			rows:   append(exportRows, []string{"a|b|c", "x\r\ny\rz"}),
+			want: "| Name | Labels |\n" +
+				"| --- | --- |\n" +
+				"| http_requests_total | path=\"/a\\|b\" |\n" +
+				"| up | a,b c |\n" +
+				"| build_info | say \"hi\" |\n" +
+				"| a\\|b\\|c | x y z |\n",
+		},
+		"markdown without rows": {
+			write:  writeMarkdown,
+			header: exportHeader,
+			want:   "| Name | Labels |\n| --- | --- |\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, tc.write(&buf, tc.header, tc.rows))
+			require.Equal(t, tc.want, buf.String())
+		})
+	}
+}
+
+func TestSeriesTable_VisibleRows(t *testing.T) {
+	t.Parallel()
+	m := newModel(nil, 0)
+	m.table.SetColumns([]table.Column{{Title: "Name", Width: 20}, {Title: "Cardinality", Width: 10}})
+	m.table.SetRows([]table.Row{
+		{markMarker + pinMarker + "http_requests_total", warnStyle.Render(" 120 ")},
+		{pinMarker + "up", "1"},
+		{"build_info", ""},
+	})
+
+	header, rows := m.visibleRows()
+	require.Equal(t, []string{"Name", "Cardinality"}, header)
+	require.Equal(t, [][]string{
+		{"http_requests_total", "120"},
+		{"up", "1"},
+		{"build_info", ""},
+	}, rows)
+
+	header, rows = markTruncated(header, rows, true)
+	require.Equal(t, []string{"Name", "Cardinality", "truncated"}, header)
+	require.Equal(t, []string{"up", "1", "true"}, rows[1])
+}
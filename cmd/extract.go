@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type extractOptions struct {
+	Options
+	Metrics    []string
+	Match      string
+	PromConfig string
+	Job        string
+	Output     string
+	Format     string
+}
+
+func (o *extractOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+
+	app.Flag("metric", "Name of a metric family to extract, or of one of its series, can be repeated, all "+
+		"families when unset").
+		StringsVar(&o.Metrics)
+
+	app.Flag("match", "Series selector the extracted series must match, e.g. 'http_requests_total{code=~\"5..\"}'").
+		Default("").
+		StringVar(&o.Match)
+
+	app.Flag("prometheus.config", "Prometheus configuration whose metric_relabel_configs are applied to the "+
+		"extracted series").
+		Default("").
+		StringVar(&o.PromConfig)
+
+	app.Flag("job", "Job of --prometheus.config scraping the target, by default the job with a static target "+
+		"matching --scrape-url").
+		Default("").
+		StringVar(&o.Job)
+
+	app.Flag("output", "File to write the extracted families to, - for stdout").
+		Default("-").
+		StringVar(&o.Output)
+
+	app.Flag("format", "Format of the output, OpenMetrics can be fed back to Prometheus, promtool or this tool").
+		Default(formatOpenMetrics).
+		EnumVar(&o.Format, formatText, formatOpenMetrics)
+}
+
+// filter reduces the extracted series with --match, the label filters and the metric relabeling of the job.
+func (o *extractOptions) filter() (scrape.FamilyFilter, error) {
+	filter := scrape.FamilyFilter{DropLabels: o.DropLabels, KeepLabels: o.KeepLabels}
+	if o.Match != "" {
+		matchers, err := parser.ParseMetricSelector(o.Match)
+		if err != nil {
+			return filter, errors.Wrap(err, "invalid --match selector")
+		}
+		filter.Matchers = matchers
+	}
+	if o.PromConfig == "" {
+		return filter, nil
+	}
+	cfg, err := scrape.LoadPromConfig(o.PromConfig)
+	if err != nil {
+		return filter, err
+	}
+	var job *scrape.JobConfig
+	if o.Job != "" {
+		job, err = cfg.Job(o.Job)
+	} else {
+		job, _, err = cfg.MatchTarget(o.ScrapeURL)
+	}
+	if err != nil {
+		return filter, errors.Wrap(err, "failed to find the job of the target, set it with --job")
+	}
+	filter.Relabel = job.MetricRelabelConfigs
+	return filter, nil
+}
+
+func registerExtractCommand(app *extkingpin.App) {
+	cmd := app.Command("extract", "Write the exposition of metric families, with their HELP and TYPE and every "+
+		"series, e.g. the buckets of a histogram, to a file or stdout, optionally reduced by the label filters, "+
+		"--match and metric relabeling.")
+	opts := &extractOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		filter, err := opts.filter()
+		if err != nil {
+			return err
+		}
+		scraper, err := opts.RawScraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			contentType, body, err := scraper.FetchWithContext(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			families, err := scrape.DecodeFamilies(body, contentType)
+			if err != nil {
+				return errors.Wrap(err, "failed to decode the exposition")
+			}
+			if len(opts.Metrics) > 0 {
+				var missing []string
+				if families, missing = scrape.ExtractFamilies(families, opts.Metrics); len(missing) > 0 {
+					return errors.Errorf("metric families not found: %s", strings.Join(missing, ", "))
+				}
+			}
+			families, merged := scrape.ReduceFamilies(families, filter)
+
+			out := io.Writer(os.Stdout)
+			if opts.Output != "-" {
+				f, err := os.Create(opts.Output)
+				if err != nil {
+					return errors.Wrap(err, "failed to create output")
+				}
+				defer f.Close()
+				out = f
+			}
+			format := expfmt.NewFormat(expfmt.TypeTextPlain)
+			if opts.Format == formatOpenMetrics {
+				format = expfmt.NewFormat(expfmt.TypeOpenMetrics)
+			}
+			if err := scrape.EncodeFamilies(out, families, format); err != nil {
+				return err
+			}
+			level.Info(logger).Log("msg", "extraction complete", "metric_families", len(families),
+				"merged_series", merged, "content_type", contentType)
+			return nil
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// metricTypes are the types the table can be filtered by, in the order the type filter cycles through.
+var metricTypes = []string{
+	"counter", "gauge", "histogram", "summary", "native_histogram", scrape.InfoType, scrape.StatesetType, "unknown",
+}
+
+// currentFilter returns the filter combining the active search and type filter, if any.
+func (m *seriesTable) currentFilter() func(info scrape.SeriesInfo) bool {
+	var filters []func(info scrape.SeriesInfo) bool
+
+	// The series list matches the search against its own rows, see setSeriesRows.
+	if query := m.searchQuery(); query != "" && m.drillDown == "" {
+		if m.groupByOrigin {
+			terms := parseQuery(query)
+			filters = append(filters, func(info scrape.SeriesInfo) bool {
+				_, ok := matchSearch(terms, searchFields(info), &info)
+				return ok
+			})
+		} else {
+			// Metric rows are matched once per query, see searchHits.
+			hits := m.searchHits(query)
+			filters = append(filters, func(info scrape.SeriesInfo) bool {
+				_, ok := hits[info.Name]
+				return ok
+			})
+		}
+	}
+
+	// The origin view has no type column, the type filter only applies to metrics.
+	if m.typeFilter != "" && !m.groupByOrigin {
+		typ := m.typeFilter
+		filters = append(filters, func(info scrape.SeriesInfo) bool {
+			return slices.Contains(strings.Split(info.Type, "|"), typ)
+		})
+	}
+
+	if m.appOnly && !m.groupByOrigin {
+		filters = append(filters, func(info scrape.SeriesInfo) bool {
+			return scrape.Classify(info.Name) == scrape.CategoryApplication
+		})
+	}
+
+	if m.minCardinality > 0 {
+		threshold := m.minCardinality
+		filters = append(filters, func(info scrape.SeriesInfo) bool {
+			return info.Cardinality >= threshold
+		})
+	}
+
+	if len(filters) == 0 {
+		return noFiltering
+	}
+	return func(info scrape.SeriesInfo) bool {
+		for _, f := range filters {
+			if !f(info) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// cycleTypeFilter moves the type filter to the next metric type, going back to all types after the last one.
+func (m *seriesTable) cycleTypeFilter() {
+	i := slices.Index(metricTypes, m.typeFilter)
+	if i+1 < len(metricTypes) {
+		m.typeFilter = metricTypes[i+1]
+	} else {
+		m.typeFilter = ""
+	}
+	m.setTableRows(m.currentFilter())
+	m.table.SetCursor(0)
+}
+
+// typeSummary renders the number of metric families per type, along with the active type filter.
+func (m *seriesTable) typeSummary() string {
+	counts := m.seriesMap.TypeCounts()
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		parts = append(parts, fmt.Sprintf("%s: %d", t, counts[t]))
+	}
+
+	summary := "Families by type: " + strings.Join(parts, " | ")
+	if m.typeFilter != "" {
+		summary += fmt.Sprintf(" (showing %s only)", m.typeFilter)
+	}
+	if m.minCardinality > 0 {
+		summary += fmt.Sprintf(" (hiding families below %d series)", m.minCardinality)
+	}
+	return summary
+}
+
+// toggleAppOnly hides or shows the standard instrumentation of the client libraries.
+func (m *seriesTable) toggleAppOnly() {
+	m.appOnly = !m.appOnly
+	m.setTableRows(m.currentFilter())
+	m.table.SetCursor(0)
+}
+
+// categorySummary renders the number of metric families and series per category, see scrape.Classify.
+func (m *seriesTable) categorySummary() string {
+	totals := m.seriesMap.CategoryTotals()
+	parts := make([]string, 0, len(totals))
+	for _, t := range totals {
+		parts = append(parts, fmt.Sprintf("%s: %d (%d series)", t.Category, t.Families, t.Series))
+	}
+	summary := "Families by category"
+	if m.appOnly {
+		summary += " (showing application only)"
+	}
+	summary += ": " + strings.Join(parts, " | ")
+	if m.width > 0 {
+		summary = ansi.Truncate(summary, m.width, "…")
+	}
+	return summary
+}
+
+// infoSummary flags the info metrics carrying more labels than --max-info-labels, empty when there are none.
+func (m *seriesTable) infoSummary() string {
+	infos := m.seriesMap.OversizedInfoMetrics(m.maxInfoLabels)
+	if len(infos) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(infos))
+	for _, info := range infos {
+		parts = append(parts, fmt.Sprintf("%s (%d labels)", info.Name, info.Labels))
+	}
+	summary := fmt.Sprintf("Oversized info metrics: %s", strings.Join(parts, ", "))
+	if m.width > 0 {
+		summary = ansi.Truncate(summary, m.width, "…")
+	}
+	return warnStyle.Render(summary)
+}
+
+// mixedTypeSummary flags the families whose series were parsed with different types, empty when there are none.
+func (m *seriesTable) mixedTypeSummary() string {
+	mixed := m.seriesMap.MixedTypes()
+	if len(mixed) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(mixed))
+	for _, f := range mixed {
+		parts = append(parts, fmt.Sprintf("%s (%s)", f.Name, f.TypesString()))
+	}
+	summary := fmt.Sprintf("Mixed-type families: %s", strings.Join(parts, ", "))
+	if m.width > 0 {
+		summary = ansi.Truncate(summary, m.width, "…")
+	}
+	return warnStyle.Render(summary)
+}
+
+// sharedLabelSummary suggests moving the labels shared by every series of the scrape to target labels or an info
+// metric, empty when there are none.
+func (m *seriesTable) sharedLabelSummary() string {
+	shared := scrape.SharedLabels(m.seriesMap)
+	if len(shared) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(shared))
+	for _, l := range shared {
+		parts = append(parts, fmt.Sprintf("%s=%q", l.Name, l.Value))
+	}
+	summary := fmt.Sprintf("Labels on every series, better as target labels or an info metric: %s",
+		strings.Join(parts, ", "))
+	if m.width > 0 {
+		summary = ansi.Truncate(summary, m.width, "…")
+	}
+	return warnStyle.Render(summary)
+}
+
+func (m *seriesTable) updateWhileEditingThreshold(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			if m.thresholdInput.Err != nil {
+				return m, m.setFlash("Invalid threshold: " + m.thresholdInput.Value())
+			}
+			threshold, _ := strconv.Atoi(m.thresholdInput.Value())
+			m.minCardinality = max(threshold, 0)
+			m.setTableRows(m.currentFilter())
+			m.table.SetCursor(0)
+			fallthrough
+		case "esc":
+			m.editingThreshold = false
+			m.thresholdInput.Blur()
+			m.table.Focus()
+			return m, nil
+		}
+	}
+
+	m.thresholdInput, cmd = m.thresholdInput.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"os"
+	"regexp"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type grepOptions struct {
+	Options
+	Metric string
+	Match  string
+}
+
+func (o *grepOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+
+	app.Flag("metric", "Regular expression the metric family or series name must match").
+		Default("").
+		StringVar(&o.Metric)
+
+	app.Flag("match", "Series selector the series must match, e.g. 'http_requests_total{code=~\"5..\"}'").
+		Default("").
+		StringVar(&o.Match)
+}
+
+func (o *grepOptions) filter() (scrape.GrepFilter, error) {
+	var filter scrape.GrepFilter
+	if o.Metric != "" {
+		re, err := regexp.Compile(o.Metric)
+		if err != nil {
+			return filter, errors.Wrap(err, "invalid --metric regular expression")
+		}
+		filter.Metric = re
+	}
+	if o.Match != "" {
+		matchers, err := parser.ParseMetricSelector(o.Match)
+		if err != nil {
+			return filter, errors.Wrap(err, "invalid --match selector")
+		}
+		filter.Matchers = matchers
+	}
+	return filter, nil
+}
+
+func registerGrepCommand(app *extkingpin.App) {
+	cmd := app.Command("grep", "Print the exposition lines of the series matching a metric regex or selector.")
+	opts := &grepOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		filter, err := opts.filter()
+		if err != nil {
+			return err
+		}
+		// Raw lines only exist in the text formats.
+		scraper, err := opts.NewScraper(logger,
+			scrape.WithMetrics(scrape.NewMetrics(reg)),
+			scrape.WithProtocols(scrape.TextProtocols...),
+		)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			contentType, body, err := scraper.FetchWithContext(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			matched, err := scrape.Grep(os.Stdout, body, contentType, filter)
+			if err != nil {
+				return err
+			}
+			level.Info(logger).Log("msg", "grep complete", "matched_series", matched, "content_type", contentType)
+			return nil
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
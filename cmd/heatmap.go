@@ -0,0 +1,339 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/termenv"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// Widths of the heatmap columns, the family column shrinks to leave room for the label columns.
+const (
+	heatmapCellWidth      = 7
+	heatmapSeriesWidth    = 8
+	heatmapMinFamilyWidth = 12
+	heatmapMaxFamilyWidth = 40
+	// heatmapDefaultWidth is the width of the heatmap until the terminal width is known.
+	heatmapDefaultWidth = 120
+)
+
+var heatmapHelp = []key.Binding{
+	key.NewBinding(
+		key.WithKeys("up", "down", "left", "right"),
+		key.WithHelp("←↑↓→/hjkl:", "move"),
+	),
+	key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter:", "label values"),
+	),
+	key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc:", "back"),
+	),
+	key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r:", "refresh"),
+	),
+	key.NewBinding(
+		key.WithKeys("q"),
+		key.WithHelp("q:", "quit"),
+	),
+}
+
+// heatmapStyles grade the cells of the heatmap from the fewest to the most distinct values, see applyTheme.
+var heatmapStyles []lipgloss.Style
+
+// heatmap is the family by label matrix of distinct values shown as a color-graded grid. The cursor is on the
+// cell at row and col, top and left are the first row and label column in view, rows and cols the number of
+// rows and label columns that fit in the last rendering.
+type heatmap struct {
+	families []string
+	matrix   scrape.LabelMatrix
+	// peak is the largest number of distinct values of the matrix, the top of the color scale.
+	peak       int
+	row, col   int
+	top, left  int
+	rows, cols int
+}
+
+// newHeatmap builds the heatmap of the given families of the scrape, in their order.
+func newHeatmap(sm scrape.SeriesMap, families []string) *heatmap {
+	h := &heatmap{families: families}
+	h.rebuild(sm)
+	return h
+}
+
+// rebuild recomputes the matrix from a new scrape, keeping the cursor within its bounds.
+func (h *heatmap) rebuild(sm scrape.SeriesMap) {
+	h.matrix = scrape.NewLabelMatrix(sm, h.families)
+	h.peak = 0
+	for _, values := range h.matrix.Values {
+		for _, v := range values {
+			h.peak = max(h.peak, v)
+		}
+	}
+	h.row = min(h.row, max(len(h.matrix.Families)-1, 0))
+	h.col = min(h.col, max(len(h.matrix.Labels)-1, 0))
+}
+
+// move moves the cursor by the given number of rows and columns, it stops at the edges.
+func (h *heatmap) move(rows, cols int) {
+	h.row = max(min(h.row+rows, len(h.matrix.Families)-1), 0)
+	h.col = max(min(h.col+cols, len(h.matrix.Labels)-1), 0)
+}
+
+// selected returns the family and the label under the cursor, with the distinct values of the label in it.
+func (h *heatmap) selected() (family, label string, values int, ok bool) {
+	if len(h.matrix.Families) == 0 || len(h.matrix.Labels) == 0 {
+		return "", "", 0, false
+	}
+	return h.matrix.Families[h.row], h.matrix.Labels[h.col], h.matrix.Values[h.row][h.col], true
+}
+
+// scrollTo resizes the view to rows by cols cells and scrolls it so the cursor stays in it.
+func (h *heatmap) scrollTo(rows, cols int) {
+	h.rows, h.cols = rows, cols
+	if h.row < h.top {
+		h.top = h.row
+	} else if h.row >= h.top+rows {
+		h.top = h.row - rows + 1
+	}
+	if h.col < h.left {
+		h.left = h.col
+	} else if h.col >= h.left+cols {
+		h.left = h.col - cols + 1
+	}
+}
+
+// heatLevel places v on the color scale of the given number of levels, on a logarithmic scale up to peak since
+// most labels have a handful of values. Labels missing from the family are at -1.
+func heatLevel(v, peak, levels int) int {
+	if v <= 0 || levels == 0 {
+		return -1
+	}
+	if peak <= 1 {
+		return levels - 1
+	}
+	level := int(math.Log(float64(v)) / math.Log(float64(peak)) * float64(levels-1))
+	return min(level, levels-1)
+}
+
+// formatHeatValue fits the number of distinct values into a heatmap cell.
+func formatHeatValue(v int) string {
+	switch {
+	case v == 0:
+		return "·"
+	case v >= 1e7:
+		return strconv.Itoa(v/1e6) + "M"
+	case v >= 1e5:
+		return strconv.Itoa(v/1e3) + "k"
+	default:
+		return strconv.Itoa(v)
+	}
+}
+
+// toggleHeatmap shows the heatmap of the families listed in the metric table, in their order, or closes it.
+func (m *seriesTable) toggleHeatmap() {
+	if m.heatmap != nil {
+		m.closeHeatmap()
+		return
+	}
+	rows := m.table.Rows()
+	families := make([]string, 0, len(rows))
+	for _, row := range rows {
+		families = append(families, rowName(row))
+	}
+	m.heatmap = newHeatmap(m.seriesMap, families)
+	m.heatmap.row = min(m.table.Cursor(), max(len(m.heatmap.matrix.Families)-1, 0))
+}
+
+// closeHeatmap goes back to the metric table, with the cursor on the family that was selected.
+func (m *seriesTable) closeHeatmap() {
+	family, _, _, ok := m.heatmap.selected()
+	m.heatmap = nil
+	if ok {
+		m.followRow(family)
+	}
+}
+
+// openHeatmapLabel closes the heatmap and ranks the values of the selected label in the selected family, or
+// lists the labels of the family when it does not carry the label.
+func (m *seriesTable) openHeatmapLabel() {
+	family, label, values, ok := m.heatmap.selected()
+	if !ok {
+		return
+	}
+	m.heatmap = nil
+	if !m.followRow(family) {
+		return
+	}
+	m.openDrillDown(true)
+	if values > 0 && m.followRow(label) {
+		m.openLabelValues()
+	}
+}
+
+// heatmapGrid renders the part of the heatmap that fits in width, with the given number of rows.
+func (m *seriesTable) heatmapGrid(width, rows int) string {
+	h := m.heatmap
+	familyWidth := heatmapMinFamilyWidth
+	for _, f := range h.matrix.Families {
+		familyWidth = max(familyWidth, runewidth.StringWidth(f)+1)
+	}
+	familyWidth = min(familyWidth, heatmapMaxFamilyWidth, max(width/3, heatmapMinFamilyWidth))
+	cols := max((width-tableBorder-familyWidth-heatmapSeriesWidth)/heatmapCellWidth, 1)
+	h.scrollTo(rows, cols)
+
+	ascii := lipgloss.ColorProfile() == termenv.Ascii
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(activeTheme.SelectedForeground)).
+		Background(lipgloss.Color(activeTheme.SelectedBackground))
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(activeTheme.Accent))
+	labels := h.matrix.Labels[min(h.left, len(h.matrix.Labels)):min(h.left+cols, len(h.matrix.Labels))]
+
+	var sb strings.Builder
+	sb.WriteString(padCell("Family", familyWidth))
+	sb.WriteString(padLeftCell("Series", heatmapSeriesWidth-1) + " ")
+	for j, l := range labels {
+		cell := padLeftCell(runewidth.Truncate(l, heatmapCellWidth-1, "…"), heatmapCellWidth-1) + " "
+		if h.left+j == h.col {
+			cell = headerStyle.Render(cell)
+		}
+		sb.WriteString(cell)
+	}
+	sb.WriteString("\n")
+	sb.WriteString(hintStyle.Render(strings.Repeat("─", familyWidth+heatmapSeriesWidth+len(labels)*heatmapCellWidth)))
+
+	for i := h.top; i < min(h.top+rows, len(h.matrix.Families)); i++ {
+		sb.WriteString("\n")
+		family := padCell(runewidth.Truncate(h.matrix.Families[i], familyWidth-1, "…"), familyWidth)
+		if i == h.row {
+			family = headerStyle.Render(family)
+		}
+		sb.WriteString(family)
+		sb.WriteString(padLeftCell(strconv.Itoa(h.matrix.Series[i]), heatmapSeriesWidth-1) + " ")
+		for j := range labels {
+			v := h.matrix.Values[i][h.left+j]
+			cell := padLeftCell(formatHeatValue(v), heatmapCellWidth-2) + " "
+			selected := i == h.row && h.left+j == h.col
+			switch level := heatLevel(v, h.peak, len(heatmapStyles)); {
+			case selected && ascii:
+				cell = ">" + cell
+			case selected:
+				cell = selectedStyle.Render(" " + cell)
+			case level < 0:
+				cell = hintStyle.Render(" " + cell)
+			default:
+				cell = heatmapStyles[level].Render(" " + cell)
+			}
+			sb.WriteString(cell)
+		}
+	}
+	return sb.String()
+}
+
+// padCell pads s with spaces to width, padLeftCell aligns it to the right.
+func padCell(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-runewidth.StringWidth(s), 0))
+}
+
+func padLeftCell(s string, width int) string {
+	return strings.Repeat(" ", max(width-runewidth.StringWidth(s), 0)) + s
+}
+
+// heatmapView renders the heatmap in place of the table.
+func (m *seriesTable) heatmapView() string {
+	width := m.width
+	if width <= 0 {
+		width = heatmapDefaultWidth
+	}
+	// Fill the space of the table, header and separator included, so the view keeps its size while scrolling.
+	rows := m.table.Height()
+	return baseStyle.Width(width - tableBorder).Height(rows + 2).Render(m.heatmapGrid(width, rows))
+}
+
+// heatmapLegend renders the color scale of the heatmap, from one value to the peak.
+func (m *seriesTable) heatmapLegend() string {
+	var sb strings.Builder
+	sb.WriteString("1 ")
+	for _, style := range heatmapStyles {
+		sb.WriteString(style.Render("  "))
+	}
+	sb.WriteString(" " + strconv.Itoa(m.heatmap.peak) + " distinct values")
+	return sb.String()
+}
+
+// heatmapFooterView describes the selected cell below the heatmap.
+func (m *seriesTable) heatmapFooterView() string {
+	h := m.heatmap
+	var view strings.Builder
+	view.WriteString(help.New().ShortHelpView(heatmapHelp))
+	view.WriteString(fmt.Sprintf("\nFamilies %d-%d of %d, labels %d-%d of %d | %s",
+		min(h.top+1, len(h.matrix.Families)), min(h.top+h.rows, len(h.matrix.Families)), len(h.matrix.Families),
+		min(h.left+1, len(h.matrix.Labels)), min(h.left+h.cols, len(h.matrix.Labels)), len(h.matrix.Labels),
+		m.heatmapLegend()))
+	if family, label, values, ok := h.selected(); ok {
+		if values > 0 {
+			view.WriteString(fmt.Sprintf("\n%s{%s}: %d distinct values across %d series",
+				family, label, values, h.matrix.Series[h.row]))
+		} else {
+			view.WriteString(fmt.Sprintf("\n%s does not carry the label %s", family, label))
+		}
+	}
+	view.WriteString("\n")
+	view.WriteString(m.infoTitle)
+
+	if m.refreshing {
+		view.WriteString("\n")
+		view.WriteString(m.spinner.View() + " Refreshing..." + m.progressView())
+	} else if m.flash != "" {
+		view.WriteString("\n")
+		view.WriteString(m.flash)
+	}
+	return view.String()
+}
+
+func (m *seriesTable) updateWhileBrowsingHeatmap(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "q":
+		return m, tea.Quit
+	case "?":
+		m.showHelp = true
+	case "esc", "H":
+		m.closeHeatmap()
+	case "enter":
+		m.openHeatmapLabel()
+	case "r":
+		return m, m.startRefresh("manual")
+	case "up", "k":
+		m.heatmap.move(-1, 0)
+	case "down", "j":
+		m.heatmap.move(1, 0)
+	case "left", "h":
+		m.heatmap.move(0, -1)
+	case "right", "l":
+		m.heatmap.move(0, 1)
+	case "pgup":
+		m.heatmap.move(-m.heatmap.rows, 0)
+	case "pgdown":
+		m.heatmap.move(m.heatmap.rows, 0)
+	case "home":
+		m.heatmap.move(0, -len(m.heatmap.matrix.Labels))
+	case "end":
+		m.heatmap.move(0, len(m.heatmap.matrix.Labels))
+	}
+	return m, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// maxHistogramBounds is the number of bucket bounds listed per histogram family.
+const maxHistogramBounds = 10
+
+type histogramsOptions struct {
+	Options
+}
+
+func (o *histogramsOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+}
+
+func registerHistogramsCommand(app *extkingpin.App) {
+	cmd := app.Command("histograms", "List the histogram families of a target with their schema and buckets: "+
+		"native histograms, with custom buckets (NHCB) or not, and the classic histograms that would convert "+
+		"cleanly to NHCB with convert_classic_histograms_to_nhcb.")
+	opts := &histogramsOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			return printHistograms(os.Stdout, scrape.Histograms(result.Series))
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printHistograms(out io.Writer, infos []scrape.HistogramInfo) error {
+	if len(infos) == 0 {
+		_, err := fmt.Fprintln(out, "No histograms in the scrape")
+		return err
+	}
+
+	var convertible, classicSeries, nhcbSeries int
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tKIND\tSCHEMA\tHISTOGRAMS\tSERIES\tNHCB SERIES\tLAYOUTS\tBUCKETS")
+	for _, h := range infos {
+		schema, nhcb, layouts := formatSchemas(h.Schemas), "-", "-"
+		if h.Kind == scrape.ClassicHistogram {
+			layouts = strconv.Itoa(h.Layouts)
+			if h.Convertible() {
+				nhcb = strconv.Itoa(h.Histograms)
+				convertible++
+				classicSeries += h.Series
+				nhcbSeries += h.Histograms
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			h.Family, h.Kind, schema, h.Histograms, h.Series, nhcb, layouts, formatBounds(h.Bounds))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if convertible > 0 {
+		fmt.Fprintf(out, "\n%d classic histogram families would convert cleanly to NHCB, %d series becoming %d\n",
+			convertible, classicSeries, nhcbSeries)
+	}
+	var problems []string
+	for _, h := range infos {
+		if h.Problem != "" {
+			problems = append(problems, fmt.Sprintf("  %s %s", h.Family, h.Problem))
+		}
+	}
+	if len(problems) > 0 {
+		fmt.Fprintln(out, "\nClassic histograms that would not convert cleanly:")
+		fmt.Fprintln(out, strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+func formatSchemas(schemas []int32) string {
+	if len(schemas) == 0 {
+		return "-"
+	}
+	s := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		s = append(s, strconv.Itoa(int(schema)))
+	}
+	return strings.Join(s, ",")
+}
+
+// formatBounds lists the first bucket bounds of a classic histogram.
+func formatBounds(bounds []float64) string {
+	if len(bounds) == 0 {
+		return "-"
+	}
+	s := make([]string, 0, maxHistogramBounds)
+	for _, b := range bounds[:min(len(bounds), maxHistogramBounds)] {
+		s = append(s, strconv.FormatFloat(b, 'g', -1, 64))
+	}
+	if more := len(bounds) - maxHistogramBounds; more > 0 {
+		s = append(s, fmt.Sprintf("... %d more", more))
+	}
+	return strings.Join(s, " ")
+}
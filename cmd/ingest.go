@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type ingestOptions struct {
+	Options
+	PromConfig   string
+	Job          string
+	Address      string
+	TargetLabels []string
+	ShowSeries   bool
+}
+
+func (o *ingestOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("prometheus.config", "Prometheus or Prometheus Agent configuration file to simulate the ingestion with").
+		Required().
+		StringVar(&o.PromConfig)
+
+	app.Flag("job", "Job of the configuration scraping the target, by default the job with a static target "+
+		"matching --scrape-url").
+		Default("").
+		StringVar(&o.Job)
+
+	app.Flag("address", "Address of the target as discovered by Prometheus, defaults to the host of --scrape-url").
+		Default("").
+		StringVar(&o.Address)
+
+	app.Flag("target-label", "Discovered label of the target as 'name=value', e.g. "+
+		"__meta_kubernetes_pod_name=api-0, can be repeated").
+		StringsVar(&o.TargetLabels)
+
+	app.Flag("series", "List every ingested series instead of the per-family summary only").
+		Default("false").
+		BoolVar(&o.ShowSeries)
+}
+
+// target returns the job scraping the target along with its address and discovered labels.
+func (o *ingestOptions) target(cfg *scrape.PromConfig) (*scrape.JobConfig, string, map[string]string, error) {
+	address := o.Address
+	if address == "" {
+		u, err := url.Parse(o.ScrapeURL)
+		if err != nil || u.Host == "" {
+			return nil, "", nil, errors.New("--address is required when --scrape-url is not an http(s) URL")
+		}
+		address = u.Host
+	}
+
+	job, groupLabels, err := cfg.MatchTarget(o.ScrapeURL)
+	if o.Job != "" {
+		if err != nil || job.JobName != o.Job {
+			groupLabels = nil
+		}
+		job, err = cfg.Job(o.Job)
+	}
+	if err != nil {
+		return nil, "", nil, errors.Wrap(err, "failed to find the job of the target, set it with --job")
+	}
+
+	discovered := make(map[string]string, len(groupLabels)+len(o.TargetLabels))
+	for name, value := range groupLabels {
+		discovered[name] = value
+	}
+	for _, l := range o.TargetLabels {
+		name, value, ok := strings.Cut(l, "=")
+		if !ok || name == "" {
+			return nil, "", nil, errors.Errorf("invalid target label %q, expected 'name=value'", l)
+		}
+		discovered[name] = value
+	}
+	return job, address, discovered, nil
+}
+
+func registerIngestCommand(app *extkingpin.App) {
+	cmd := app.Command("ingest", "Simulate the relabeling, honor_labels and external labels of a Prometheus "+
+		"configuration and report the series the server would ingest from the target.")
+	opts := &ingestOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		cfg, err := scrape.LoadPromConfig(opts.PromConfig)
+		if err != nil {
+			return err
+		}
+		job, address, discovered, err := opts.target(cfg)
+		if err != nil {
+			return err
+		}
+		target, ok, err := job.TargetLabels(address, discovered)
+		if err != nil {
+			return err
+		}
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			if !ok {
+				fmt.Fprintf(os.Stdout, "The relabel_configs of job %s drop target %s, nothing is ingested.\n",
+					job.JobName, address)
+				return nil
+			}
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			report := scrape.SimulateIngest(cfg, job, target, result.Series)
+			return printIngestReport(os.Stdout, report, opts.ShowSeries)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printIngestReport(out io.Writer, report scrape.IngestReport, showSeries bool) error {
+	total := report.Total
+	fmt.Fprintf(out, "Job %s, target labels %s\n", report.Job, report.Target)
+	if !report.ExternalLabels.IsEmpty() {
+		fmt.Fprintf(out, "External labels %s, added on remote write and federation\n", report.ExternalLabels)
+	}
+	fmt.Fprintf(out, "%d series scraped, %d ingested, %d dropped by metric_relabel_configs\n",
+		total.Scraped, total.Ingested, total.Dropped)
+	if total.Duplicates > 0 {
+		fmt.Fprintf(out, "%d series collide with another one once relabeled, Prometheus rejects their samples\n",
+			total.Duplicates)
+	}
+	if total.Exported > 0 {
+		fmt.Fprintf(out, "%d series have labels renamed to exported_<name> as the target sets them too\n",
+			total.Exported)
+	}
+	if total.Overridden > 0 {
+		fmt.Fprintf(out, "%d series override target labels through honor_labels\n", total.Overridden)
+	}
+	fmt.Fprintln(out)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tSCRAPED\tINGESTED\tDROPPED\tDUPLICATES\tEXPORTED\tOVERRIDDEN")
+	for _, f := range report.Families {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n", f.Name,
+			f.Scraped, f.Ingested, f.Dropped, f.Duplicates, f.Exported, f.Overridden)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if !showSeries {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	for _, s := range report.Series {
+		switch {
+		case s.Dropped():
+			fmt.Fprintf(out, "%s dropped\n", s.Scraped)
+		case s.Duplicate:
+			fmt.Fprintf(out, "%s duplicate\n", s.Ingested)
+		default:
+			fmt.Fprintln(out, s.Ingested)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func registerInspectCommand(app *extkingpin.App) {
+	cmd := app.Command("inspect", "Probe the content negotiation of a target with edge-case Accept headers and "+
+		"check that it answers in a format it was asked for, or falls back to the text format.")
+	opts := &Options{}
+	opts.AddFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.NewScraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			results, err := scraper.ProbeNegotiation(ctx, scrape.NegotiationProbes)
+			if err != nil {
+				return err
+			}
+			failed, err := printNegotiation(os.Stdout, results)
+			if err != nil {
+				return err
+			}
+			if failed > 0 {
+				return errors.Errorf("%d of %d negotiation probes failed", failed, len(results))
+			}
+			return nil
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+// printNegotiation prints the answer of the target to every probe and returns the number of wrong answers.
+func printNegotiation(out io.Writer, results []scrape.NegotiationResult) (int, error) {
+	failed := 0
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROBE\tRESULT\tCONTENT TYPE\tSERIES\tACCEPT")
+	for _, r := range results {
+		status := "ok"
+		if r.Problem != "" {
+			status = "FAIL"
+			failed++
+		}
+		accept := r.Probe.Accept
+		if accept == "" {
+			accept = "(empty)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", r.Probe.Name, status, r.ContentType, r.Series, accept)
+	}
+	if err := tw.Flush(); err != nil {
+		return failed, err
+	}
+	for _, r := range results {
+		if r.Problem != "" {
+			fmt.Fprintf(out, "\n%s: %s", r.Probe.Name, r.Problem)
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintln(out)
+	}
+	return failed, nil
+}
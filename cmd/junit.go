@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// junitTestSuites is the root of a JUnit XML report, as read by Jenkins and GitLab.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	// SystemOut holds the warnings, they do not fail the test case.
+	SystemOut string `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the check results as a JUnit XML report, one test suite per kind of check and one test
+// case per metric family.
+func writeJUnit(w io.Writer, name string, results []scrape.CheckResult) error {
+	report := junitTestSuites{Name: name}
+	suites := make(map[string]int)
+	for _, r := range results {
+		i, ok := suites[r.Check]
+		if !ok {
+			i = len(report.Suites)
+			suites[r.Check] = i
+			report.Suites = append(report.Suites, junitTestSuite{Name: r.Check})
+		}
+		suite := &report.Suites[i]
+		tc := junitTestCase{Name: checkSubject(r), ClassName: r.Check}
+		var errs, warnings []string
+		for _, p := range r.Problems {
+			line := "[" + p.Rule + "] " + p.Text
+			if p.Severity == scrape.SeverityWarning {
+				warnings = append(warnings, "warning: "+line)
+			} else {
+				errs = append(errs, line)
+			}
+		}
+		if len(errs) > 0 {
+			tc.Failure = &junitFailure{
+				Message: errs[0],
+				Type:    r.Check,
+				Text:    strings.Join(errs, "\n"),
+			}
+			suite.Failures++
+			report.Failures++
+		}
+		tc.SystemOut = strings.Join(warnings, "\n")
+		suite.Tests++
+		report.Tests++
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// checkSubject names what a check result is about, the metric family or the whole scrape.
+func checkSubject(r scrape.CheckResult) string {
+	if r.Metric == "" {
+		return "all metric families"
+	}
+	return r.Metric
+}
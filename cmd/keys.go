@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keyMap documents the keybindings of the table, the update functions match on the same keys.
+type keyMap struct {
+	Up             key.Binding
+	Down           key.Binding
+	Focus          key.Binding
+	Help           key.Binding
+	Quit           key.Binding
+	Search         key.Binding
+	SearchExplore  key.Binding
+	SearchFilters  key.Binding
+	SearchExclude  key.Binding
+	SearchClear    key.Binding
+	TypeFilter     key.Binding
+	MinCardinality key.Binding
+	AppOnly        key.Binding
+	ApplyThreshold key.Binding
+	CancelPrompt   key.Binding
+	Export         key.Binding
+	ApplyExport    key.Binding
+	Refresh        key.Binding
+	AutoRefresh    key.Binding
+	Columns        key.Binding
+	Group          key.Binding
+	Pin            key.Binding
+	PinnedOnly     key.Binding
+	Mark           key.Binding
+	ClearMarks     key.Binding
+	CopyName       key.Binding
+	CopySelector   key.Binding
+	Open           key.Binding
+	OpenPrometheus key.Binding
+	OpenTrace      key.Binding
+	Tree           key.Binding
+	Expand         key.Binding
+	Collapse       key.Binding
+	DrillDown      key.Binding
+	Labels         key.Binding
+	LabelValues    key.Binding
+	Aggregate      key.Binding
+	Back           key.Binding
+	OpenTarget     key.Binding
+	Targets        key.Binding
+	Divergence     key.Binding
+	Diff           key.Binding
+	Older          key.Binding
+	Newer          key.Binding
+	Heatmap        key.Binding
+}
+
+var keys = keyMap{
+	Up:             key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:           key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Focus:          key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "focus/blur table")),
+	Help:           key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+	Quit:           key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+	Search:         key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "fuzzy search names and labels")),
+	SearchExplore:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "explore table")),
+	SearchClear:    key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "clear search")),
+	TypeFilter:     key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "cycle type filter")),
+	MinCardinality: key.NewBinding(key.WithKeys(">"), key.WithHelp(">", "min cardinality")),
+	AppOnly: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "hide/show the Go runtime, process, promhttp and build info metrics"),
+	),
+	ApplyThreshold: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "apply threshold")),
+	CancelPrompt:   key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+	Refresh:        key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
+	AutoRefresh:    key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "toggle auto-refresh")),
+	Columns: key.NewBinding(
+		key.WithKeys(columnToggleKeys()...),
+		key.WithHelp(fmt.Sprintf("2-%d", len(metricColumnDefs)), "toggle columns ("+columnToggleNames()+")"),
+	),
+	Pin:        key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "pin/unpin metric")),
+	PinnedOnly: key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "show pinned metrics only")),
+	Mark:       key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "mark/unmark metric")),
+	ClearMarks: key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "unmark all metrics")),
+	CopyName:   key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "copy metric name")),
+	CopySelector: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "copy selector with the labels shared by all series"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open metric in Grafana Explore (or Prometheus if --grafana.url is unset)"),
+	),
+	OpenPrometheus: key.NewBinding(key.WithKeys("O"), key.WithHelp("O", "open metric in the Prometheus UI")),
+	Tree:           key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "toggle tree view by name prefix")),
+	SearchFilters: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("name:", "filter metrics by name:, label:, type: or card:>N, terms combine with AND"),
+	),
+	SearchExclude: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("-/!", "exclude the rows matching a term, e.g. -go_ or !type:gauge"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "write the visible rows to a CSV, JSON or Markdown file"),
+	),
+	ApplyExport: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "export, the format follows the extension")),
+	OpenTrace: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "open the trace of the series' latest exemplar in Tempo or Jaeger (series list)"),
+	),
+	Expand: key.NewBinding(
+		key.WithKeys("enter", "right", "l"),
+		key.WithHelp("→/l", "expand prefix (tree view)"),
+	),
+	Collapse: key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "collapse prefix (tree view)")),
+	Group: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "group by origin job (federation) or push group (Pushgateway)"),
+	),
+	DrillDown: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "list the series of the metric, with value, created TS and exemplars"),
+	),
+	Labels: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "list the labels of the metric with their number of distinct values"),
+	),
+	LabelValues: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "rank the values of the selected label by series count (label list)"),
+	),
+	Aggregate: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "aggregate the selected label away, to see the cardinality without it (label list)"),
+	),
+	Back: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back up one level")),
+	OpenTarget: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "open the metrics of the selected target (target report)"),
+		key.WithDisabled(),
+	),
+	Targets: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back to the target report from the metric table"),
+		key.WithDisabled(),
+	),
+	Divergence: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "compare the cardinality of the families across targets (target report)"),
+		key.WithDisabled(),
+	),
+	Older: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "step back to the previous scrape, with the changes since marked ▲/▼"),
+	),
+	Newer: key.NewBinding(key.WithKeys("]"), key.WithHelp("]", "step forward towards the latest scrape")),
+	Heatmap: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "heatmap of the distinct values of every label of the listed metrics, enter ranks the values"),
+	),
+	Diff: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "diff against --baseline, enter lists the label values that appeared or disappeared"),
+		key.WithDisabled(),
+	),
+}
+
+// ShortHelp is shown below the table.
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Search, k.TypeFilter, k.Refresh, k.Help, k.Quit}
+}
+
+type helpSection struct {
+	title    string
+	bindings []key.Binding
+}
+
+// helpSections lists every keybinding of the table grouped by mode, as shown by the help overlay.
+func (k keyMap) helpSections() []helpSection {
+	return []helpSection{
+		{title: "Navigation", bindings: []key.Binding{k.Up, k.Down, k.Focus, k.Help, k.Quit}},
+		{title: "Filtering", bindings: []key.Binding{k.Search, k.TypeFilter, k.MinCardinality, k.AppOnly}},
+		{title: "Search mode", bindings: []key.Binding{k.SearchFilters, k.SearchExclude, k.SearchExplore, k.SearchClear}},
+		{title: "Threshold prompt", bindings: []key.Binding{k.ApplyThreshold, k.CancelPrompt}},
+		{title: "Export", bindings: []key.Binding{k.Export, k.ApplyExport, k.CancelPrompt}},
+		{title: "Selection", bindings: []key.Binding{k.Mark, k.ClearMarks}},
+		{title: "Clipboard", bindings: []key.Binding{k.CopyName, k.CopySelector}},
+		{title: "Browser", bindings: []key.Binding{k.Open, k.OpenPrometheus, k.OpenTrace}},
+		{title: "Refresh", bindings: []key.Binding{k.Refresh, k.AutoRefresh, k.Older, k.Newer}},
+		{title: "View", bindings: []key.Binding{k.Columns, k.Pin, k.PinnedOnly, k.Group, k.Heatmap}},
+		{title: "Tree view", bindings: []key.Binding{k.Tree, k.Expand, k.Collapse}},
+		{title: "Drill-down", bindings: []key.Binding{k.DrillDown, k.Labels, k.LabelValues, k.Aggregate, k.Back}},
+		{title: "Targets", bindings: []key.Binding{k.OpenTarget, k.Targets, k.Divergence}},
+		{title: "Diff", bindings: []key.Binding{k.Diff}},
+	}
+}
+
+var (
+	// The help styles are built from the active theme, see applyTheme.
+	helpTitleStyle   lipgloss.Style
+	helpKeyStyle     lipgloss.Style
+	helpSectionStyle lipgloss.Style
+)
+
+// helpOverlay renders the full list of keybindings.
+func (m *seriesTable) helpOverlay() string {
+	var sb strings.Builder
+	sb.WriteString(helpTitleStyle.Render("Keybindings"))
+	for _, section := range keys.helpSections() {
+		sb.WriteString("\n")
+		sb.WriteString(helpSectionStyle.Render(section.title))
+		for _, b := range section.bindings {
+			if !b.Enabled() {
+				continue
+			}
+			sb.WriteString("\n")
+			sb.WriteString(helpKeyStyle.Render(b.Help().Key))
+			sb.WriteString(hintStyle.Render(b.Help().Desc))
+		}
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(hintStyle.Render("press ? or esc to close"))
+	return baseStyle.Padding(0, 1).Render(sb.String())
+}
+
+// shortHelpView renders the one line help shown below the table.
+func (m *seriesTable) shortHelpView() string {
+	return help.New().ShortHelpView(keys.ShortHelp())
+}
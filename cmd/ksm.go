@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type ksmOptions struct {
+	Options
+	Top int
+}
+
+func (o *ksmOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("top", "Number of namespaces to list, the most series first, 0 for all").
+		Default("20").
+		IntVar(&o.Top)
+}
+
+func registerKSMCommand(app *extkingpin.App) {
+	cmd := app.Command("kube-state-metrics", "Report the cardinality of a kube-state-metrics target per Kubernetes "+
+		"object kind and per namespace, with the objects of each kind and the labels allowlisted on kube_*_labels.")
+	opts := &ksmOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			return printKSMReport(os.Stdout, scrape.KubeStateMetrics(result.Series), opts.Top)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printKSMReport(out io.Writer, report scrape.KSMReport, top int) error {
+	if report.Series == 0 {
+		_, err := fmt.Fprintln(out, "No kube-state-metrics series in the scrape")
+		return err
+	}
+	fmt.Fprintf(out, "%d kube-state-metrics series about %d object kinds, %d other series\n\n",
+		report.Series, len(report.Kinds), report.Other)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tFAMILIES\tSERIES\tOBJECTS\tSERIES/OBJECT\tLABELS\tANNOTATIONS\tSHARE")
+	for _, k := range report.Kinds {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f\t%d\t%d\t%s\n", k.Kind, k.Families, k.Series, k.Objects,
+			k.SeriesPerObject(), k.Labels, k.Annotations, formatShare(k.Series, report.Series))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	namespaces := report.Namespaces
+	if top > 0 && len(namespaces) > top {
+		namespaces = namespaces[:top]
+	}
+	fmt.Fprintln(out)
+	tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tSERIES\tOBJECTS\tSHARE")
+	for _, n := range namespaces {
+		name := n.Namespace
+		if name == "" {
+			name = "(cluster-scoped)"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", name, n.Series, n.Objects, formatShare(n.Series, report.Series))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if more := len(report.Namespaces) - len(namespaces); more > 0 {
+		fmt.Fprintf(out, "... %d more namespaces\n", more)
+	}
+	return nil
+}
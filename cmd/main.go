@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"regexp"
 	"syscall"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -17,22 +21,89 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	versioncollector "github.com/prometheus/client_golang/prometheus/collectors/version"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/thanos-io/thanos/pkg/extkingpin"
 	"github.com/thanos-io/thanos/pkg/logging"
+	"github.com/thanos-io/thanos/pkg/tracing/client"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
+// version is set at build time, see the Makefile.
+var version = "dev"
+
 func main() {
-	app := extkingpin.NewApp(kingpin.New(filepath.Base(os.Args[0]), "A tool to analyze Prometheus scrape data."))
+	kp := kingpin.New(filepath.Base(os.Args[0]), "A tool to analyze Prometheus scrape data.")
+	app := extkingpin.NewApp(kp)
 	logLevel := app.Flag("log.level", "Log filtering level.").
 		Default("info").Enum("error", "warn", "info", "debug")
 	logFormat := app.Flag("log.format", "Log format to use. Possible options: logfmt or json.").
 		Default(logging.LogFormatLogfmt).Enum(logging.LogFormatLogfmt, logging.LogFormatJSON)
 	logFile := app.Flag("log.file", "Log file to write to, if empty will log to stderr.").Default("").String()
+	listenAddress := app.Flag("web.listen-address",
+		"Address to serve the analyzer's own /metrics and /debug/pprof endpoints on, disabled if empty.").
+		Default("").String()
+	tracingConfig := extkingpin.RegisterCommonTracingFlags(app)
+	// The profile flags are read from the raw command line before parsing, see profileArgs.
+	configFile := app.Flag("config", "Configuration file defining profiles of flag values and themes.").
+		Default(defaultConfigFile).String()
+	app.Flag("profile", "Profile of the configuration file to use, flags given on the command line take precedence.").
+		Default("").String()
+	themeName := app.Flag("theme", "Color theme of the TUI: dark, light, auto to follow the terminal background, "+
+		"or a theme of the configuration file.").
+		Default("dark").String()
+	noColor := app.Flag("no-color", "Disable colors, also disabled when the NO_COLOR environment variable is set.").
+		Bool()
 
 	registerCardinalityCommand(app)
+	registerMimirCommand(app)
+	registerWatchCommand(app)
+	registerTopCommand(app)
+	registerGrepCommand(app)
+	registerConvertCommand(app)
+	registerBenchmarkCommand(app)
+	registerRecordCommand(app)
+	registerReplayCommand(app)
+	registerSnapshotCommand(app)
+	registerIngestCommand(app)
+	registerQueryCommand(app)
+	registerCostCommand(app)
+	registerBandwidthCommand(app)
+	registerCheckCommand(app)
+	registerSuggestAlertsCommand(app)
+	registerExemplarStatsCommand(app)
+	registerCollisionsCommand(app)
+	registerCompressionCommand(app)
+	registerInspectCommand(app)
+	registerExtractCommand(app)
+	registerHistogramsCommand(app)
+	registerSummariesCommand(app)
+	registerTimestampsCommand(app)
+	registerDuplicatesCommand(app)
+	registerCapacityCommand(app)
+	registerKSMCommand(app)
+	registerBenchParseCommand(app, kp)
+	bindEnvVars(kp)
+
+	if profile := argValue(os.Args[1:], "profile"); profile != "" {
+		*configFile = argValue(os.Args[1:], "config")
+		if *configFile == "" {
+			*configFile = defaultConfigFile
+		}
+		extra, err := profileArgs(kp, os.Args[1:], *configFile, profile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		os.Args = append(os.Args[:1], withProfileArgs(kp, os.Args[1:], extra)...)
+	}
 
 	cmd, setup := app.Parse()
+	command = cmd
+
+	if err := setupTheme(*themeName, *configFile, *noColor); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
 	metrics := prometheus.NewRegistry()
 	metrics.MustRegister(
@@ -54,12 +125,66 @@ func main() {
 
 	var g run.Group
 	var tracer opentracing.Tracer
+	// Setup optional tracing.
+	{
+		var (
+			ctx             = context.Background()
+			confContentYaml []byte
+		)
+
+		confContentYaml, err = tracingConfig.Content()
+		if err != nil {
+			level.Error(logger).Log("msg", "getting tracing config failed", "err", err)
+			os.Exit(1)
+		}
+
+		if len(confContentYaml) == 0 {
+			tracer = client.NoopTracer()
+		} else {
+			var closer io.Closer
+			tracer, closer, err = client.NewTracer(ctx, logger, metrics, confContentYaml)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, errors.Wrapf(err, "tracing failed"))
+				os.Exit(1)
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			g.Add(func() error {
+				<-ctx.Done()
+				return ctx.Err()
+			}, func(error) {
+				if closer != nil {
+					if err := closer.Close(); err != nil {
+						level.Warn(logger).Log("msg", "closing tracer failed", "err", err)
+					}
+				}
+				cancel()
+			})
+		}
+	}
+
 	if err := setup(&g, logger, metrics, tracer, reloadCh, *logLevel == "debug"); err != nil {
 		// Use %+v for github.com/pkg/errors error to print with stack.
 		level.Error(logger).Log("err", fmt.Sprintf("%+v", errors.Wrapf(err, "preparing %s command failed", cmd)))
 		os.Exit(1)
 	}
 
+	// Expose the analyzer's own metrics and profiles.
+	if *listenAddress != "" {
+		srv := newSelfMonitoringServer(*listenAddress, metrics)
+		g.Add(func() error {
+			level.Info(logger).Log("msg", "serving self-monitoring endpoints", "address", *listenAddress)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return errors.Wrap(err, "self-monitoring server")
+			}
+			return nil
+		}, func(error) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(ctx)
+		})
+	}
+
 	// Listen for termination signals.
 	{
 		cancel := make(chan struct{})
@@ -88,6 +213,22 @@ func main() {
 	level.Info(logger).Log("msg", "exiting")
 }
 
+func newSelfMonitoringServer(address string, reg *prometheus.Registry) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
 func setupLogging(logLevel, logFormat, file string) (log.Logger, error) {
 	var (
 		logger log.Logger
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/docker/go-units"
+)
+
+// markMarker prefixes the name of marked metrics in the table.
+const markMarker = "✓ "
+
+// toggleMark marks the selected metric for the aggregate footer, or unmarks it.
+func (m *seriesTable) toggleMark() {
+	name := rowName(m.table.SelectedRow())
+	if name == "" {
+		return
+	}
+	if m.isMarked(name) {
+		delete(m.marked, name)
+	} else {
+		m.marked[name] = struct{}{}
+	}
+	cursor := m.table.Cursor()
+	m.setTableRows(m.currentFilter())
+	// Move on to the next row so consecutive metrics can be marked by holding the key.
+	m.table.SetCursor(min(cursor+1, max(len(m.table.Rows())-1, 0)))
+}
+
+// clearMarks unmarks every metric.
+func (m *seriesTable) clearMarks() {
+	if len(m.marked) == 0 {
+		return
+	}
+	clear(m.marked)
+	m.setTableRows(m.currentFilter())
+}
+
+// isMarked reports whether the metric is marked.
+func (m *seriesTable) isMarked(name string) bool {
+	_, ok := m.marked[name]
+	return ok
+}
+
+// marksSummary aggregates the marked metrics present in the last scrape: their combined cardinality,
+// the union of their label names and their estimated memory.
+func (m *seriesTable) marksSummary() string {
+	if len(m.marked) == 0 {
+		return ""
+	}
+
+	var metrics, cardinality, memory int
+	labelNames := make(map[string]struct{})
+	for name := range m.marked {
+		set, ok := m.seriesMap[name]
+		if !ok {
+			continue
+		}
+		metrics++
+		cardinality += set.Cardinality()
+		memory += set.EstimatedMemory()
+		for _, l := range set.LabelStats() {
+			labelNames[l.Name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(labelNames))
+	for name := range labelNames {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	summary := fmt.Sprintf("Marked %d metrics: %d series, est. memory %s, labels: %s",
+		metrics, cardinality, units.BytesSize(float64(memory)), strings.Join(names, ", "))
+	if m.width > 0 {
+		// Long label lists would wrap and throw off the table height.
+		summary = ansi.Truncate(summary, m.width, "…")
+	}
+	return summary
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/mimir"
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type mimirOptions struct {
+	Options
+	Address     string
+	Tenant      string
+	Selector    string
+	Limit       int
+	LabelValues int
+}
+
+func (o *mimirOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("mimir.address",
+		"Base URL of the Mimir/Cortex API, including any path prefix (e.g. http://mimir/prometheus)").
+		Required().
+		StringVar(&o.Address)
+
+	app.Flag("mimir.tenant", "Tenant ID sent in the X-Scope-OrgID header").
+		Default("").
+		StringVar(&o.Tenant)
+
+	app.Flag("mimir.selector", "Series selector used to scope the tenant's cardinality (e.g. {job=\"api\"})").
+		Default("").
+		StringVar(&o.Selector)
+
+	app.Flag("mimir.limit", "Maximum number of label names returned by the cardinality API").
+		Default("500").
+		IntVar(&o.Limit)
+
+	app.Flag("mimir.label-values", "Number of top labels to fetch tenant series counts for, 0 to disable").
+		Default("10").
+		IntVar(&o.LabelValues)
+}
+
+func registerMimirCommand(app *extkingpin.App) {
+	cmd := app.Command("mimir-compare", "Compare the labels of a scrape against a Mimir/Cortex tenant's cardinality.")
+	opts := &mimirOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			return runMimirCompare(ctx, opts, logger, scrape.NewMetrics(reg), os.Stdout)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func runMimirCompare(
+	ctx context.Context,
+	opts *mimirOptions,
+	logger log.Logger,
+	metrics *scrape.Metrics,
+	out io.Writer,
+) error {
+	scraper, err := opts.Scraper(logger, scrape.WithMetrics(metrics))
+	if err != nil {
+		return err
+	}
+
+	level.Info(logger).Log("msg", "scraping", "url", opts.ScrapeURL)
+	result, err := scraper.Scrape(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to scrape target")
+	}
+
+	client := mimir.NewClient(opts.Address, opts.Tenant, logger, mimir.WithTimeout(opts.Timeout))
+	level.Info(logger).Log("msg", "querying tenant cardinality", "address", opts.Address, "tenant", opts.Tenant)
+	var names *mimir.LabelNamesResponse
+	err = tracing.DoInSpanWithErr(ctx, "mimir_label_names", func(ctx context.Context) error {
+		names, err = client.LabelNames(ctx, opts.Selector, opts.Limit)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to query label names cardinality")
+	}
+
+	var comparison []mimir.LabelComparison
+	tracing.DoInSpan(ctx, "mimir_compare", func(context.Context) {
+		comparison = mimir.Compare(result.Series.LabelStats(), names)
+	})
+
+	if opts.LabelValues > 0 {
+		var top []string
+		for _, c := range comparison {
+			if len(top) == opts.LabelValues {
+				break
+			}
+			if c.InTenant() {
+				top = append(top, c.Name)
+			}
+		}
+		if len(top) > 0 {
+			values, err := client.LabelValues(ctx, top, opts.Selector, 1)
+			if err != nil {
+				return errors.Wrap(err, "failed to query label values cardinality")
+			}
+			mimir.AddSeriesCounts(comparison, values)
+		}
+	}
+
+	return printMimirComparison(out, comparison, names)
+}
+
+func printMimirComparison(out io.Writer, comparison []mimir.LabelComparison, names *mimir.LabelNamesResponse) error {
+	fmt.Fprintf(out, "Tenant label names: %d, total label values: %d\n\n",
+		names.LabelNamesCount, names.LabelValuesCountTotal)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LABEL\tSCRAPE VALUES\tTENANT VALUES\tTENANT SERIES\t% OF TENANT VALUES\t% EXPOSED BY SCRAPE")
+	for _, c := range comparison {
+		if !c.InTenant() {
+			fmt.Fprintf(tw, "%s\t%d\t-\t-\t-\t-\n", c.Name, c.ScrapeValues)
+			continue
+		}
+		series := "-"
+		if c.TenantSeries > 0 {
+			series = fmt.Sprintf("%d", c.TenantSeries)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%.2f%%\t%.2f%%\n",
+			c.Name, c.ScrapeValues, c.TenantValues, series, c.TenantShare*100, c.ScrapeShare*100)
+	}
+	return tw.Flush()
+}
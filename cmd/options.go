@@ -1,18 +1,67 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/go-units"
+	"github.com/go-kit/log"
 	"github.com/pkg/errors"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
 )
 
 type Options struct {
 	ScrapeURL     string
 	OutputHeight  int
 	MaxScrapeSize string
+	MaxSeries     int
+	DropLabels    []string
+	KeepLabels    []string
+	SkipExemplars bool
 	Timeout       time.Duration
+	Retries       int
+	RetryBackoff  time.Duration
+	Headers       []string
+	Params        []string
+	UserAgent     string
+	TimeoutHeader bool
+	// SaveBodyDir is where the raw response bodies are archived, empty to not archive them.
+	SaveBodyDir string
+	// ScrapeInterval and SlowScrapeRatio report the scrapes taking a large share of the interval as slow.
+	ScrapeInterval  time.Duration
+	SlowScrapeRatio float64
+	// BearerToken and BasicAuth* authenticate the scrape requests, the *File variants are read on every
+	// request so rotated credentials are picked up.
+	BearerToken           string
+	BearerTokenFile       string
+	BasicAuthUsername     string
+	BasicAuthPassword     string
+	BasicAuthPasswordFile string
+	// TLS* configure the TLS connection to the target, e.g. a client certificate for mTLS.
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+	// CacheDir and CacheTTL cache the scrapes on disk, for the commands registering addCacheFlags.
+	CacheDir string
+	CacheTTL time.Duration
+	// RateLimit and Jitter space out the scrapes, for the commands registering addThrottleFlags.
+	RateLimit float64
+	Jitter    time.Duration
 }
 
 func (o *Options) MaxScrapeSizeBytes() (int64, error) {
@@ -23,20 +72,466 @@ func (o *Options) MaxScrapeSizeBytes() (int64, error) {
 	return size, nil
 }
 
+// NewScraper builds an HTTP scraper for the configured target using the scrape related flags. Extra
+// options are applied after the ones derived from the flags.
+func (o *Options) NewScraper(logger log.Logger, extra ...scrape.ScraperOption) (*scrape.PromScraper, error) {
+	scraperOpts, err := o.scraperOptions(logger)
+	if err != nil {
+		return nil, err
+	}
+	return scrape.NewPromScraper(o.ScrapeURL, logger, append(scraperOpts, extra...)...), nil
+}
+
+// Scraper is like NewScraper but builds the scraper registered for the scheme of the target, e.g. file
+// targets are read from disk. HTTP targets are cached when --cache.dir is set.
+func (o *Options) Scraper(logger log.Logger, extra ...scrape.ScraperOption) (scrape.Scraper, error) {
+	scraper, err := o.uncachedScraper(logger, extra...)
+	if err != nil {
+		return nil, err
+	}
+	// Filtered scrapes are not cached, the next command may filter differently. Archived ones neither, the body
+	// of a cached scrape is not archived.
+	filtered := len(o.DropLabels) > 0 || len(o.KeepLabels) > 0
+	if _, ok := scraper.(*scrape.PromScraper); ok && o.CacheDir != "" && !filtered && o.SaveBodyDir == "" {
+		return scrape.NewCachingScraper(scraper, o.cacheKey(), o.CacheDir, o.CacheTTL, logger), nil
+	}
+	return scraper, nil
+}
+
+// cacheKey identifies the scrape in the cache: the target and the flags selecting or limiting what it serves, the
+// --scrape.param params, the --scrape.header headers, e.g. a tenant, the credentials, --max-scrape-size,
+// --max-series and --skip-exemplars. A scrape is then never reused by a command asking for another one. Bearer
+// tokens often select the tenant too, they are hashed so that the key never holds them.
+func (o *Options) cacheKey() string {
+	key := url.Values{}
+	for _, p := range slices.Sorted(slices.Values(o.Params)) {
+		key.Add("param", p)
+	}
+	for _, h := range slices.Sorted(slices.Values(o.Headers)) {
+		key.Add("header", h)
+	}
+	if o.BasicAuthUsername != "" {
+		key.Set("basic-auth.username", o.BasicAuthUsername)
+	}
+	if o.BearerToken != "" {
+		key.Set("bearer-token", secretHash(o.BearerToken))
+	}
+	if o.BearerTokenFile != "" {
+		key.Set("bearer-token-file", secretHash(o.BearerTokenFile))
+	}
+	// An invalid size fails the scraper before the cache is used.
+	if size, err := o.MaxScrapeSizeBytes(); err == nil {
+		key.Set("max-scrape-size", strconv.FormatInt(size, 10))
+	}
+	if o.MaxSeries > 0 {
+		key.Set("max-series", strconv.Itoa(o.MaxSeries))
+	}
+	if o.SkipExemplars {
+		key.Set("skip-exemplars", "true")
+	}
+	if len(key) == 0 {
+		return o.ScrapeURL
+	}
+	return o.ScrapeURL + "#" + key.Encode()
+}
+
+// secretHash returns a hash of a secret, telling secrets apart without revealing them.
+func secretHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:16])
+}
+
+// previousResult returns the result of the previous run of the tool against the target, nil unless --cache.dir
+// keeps it.
+func previousResult(scraper scrape.Scraper) *scrape.Result {
+	if c, ok := scraper.(*scrape.CachingScraper); ok {
+		return c.Previous()
+	}
+	return nil
+}
+
+// RawScraper is like Scraper for the commands analyzing the exposition itself, they are never cached.
+func (o *Options) RawScraper(logger log.Logger, extra ...scrape.ScraperOption) (scrape.RawScraper, error) {
+	scraper, err := o.uncachedScraper(logger, extra...)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := scraper.(scrape.RawScraper)
+	if !ok {
+		return nil, errors.Errorf("target %s does not provide a raw exposition", o.ScrapeURL)
+	}
+	return raw, nil
+}
+
+func (o *Options) uncachedScraper(logger log.Logger, extra ...scrape.ScraperOption) (scrape.Scraper, error) {
+	scraperOpts, err := o.scraperOptions(logger)
+	if err != nil {
+		return nil, err
+	}
+	return scrape.NewScraper(o.ScrapeURL, logger, append(scraperOpts, extra...)...)
+}
+
+// scraperOptions derives the scraper options from the scrape related flags.
+func (o *Options) scraperOptions(logger log.Logger) ([]scrape.ScraperOption, error) {
+	maxSize, err := o.MaxScrapeSizeBytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse max scrape size")
+	}
+
+	headers, err := parseHeaders(o.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := parseParams(o.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := o.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return []scrape.ScraperOption{
+		scrape.WithTimeout(o.Timeout),
+		scrape.WithMaxBodySize(maxSize),
+		scrape.WithRetries(o.Retries, o.RetryBackoff),
+		scrape.WithHeaders(headers),
+		scrape.WithParams(params),
+		scrape.WithHTTPClient(client),
+		scrape.WithUserAgent(o.UserAgent),
+		scrape.WithTimeoutHeader(o.TimeoutHeader),
+		scrape.WithProgress(logProgress(logger), logProgressInterval),
+		scrape.WithMaxSeries(o.MaxSeries),
+		scrape.WithLabelFilter(o.DropLabels, o.KeepLabels),
+		scrape.WithSkipExemplars(o.SkipExemplars),
+		scrape.WithBodyArchive(o.SaveBodyDir),
+	}, nil
+}
+
+// httpClient builds the HTTP client of the scraper from the authentication and TLS flags.
+func (o *Options) httpClient() (*http.Client, error) {
+	cfg := config_util.DefaultHTTPClientConfig
+	if o.BearerToken != "" || o.BearerTokenFile != "" {
+		cfg.Authorization = &config_util.Authorization{
+			Credentials:     config_util.Secret(o.BearerToken),
+			CredentialsFile: o.BearerTokenFile,
+		}
+	}
+	if o.BasicAuthUsername == "" && (o.BasicAuthPassword != "" || o.BasicAuthPasswordFile != "") {
+		return nil, errors.New("--scrape.basic-auth.password and --scrape.basic-auth.password-file require " +
+			"--scrape.basic-auth.username")
+	}
+	if o.BasicAuthUsername != "" {
+		cfg.BasicAuth = &config_util.BasicAuth{
+			Username:     o.BasicAuthUsername,
+			Password:     config_util.Secret(o.BasicAuthPassword),
+			PasswordFile: o.BasicAuthPasswordFile,
+		}
+	}
+	cfg.TLSConfig = config_util.TLSConfig{
+		CAFile:             o.TLSCAFile,
+		CertFile:           o.TLSCertFile,
+		KeyFile:            o.TLSKeyFile,
+		ServerName:         o.TLSServerName,
+		InsecureSkipVerify: o.TLSInsecureSkipVerify,
+	}
+	return newHTTPClient(cfg, "scrape")
+}
+
+// newHTTPClient builds an HTTP client from cfg, built from the flags of kind. Like http.DefaultClient, the
+// client goes through the proxy of the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func newHTTPClient(cfg config_util.HTTPClientConfig, kind string) (*http.Client, error) {
+	cfg.ProxyConfig.ProxyFromEnvironment = true
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s authentication or TLS flags", kind)
+	}
+	client, err := config_util.NewClientFromConfig(cfg, "prom-scrape-analyzer")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the %s HTTP client", kind)
+	}
+	return client, nil
+}
+
+// redactedScrapeURL returns the scrape URL without its password, for reports and notifications.
+func (o *Options) redactedScrapeURL() string {
+	u, err := url.Parse(o.ScrapeURL)
+	if err != nil {
+		return o.ScrapeURL
+	}
+	return u.Redacted()
+}
+
+// parseHeaders parses the 'Name: value' headers of the --scrape.header flag.
+func parseHeaders(flags []string) (http.Header, error) {
+	headers := make(http.Header, len(flags))
+	for _, h := range flags {
+		name, value, ok := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, errors.Errorf("invalid header %q, expected 'Name: value'", h)
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// parseParams parses the 'name=value' params of the --scrape.param flag.
+func parseParams(flags []string) (url.Values, error) {
+	params := make(url.Values, len(flags))
+	for _, p := range flags {
+		name, value, ok := strings.Cut(p, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, errors.Errorf("invalid param %q, expected 'name=value'", p)
+		}
+		params.Add(name, value)
+	}
+	return params, nil
+}
+
 func (o *Options) AddFlags(app extkingpin.AppClause) {
-	app.Flag("scrape-url", "URL to scrape metrics from").
+	app.Flag("scrape-url", "URL to scrape metrics from, a file:// URL or a path reads them from a file").
 		Required().
 		StringVar(&o.ScrapeURL)
 
+	o.addScrapeFlags(app)
+}
+
+// addScrapeFlags registers the flags of AddFlags but --scrape-url, for the commands finding their targets
+// otherwise too.
+func (o *Options) addScrapeFlags(app extkingpin.AppClause) {
 	app.Flag("timeout", "Timeout for the scrape request").
 		Default("10s").
 		DurationVar(&o.Timeout)
 
-	app.Flag("output-height", "Height of the output table").
-		Default("40").
+	app.Flag("scrape.interval", "Scrape interval of the target in Prometheus, scrapes taking more than "+
+		"--scrape.slow-ratio of it, or of --timeout when unset, are reported as slow").
+		Default("0s").
+		DurationVar(&o.ScrapeInterval)
+
+	app.Flag("scrape.slow-ratio", "Share of the scrape interval, or timeout, above which a scrape is slow").
+		Default("0.5").
+		Float64Var(&o.SlowScrapeRatio)
+
+	app.Flag("scrape.retries", "Retries of a scrape failing with a transient error (connection, timeout, 5xx)").
+		Default("0").
+		IntVar(&o.Retries)
+
+	app.Flag("scrape.retry-backoff", "Wait before the first retry, doubled on every further retry").
+		Default("1s").
+		DurationVar(&o.RetryBackoff)
+
+	app.Flag("scrape.header", "Header to add to the scrape request as 'Name: value', can be repeated").
+		StringsVar(&o.Headers)
+
+	app.Flag("scrape.param", "URL param to add to the scrape request as 'name=value', overriding the one of "+
+		"--scrape-url, can be repeated, e.g. module=http_2xx and target=example.com for the blackbox exporter").
+		StringsVar(&o.Params)
+
+	app.Flag("scrape.user-agent", "User-Agent of the scrape requests, e.g. Prometheus/2.53.0 to mimic Prometheus").
+		Default("prom-scrape-analyzer/" + version).
+		StringVar(&o.UserAgent)
+
+	app.Flag("scrape.timeout-header", "Send the X-Prometheus-Scrape-Timeout-Seconds header like Prometheus does").
+		Default("true").
+		BoolVar(&o.TimeoutHeader)
+
+	app.Flag("scrape.bearer-token", "Bearer token to authenticate the scrape requests with").
+		StringVar(&o.BearerToken)
+
+	app.Flag("scrape.bearer-token-file", "File to read the bearer token from on every scrape").
+		StringVar(&o.BearerTokenFile)
+
+	app.Flag("scrape.basic-auth.username", "Username to authenticate the scrape requests with basic auth").
+		StringVar(&o.BasicAuthUsername)
+
+	app.Flag("scrape.basic-auth.password", "Password for basic auth").
+		StringVar(&o.BasicAuthPassword)
+
+	app.Flag("scrape.basic-auth.password-file", "File to read the basic auth password from on every scrape").
+		StringVar(&o.BasicAuthPasswordFile)
+
+	app.Flag("scrape.tls.ca-file", "CA certificate to verify the target's certificate with").
+		StringVar(&o.TLSCAFile)
+
+	app.Flag("scrape.tls.cert-file", "Client certificate to present to the target, requires --scrape.tls.key-file").
+		StringVar(&o.TLSCertFile)
+
+	app.Flag("scrape.tls.key-file", "Key of the client certificate").
+		StringVar(&o.TLSKeyFile)
+
+	app.Flag("scrape.tls.server-name", "Server name to verify the target's certificate against, defaults to the URL host").
+		StringVar(&o.TLSServerName)
+
+	app.Flag("scrape.tls.insecure-skip-verify", "Skip the verification of the target's certificate").
+		BoolVar(&o.TLSInsecureSkipVerify)
+
+	app.Flag("save-body", "Directory to archive the raw response bodies of the scrapes in, with their headers, "+
+		"e.g. to keep what an exporter served when a cardinality incident started").
+		Default("").
+		StringVar(&o.SaveBodyDir)
+
+	app.Flag("output-height", "Override the height of the output table, by default it fits the terminal").
+		Default("0").
 		IntVar(&o.OutputHeight)
 
 	app.Flag("max-scrape-size", "Maximum size of the scrape response body (e.g. 10MB, 1GB)").
 		Default("100MB").
 		StringVar(&o.MaxScrapeSize)
+
+	addMaxSeriesFlag(app, &o.MaxSeries)
+	addLabelFilterFlags(app, &o.DropLabels, &o.KeepLabels)
+	addSkipExemplarsFlag(app, &o.SkipExemplars)
+}
+
+// slowScrape describes a scrape taking more than the slow ratio of the scrape interval, or of the timeout when
+// the interval is unset, empty for the other scrapes. Slow handlers end up in scrape timeouts that look like
+// cardinality problems.
+func (o *Options) slowScrape(t scrape.Timing) string {
+	budget, of := o.ScrapeInterval, "scrape interval"
+	if budget == 0 {
+		budget, of = o.Timeout, "timeout"
+	}
+	if !t.SlowerThan(budget, o.SlowScrapeRatio) {
+		return ""
+	}
+	return fmt.Sprintf("Slow scrape: %s is %.0f%% of the %s %s", t.Total.Round(time.Millisecond),
+		float64(t.Total)*100/float64(budget), budget, of)
+}
+
+// addMaxSeriesFlag registers --max-series, shared by the commands parsing scrapes.
+func addMaxSeriesFlag(app extkingpin.AppClause, maxSeries *int) {
+	app.Flag("max-series", "Stop parsing after this many series and analyze the partial result, 0 for no limit").
+		Default("0").
+		IntVar(maxSeries)
+}
+
+// addLabelFilterFlags registers --drop-label and --keep-label, shared by the commands parsing scrapes.
+func addLabelFilterFlags(app extkingpin.AppClause, drop, keep *[]string) {
+	app.Flag("drop-label", "Label to remove from every series before counting them, to see the cardinality "+
+		"without it, can be repeated").
+		SetValue((*droppedLabels)(drop))
+
+	app.Flag("keep-label", "Label to keep on every series before counting them, removing all the others but the "+
+		"metric name, can be repeated").
+		StringsVar(keep)
+}
+
+// droppedLabels is the value of --drop-label. The metric name cannot be dropped, every series would be.
+type droppedLabels []string
+
+func (d *droppedLabels) Set(name string) error {
+	if name == labels.MetricName {
+		return errors.Errorf("cannot drop the %s label, it holds the metric name", labels.MetricName)
+	}
+	*d = append(*d, name)
+	return nil
+}
+
+func (d *droppedLabels) String() string {
+	return strings.Join(*d, ",")
+}
+
+func (d *droppedLabels) IsCumulative() bool {
+	return true
+}
+
+// addSkipExemplarsFlag registers --skip-exemplars, shared by the commands parsing scrapes.
+func addSkipExemplarsFlag(app extkingpin.AppClause, skip *bool) {
+	app.Flag("skip-exemplars", "Do not keep the exemplars of the series, whose trace IDs can take a large share "+
+		"of the memory on heavily instrumented targets").
+		Default("false").
+		BoolVar(skip)
+}
+
+// addCacheFlags registers the flags caching the first scrape of a command on disk, for the commands scraping
+// the target once so that running several of them in a row reaches the target once.
+func (o *Options) addCacheFlags(app extkingpin.AppClause) {
+	app.Flag("cache.dir", "Directory caching the last scrape of every target, reused by the next command "+
+		"within --cache.ttl instead of scraping the target again, and compared by top against the previous run. "+
+		"Only the commands with this flag use the cache, check, grep and the others reading the raw exposition "+
+		"always scrape the target").
+		Default("").
+		StringVar(&o.CacheDir)
+
+	app.Flag("cache.ttl", "How long a cached scrape is reused").
+		Default("5m").
+		DurationVar(&o.CacheTTL)
+}
+
+// addThrottleFlags registers the flags spacing out the scrapes, for the commands scraping many targets or the
+// same target over and over.
+func (o *Options) addThrottleFlags(app extkingpin.AppClause) {
+	app.Flag("scrape.rate-limit", "Maximum number of scrapes started per second, 0 for no limit").
+		Default("0").
+		Float64Var(&o.RateLimit)
+
+	app.Flag("scrape.jitter", "Random delay of up to this duration before every scrape, so that the scrapes "+
+		"of a fleet of exporters do not line up").
+		Default("0s").
+		DurationVar(&o.Jitter)
+}
+
+// throttle returns the throttle of the scrapes, nil when they are neither rate limited nor jittered.
+func (o *Options) throttle() *scrape.Throttle {
+	if o.RateLimit <= 0 && o.Jitter <= 0 {
+		return nil
+	}
+	return scrape.NewThrottle(o.RateLimit, o.Jitter)
+}
+
+// bindEnvVars makes every visible flag of the application and its commands, nested ones included, fall back to
+// its environment variable, see flagEnvVar. It must be called once all the commands are registered.
+func bindEnvVars(app *kingpin.Application) {
+	model := app.Model()
+	for _, f := range model.Flags {
+		if !f.Hidden && f.Name != "help" {
+			app.GetFlag(f.Name).Envar(envVar(f.Name))
+		}
+	}
+	for _, cmd := range model.FlattenedCommands() {
+		path := strings.Fields(cmd.FullCommand)
+		clause := app.GetCommand(path[0])
+		for _, name := range path[1:] {
+			clause = clause.GetCommand(name)
+		}
+		for _, f := range cmd.Flags {
+			if !f.Hidden {
+				clause.GetFlag(f.Name).Envar(flagEnvVar(cmd.FullCommand, f.Name))
+			}
+		}
+	}
+}
+
+// sharedFlags are the names of the flags of Options, they mean the same to every command defining them.
+var sharedFlags = sync.OnceValue(func() map[string]bool {
+	kp := kingpin.New("shared", "")
+	cmd := extkingpin.NewApp(kp).Command("shared", "")
+	var o Options
+	o.AddFlags(cmd)
+	o.addCacheFlags(cmd)
+	o.addThrottleFlags(cmd)
+	names := make(map[string]bool)
+	for _, f := range kp.Model().Commands[0].Flags {
+		names[f.Name] = true
+	}
+	return names
+})
+
+// flagEnvVar is the environment variable a flag of the command falls back to. The flags shared by the commands,
+// see sharedFlags, have a single variable, e.g. PROM_SCRAPE_ANALYZER_SCRAPE_URL, the others are prefixed with
+// the command since their meaning differs between commands, e.g. PROM_SCRAPE_ANALYZER_SNAPSHOT_OUTPUT.
+func flagEnvVar(cmd, flag string) string {
+	if cmd == "" || sharedFlags()[flag] {
+		return envVar(flag)
+	}
+	return envVar(strings.ReplaceAll(cmd, " ", "_") + "_" + flag)
+}
+
+// envVar is the environment variable a flag falls back to, e.g. PROM_SCRAPE_ANALYZER_SCRAPE_BEARER_TOKEN
+// for --scrape.bearer-token.
+func envVar(flag string) string {
+	return "PROM_SCRAPE_ANALYZER_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(flag))
 }
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	stdlog "log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestOptions_HTTPClient_Auth(t *testing.T) {
+	t.Parallel()
+	authorization := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+	}))
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("from-file\n"), 0o600))
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("s3cret"), 0o600))
+
+	for name, tc := range map[string]struct {
+		opts    Options
+		want    string
+		wantErr string
+	}{
+		"none": {},
+		"bearer token": {
+			opts: Options{BearerToken: "token"},
+			want: "Bearer token",
+		},
+		"bearer token file": {
+			opts: Options{BearerTokenFile: tokenFile},
+			want: "Bearer from-file",
+		},
+		"basic auth": {
+			opts: Options{BasicAuthUsername: "user", BasicAuthPassword: "s3cret"},
+			// base64("user:s3cret")
+			want: "Basic dXNlcjpzM2NyZXQ=",
+		},
+		"basic auth password file": {
+			opts: Options{BasicAuthUsername: "user", BasicAuthPasswordFile: passwordFile},
+			want: "Basic dXNlcjpzM2NyZXQ=",
+		},
+		"basic auth without password": {
+			opts: Options{BasicAuthUsername: "user"},
+			want: "Basic dXNlcjo=",
+		},
+		"basic auth password without username": {
+			opts:    Options{BasicAuthPassword: "s3cret"},
+			wantErr: "require --scrape.basic-auth.username",
+		},
+		"basic auth password file without username": {
+			opts:    Options{BasicAuthPasswordFile: passwordFile},
+			wantErr: "require --scrape.basic-auth.username",
+		},
+		"bearer token and basic auth": {
+			opts:    Options{BearerToken: "token", BasicAuthUsername: "user"},
+			wantErr: "invalid scrape authentication or TLS flags",
+		},
+		"bearer token and its file": {
+			opts:    Options{BearerToken: "token", BearerTokenFile: tokenFile},
+			wantErr: "invalid scrape authentication or TLS flags",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, err := tc.opts.httpClient()
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			resp, err := client.Get(srv.URL)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, tc.want, <-authorization)
+		})
+	}
+}
+
+// writeClientCert writes a self-signed client certificate and its key to dir.
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "prom-scrape-analyzer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestOptions_HTTPClient_TLS(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			w.Header().Set("X-Client-Cert", r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	// The failed handshakes are expected.
+	srv.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, ca, 0o600))
+	certFile, keyFile := writeClientCert(t, dir)
+
+	for name, tc := range map[string]struct {
+		opts       Options
+		wantCert   string
+		wantErr    string
+		wantGetErr string
+	}{
+		"unknown authority": {
+			wantGetErr: "certificate signed by unknown authority",
+		},
+		"insecure skip verify": {
+			opts: Options{TLSInsecureSkipVerify: true},
+		},
+		"ca file": {
+			opts: Options{TLSCAFile: caFile},
+		},
+		"ca file and server name": {
+			// The test server certificate is valid for example.com.
+			opts: Options{TLSCAFile: caFile, TLSServerName: "example.com"},
+		},
+		"ca file and another server name": {
+			opts:       Options{TLSCAFile: caFile, TLSServerName: "prometheus.io"},
+			wantGetErr: "certificate is valid for",
+		},
+		"client certificate": {
+			opts:     Options{TLSCAFile: caFile, TLSCertFile: certFile, TLSKeyFile: keyFile},
+			wantCert: "prom-scrape-analyzer",
+		},
+		"client certificate without its key": {
+			opts:    Options{TLSCAFile: caFile, TLSCertFile: certFile},
+			wantErr: "key_file must be configured",
+		},
+		"missing ca file": {
+			opts:    Options{TLSCAFile: filepath.Join(dir, "missing.crt")},
+			wantErr: "missing.crt",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			client, err := tc.opts.httpClient()
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			resp, err := client.Get(srv.URL)
+			if tc.wantGetErr != "" {
+				require.ErrorContains(t, err, tc.wantGetErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, tc.wantCert, resp.Header.Get("X-Client-Cert"))
+		})
+	}
+}
+
+func TestAddLabelFilterFlags(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		args     []string
+		wantDrop []string
+		wantKeep []string
+		wantErr  string
+	}{
+		"repeated": {
+			args:     []string{"--drop-label=pod", "--drop-label=instance", "--keep-label=job"},
+			wantDrop: []string{"pod", "instance"},
+			wantKeep: []string{"job"},
+		},
+		"metric name": {
+			args:    []string{"--drop-label=pod", "--drop-label=__name__"},
+			wantErr: "cannot drop the __name__ label",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			kp := kingpin.New("test", "")
+			var drop, keep []string
+			addLabelFilterFlags(extkingpin.NewApp(kp).Command("top", ""), &drop, &keep)
+			_, err := kp.Parse(append([]string{"top"}, tc.args...))
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantDrop, drop)
+			require.Equal(t, tc.wantKeep, keep)
+		})
+	}
+}
+
+// The scrapes go through the proxy of the environment, as they did with http.DefaultClient.
+func TestOptions_HTTPClient_Proxy(t *testing.T) {
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+	}))
+	t.Cleanup(proxy.Close)
+	for _, name := range []string{"HTTP_PROXY", "http_proxy"} {
+		t.Setenv(name, proxy.URL)
+	}
+	for _, name := range []string{"NO_PROXY", "no_proxy"} {
+		t.Setenv(name, "")
+	}
+
+	client, err := (&Options{}).httpClient()
+	require.NoError(t, err)
+	resp, err := client.Get("http://exporter.invalid:9100/metrics")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "http://exporter.invalid:9100/metrics", <-proxied)
+}
+
+func TestOptions_CacheKey(t *testing.T) {
+	t.Parallel()
+	base := Options{ScrapeURL: "http://api:8080/metrics", MaxScrapeSize: "100MB"}
+	keys := map[string]string{"base": base.cacheKey()}
+	for name, change := range map[string]func(*Options){
+		"param":                func(o *Options) { o.Params = []string{"module=http_2xx"} },
+		"header":               func(o *Options) { o.Headers = []string{"X-Scope-OrgID: team-a"} },
+		"basic auth user":      func(o *Options) { o.BasicAuthUsername = "team-a" },
+		"bearer token":         func(o *Options) { o.BearerToken = "token-a" },
+		"another bearer token": func(o *Options) { o.BearerToken = "token-b" },
+		"bearer token file":    func(o *Options) { o.BearerTokenFile = "/var/run/secrets/team-a" },
+		"max scrape size":      func(o *Options) { o.MaxScrapeSize = "10MB" },
+		"max series":           func(o *Options) { o.MaxSeries = 1000 },
+		"skip exemplars":       func(o *Options) { o.SkipExemplars = true },
+	} {
+		o := base
+		change(&o)
+		key := o.cacheKey()
+		for other, otherKey := range keys {
+			require.NotEqual(t, otherKey, key, "%s and %s share a cache key", name, other)
+		}
+		keys[name] = key
+	}
+
+	// Equal values give equal keys, however written.
+	same := base
+	same.MaxScrapeSize = "100000000"
+	require.Equal(t, keys["base"], same.cacheKey())
+	same.Params = []string{"b=2", "a=1"}
+	reordered := base
+	reordered.Params = []string{"a=1", "b=2"}
+	require.Equal(t, same.cacheKey(), reordered.cacheKey())
+
+	token := base
+	token.BearerToken = "s3cret-token"
+	require.NotContains(t, token.cacheKey(), "s3cret-token")
+}
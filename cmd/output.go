@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// output formats of the cardinality command, the TUI is the interactive default.
+const (
+	outputTUI        = "tui"
+	outputTemplate   = "template"
+	outputProm       = "prom"
+	outputMatrixCSV  = "matrix-csv"
+	outputMatrixJSON = "matrix-json"
+)
+
+var outputFormats = []string{outputTUI, outputTemplate, outputProm, outputMatrixCSV, outputMatrixJSON}
+
+// templateFuncs are the functions available to --template on top of the built-in ones.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// parseOutputTemplate parses the --template flag, required by --output=template.
+func parseOutputTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, errors.New("--output=template requires --template, e.g. '{{.Name}} {{.Cardinality}}'")
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid --template")
+	}
+	return tmpl, nil
+}
+
+// runOutput scrapes the target once and writes the metric families matching the view flags to stdout in the
+// non-interactive --output format.
+func (o *cardinalityOptions) runOutput(
+	ctx context.Context,
+	g *run.Group,
+	logger log.Logger,
+	metrics *scrape.Metrics,
+) error {
+	if len(o.Targets) > 0 {
+		return errors.Errorf("--target is only supported by --output=%s", outputTUI)
+	}
+	if o.Type != "" && !slices.Contains(metricTypes, o.Type) {
+		return errors.Errorf("unknown metric type %q, expected one of: %s", o.Type, strings.Join(metricTypes, ", "))
+	}
+	var (
+		tmpl *template.Template
+		err  error
+	)
+	if o.Output == outputTemplate {
+		if tmpl, err = parseOutputTemplate(o.Template); err != nil {
+			return err
+		}
+	}
+	scraper, err := o.Scraper(logger, scrape.WithMetrics(metrics))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	g.Add(func() error {
+		result, err := scraper.Scrape(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to scrape target")
+		}
+		rows := o.outputRows(result.Series)
+		switch o.Output {
+		case outputProm:
+			return writeProm(os.Stdout, result, rows, o.redactedScrapeURL())
+		case outputMatrixCSV, outputMatrixJSON:
+			families := make([]string, 0, len(rows))
+			for _, row := range rows {
+				families = append(families, row.Name)
+			}
+			matrix := scrape.NewLabelMatrix(result.Series, families)
+			matrix.Truncated = result.Truncated
+			if o.Output == outputMatrixJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(matrix)
+			}
+			return writeMatrixCSV(os.Stdout, matrix)
+		default:
+			return writeTemplate(os.Stdout, tmpl, rows)
+		}
+	}, func(error) {
+		cancel()
+	})
+	return nil
+}
+
+// outputRows returns the metric families matching the --type and --min-cardinality flags, the highest
+// cardinality first.
+func (o *cardinalityOptions) outputRows(sm scrape.SeriesMap) []scrape.SeriesInfo {
+	var rows []scrape.SeriesInfo
+	for _, info := range sm.AsRows() {
+		if o.Type != "" && !slices.Contains(strings.Split(info.Type, "|"), o.Type) {
+			continue
+		}
+		if info.Cardinality < o.MinCardinality {
+			continue
+		}
+		rows = append(rows, info)
+	}
+	return rows
+}
+
+// writeTemplate renders the template once per metric family, each on its own line unless the template ends
+// with a newline.
+func writeTemplate(w io.Writer, tmpl *template.Template, rows []scrape.SeriesInfo) error {
+	var sb strings.Builder
+	for _, row := range rows {
+		sb.Reset()
+		if err := tmpl.Execute(&sb, row); err != nil {
+			return errors.Wrapf(err, "failed to render the template for %s", row.Name)
+		}
+		if !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMatrixCSV writes the label matrix with a row per metric family and a column per label name, after the
+// family and its number of series. A partial scrape gets a last truncated column, see markTruncated.
+func writeMatrixCSV(w io.Writer, m scrape.LabelMatrix) error {
+	header := append([]string{"family", "series"}, m.Labels...)
+	rows := make([][]string, 0, len(m.Families))
+	for i, family := range m.Families {
+		row := make([]string, 0, len(header))
+		row = append(row, family, strconv.Itoa(m.Series[i]))
+		for _, v := range m.Values[i] {
+			row = append(row, strconv.Itoa(v))
+		}
+		rows = append(rows, row)
+	}
+	header, rows = markTruncated(header, rows, m.Truncated)
+	return writeCSV(w, header, rows)
+}
+
+// writeProm writes the analysis of the metric families as Prometheus metrics in the text format, for the
+// textfile collector or a Pushgateway. The totals describe the whole scrape, whatever the rows.
+func writeProm(w io.Writer, result *scrape.Result, rows []scrape.SeriesInfo, target string) error {
+	metrics := make([]string, 0, len(rows))
+	for _, row := range rows {
+		metrics = append(metrics, row.Name)
+	}
+	families := scrape.AnalysisFamilies(result, target, metrics)
+	return scrape.EncodeFamilies(w, families, expfmt.NewFormat(expfmt.TypeTextPlain))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestParseOutputTemplate(t *testing.T) {
+	t.Parallel()
+	_, err := parseOutputTemplate("")
+	require.ErrorContains(t, err, "--output=template requires --template")
+
+	_, err = parseOutputTemplate("{{.Name")
+	require.ErrorContains(t, err, "invalid --template")
+
+	_, err = parseOutputTemplate("{{unknown .Name}}")
+	require.ErrorContains(t, err, `invalid --template: template: output:1: function "unknown" not defined`)
+}
+
+func TestWriteTemplate(t *testing.T) {
+	t.Parallel()
+	rows := []scrape.SeriesInfo{
+		{Name: "http_requests_total", Cardinality: 120, Type: "counter", Labels: `path(60)|code(2)`},
+		{Name: "up", Cardinality: 1, Type: "gauge"},
+	}
+	for name, tc := range map[string]struct {
+		template string
+		want     string
+	}{
+		"a line per family": {
+			template: "{{.Name}} {{.Cardinality}}",
+			want:     "http_requests_total 120\nup 1\n",
+		},
+		"trailing newline is not doubled": {
+			template: "{{.Name}}\n",
+			want:     "http_requests_total\nup\n",
+		},
+		"inner newlines are kept": {
+			template: "{{.Name}}\n{{.Type}}",
+			want:     "http_requests_total\ncounter\nup\ngauge\n",
+		},
+		"empty output still ends the line": {
+			template: "{{/* nothing */}}",
+			want:     "\n\n",
+		},
+		"json": {
+			template: "{{json .}}",
+			want: `{"Name":"http_requests_total","Cardinality":120,"Type":"counter",` +
+				`"Labels":"path(60)|code(2)","CreatedTS":""}` + "\n" +
+				`{"Name":"up","Cardinality":1,"Type":"gauge","Labels":"","CreatedTS":""}` + "\n",
+		},
+		"json of a field": {
+			template: `{{json .Labels}}`,
+			want:     `"path(60)|code(2)"` + "\n" + `""` + "\n",
+		},
+		"upper and lower": {
+			template: "{{upper .Name}} {{lower \"GAUGE\"}}",
+			want:     "HTTP_REQUESTS_TOTAL gauge\nUP gauge\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tmpl, err := parseOutputTemplate(tc.template)
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			require.NoError(t, writeTemplate(&buf, tmpl, rows))
+			require.Equal(t, tc.want, buf.String())
+		})
+	}
+}
+
+func TestWriteTemplate_Error(t *testing.T) {
+	t.Parallel()
+	tmpl, err := parseOutputTemplate("{{.Name}} {{.Missing}}")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	err = writeTemplate(&buf, tmpl, []scrape.SeriesInfo{{Name: "up"}})
+	require.ErrorContains(t, err, "failed to render the template for up: ")
+	require.ErrorContains(t, err, "can't evaluate field Missing")
+	require.Empty(t, buf.String(), "nothing is written for a family that fails to render")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pinMarker prefixes the name of pinned metrics in the table.
+const pinMarker = "★ "
+
+// togglePin pins the selected metric to the top of the table, or unpins it.
+func (m *seriesTable) togglePin() {
+	name := rowName(m.table.SelectedRow())
+	if name == "" {
+		return
+	}
+	if _, ok := m.pinned[name]; ok {
+		delete(m.pinned, name)
+	} else {
+		m.pinned[name] = struct{}{}
+	}
+	if len(m.pinned) == 0 {
+		m.pinnedOnly = false
+	}
+	m.setTableRows(m.currentFilter())
+	m.followRow(name)
+}
+
+// togglePinnedOnly switches between the full table and a view of the pinned metrics only.
+func (m *seriesTable) togglePinnedOnly() {
+	if len(m.pinned) == 0 && !m.pinnedOnly {
+		return
+	}
+	m.pinnedOnly = !m.pinnedOnly
+	m.setTableRows(m.currentFilter())
+	m.table.SetCursor(0)
+}
+
+// isPinned reports whether the metric is pinned.
+func (m *seriesTable) isPinned(name string) bool {
+	_, ok := m.pinned[name]
+	return ok
+}
+
+// pinsSummary describes the pinned metrics for the footer, pins missing from the last scrape included.
+func (m *seriesTable) pinsSummary() string {
+	if len(m.pinned) == 0 {
+		return ""
+	}
+	var missing []string
+	for name := range m.pinned {
+		if _, ok := m.seriesMap[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	summary := fmt.Sprintf("Pinned metrics: %d", len(m.pinned))
+	if m.pinnedOnly {
+		summary += " (showing pinned only)"
+	}
+	if len(missing) > 0 {
+		summary += fmt.Sprintf(", not in last scrape: %s", strings.Join(missing, ", "))
+	}
+	return summary
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+const (
+	// tableProgressInterval is how often the table updates the progress of a scrape.
+	tableProgressInterval = 100 * time.Millisecond
+	// logProgressInterval is how often headless commands log the progress of a scrape.
+	logProgressInterval = 5 * time.Second
+)
+
+// progressMsg carries the progress of the scrape in flight to the table.
+type progressMsg scrape.Progress
+
+// tableProgress returns a scraper option forwarding the progress of the scrapes to the returned channel,
+// read by the table. Reports are dropped while the table has not consumed the previous one.
+func tableProgress() (scrape.ScraperOption, <-chan scrape.Progress) {
+	ch := make(chan scrape.Progress, 1)
+	return scrape.WithProgress(func(p scrape.Progress) {
+		select {
+		case ch <- p:
+		default:
+		}
+	}, tableProgressInterval), ch
+}
+
+// waitForProgress returns a command delivering the next progress report of ch to the table.
+func waitForProgress(ch <-chan scrape.Progress) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return progressMsg(<-ch)
+	}
+}
+
+// logProgress logs the progress of the scrapes of headless commands.
+func logProgress(logger log.Logger) scrape.ProgressFunc {
+	return func(p scrape.Progress) {
+		level.Info(logger).Log("msg", "scrape in progress", "progress", formatProgress(p))
+	}
+}
+
+// formatProgress describes a progress report, e.g. "downloaded 12MiB of 200MiB (6%) in 3s".
+func formatProgress(p scrape.Progress) string {
+	elapsed := p.Elapsed.Round(time.Second)
+	if p.Phase == scrape.ProgressParse {
+		return fmt.Sprintf("parsed %d series in %s", p.Series, elapsed)
+	}
+	read := units.BytesSize(float64(p.BytesRead))
+	if p.TotalBytes <= 0 {
+		return fmt.Sprintf("downloaded %s in %s", read, elapsed)
+	}
+	return fmt.Sprintf("downloaded %s of %s (%d%%) in %s",
+		read, units.BytesSize(float64(p.TotalBytes)), p.BytesRead*100/p.TotalBytes, elapsed)
+}
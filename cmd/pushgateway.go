@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/docker/go-units"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+var pushGroupColumnLayout = []columnLayout{
+	{title: "Push group", width: 60, flex: true},
+	{title: "Cardinality", width: 16},
+	{title: "Metrics", width: 10},
+	{title: "Last push", width: 20},
+	{title: "Age", width: 24},
+	{title: "Top metric", width: 60, flex: true},
+}
+
+// defaultPushStaleAfter is the default of --pushgateway.stale-after.
+const defaultPushStaleAfter = 24 * time.Hour
+
+// detectPushgateway enables the per-group view when the scrape comes from a Pushgateway.
+func (m *seriesTable) detectPushgateway(sm scrape.SeriesMap) {
+	m.pushgateway = sm.IsPushgateway()
+	keys.Group.SetEnabled(m.federated || m.pushgateway)
+}
+
+// scrapeTime returns the time of the scrape shown, the push groups are as old as of then.
+func (m *seriesTable) scrapeTime() time.Time {
+	if r := m.timeline.At(m.travelBack); r != nil && !r.Time.IsZero() {
+		return r.Time
+	}
+	return time.Now()
+}
+
+// setPushGroupRows fills the table with the push groups of a Pushgateway scrape, in place of the origins of a
+// federated one. The filter is applied to the grouping labels.
+func (m *seriesTable) setPushGroupRows(filter func(info scrape.SeriesInfo) bool) {
+	widths := m.table.Columns()
+	terms := m.searchTerms()
+	now := m.scrapeTime()
+	groups, _ := m.seriesMap.PushGroups()
+	var rows []scoredRow
+	for _, g := range groups {
+		info := scrape.SeriesInfo{Name: g.Name(), Cardinality: g.Cardinality}
+		if filter != nil && !filter(info) {
+			continue
+		}
+		match, _ := matchSearch(terms, searchFields(info), &info)
+		lastPush, age := "never", "-"
+		if !g.PushTime.IsZero() {
+			lastPush = g.PushTime.Local().Format(time.DateTime)
+			age = units.HumanDuration(now.Sub(g.PushTime)) + " ago"
+		}
+		if g.Stale(now, m.pushStaleAfter) {
+			age = styleCell(warnStyle, age+", stale", widths[4].Width)
+		}
+		rows = append(rows, scoredRow{score: match.score, row: table.Row{
+			highlightMatches(info.Name, match.positions["name"], widths[0].Width),
+			strconv.Itoa(g.Cardinality),
+			strconv.Itoa(g.Metrics),
+			lastPush,
+			age,
+			fmt.Sprintf("%s (%d)", g.TopMetric, g.TopMetricCardinality),
+		}})
+	}
+
+	m.table.SetRows(rankRows(rows))
+}
+
+// pushSummary flags the push groups nobody pushed to for longer than --pushgateway.stale-after, abandoned groups
+// are scraped until deleted from the Pushgateway.
+func (m *seriesTable) pushSummary() string {
+	if !m.pushgateway {
+		return ""
+	}
+	now := m.scrapeTime()
+	groups, ungrouped := m.seriesMap.PushGroups()
+	var stale, series int
+	for _, g := range groups {
+		if g.Stale(now, m.pushStaleAfter) {
+			stale++
+			series += g.Cardinality
+		}
+	}
+	summary := fmt.Sprintf("Pushgateway: %d push groups, %d series of the Pushgateway itself", len(groups), ungrouped)
+	if stale == 0 {
+		return summary
+	}
+	return summary + "\n" + warnStyle.Render(fmt.Sprintf(
+		"%d push groups were last pushed over %s ago, %d series likely abandoned, delete them from the Pushgateway",
+		stale, units.HumanDuration(m.pushStaleAfter), series))
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type queryOptions struct {
+	Options
+	Query string
+}
+
+func (o *queryOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("query", "PromQL expression to evaluate over the scrape, e.g. 'count by (path) (http_requests_total)'").
+		Required().
+		StringVar(&o.Query)
+}
+
+func registerQueryCommand(app *extkingpin.App) {
+	cmd := app.Command("query", "Scrape a target once, or read a snapshot, and evaluate an instant PromQL query "+
+		"over its series.")
+	opts := &queryOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		if _, err := parser.ParseExpr(opts.Query); err != nil {
+			return errors.Wrap(err, "invalid --query")
+		}
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			ts := result.Time
+			if ts.IsZero() {
+				ts = time.Now()
+			}
+			value, warnings, err := scrape.Query(ctx, result.Series, ts, opts.Query)
+			for _, w := range warnings {
+				level.Warn(logger).Log("msg", "query warning", "warning", w)
+			}
+			if err != nil {
+				return errors.Wrap(err, "failed to evaluate query")
+			}
+			return printQueryValue(os.Stdout, value)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+// printQueryValue prints the result of a query, one row per series for vectors and matrices.
+func printQueryValue(out io.Writer, value parser.Value) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	switch v := value.(type) {
+	case promql.Vector:
+		fmt.Fprintln(tw, "SERIES\tVALUE")
+		for _, s := range v {
+			value := formatFloat(s.F)
+			if s.H != nil {
+				value = s.H.String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", s.Metric, value)
+		}
+	case promql.Matrix:
+		fmt.Fprintln(tw, "SERIES\tVALUES")
+		for _, s := range v {
+			fmt.Fprintf(tw, "%s\t", s.Metric)
+			for i, p := range s.Floats {
+				if i > 0 {
+					fmt.Fprint(tw, " ")
+				}
+				fmt.Fprint(tw, formatFloat(p.F))
+			}
+			fmt.Fprintln(tw)
+		}
+	case promql.Scalar:
+		fmt.Fprintln(tw, formatFloat(v.V))
+	case promql.String:
+		fmt.Fprintln(tw, v.V)
+	default:
+		fmt.Fprintln(tw, value.String())
+	}
+	return tw.Flush()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type recordOptions struct {
+	Options
+	Dir      string
+	Interval time.Duration
+	Count    int
+}
+
+func (o *recordOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+
+	app.Flag("dir", "Directory to store the recording in, scrapes are appended to an existing recording").
+		Required().
+		StringVar(&o.Dir)
+
+	app.Flag("interval", "Interval between scrapes").
+		Default("15s").
+		DurationVar(&o.Interval)
+
+	app.Flag("count", "Number of scrapes to record, 0 to run until interrupted").
+		Default("0").
+		IntVar(&o.Count)
+}
+
+func registerRecordCommand(app *extkingpin.App) {
+	cmd := app.Command("record", "Scrape a target repeatedly and store the scrapes in a directory for later replay.")
+	opts := &recordOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.NewScraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+		recorder, err := scrape.NewRecorder(opts.Dir, opts.ScrapeURL)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			return runRecord(ctx, opts, scraper, recorder, logger)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func runRecord(
+	ctx context.Context,
+	opts *recordOptions,
+	scraper *scrape.PromScraper,
+	recorder *scrape.Recorder,
+	logger log.Logger,
+) error {
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	recorded := 0
+	for {
+		t := time.Now()
+		fetch, err := scraper.FetchRawWithContext(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			// A single failed scrape should not end a long recording session.
+			level.Warn(logger).Log("msg", "scrape failed", "url", opts.ScrapeURL, "err", err)
+		default:
+			rec, err := recorder.Record(fetch, t)
+			if err != nil {
+				return err
+			}
+			recorded++
+			level.Info(logger).Log(
+				"msg", "scrape recorded",
+				"file", rec.File,
+				"scrapes", recorder.Count(),
+				"body_size", units.BytesSize(float64(rec.BodyBytes)),
+				"content_type", rec.ContentType,
+			)
+		}
+
+		if opts.Count > 0 && recorded >= opts.Count {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
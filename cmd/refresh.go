@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+const (
+	// flashDuration is how long a flash message stays in the footer.
+	flashDuration = 5 * time.Second
+	// defaultAutoRefreshInterval is used when auto-refresh is toggled on without --refresh.
+	defaultAutoRefreshInterval = 30 * time.Second
+	// defaultHistorySize is the number of scrapes kept when not configured.
+	defaultHistorySize = 20
+)
+
+// refreshMsg asks the table to re-scrape the target, reason is shown once the refresh completes.
+type refreshMsg struct {
+	reason string
+}
+
+type refreshResultMsg struct {
+	reason string
+	result *scrape.Result
+	err    error
+}
+
+type clearFlashMsg struct {
+	id int
+}
+
+type autoRefreshMsg struct {
+	id int
+}
+
+// startRefresh kicks off a background refresh and animates the spinner in the status area until
+// it completes.
+func (m *seriesTable) startRefresh(reason string) tea.Cmd {
+	if m.refreshing {
+		return nil
+	}
+	cmd := m.refresh(reason)
+	if cmd == nil {
+		return nil
+	}
+	return tea.Batch(cmd, m.spinner.Tick)
+}
+
+// refresh returns a command re-scraping the target in the background. It is a no-op while another
+// refresh is still in flight.
+func (m *seriesTable) refresh(reason string) tea.Cmd {
+	if m.scrapeFn == nil || m.refreshing {
+		return nil
+	}
+	m.refreshing = true
+
+	scrapeFn := m.scrapeFn
+	return func() tea.Msg {
+		result, err := scrapeFn()
+		return refreshResultMsg{reason: reason, result: result, err: err}
+	}
+}
+
+// applyRefresh swaps in the result of a refresh, keeping the current search and cursor position.
+func (m *seriesTable) applyRefresh(msg refreshResultMsg) tea.Cmd {
+	m.refreshing = false
+	m.lastProgress = nil
+	if errors.Is(msg.err, scrape.ErrEndOfRecording) {
+		m.autoRefreshOn = false
+		return m.setFlash("Replay complete, every recorded scrape has been shown")
+	}
+	if msg.err != nil {
+		return m.setFlash(fmt.Sprintf("Refresh (%s) failed: %v", msg.reason, msg.err))
+	}
+
+	span := m.tracer.StartSpan("analyze_series")
+	span.SetTag("metric_families", len(msg.result.Series))
+	defer span.Finish()
+
+	cursor := m.table.Cursor()
+	var selected string
+	if row := m.table.SelectedRow(); len(row) > 0 {
+		selected = rowName(row)
+	}
+
+	m.recordTargetRefresh(msg.result)
+	m.recordScrape(msg.result)
+	// The table stays on the past scrape it shows, with the changes to the new latest one.
+	m.travel(m.travelBack)
+
+	hadTrend, hadRunTrends := m.showTrend(), m.runTrends != nil
+	m.history.Add(msg.result.Series)
+	m.setRunTrends(msg.result.Series)
+	if !m.groupByOrigin && (hadTrend != m.showTrend() || hadRunTrends != (m.runTrends != nil)) {
+		m.resetColumns()
+	} else {
+		m.setTableRows(m.currentFilter())
+	}
+
+	// Follow the previously selected row if it still exists, otherwise stay at the same position.
+	if !m.followRow(selected) {
+		m.table.SetCursor(min(cursor, max(len(m.table.Rows())-1, 0)))
+	}
+
+	return m.setFlash(fmt.Sprintf("Refreshed (%s) at %s, %d metrics changed",
+		msg.reason, time.Now().Format(time.TimeOnly), len(m.changes)))
+}
+
+// followRow moves the cursor to the row with the given name, it reports whether the row was found.
+func (m *seriesTable) followRow(name string) bool {
+	i := slices.IndexFunc(m.table.Rows(), func(r table.Row) bool { return rowName(r) == name })
+	if i < 0 {
+		return false
+	}
+	m.table.SetCursor(i)
+	return true
+}
+
+// toggleAutoRefresh turns periodic refreshes on or off.
+func (m *seriesTable) toggleAutoRefresh() tea.Cmd {
+	m.autoRefreshOn = !m.autoRefreshOn
+	if !m.autoRefreshOn {
+		// Invalidate the pending tick.
+		m.autoRefreshID++
+		return m.setFlash("Auto-refresh disabled")
+	}
+	return tea.Batch(
+		m.setFlash(fmt.Sprintf("Auto-refresh enabled, every %s", m.autoRefresh)),
+		m.scheduleAutoRefresh(),
+	)
+}
+
+// scheduleAutoRefresh schedules the next automatic refresh, replacing any pending one.
+func (m *seriesTable) scheduleAutoRefresh() tea.Cmd {
+	if !m.autoRefreshOn || m.autoRefresh <= 0 {
+		return nil
+	}
+	m.autoRefreshID++
+
+	id := m.autoRefreshID
+	return tea.Tick(m.autoRefresh, func(time.Time) tea.Msg {
+		return autoRefreshMsg{id: id}
+	})
+}
+
+// formatCardinality renders the cardinality of a metric, marking it when it changed in the last refresh.
+func (m *seriesTable) formatCardinality(name string, cardinality int) string {
+	d, ok := m.changes[name]
+	switch {
+	case !ok:
+		return strconv.Itoa(cardinality)
+	case d > 0:
+		return strconv.Itoa(cardinality) + " ▲"
+	default:
+		return strconv.Itoa(cardinality) + " ▼"
+	}
+}
+
+// cardinalityChanges returns the cardinality delta of every metric whose cardinality differs between
+// the two scrapes, metrics that appeared or disappeared included.
+func cardinalityChanges(prev, cur scrape.SeriesMap) map[string]int {
+	changes := make(map[string]int)
+	for name, set := range cur {
+		if d := set.Cardinality() - prev[name].Cardinality(); d != 0 {
+			changes[name] = d
+		}
+	}
+	for name, set := range prev {
+		if _, ok := cur[name]; !ok {
+			changes[name] = -set.Cardinality()
+		}
+	}
+	return changes
+}
+
+// setFlash shows a transient message in the footer and schedules its removal.
+func (m *seriesTable) setFlash(text string) tea.Cmd {
+	m.flashID++
+	m.flash = text
+
+	id := m.flashID
+	return tea.Tick(flashDuration, func(time.Time) tea.Msg {
+		return clearFlashMsg{id: id}
+	})
+}
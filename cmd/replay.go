@@ -0,0 +1,91 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type replayOptions struct {
+	cardinalityOptions
+	Dir      string
+	Interval time.Duration
+}
+
+func (o *replayOptions) addFlags(app extkingpin.AppClause) {
+	app.Flag("dir", "Directory of the recording to replay, as written by the record command").
+		Required().
+		StringVar(&o.Dir)
+
+	app.Flag("interval", "Interval between replayed scrapes, 0 to only advance on refresh (r)").
+		Default("2s").
+		DurationVar(&o.Interval)
+
+	app.Flag("output-height", "Override the height of the output table, by default it fits the terminal").
+		Default("0").
+		IntVar(&o.OutputHeight)
+
+	addMaxSeriesFlag(app, &o.MaxSeries)
+	addLabelFilterFlags(app, &o.DropLabels, &o.KeepLabels)
+	addSkipExemplarsFlag(app, &o.SkipExemplars)
+	o.addViewFlags(app)
+}
+
+func registerReplayCommand(app *extkingpin.App) {
+	cmd := app.Command("replay", "Replay a recording in the cardinality TUI, one recorded scrape per refresh.")
+	opts := &replayOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		reloadCh <-chan struct{},
+		_ bool,
+	) error {
+		recording, err := scrape.OpenRecording(opts.Dir)
+		if err != nil {
+			return err
+		}
+		scrapeURL := recording.Scrapes[0].URL
+		progressOpt, progress := tableProgress()
+		parser := scrape.NewPromScraper(scrapeURL, logger,
+			scrape.WithMetrics(scrape.NewMetrics(reg)),
+			scrape.WithMaxSeries(opts.MaxSeries),
+			scrape.WithLabelFilter(opts.DropLabels, opts.KeepLabels),
+			scrape.WithSkipExemplars(opts.SkipExemplars),
+			progressOpt,
+		)
+		replayer := scrape.NewReplayer(recording, parser)
+		scrapeFn := func() (*scrape.Result, error) {
+			result, err := replayer.Next()
+			if err != nil {
+				return nil, err
+			}
+			level.Info(logger).Log("msg", "replaying scrape", "url", scrapeURL, "recorded_at", result.Time)
+			return result, nil
+		}
+
+		opts.stateKey = replayStateKey(opts.Dir)
+		metricTable, err := opts.newTable(logger, tracer, scrapeURL, scrapeFn)
+		if err != nil {
+			return err
+		}
+		metricTable.replaying = true
+		metricTable.progress = progress
+		if opts.Interval > 0 {
+			metricTable.autoRefresh = opts.Interval
+			metricTable.autoRefreshOn = true
+		}
+		level.Info(logger).Log("msg", "replaying recording", "dir", opts.Dir, "scrapes", len(recording.Scrapes))
+		runTable(g, logger, metricTable, reloadCh)
+		return nil
+	})
+}
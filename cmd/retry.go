@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+const (
+	// retryBackoff is the delay before retrying a failed first scrape, doubled on every failure up to
+	// maxRetryBackoff.
+	retryBackoff    = time.Second
+	maxRetryBackoff = time.Minute
+)
+
+// scrapeFailure is the failure of the first scrape, the error screen retries it until it succeeds.
+type scrapeFailure struct {
+	err error
+	// last is the error of the last attempt, kept while the next one runs and returned on exit.
+	last     error
+	attempts int
+	// retryAt is when the next attempt starts, retryID tells its retryMsg apart from stale ones.
+	retryAt time.Time
+	retryID int
+}
+
+// retryMsg asks the table to retry the failed first scrape.
+type retryMsg struct {
+	id int
+}
+
+// retargetFunc returns the scrape function of another target URL.
+type retargetFunc func(scrapeURL string) (func() (*scrape.Result, error), error)
+
+// failScrape shows the error screen for a failed first scrape and schedules the next attempt.
+func (m *seriesTable) failScrape(err error) tea.Cmd {
+	m.loading = false
+	m.lastProgress = nil
+	m.failure.err = err
+	m.failure.last = err
+	m.failure.attempts++
+	m.failure.retryID++
+	if m.scrapeFn == nil {
+		return nil
+	}
+	delay := min(retryBackoff<<min(m.failure.attempts-1, 16), maxRetryBackoff)
+	m.failure.retryAt = time.Now().Add(delay)
+	id := m.failure.retryID
+	return tea.Tick(delay, func(time.Time) tea.Msg { return retryMsg{id: id} })
+}
+
+// retryScrape starts another attempt of the first scrape, the result is delivered as the first one is.
+func (m *seriesTable) retryScrape() tea.Cmd {
+	if m.scrapeFn == nil {
+		return nil
+	}
+	m.failure.err = nil
+	m.failure.retryID++
+	m.loading = true
+	scrapeFn := m.scrapeFn
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		result, err := scrapeFn()
+		if err != nil {
+			return err
+		}
+		return result
+	})
+}
+
+// editTarget prompts for another target URL to scrape.
+func (m *seriesTable) editTarget() tea.Cmd {
+	m.editingURL = true
+	m.urlInput.SetValue(m.target)
+	m.urlInput.SetCursor(int(cursor.CursorBlink))
+	m.urlInput.CursorEnd()
+	return m.urlInput.Focus()
+}
+
+// retarget switches the table to another target URL and scrapes it.
+func (m *seriesTable) retarget(scrapeURL string) tea.Cmd {
+	scrapeFn, err := m.newScrapeFn(scrapeURL)
+	if err != nil {
+		m.failure.err = errors.Wrapf(err, "invalid target %s", scrapeURL)
+		m.failure.last = m.failure.err
+		return nil
+	}
+	m.scrapeFn = scrapeFn
+	m.target = scrapeURL
+	m.stateKey = scrapeURL
+	m.federated = scrape.IsFederationURL(scrapeURL)
+	keys.Group.SetEnabled(m.federated)
+	m.failure.attempts = 0
+	return m.retryScrape()
+}
+
+// exitErr is the error the TUI exits with, the failure of the first scrape when the user quits before it
+// succeeds.
+func (m *seriesTable) exitErr() error {
+	if m.loaded {
+		return nil
+	}
+	return m.failure.last
+}
+
+func (m *seriesTable) updateWhileFailed(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if m.editingURL {
+		if ok {
+			switch keyMsg.String() {
+			case "enter":
+				m.editingURL = false
+				m.urlInput.Blur()
+				return m, m.retarget(strings.TrimSpace(m.urlInput.Value()))
+			case "esc":
+				m.editingURL = false
+				m.urlInput.Blur()
+				return m, nil
+			}
+		}
+		m.urlInput, cmd = m.urlInput.Update(msg)
+		return m, cmd
+	}
+
+	if ok {
+		switch keyMsg.String() {
+		case "q", "esc":
+			return m, tea.Quit
+		case "r":
+			return m, m.retryScrape()
+		case "e":
+			if m.newScrapeFn != nil {
+				return m, m.editTarget()
+			}
+		}
+	}
+	return m, nil
+}
+
+// failureView is the error screen shown while the first scrape fails.
+func (m *seriesTable) failureView() string {
+	var sb strings.Builder
+	sb.WriteString(warnStyle.Render("Scrape failed: " + m.failure.err.Error()))
+	sb.WriteString("\n")
+	if m.failure.retryAt.IsZero() {
+		sb.WriteString(hintStyle.Render(fmt.Sprintf("Attempt %d", m.failure.attempts)))
+	} else {
+		sb.WriteString(hintStyle.Render(fmt.Sprintf("Attempt %d, retrying automatically at %s",
+			m.failure.attempts, m.failure.retryAt.Format(time.TimeOnly))))
+	}
+	sb.WriteString("\n\n")
+	if m.editingURL {
+		sb.WriteString(m.urlInput.View())
+		sb.WriteString("\n")
+		sb.WriteString(hintStyle.Render("enter scrape the new target • esc cancel"))
+	} else {
+		help := "r retry now • q quit"
+		if m.newScrapeFn != nil {
+			help = "r retry now • e edit the target URL • q quit"
+		}
+		sb.WriteString(hintStyle.Render(help))
+	}
+	return baseStyle.Padding(0, 1).Render(sb.String())
+}
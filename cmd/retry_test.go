@@ -0,0 +1,164 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// runCmd runs cmd and the commands it batches, returning the messages that are not spinner ticks.
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		var msgs []tea.Msg
+		for _, c := range msg {
+			msgs = append(msgs, runCmd(c)...)
+		}
+		return msgs
+	case nil, spinner.TickMsg:
+		return nil
+	default:
+		return []tea.Msg{msg}
+	}
+}
+
+func quitKey() tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}
+}
+
+func TestSeriesTable_FailScrape(t *testing.T) {
+	t.Parallel()
+	m := newModel(nil, 0)
+	require.Nil(t, m.failScrape(errors.New("refused")), "nothing to retry without a scrape function")
+	require.False(t, m.loading)
+	require.EqualError(t, m.failure.err, "refused")
+	require.True(t, m.failure.retryAt.IsZero())
+
+	m = newModel(nil, 0)
+	m.scrapeFn = func() (*scrape.Result, error) { return nil, errors.New("refused") }
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		before := time.Now()
+		require.NotNil(t, m.failScrape(errors.New("refused")))
+		require.Equal(t, attempt+1, m.failure.attempts)
+		require.WithinDuration(t, before.Add(want), m.failure.retryAt, time.Second)
+	}
+	m.failure.attempts = 20
+	before := time.Now()
+	m.failScrape(errors.New("refused"))
+	require.WithinDuration(t, before.Add(maxRetryBackoff), m.failure.retryAt, time.Second)
+
+	// A retry scheduled before the last failure is stale.
+	stale := retryMsg{id: m.failure.retryID - 1}
+	_, cmd := m.Update(stale)
+	require.Nil(t, cmd)
+	require.False(t, m.loading)
+}
+
+func TestSeriesTable_RetryScrape(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	m := newModel(nil, 0)
+	m.scrapeFn = func() (*scrape.Result, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("refused")
+		}
+		return &scrape.Result{Series: scrape.SeriesMap{}}, nil
+	}
+	m.failScrape(errors.New("refused"))
+
+	_, cmd := m.Update(retryMsg{id: m.failure.retryID})
+	require.True(t, m.loading)
+	require.NoError(t, m.failure.err)
+	require.EqualError(t, m.failure.last, "refused", "the last failure is kept while the retry runs")
+	msgs := runCmd(cmd)
+	require.Len(t, msgs, 1)
+	require.EqualError(t, msgs[0].(error), "refused")
+
+	m.Update(msgs[0])
+	require.Equal(t, 2, m.failure.attempts)
+	msgs = runCmd(m.retryScrape())
+	require.Len(t, msgs, 1)
+	m.Update(msgs[0])
+	require.True(t, m.loaded)
+	require.Equal(t, scrapeFailure{}, m.failure)
+	require.NoError(t, m.exitErr())
+}
+
+func TestSeriesTable_Retarget(t *testing.T) {
+	m := newModel(nil, 0)
+	m.target = "http://down:9090/metrics"
+	m.scrapeFn = func() (*scrape.Result, error) { return nil, errors.New("refused") }
+	m.newScrapeFn = func(scrapeURL string) (func() (*scrape.Result, error), error) {
+		if scrapeURL == "::" {
+			return nil, errors.New("missing protocol scheme")
+		}
+		return func() (*scrape.Result, error) { return &scrape.Result{Series: scrape.SeriesMap{}}, nil }, nil
+	}
+	m.failScrape(errors.New("refused"))
+	m.failScrape(errors.New("refused"))
+
+	require.Nil(t, m.retarget("::"))
+	require.EqualError(t, m.failure.err, "invalid target ::: missing protocol scheme")
+	require.Equal(t, "http://down:9090/metrics", m.target)
+
+	cmd := m.retarget("http://up:9090/federate")
+	require.Equal(t, "http://up:9090/federate", m.target)
+	require.True(t, m.federated)
+	require.Zero(t, m.failure.attempts)
+	require.True(t, m.loading)
+	msgs := runCmd(cmd)
+	require.Len(t, msgs, 1)
+	m.Update(msgs[0])
+	require.True(t, m.loaded)
+	keys.Group.SetEnabled(false)
+}
+
+func TestSeriesTable_QuitWhileFailed(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		msgs    []tea.Msg
+		wantErr string
+	}{
+		"on the error screen": {
+			msgs:    []tea.Msg{errors.New("refused"), quitKey()},
+			wantErr: "refused",
+		},
+		"while retrying": {
+			msgs:    []tea.Msg{errors.New("refused"), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")}, quitKey()},
+			wantErr: "refused",
+		},
+		"after a retry succeeded": {
+			msgs: []tea.Msg{errors.New("refused"), &scrape.Result{Series: scrape.SeriesMap{}}, quitKey()},
+		},
+		"before the first scrape": {
+			msgs: []tea.Msg{quitKey()},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			m := newModel(nil, 0)
+			m.scrapeFn = func() (*scrape.Result, error) { return nil, errors.New("refused") }
+			var cmd tea.Cmd
+			for _, msg := range tc.msgs {
+				_, cmd = m.Update(msg)
+			}
+			require.IsType(t, tea.QuitMsg{}, cmd())
+			if tc.wantErr == "" {
+				require.NoError(t, m.exitErr())
+				return
+			}
+			require.EqualError(t, m.exitErr(), tc.wantErr)
+			require.False(t, m.loaded)
+		})
+	}
+}
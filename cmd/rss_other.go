@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// peakRSS is not supported outside of Unix systems.
+func peakRSS() (int64, bool) {
+	return 0, false
+}
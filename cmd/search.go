@@ -0,0 +1,425 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// matchStyle sticks to a basic ANSI color to keep the escape sequences short, see highlightMatches.
+var matchStyle lipgloss.Style
+
+// searchMatch describes how the search query matched a row.
+type searchMatch struct {
+	score int
+	// positions are the matched rune positions, keyed by the column showing the field.
+	positions map[string][]int
+}
+
+// searchQuery returns the active search query, lower cased, or an empty string when not searching.
+func (m *seriesTable) searchQuery() string {
+	if !m.searchingMetrics {
+		return ""
+	}
+	return strings.ToLower(m.searchInput.Value())
+}
+
+// searchTerms returns the terms of the active search query, none when not searching.
+func (m *seriesTable) searchTerms() []queryTerm {
+	return parseQuery(m.searchQuery())
+}
+
+// queryTerm is a whitespace separated term of a search query: free text fuzzy matched against every field
+// of a row, or a filter on one attribute of a metric, e.g. name:http_, label:path, type:histogram or
+// card:>1000. Terms prefixed with ! or - exclude the rows they match, e.g. -go_ or !type:gauge.
+type queryTerm struct {
+	// field is empty for free text.
+	field  string
+	value  string
+	negate bool
+	// op and n hold the comparison of cardinality filters, op is empty while the comparison is incomplete.
+	op string
+	n  int
+}
+
+// queryFields are the fields search terms can filter on.
+var queryFields = []string{"name", "label", "type", "card"}
+
+// cardOps are the comparisons of cardinality filters, longest first so that >= is not taken for >.
+var cardOps = []string{">=", "<=", ">", "<", "="}
+
+// parseQuery splits a lower cased search query into terms. Terms with an unknown field, or an invalid
+// cardinality, are free text so that label values such as host:9090 can still be searched.
+func parseQuery(query string) []queryTerm {
+	var terms []queryTerm
+	for _, s := range strings.Fields(query) {
+		terms = append(terms, parseTerm(s))
+	}
+	return terms
+}
+
+func parseTerm(s string) queryTerm {
+	if rest, ok := cutNegation(s); ok {
+		t := parseTerm(rest)
+		t.negate = true
+		return t
+	}
+	field, value, ok := strings.Cut(s, ":")
+	if !ok || !slices.Contains(queryFields, field) {
+		return queryTerm{value: s}
+	}
+	if field != "card" {
+		return queryTerm{field: field, value: value}
+	}
+
+	op := "="
+	for _, o := range cardOps {
+		if rest, ok := strings.CutPrefix(value, o); ok {
+			op, value = o, rest
+			break
+		}
+	}
+	if value == "" {
+		// Still being typed, match everything meanwhile.
+		return queryTerm{field: field}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return queryTerm{value: s}
+	}
+	return queryTerm{field: field, value: value, op: op, n: n}
+}
+
+func cutNegation(s string) (string, bool) {
+	if rest, ok := strings.CutPrefix(s, "!"); ok {
+		return rest, true
+	}
+	return strings.CutPrefix(s, "-")
+}
+
+// narrows reports whether every row matching terms also matches prev, so that only the matches of prev need
+// to be searched. It holds when terms adds terms to prev or extends its last one, as typing does, except for
+// negated terms and cardinality filters, which extending can widen.
+func narrows(terms, prev []queryTerm) bool {
+	if len(prev) == 0 || len(terms) < len(prev) {
+		return false
+	}
+	last := len(prev) - 1
+	if !slices.Equal(terms[:last], prev[:last]) {
+		return false
+	}
+	t, p := terms[last], prev[last]
+	if t.field == "card" || p.field == "card" || t.negate || p.negate {
+		return t == p
+	}
+	return t.field == p.field && strings.HasPrefix(t.value, p.value)
+}
+
+// searchFields are the lower cased fields of a row the search is matched against, keyed by column.
+func searchFields(info scrape.SeriesInfo) map[string]string {
+	return map[string]string{"name": strings.ToLower(info.Name), "labels": strings.ToLower(info.Labels)}
+}
+
+// metricRow is a row of the metric table along with its search fields.
+type metricRow struct {
+	info   scrape.SeriesInfo
+	fields map[string]string
+}
+
+// searchCache holds the matches of the last search of the metric rows, keyed by metric name.
+type searchCache struct {
+	query string
+	hits  map[string]searchMatch
+}
+
+// metricRows returns the rows of the metric table, they are computed once per scrape.
+func (m *seriesTable) metricRows() []metricRow {
+	if m.rows == nil {
+		infos := m.seriesMap.AsRows()
+		m.rows = make([]metricRow, 0, len(infos))
+		for _, info := range infos {
+			m.rows = append(m.rows, metricRow{info: info, fields: searchFields(info)})
+		}
+	}
+	return m.rows
+}
+
+// setSeriesMap swaps in the series of a new scrape, dropping the rows and search matches of the previous one.
+func (m *seriesTable) setSeriesMap(sm scrape.SeriesMap) {
+	m.seriesMap = sm
+	m.rows = nil
+	m.search = searchCache{}
+	if m.heatmap != nil {
+		m.heatmap.rebuild(sm)
+	}
+}
+
+// searchHits returns the metric rows matching the query, by name. While the query is being typed it usually
+// narrows the previous one, only the rows matching the previous query are searched then, see narrows.
+func (m *seriesTable) searchHits(query string) map[string]searchMatch {
+	if m.search.hits != nil && m.search.query == query {
+		return m.search.hits
+	}
+	terms := parseQuery(query)
+	narrow := m.search.hits != nil && narrows(terms, parseQuery(m.search.query))
+	hits := make(map[string]searchMatch)
+	for _, r := range m.metricRows() {
+		if _, ok := m.search.hits[r.info.Name]; narrow && !ok {
+			continue
+		}
+		if match, ok := matchSearch(terms, r.fields, &r.info); ok {
+			hits[r.info.Name] = match
+		}
+	}
+	m.search = searchCache{query: query, hits: hits}
+	return hits
+}
+
+// matchSearch matches the terms against a row, it matches when every term does. Free text terms are fuzzy
+// matched against the fields of the row, keyed by the column showing them, and match if any field does.
+// Field filters apply to the metric given as info and are ignored by rows that are not metrics.
+func matchSearch(terms []queryTerm, fields map[string]string, info *scrape.SeriesInfo) (searchMatch, bool) {
+	match := searchMatch{positions: make(map[string][]int, len(fields))}
+	for _, t := range terms {
+		if t.negate {
+			if excludes(t, fields, info) {
+				return searchMatch{}, false
+			}
+			continue
+		}
+		if t.field == "" {
+			if !match.fuzzy(t.value, fields) {
+				return searchMatch{}, false
+			}
+			continue
+		}
+		if info != nil && !match.filter(t, fields, *info) {
+			return searchMatch{}, false
+		}
+	}
+	return match, true
+}
+
+// excludes reports whether a negated term matches the row. Negated free text has to be a substring of a
+// field, as fuzzy matching would exclude far more than intended.
+func excludes(t queryTerm, fields map[string]string, info *scrape.SeriesInfo) bool {
+	switch {
+	case t.value == "":
+		// Still being typed.
+		return false
+	case t.field == "":
+		for _, s := range fields {
+			if strings.Contains(s, t.value) {
+				return true
+			}
+		}
+		return false
+	case info == nil:
+		return false
+	}
+	discard := searchMatch{positions: make(map[string][]int, 1)}
+	return discard.filter(t, fields, *info)
+}
+
+// fuzzy matches a free text term against every field, adding the best score and the matched positions.
+func (match *searchMatch) fuzzy(value string, fields map[string]string) bool {
+	best, ok := 0, false
+	for key, s := range fields {
+		score, positions, matched := fuzzyMatch(value, s)
+		if !matched {
+			continue
+		}
+		match.positions[key] = append(match.positions[key], positions...)
+		best, ok = max(best, score), true
+	}
+	match.score += best
+	return ok
+}
+
+// filter matches a field filter against the metric, name and label filters match substrings of the metric
+// name and of its label names, type filters a prefix of one of its types.
+func (match *searchMatch) filter(t queryTerm, fields map[string]string, info scrape.SeriesInfo) bool {
+	switch t.field {
+	case "name":
+		score, positions, ok := substringMatch(t.value, fields["name"])
+		if !ok {
+			return false
+		}
+		match.positions["name"] = append(match.positions["name"], positions...)
+		match.score += score
+		return true
+	case "label":
+		positions, ok := matchLabelName(t.value, fields["labels"])
+		match.positions["labels"] = append(match.positions["labels"], positions...)
+		return ok
+	case "type":
+		return slices.ContainsFunc(strings.Split(info.Type, "|"), func(typ string) bool {
+			return strings.HasPrefix(typ, t.value)
+		})
+	case "card":
+		return compareCardinality(info.Cardinality, t.op, t.n)
+	}
+	return false
+}
+
+func compareCardinality(cardinality int, op string, n int) bool {
+	switch op {
+	case ">=":
+		return cardinality >= n
+	case "<=":
+		return cardinality <= n
+	case ">":
+		return cardinality > n
+	case "<":
+		return cardinality < n
+	case "=":
+		return cardinality == n
+	}
+	return true
+}
+
+// matchLabelName matches value as a substring of one of the label names of a labels field, such as
+// "path(7)|code(6)", and returns the matched positions.
+func matchLabelName(value, labels string) ([]int, bool) {
+	offset := 0
+	for _, l := range strings.Split(labels, "|") {
+		name, _, _ := strings.Cut(l, "(")
+		if i := strings.Index(name, value); i >= 0 {
+			return runeRange(offset+utf8.RuneCountInString(name[:i]), utf8.RuneCountInString(value)), true
+		}
+		offset += utf8.RuneCountInString(l) + 1
+	}
+	return nil, false
+}
+
+// runeRange returns the n positions starting at start.
+func runeRange(start, n int) []int {
+	positions := make([]int, n)
+	for i := range positions {
+		positions[i] = start + i
+	}
+	return positions
+}
+
+// substringMatch reports whether the query is a substring of s, with a score favoring longer and earlier
+// matches and matches at the start of words. Both are expected to be lower cased.
+func substringMatch(query, s string) (int, []int, bool) {
+	i := strings.Index(s, query)
+	if i < 0 {
+		return 0, nil, false
+	}
+	start := utf8.RuneCountInString(s[:i])
+	n := utf8.RuneCountInString(query)
+	score := 100 + 10*n - start
+	if isWordStart([]rune(s), start) {
+		score += 50
+	}
+	return score, runeRange(start, n), true
+}
+
+// fuzzyMatch reports whether all runes of the query appear in s in order, with a score favoring contiguous
+// matches and matches at the start of words. Both are expected to be lower cased.
+func fuzzyMatch(query, s string) (int, []int, bool) {
+	if query == "" {
+		return 0, nil, false
+	}
+	// A plain substring is the strongest match, the earlier the better.
+	if score, positions, ok := substringMatch(query, s); ok {
+		return score, positions, true
+	}
+
+	q := []rune(query)
+	target := []rune(s)
+
+	positions := make([]int, 0, len(q))
+	score := 0
+	for i := 0; i < len(target) && len(positions) < len(q); i++ {
+		if target[i] != q[len(positions)] {
+			continue
+		}
+		score += 10
+		if n := len(positions); n > 0 {
+			if positions[n-1] == i-1 {
+				score += 15
+			} else {
+				score -= i - positions[n-1] - 1
+			}
+		}
+		if isWordStart(target, i) {
+			score += 10
+		}
+		positions = append(positions, i)
+	}
+	if len(positions) < len(q) {
+		return 0, nil, false
+	}
+	return score, positions, true
+}
+
+// isWordStart reports whether the rune at i starts a word, e.g. a metric name segment or a label name.
+func isWordStart(s []rune, i int) bool {
+	return i == 0 || !unicode.IsLetter(s[i-1]) && !unicode.IsDigit(s[i-1])
+}
+
+// styleCell renders s with style, unless its escape sequences would not fit the column width, see
+// highlightMatches.
+func styleCell(style lipgloss.Style, s string, width int) string {
+	styled := style.Render(s)
+	if textWidth(s)+len(styled)-len(s) > width {
+		return s
+	}
+	return styled
+}
+
+// highlightMatches renders the matched runes of s with matchStyle. The table truncates cells without
+// accounting for escape sequences, so cells that would not fit the column width are left unstyled.
+func highlightMatches(s string, positions []int, width int) string {
+	if len(positions) == 0 {
+		return s
+	}
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+
+	var sb, span strings.Builder
+	flush := func() {
+		if span.Len() > 0 {
+			sb.WriteString(matchStyle.Render(span.String()))
+			span.Reset()
+		}
+	}
+	for i, r := range []rune(s) {
+		if matched[i] {
+			span.WriteRune(r)
+			continue
+		}
+		flush()
+		sb.WriteRune(r)
+	}
+	flush()
+
+	// The table counts the bytes of the escape sequences as visible runes, they only add ASCII bytes to s.
+	if textWidth(s)+sb.Len()-len(s) > width {
+		return s
+	}
+	return sb.String()
+}
+
+// textWidth returns the display width of s, skipping the costly grapheme segmentation for ASCII strings
+// such as metric names.
+func textWidth(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return runewidth.StringWidth(s)
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		query string
+		want  []queryTerm
+	}{
+		"empty":      {query: "", want: nil},
+		"whitespace": {query: " \t ", want: nil},
+		"free text":  {query: "http", want: []queryTerm{{value: "http"}}},
+		"terms are split on any whitespace": {
+			query: "  http\tname:req \n type:gauge ",
+			want:  []queryTerm{{value: "http"}, {field: "name", value: "req"}, {field: "type", value: "gauge"}},
+		},
+		"name":  {query: "name:http_", want: []queryTerm{{field: "name", value: "http_"}}},
+		"label": {query: "label:path", want: []queryTerm{{field: "label", value: "path"}}},
+		"type":  {query: "type:hist", want: []queryTerm{{field: "type", value: "hist"}}},
+		"field without a value": {
+			query: "name:",
+			want:  []queryTerm{{field: "name"}},
+		},
+		"value containing a colon": {
+			query: "label:a:b",
+			want:  []queryTerm{{field: "label", value: "a:b"}},
+		},
+		"unknown field is free text": {
+			query: "host:9090",
+			want:  []queryTerm{{value: "host:9090"}},
+		},
+		"field is case sensitive, queries are lower cased first": {
+			query: "NAME:http",
+			want:  []queryTerm{{value: "NAME:http"}},
+		},
+		"quotes are literal": {
+			query: `name:"http requests"`,
+			want:  []queryTerm{{field: "name", value: `"http`}, {value: `requests"`}},
+		},
+		"card without an operator": {query: "card:10", want: []queryTerm{{field: "card", value: "10", op: "=", n: 10}}},
+		"card =":                   {query: "card:=10", want: []queryTerm{{field: "card", value: "10", op: "=", n: 10}}},
+		"card >":                   {query: "card:>10", want: []queryTerm{{field: "card", value: "10", op: ">", n: 10}}},
+		"card >=":                  {query: "card:>=10", want: []queryTerm{{field: "card", value: "10", op: ">=", n: 10}}},
+		"card <":                   {query: "card:<10", want: []queryTerm{{field: "card", value: "10", op: "<", n: 10}}},
+		"card <=":                  {query: "card:<=10", want: []queryTerm{{field: "card", value: "10", op: "<=", n: 10}}},
+		"card being typed":         {query: "card:", want: []queryTerm{{field: "card"}}},
+		"card operator being typed": {
+			query: "card:>=",
+			want:  []queryTerm{{field: "card"}},
+		},
+		"card with an invalid number is free text": {
+			query: "card:>abc",
+			want:  []queryTerm{{value: "card:>abc"}},
+		},
+		"card with a repeated operator is free text": {
+			query: "card:>>1",
+			want:  []queryTerm{{value: "card:>>1"}},
+		},
+		"card with a trailing unit is free text": {
+			query: "card:10k",
+			want:  []queryTerm{{value: "card:10k"}},
+		},
+		"negation with -": {query: "-go_", want: []queryTerm{{value: "go_", negate: true}}},
+		"negation with !": {query: "!go_", want: []queryTerm{{value: "go_", negate: true}}},
+		"bare -":          {query: "-", want: []queryTerm{{negate: true}}},
+		"bare !":          {query: "!", want: []queryTerm{{negate: true}}},
+		"negated field":   {query: "-type:gauge", want: []queryTerm{{field: "type", value: "gauge", negate: true}}},
+		"negated card": {
+			query: "!card:>10",
+			want:  []queryTerm{{field: "card", value: "10", op: ">", n: 10, negate: true}},
+		},
+		"negated unknown field": {query: "-host:9090", want: []queryTerm{{value: "host:9090", negate: true}}},
+		"dash inside a term":    {query: "a-b", want: []queryTerm{{value: "a-b"}}},
+		"double negation": {
+			query: "--go",
+			want:  []queryTerm{{value: "go", negate: true}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.want, parseQuery(tc.query))
+		})
+	}
+}
+
+func TestCompareCardinality(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		op   string
+		n    int
+		want []int
+	}{
+		{op: "=", n: 10, want: []int{10}},
+		{op: ">", n: 10, want: []int{11}},
+		{op: ">=", n: 10, want: []int{10, 11}},
+		{op: "<", n: 10, want: []int{9}},
+		{op: "<=", n: 10, want: []int{9, 10}},
+		// An incomplete comparison matches everything.
+		{op: "", n: 0, want: []int{9, 10, 11}},
+	} {
+		var got []int
+		for _, cardinality := range []int{9, 10, 11} {
+			if compareCardinality(cardinality, tc.op, tc.n) {
+				got = append(got, cardinality)
+			}
+		}
+		require.Equal(t, tc.want, got, "card:%s%d", tc.op, tc.n)
+	}
+}
+
+func TestMatchLabelName(t *testing.T) {
+	t.Parallel()
+	positions, ok := matchLabelName("co", "path(7)|code(6)")
+	require.True(t, ok)
+	require.Equal(t, []int{8, 9}, positions)
+
+	_, ok = matchLabelName("7", "path(7)|code(6)")
+	require.False(t, ok, "only label names are matched, not their counts")
+
+	_, ok = matchLabelName("path", "")
+	require.False(t, ok)
+}
+
+// searchRows are the metric rows searched by the search tests.
+var searchRows = []scrape.SeriesInfo{
+	{Name: "http_requests_total", Cardinality: 120, Type: "counter", Labels: "path(60)|code(2)"},
+	{Name: "http_request_duration_seconds", Cardinality: 600, Type: "histogram", Labels: "path(60)|le(10)"},
+	{Name: "go_goroutines", Cardinality: 1, Type: "gauge"},
+	{Name: "go_gc_duration_seconds", Cardinality: 5, Type: "summary", Labels: "quantile(5)"},
+	{Name: "process_open_fds", Cardinality: 1, Type: "gauge"},
+	{Name: "build_info", Cardinality: 1, Type: "gauge|info", Labels: "version(1)"},
+}
+
+// allSearchRows are the names of searchRows, sorted.
+var allSearchRows = []string{
+	"build_info", "go_gc_duration_seconds", "go_goroutines", "http_request_duration_seconds", "http_requests_total",
+	"process_open_fds",
+}
+
+// searchTable returns a table listing searchRows.
+func searchTable() *seriesTable {
+	m := newModel(nil, 0)
+	for _, info := range searchRows {
+		m.rows = append(m.rows, metricRow{info: info, fields: searchFields(info)})
+	}
+	return m
+}
+
+// hitNames returns the sorted metric names of hits.
+func hitNames(hits map[string]searchMatch) []string {
+	return slices.Sorted(maps.Keys(hits))
+}
+
+func TestSearchHits(t *testing.T) {
+	t.Parallel()
+	withoutGo := []string{"build_info", "http_request_duration_seconds", "http_requests_total", "process_open_fds"}
+	for query, want := range map[string][]string{
+		"":                          allSearchRows,
+		"http":                      {"http_request_duration_seconds", "http_requests_total"},
+		"name:duration":             {"go_gc_duration_seconds", "http_request_duration_seconds"},
+		"label:path":                {"http_request_duration_seconds", "http_requests_total"},
+		"label:60":                  nil,
+		"type:gauge":                {"build_info", "go_goroutines", "process_open_fds"},
+		"type:info":                 {"build_info"},
+		"type:hist":                 {"http_request_duration_seconds"},
+		"card:1":                    {"build_info", "go_goroutines", "process_open_fds"},
+		"card:>100":                 {"http_request_duration_seconds", "http_requests_total"},
+		"card:>=120":                {"http_request_duration_seconds", "http_requests_total"},
+		"card:<5":                   {"build_info", "go_goroutines", "process_open_fds"},
+		"card:<=5":                  {"build_info", "go_gc_duration_seconds", "go_goroutines", "process_open_fds"},
+		"card:>":                    allSearchRows,
+		"card:>abc":                 nil,
+		"type:gauge name:go":        {"go_goroutines"},
+		"name:http card:<200":       {"http_requests_total"},
+		"duration label:quantile":   {"go_gc_duration_seconds"},
+		"type:counter type:summary": nil,
+		"unknown:field":             nil,
+		"-go_":                      withoutGo,
+		"!go_":                      withoutGo,
+		"http -total":               {"http_request_duration_seconds"},
+		"-":                         allSearchRows,
+		"go !":                      {"go_gc_duration_seconds", "go_goroutines"},
+		"-type:gauge":               {"go_gc_duration_seconds", "http_request_duration_seconds", "http_requests_total"},
+		"-label:path":               {"build_info", "go_gc_duration_seconds", "go_goroutines", "process_open_fds"},
+		"-name:_seconds http":       {"http_requests_total"},
+		"-card:1":                   {"go_gc_duration_seconds", "http_request_duration_seconds", "http_requests_total"},
+		"!card:>=5 -type:info":      {"go_goroutines", "process_open_fds"},
+		// Negated free text excludes substrings only, as fuzzy matching would exclude far more.
+		"-gort": allSearchRows,
+	} {
+		hits := searchTable().searchHits(query)
+		require.Equal(t, want, hitNames(hits), "query %q", query)
+	}
+}
+
+func TestNarrows(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		prev, query string
+		want        bool
+	}{
+		{prev: "", query: "http", want: false},
+		{prev: "htt", query: "http", want: true},
+		{prev: "http", query: "http dur", want: true},
+		{prev: "http", query: "htt", want: false},
+		{prev: "http dur", query: "http", want: false},
+		{prev: "http dur", query: "go dur", want: false},
+		{prev: "name:ht", query: "name:http", want: true},
+		{prev: "name:ht", query: "label:ht", want: false},
+		{prev: "ht", query: "name:ht", want: false},
+		{prev: "type:g", query: "type:gauge", want: true},
+		// Extending a cardinality filter can widen it.
+		{prev: "card:>1", query: "card:>10", want: false},
+		{prev: "card:1", query: "card:10", want: false},
+		{prev: "card:", query: "card:>1", want: false},
+		{prev: "card:>1", query: "card:>1 http", want: true},
+		{prev: "card:>1 h", query: "card:>1 http", want: true},
+		// Adding a negated term narrows, extending or negating the last term can widen.
+		{prev: "http", query: "http -total", want: true},
+		{prev: "http -", query: "http -t", want: false},
+		{prev: "http -t", query: "http -total", want: false},
+		{prev: "-go", query: "-go_", want: false},
+		{prev: "go", query: "-go", want: false},
+		{prev: "-go", query: "go", want: false},
+		{prev: "-type:g", query: "-type:gauge", want: false},
+		{prev: "-go", query: "-go http", want: true},
+	} {
+		got := narrows(parseQuery(tc.query), parseQuery(tc.prev))
+		require.Equal(t, tc.want, got, "%q after %q", tc.query, tc.prev)
+	}
+}
+
+// TestSearchHits_Narrowing types queries one after the other and checks that the hits searched among the
+// previous ones are the hits of a full search.
+func TestSearchHits_Narrowing(t *testing.T) {
+	t.Parallel()
+	for _, queries := range [][]string{
+		{"h", "ht", "htt", "http", "http ", "http d", "http du"},
+		{"http du", "http d", "http", "go"},
+		{"c", "ca", "car", "card", "card:", "card:>", "card:>1", "card:>10", "card:>100"},
+		{"card:<6", "card:<60", "card:<600"},
+		{"name:g", "name:go", "name:go ", "name:go type:", "name:go type:s"},
+		{"type:g", "type:", "type:c"},
+		{"go", "go ", "go -", "go -g", "go -go", "go -go_", "go -go_g", "go -go_gc"},
+		{"-", "-h", "-ht", "-http", "-http_", "-http_r"},
+		{"!go", "!go_", "!go_g"},
+		{"http", "http -", "http -t", "http -to", "http -tot"},
+		{"-type:", "-type:g", "-type:ga", "-type:gauge"},
+		{"-card:", "-card:>", "-card:>1", "-card:>10", "-card:>100"},
+		{"-go", "go"},
+	} {
+		m := searchTable()
+		for _, query := range queries {
+			got := m.searchHits(query)
+			want := searchTable().searchHits(query)
+			require.Equal(t, hitNames(want), hitNames(got), "query %q in %q", query, queries)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type snapshotOptions struct {
+	Options
+	Output string
+}
+
+func (o *snapshotOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("output", "File to write the snapshot to, it can be passed back as --scrape-url to analyze it offline").
+		Required().
+		StringVar(&o.Output)
+}
+
+func registerSnapshotCommand(app *extkingpin.App) {
+	cmd := app.Command("snapshot", "Scrape a target once and save the parsed series as a versioned JSON snapshot.")
+	opts := &snapshotOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			if err := scrape.SaveResult(opts.Output, result); err != nil {
+				return err
+			}
+			level.Info(logger).Log("msg", "snapshot saved", "path", opts.Output, "metric_families", len(result.Series))
+			return nil
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// sessionState is the state of the TUI saved on exit and restored on the next run against the same target.
+type sessionState struct {
+	Search         string   `json:"search,omitempty"`
+	Pins           []string `json:"pins,omitempty"`
+	Columns        []string `json:"columns,omitempty"`
+	Type           string   `json:"type,omitempty"`
+	MinCardinality int      `json:"min_cardinality,omitempty"`
+	AppOnly        bool     `json:"app_only,omitempty"`
+}
+
+// stateFile is the file the session states are saved to, keyed by target URL.
+type stateFile struct {
+	Targets map[string]sessionState `json:"targets"`
+}
+
+// defaultStateFile returns the default value of the --state-file flag, empty when the user has no
+// configuration directory.
+func defaultStateFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "prom-scrape-analyzer", "state.json")
+}
+
+func readStateFile(path string) (stateFile, error) {
+	f := stateFile{Targets: make(map[string]sessionState)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return f, errors.Wrapf(err, "failed to parse state file %s", path)
+	}
+	if f.Targets == nil {
+		f.Targets = make(map[string]sessionState)
+	}
+	return f, nil
+}
+
+// loadSessionState returns the session state saved for the target, if any.
+func loadSessionState(path, target string) (sessionState, bool, error) {
+	f, err := readStateFile(path)
+	if err != nil {
+		return sessionState{}, false, err
+	}
+	state, ok := f.Targets[target]
+	return state, ok, nil
+}
+
+// saveSessionState saves the session state of the target, keeping the states of the other targets. The
+// file is replaced atomically so that concurrent sessions never leave it half written.
+func saveSessionState(path, target string, state sessionState) error {
+	f, err := readStateFile(path)
+	if err != nil {
+		return err
+	}
+	f.Targets[target] = state
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// sessionState captures the state of the table to restore on the next run.
+func (m *seriesTable) sessionState() sessionState {
+	state := sessionState{
+		Columns:        m.columns,
+		Type:           m.typeFilter,
+		MinCardinality: m.minCardinality,
+		AppOnly:        m.appOnly,
+	}
+	switch {
+	case m.drillDown != "" && m.metricSearch.active:
+		state.Search = m.metricSearch.query
+	case m.drillDown == "" && m.searchingMetrics:
+		state.Search = m.searchInput.Value()
+	}
+	for name := range m.pinned {
+		state.Pins = append(state.Pins, name)
+	}
+	slices.Sort(state.Pins)
+	return state
+}
+
+// restoreSessionState applies a saved state to the table. Settings given as flags or environment variables
+// take precedence, saved pins add up to the pinned flags.
+func (m *seriesTable) restoreSessionState(state sessionState) {
+	if state.Search != "" {
+		m.searchInput.SetValue(state.Search)
+		m.searchingMetrics = true
+	}
+	for _, name := range state.Pins {
+		m.pinned[name] = struct{}{}
+	}
+	if len(state.Columns) > 0 && !flagSet("columns") {
+		if cols, err := parseColumns(strings.Join(state.Columns, ",")); err == nil {
+			m.columns = cols
+		}
+	}
+	if slices.Contains(metricTypes, state.Type) && !flagSet("type") {
+		m.typeFilter = state.Type
+	}
+	if !flagSet("min-cardinality") {
+		m.minCardinality = max(state.MinCardinality, 0)
+	}
+	if !flagSet("app-only") {
+		m.appOnly = state.AppOnly
+	}
+}
+
+// saveSessionState saves the state of the table, it is a no-op when the state file is disabled.
+func (m *seriesTable) saveSessionState() error {
+	if m.stateFile == "" {
+		return nil
+	}
+	return saveSessionState(m.stateFile, m.stateKey, m.sessionState())
+}
+
+// replayStateKey is the key of the session state of a replay, kept apart from the state of the live sessions
+// against the recorded target.
+func replayStateKey(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return "replay:" + dir
+}
+
+// command is the full name of the command being run, set once the command line is parsed.
+var command string
+
+// flagSet reports whether the flag is set on the command line, by a profile, or by its environment variable.
+func flagSet(name string) bool {
+	return flagGiven(os.Args[1:], name) || os.Getenv(flagEnvVar(command, name)) != ""
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestSessionState_SaveLoad(t *testing.T) {
+	t.Parallel()
+	// The directory of the state file is created on the first save.
+	path := filepath.Join(t.TempDir(), "prom-scrape-analyzer", "state.json")
+
+	_, ok, err := loadSessionState(path, "http://api:8080/metrics")
+	require.NoError(t, err)
+	require.False(t, ok, "a missing state file holds no state")
+
+	api := sessionState{Search: "http", Pins: []string{"up"}, Columns: []string{"name", "cardinality"}}
+	db := sessionState{Type: "gauge", MinCardinality: 10, AppOnly: true}
+	require.NoError(t, saveSessionState(path, "http://api:8080/metrics", api))
+	require.NoError(t, saveSessionState(path, "http://db:9104/metrics", db))
+
+	state, ok, err := loadSessionState(path, "http://api:8080/metrics")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, api, state, "saving another target keeps the state of the first one")
+	state, ok, err = loadSessionState(path, "http://db:9104/metrics")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, db, state)
+
+	api.Search = "grpc"
+	require.NoError(t, saveSessionState(path, "http://api:8080/metrics", api))
+	state, _, err = loadSessionState(path, "http://api:8080/metrics")
+	require.NoError(t, err)
+	require.Equal(t, api, state)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no temporary file is left behind")
+}
+
+func TestSessionState_Corrupt(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"targets": {`), 0o600))
+
+	_, ok, err := loadSessionState(path, "http://api:8080/metrics")
+	require.ErrorContains(t, err, "failed to parse state file")
+	require.False(t, ok)
+
+	// The states of the other targets cannot be kept, the file is left as is rather than overwritten.
+	err = saveSessionState(path, "http://api:8080/metrics", sessionState{Search: "http"})
+	require.ErrorContains(t, err, "failed to parse state file")
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `{"targets": {`, string(b))
+}
+
+// Concurrent sessions replace the file atomically, a reader never sees it half written.
+func TestSessionState_AtomicWrite(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, saveSessionState(path, "http://api:8080/metrics", sessionState{}))
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 50 {
+				state := sessionState{Search: fmt.Sprintf("writer-%d-%d", i, j), Pins: make([]string, 100)}
+				if err := saveSessionState(path, "http://api:8080/metrics", state); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			entries, err := os.ReadDir(filepath.Dir(path))
+			require.NoError(t, err)
+			require.Len(t, entries, 1, "no temporary file is left behind")
+			return
+		default:
+			_, ok, err := loadSessionState(path, "http://api:8080/metrics")
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+	}
+}
+
+// Settings given as flags, or their environment variables, beat the restored state.
+func TestSeriesTable_RestoreSessionState(t *testing.T) {
+	t.Setenv(envVar("min-cardinality"), "5")
+	m := newModel(nil, 0)
+	m.minCardinality = 5
+	m.pinned["build_info"] = struct{}{}
+
+	m.restoreSessionState(sessionState{
+		Search:         "http",
+		Pins:           []string{"up"},
+		Columns:        []string{"name", "cardinality"},
+		Type:           "gauge",
+		MinCardinality: 100,
+		AppOnly:        true,
+	})
+	require.Equal(t, "http", m.searchInput.Value())
+	require.True(t, m.searchingMetrics)
+	require.Equal(t, map[string]struct{}{"build_info": {}, "up": {}}, m.pinned, "saved pins add up to the flags")
+	require.Equal(t, []string{"name", "cardinality"}, m.columns)
+	require.Equal(t, "gauge", m.typeFilter)
+	require.Equal(t, 5, m.minCardinality, "the flag beats the saved state")
+	require.True(t, m.appOnly)
+
+	m = newModel(nil, 0)
+	columns := m.columns
+	m.restoreSessionState(sessionState{Type: "bogus", Columns: []string{"bogus"}})
+	require.Empty(t, m.typeFilter, "invalid saved settings are ignored")
+	require.Equal(t, columns, m.columns)
+}
+
+// A replay restores and saves its own state, never the live state of the recorded target.
+func TestCardinalityOptions_ReplayStateKey(t *testing.T) {
+	t.Parallel()
+	const target = "http://api:8080/metrics"
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, saveSessionState(path, target, sessionState{Search: "live"}))
+	scrapeFn := func() (*scrape.Result, error) { return nil, nil }
+
+	replay := &cardinalityOptions{StateFile: path, Columns: defaultColumns, stateKey: replayStateKey("recording")}
+	m, err := replay.newTable(log.NewNopLogger(), opentracing.NoopTracer{}, target, scrapeFn)
+	require.NoError(t, err)
+	require.Empty(t, m.searchInput.Value(), "the live state is not restored")
+	m.loaded = true
+	m.searchingMetrics = true
+	m.searchInput.SetValue("replayed")
+	require.NoError(t, m.saveSessionState())
+
+	live := &cardinalityOptions{StateFile: path, Columns: defaultColumns}
+	m, err = live.newTable(log.NewNopLogger(), opentracing.NoopTracer{}, target, scrapeFn)
+	require.NoError(t, err)
+	require.Equal(t, "live", m.searchInput.Value(), "the replay did not overwrite the live state")
+
+	m, err = replay.newTable(log.NewNopLogger(), opentracing.NoopTracer{}, target, scrapeFn)
+	require.NoError(t, err)
+	require.Equal(t, "replayed", m.searchInput.Value())
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type summariesOptions struct {
+	Options
+	MaxQuantiles int
+}
+
+func (o *summariesOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("max-quantiles", "Flag the summaries exposing more quantiles than this, every quantile is a series").
+		Default("5").
+		IntVar(&o.MaxQuantiles)
+}
+
+func registerSummariesCommand(app *extkingpin.App) {
+	cmd := app.Command("summaries", "List the quantiles exposed by the summary families of a target, flagging the "+
+		"summaries with too many quantiles and the ones exposing different quantiles across label sets.")
+	opts := &summariesOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			return printSummaries(os.Stdout, scrape.Summaries(result.Series), opts.MaxQuantiles)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printSummaries(out io.Writer, infos []scrape.SummaryInfo, maxQuantiles int) error {
+	if len(infos) == 0 {
+		_, err := fmt.Fprintln(out, "No summaries in the scrape")
+		return err
+	}
+
+	var excessive, inconsistent []string
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tSUMMARIES\tSERIES\tQUANTILES\tQUANTILE SETS")
+	for _, s := range infos {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%d\n",
+			s.Family, s.Summaries, s.Series, formatQuantiles(s.Quantiles), s.QuantileSets)
+		if maxQuantiles > 0 && len(s.Quantiles) > maxQuantiles {
+			excessive = append(excessive, fmt.Sprintf("  %s %d quantiles, %d series",
+				s.Family, len(s.Quantiles), len(s.Quantiles)*s.Summaries))
+		}
+		if !s.Consistent() {
+			inconsistent = append(inconsistent, fmt.Sprintf("  %s %s exposes %s instead of %s",
+				s.Family, s.Mismatch, formatQuantiles(s.MismatchQuantiles), formatQuantiles(s.Quantiles)))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(excessive) > 0 {
+		fmt.Fprintf(out, "\nSummaries exposing more than %d quantiles:\n", maxQuantiles)
+		fmt.Fprintln(out, strings.Join(excessive, "\n"))
+	}
+	if len(inconsistent) > 0 {
+		fmt.Fprintln(out, "\nSummaries exposing different quantiles across label sets, which breaks aggregating them:")
+		fmt.Fprintln(out, strings.Join(inconsistent, "\n"))
+	}
+	return nil
+}
+
+// formatQuantiles lists the φ quantiles of a summary.
+func formatQuantiles(quantiles []float64) string {
+	if len(quantiles) == 0 {
+		return "-"
+	}
+	s := make([]string, 0, len(quantiles))
+	for _, q := range quantiles {
+		s = append(s, strconv.FormatFloat(q, 'g', -1, 64))
+	}
+	return strings.Join(s, ",")
+}
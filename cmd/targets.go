@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+var targetColumnLayout = []columnLayout{
+	{title: "Target", width: 60, flex: true},
+	{title: "Status", width: 8},
+	{title: "Duration", width: 10},
+	{title: "Body", width: 10},
+	{title: "Series", width: 10},
+	{title: "Families", width: 10},
+	{title: "Parse", width: 8},
+}
+
+var divergenceColumnLayout = []columnLayout{
+	{title: "Family", width: 60, flex: true},
+	{title: "Min", width: 8},
+	{title: "Median", width: 8},
+	{title: "Max", width: 8},
+	{title: "Ratio", width: 8},
+	{title: "Top target", width: 40, flex: true},
+	{title: "Shared", width: 8},
+}
+
+// defaultDivergenceRatio is the default of --targets.divergence-ratio.
+const defaultDivergenceRatio = 3
+
+var targetHelp = []key.Binding{
+	key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter:", "open target"),
+	),
+	key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d:", "cardinality divergence"),
+	),
+	key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r:", "re-scrape all targets"),
+	),
+	key.NewBinding(
+		key.WithKeys("q"),
+		key.WithHelp("q:", "quit"),
+	),
+}
+
+// targetState is a target of multi-target mode, outcome is nil until its first scrape finishes.
+type targetState struct {
+	url      string
+	scrapeFn func() (*scrape.Result, error)
+	outcome  *scrape.TargetResult
+	// history is the history of the target, it replaces the one of the table while the target is open.
+	history *scrape.History
+}
+
+// targetResultMsg delivers the outcome of a target scraped in multi-target mode.
+type targetResultMsg scrape.TargetResult
+
+// setTargets switches the table to multi-target mode, it opens on the report of the targets.
+func (m *seriesTable) setTargets(targets []targetState, rescrape chan<- struct{}) {
+	m.targets = targets
+	m.rescrapeTargets = rescrape
+	m.targetView = true
+	m.loading = false
+	keys.OpenTarget.SetEnabled(true)
+	keys.Targets.SetEnabled(true)
+	keys.Divergence.SetEnabled(true)
+	m.resetColumns()
+}
+
+// applyTargetResult records the outcome of a target, the history of the target only grows with successful
+// scrapes.
+func (m *seriesTable) applyTargetResult(msg targetResultMsg) {
+	for i := range m.targets {
+		t := &m.targets[i]
+		if t.url != msg.Target {
+			continue
+		}
+		outcome := scrape.TargetResult(msg)
+		t.outcome = &outcome
+		if outcome.Err == nil {
+			t.history.Add(outcome.Result.Series)
+		}
+	}
+	m.compareTargets()
+	if m.targetView {
+		m.setTableRows(m.currentFilter())
+	}
+}
+
+// openTarget shows the metric table of the target selected in the report, or of the target exposing the
+// most series of the family selected in the divergence view, with the cursor on the family.
+func (m *seriesTable) openTarget() tea.Cmd {
+	i := m.table.Cursor()
+	var family string
+	if m.divergenceView {
+		if i < 0 || i >= len(m.divergences) {
+			return nil
+		}
+		family = m.divergences[i].Name
+		i = m.divergenceTargets[m.divergences[i].Outlier]
+	}
+	if i < 0 || i >= len(m.targets) {
+		return nil
+	}
+	t := m.targets[i]
+	switch {
+	case t.outcome == nil:
+		return m.setFlash(t.url + " is still being scraped")
+	case t.outcome.Err != nil:
+		return m.setFlash(fmt.Sprintf("%s is down: %v", t.url, t.outcome.Err))
+	}
+
+	m.targetView = false
+	m.targetIndex = i
+	m.target = t.url
+	m.scrapeFn = t.scrapeFn
+	m.history = t.history
+	m.changes = nil
+	m.timeline = scrape.NewTimeline(m.timelineSize)
+	m.timeline.Add(t.outcome.Result)
+	m.travelBack = 0
+	m.treeView = false
+	m.federated = scrape.IsFederationURL(t.url)
+	keys.Group.SetEnabled(m.federated)
+	m.setSeriesMap(t.outcome.Result.Series)
+	m.detectPushgateway(t.outcome.Result.Series)
+	m.infoTitle = m.formatInfoTitle(t.outcome.Result)
+	if m.federated || m.pushgateway {
+		m.setGroupByOrigin(true)
+	} else {
+		m.groupByOrigin = false
+		m.resetColumns()
+		if !m.followRow(family) {
+			m.table.SetCursor(0)
+		}
+	}
+	return nil
+}
+
+// showTargets goes back to the report of the targets, with the cursor on the target that was open.
+func (m *seriesTable) showTargets() {
+	m.targetView = true
+	m.scrapeFn = nil
+	m.searchInput.Reset()
+	m.searchingMetrics = false
+	m.resetColumns()
+	if !m.divergenceView {
+		m.table.SetCursor(m.targetIndex)
+	}
+}
+
+// rescrapeAll asks the runner to scrape every target again.
+func (m *seriesTable) rescrapeAll() tea.Cmd {
+	select {
+	case m.rescrapeTargets <- struct{}{}:
+		return m.setFlash("Re-scraping every target")
+	default:
+		return m.setFlash("Targets are already being scraped")
+	}
+}
+
+// compareTargets compares the cardinality of the families across the targets that are up.
+func (m *seriesTable) compareTargets() {
+	var scrapes []scrape.SeriesMap
+	m.divergenceTargets = m.divergenceTargets[:0]
+	for i, t := range m.targets {
+		if t.outcome != nil && t.outcome.Err == nil {
+			scrapes = append(scrapes, t.outcome.Result.Series)
+			m.divergenceTargets = append(m.divergenceTargets, i)
+		}
+	}
+	m.divergences = nil
+	if len(scrapes) > 1 {
+		m.divergences = scrape.Divergence(scrapes)
+	}
+}
+
+// toggleDivergenceView switches between the report of the targets and the divergence of their families.
+func (m *seriesTable) toggleDivergenceView() {
+	m.divergenceView = !m.divergenceView
+	m.resetColumns()
+	m.table.SetCursor(0)
+}
+
+// divergentFamilies returns the number of families whose cardinality diverges by at least the ratio.
+func (m *seriesTable) divergentFamilies() int {
+	n := 0
+	for _, d := range m.divergences {
+		if d.Ratio >= m.divergenceRatio {
+			n++
+		}
+	}
+	return n
+}
+
+// setDivergenceRows fills the table with the families of the targets, the most divergent first.
+func (m *seriesTable) setDivergenceRows() {
+	rows := make([]table.Row, 0, len(m.divergences))
+	for _, d := range m.divergences {
+		ratio := fmt.Sprintf("%.1fx", d.Ratio)
+		if d.Ratio >= m.divergenceRatio {
+			ratio = warnStyle.Render(ratio)
+		}
+		rows = append(rows, table.Row{
+			d.Name,
+			strconv.Itoa(d.Min),
+			strconv.Itoa(d.Median),
+			strconv.Itoa(d.Max),
+			ratio,
+			m.targets[m.divergenceTargets[d.Outlier]].url,
+			strconv.Itoa(d.Shared),
+		})
+	}
+	m.table.SetRows(rows)
+}
+
+// setTargetRows fills the table with a row per target, in the order they were given.
+func (m *seriesTable) setTargetRows() {
+	if m.divergenceView {
+		m.setDivergenceRows()
+		return
+	}
+	rows := make([]table.Row, 0, len(m.targets))
+	for _, t := range m.targets {
+		row := table.Row{t.url, "pending", "-", "-", "-", "-", "-"}
+		if o := t.outcome; o != nil {
+			row[1] = "down"
+			row[2] = o.Duration.Round(time.Millisecond).String()
+			if o.Err == nil {
+				row[1] = "up"
+				row[3] = units.BytesSize(float64(o.Result.BodyBytes))
+				row[4] = strconv.Itoa(seriesCount(o.Result.Series))
+				row[5] = strconv.Itoa(len(o.Result.Series))
+				row[6] = "ok"
+				if o.Result.ParseFailed {
+					row[6] = "failed"
+				}
+			}
+		}
+		if row[1] != "up" {
+			row[1] = warnStyle.Render(row[1])
+		}
+		if row[6] == "failed" {
+			row[6] = warnStyle.Render(row[6])
+		}
+		rows = append(rows, row)
+	}
+	m.table.SetRows(rows)
+}
+
+// targetFooterView sums up the targets below their report, with the error of the selected target if down.
+func (m *seriesTable) targetFooterView() string {
+	var up, down, pending int
+	for _, t := range m.targets {
+		switch {
+		case t.outcome == nil:
+			pending++
+		case t.outcome.Err != nil:
+			down++
+		default:
+			up++
+		}
+	}
+
+	var view strings.Builder
+	view.WriteString(help.New().ShortHelpView(targetHelp))
+	view.WriteString(fmt.Sprintf("\nTargets: %d up, %d down, %d pending", up, down, pending))
+	if m.divergenceView {
+		view.WriteString(fmt.Sprintf("\nFamilies compared across %d targets: %d, enter opens the top target",
+			len(m.divergenceTargets), len(m.divergences)))
+	}
+	if n := m.divergentFamilies(); n > 0 {
+		view.WriteString("\n")
+		view.WriteString(warnStyle.Render(fmt.Sprintf(
+			"%d families diverge by %gx or more across targets, a sign of a leak on one instance", n, m.divergenceRatio)))
+	}
+	if i := m.table.Cursor(); !m.divergenceView && i >= 0 && i < len(m.targets) {
+		if o := m.targets[i].outcome; o != nil && o.Err != nil {
+			view.WriteString("\n")
+			view.WriteString(warnStyle.Render("Error: " + o.Err.Error()))
+		}
+	}
+	if m.flash != "" {
+		view.WriteString("\n")
+		view.WriteString(m.flash)
+	}
+	return view.String()
+}
+
+// recordTargetRefresh keeps the report up to date with the refreshes of the open target.
+func (m *seriesTable) recordTargetRefresh(result *scrape.Result) {
+	if m.targets == nil || m.targetView {
+		return
+	}
+	if o := m.targets[m.targetIndex].outcome; o != nil {
+		refreshed := *o
+		refreshed.Result = result
+		m.targets[m.targetIndex].outcome = &refreshed
+		m.compareTargets()
+	}
+}
+
+func (m *seriesTable) updateWhileBrowsingTargets(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "?":
+			m.showHelp = true
+			return m, nil
+		case "enter":
+			return m, m.openTarget()
+		case "d":
+			m.toggleDivergenceView()
+			return m, nil
+		case "esc":
+			if m.divergenceView {
+				m.toggleDivergenceView()
+			}
+			return m, nil
+		case "r":
+			return m, m.rescrapeAll()
+		}
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func seriesCount(sm scrape.SeriesMap) int {
+	n := 0
+	for _, set := range sm {
+		n += set.Cardinality()
+	}
+	return n
+}
+
+// runTargets runs the TUI in multi-target mode, the targets are scraped concurrently by the manager and
+// their outcomes fed to the report as they finish. Every reload event or re-scrape request scrapes them
+// all again.
+func runTargets(
+	ctx context.Context,
+	g *run.Group,
+	logger log.Logger,
+	metricTable *seriesTable,
+	manager *scrape.Manager,
+	targets []scrape.Target,
+	reloadCh <-chan struct{},
+) {
+	ctx, cancel := context.WithCancel(ctx)
+	rescrape := make(chan struct{})
+	// The manager and the refresh of the open target scrape the targets concurrently, their scrapers are not
+	// safe for concurrent use.
+	targets = slices.Clone(targets)
+	states := make([]targetState, 0, len(targets))
+	for i, t := range targets {
+		scraper := &lockedScraper{scraper: t.Scraper}
+		targets[i].Scraper = scraper
+		states = append(states, targetState{
+			url: t.Name,
+			scrapeFn: func() (*scrape.Result, error) {
+				return scraper.Scrape(ctx)
+			},
+			history: scrape.NewHistory(metricTable.historySize),
+		})
+	}
+	metricTable.setTargets(states, rescrape)
+	p := tea.NewProgram(metricTable)
+
+	g.Add(func() error {
+		_, err := p.Run()
+		if err := metricTable.saveSessionState(); err != nil {
+			level.Warn(logger).Log("msg", "failed to save the session state", "err", err)
+		}
+		return err
+	}, func(error) {
+		cancel()
+	})
+
+	g.Add(func() error {
+		for {
+			err := manager.Run(ctx, targets, func(outcome scrape.TargetResult) {
+				p.Send(targetResultMsg(outcome))
+			})
+			if err != nil {
+				level.Warn(logger).Log("msg", "some targets failed", "err", err)
+			}
+			select {
+			case <-reloadCh:
+				level.Info(logger).Log("msg", "reload requested, re-scraping every target")
+			case <-rescrape:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}, func(error) {})
+}
+
+// lockedScraper serializes the scrapes of a scraper.
+type lockedScraper struct {
+	mtx     sync.Mutex
+	scraper scrape.Scraper
+}
+
+func (s *lockedScraper) Scrape(ctx context.Context) (*scrape.Result, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.scraper.Scrape(ctx)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/pkg/errors"
+)
+
+// theme holds the colors of the TUI, as ANSI color numbers or #rrggbb hex codes.
+type theme struct {
+	// Base is the built-in theme the colors left empty are taken from, only used by custom themes.
+	Base               string `yaml:"base"`
+	Border             string `yaml:"border"`
+	Hint               string `yaml:"hint"`
+	Warn               string `yaml:"warn"`
+	Accent             string `yaml:"accent"`
+	Key                string `yaml:"key"`
+	Section            string `yaml:"section"`
+	SelectedForeground string `yaml:"selected-foreground"`
+	SelectedBackground string `yaml:"selected-background"`
+	Match              string `yaml:"match"`
+	// Added and Removed color what appeared and disappeared since the baseline in the diff view.
+	Added   string `yaml:"added"`
+	Removed string `yaml:"removed"`
+	// Heatmap are the background colors of the heatmap cells, from the fewest to the most distinct values.
+	Heatmap []string `yaml:"heatmap"`
+}
+
+var builtinThemes = map[string]theme{
+	"dark": {
+		Border:             "240",
+		Hint:               "241",
+		Warn:               "214",
+		Accent:             "205",
+		Key:                "229",
+		Section:            "57",
+		SelectedForeground: "229",
+		SelectedBackground: "57",
+		Match:              "5",
+		Added:              "42",
+		Removed:            "203",
+		Heatmap:            []string{"66", "72", "108", "143", "179", "209", "203"},
+	},
+	"light": {
+		Border:             "245",
+		Hint:               "242",
+		Warn:               "130",
+		Accent:             "161",
+		Key:                "24",
+		Section:            "55",
+		SelectedForeground: "231",
+		SelectedBackground: "61",
+		Match:              "5",
+		Added:              "28",
+		Removed:            "160",
+		Heatmap:            []string{"195", "158", "194", "229", "223", "217", "210"},
+	},
+}
+
+// autoTheme picks the dark or light theme from the background color of the terminal.
+const autoTheme = "auto"
+
+// activeTheme is the theme the styles of the TUI are built from.
+var activeTheme = builtinThemes["dark"]
+
+// setupTheme applies the named theme, built-in or defined in the themes of the configuration file, and
+// disables colors altogether when noColor is set or the NO_COLOR environment variable is not empty.
+func setupTheme(name, configFile string, noColor bool) error {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	t, err := lookupTheme(name, configFile)
+	if err != nil {
+		return err
+	}
+	applyTheme(t)
+	return nil
+}
+
+func lookupTheme(name, configFile string) (theme, error) {
+	if name == autoTheme {
+		name = "light"
+		if lipgloss.HasDarkBackground() {
+			name = "dark"
+		}
+	}
+	if t, ok := builtinThemes[name]; ok {
+		return t, nil
+	}
+
+	cfg, err := readToolConfig(configFile)
+	if err != nil {
+		return theme{}, errors.Wrapf(err, "theme %q is not built in", name)
+	}
+	node, ok := cfg.Themes[name]
+	if !ok {
+		names := []string{autoTheme}
+		for n := range builtinThemes {
+			names = append(names, n)
+		}
+		for n := range cfg.Themes {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return theme{}, errors.Errorf("theme %q not found, available themes: %s", name, strings.Join(names, ", "))
+	}
+	var custom theme
+	if err := node.Decode(&custom); err != nil {
+		return theme{}, errors.Wrapf(err, "invalid theme %q", name)
+	}
+	if custom.Base == "" {
+		custom.Base = "dark"
+	}
+	t, ok := builtinThemes[custom.Base]
+	if !ok {
+		return theme{}, errors.Errorf("unknown base %q of theme %q, expected dark or light", custom.Base, name)
+	}
+	// Decoding over the base theme keeps the colors the custom theme leaves out.
+	if err := node.Decode(&t); err != nil {
+		return theme{}, errors.Wrapf(err, "invalid theme %q", name)
+	}
+	return t, nil
+}
+
+// applyTheme rebuilds the styles of the TUI from the theme.
+func applyTheme(t theme) {
+	activeTheme = t
+	baseStyle = lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(t.Border))
+	hintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Hint))
+	warnStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Warn))
+	matchStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(t.Match))
+	addedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Added))
+	removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Removed))
+	heatmapStyles = make([]lipgloss.Style, 0, len(t.Heatmap))
+	for _, c := range t.Heatmap {
+		heatmapStyles = append(heatmapStyles,
+			lipgloss.NewStyle().Foreground(lipgloss.Color("16")).Background(lipgloss.Color(c)))
+	}
+	helpTitleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(t.Accent))
+	helpKeyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(t.Key)).Width(10)
+	helpSectionStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(t.Section)).MarginTop(1)
+}
+
+func init() {
+	applyTheme(activeTheme)
+}
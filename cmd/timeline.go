@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// defaultTimelineSize is the default of --timeline-size.
+const defaultTimelineSize = 10
+
+// recordScrape adds a scrape to the timeline. While looking at a past scrape, the table stays on it, it is one
+// more scrape back now.
+func (m *seriesTable) recordScrape(result *scrape.Result) {
+	m.timeline.Add(result)
+	if m.travelBack > 0 {
+		m.travelBack = min(m.travelBack+1, m.timeline.Len()-1)
+	}
+}
+
+// travel shows the scrape back scrapes before the latest one, 0 for the latest. The cardinality column of a past
+// scrape marks the families that changed since, the latest marks the changes of the last refresh.
+func (m *seriesTable) travel(back int) {
+	past := m.timeline.At(back)
+	if past == nil {
+		return
+	}
+	m.travelBack = back
+	latest := m.timeline.At(0)
+	switch previous := m.timeline.At(1); {
+	case back > 0:
+		m.changes = cardinalityChanges(past.Series, latest.Series)
+	case previous != nil:
+		m.changes = cardinalityChanges(previous.Series, latest.Series)
+	default:
+		m.changes = nil
+	}
+	m.setSeriesMap(past.Series)
+	m.infoTitle = m.formatInfoTitle(past)
+}
+
+// stepTimeline moves one scrape back in the timeline, or forward towards the latest scrape.
+func (m *seriesTable) stepTimeline(back bool) tea.Cmd {
+	to := m.travelBack - 1
+	if back {
+		to = m.travelBack + 1
+	}
+	switch {
+	case to < 0:
+		return m.setFlash("Already on the latest scrape")
+	case to >= m.timeline.Len():
+		return m.setFlash(fmt.Sprintf("Oldest scrape kept, %d of the --timeline-size=%d last scrapes are kept",
+			m.timeline.Len(), m.timelineSize))
+	}
+	selected := ""
+	if row := m.table.SelectedRow(); len(row) > 0 {
+		selected = rowName(row)
+	}
+	m.travel(to)
+	m.setTableRows(m.currentFilter())
+	if !m.followRow(selected) {
+		m.table.SetCursor(0)
+	}
+	if to == 0 {
+		return m.setFlash("Back on the latest scrape")
+	}
+	return nil
+}
+
+// timelineSummary describes the past scrape shown and how it differs from the latest one, empty on the latest.
+func (m *seriesTable) timelineSummary() string {
+	if m.travelBack == 0 {
+		return ""
+	}
+	past, latest := m.timeline.At(m.travelBack), m.timeline.At(0)
+	var grew, shrank, added int
+	for name, d := range m.changes {
+		switch _, ok := past.Series[name]; {
+		case !ok:
+			added++
+		case d > 0:
+			grew++
+		default:
+			shrank++
+		}
+	}
+	return warnStyle.Render(fmt.Sprintf(
+		"Scrape of %s, %d back and %s before the latest: since then %d families grew, %d shrank, %d appeared "+
+			"([ older, ] newer)",
+		past.Time.Format(time.TimeOnly), m.travelBack, latest.Time.Sub(past.Time).Round(time.Second),
+		grew, shrank, added))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type timestampsOptions struct {
+	Options
+	StaleAfter time.Duration
+	Top        int
+}
+
+func (o *timestampsOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("stale-after", "Flag the series whose timestamp is older than this at the time of the scrape, "+
+		"by default the lookback delta of Prometheus").
+		Default(scrape.DefaultStaleAfter.String()).
+		DurationVar(&o.StaleAfter)
+
+	app.Flag("top", "Number of stale series to list, the oldest first, 0 for all").
+		Default("20").
+		IntVar(&o.Top)
+}
+
+func registerTimestampsCommand(app *extkingpin.App) {
+	cmd := app.Command("timestamps", "Report the age of the explicit timestamps of the series of a target and "+
+		"flag the stale ones, e.g. served by a Pushgateway or a caching proxy, whose samples are dropped at ingestion.")
+	opts := &timestampsOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			report := scrape.TimestampAges(result.Series, result.Time, opts.StaleAfter)
+			return printTimestampReport(os.Stdout, report, opts.StaleAfter, opts.Top)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func printTimestampReport(out io.Writer, report scrape.TimestampReport, staleAfter time.Duration, top int) error {
+	if report.WithTimestamp == 0 {
+		_, err := fmt.Fprintf(out, "None of the %d series is exposed with a timestamp\n", report.Series)
+		return err
+	}
+	fmt.Fprintf(out, "%d of %d series exposed with a timestamp, %d in the future\n\n",
+		report.WithTimestamp, report.Series, report.Future)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AGE\tSERIES")
+	for _, a := range report.Ages {
+		age := "older"
+		if a.UpTo > 0 {
+			age = "<= " + a.UpTo.String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", age, a.Count)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.Stale) == 0 {
+		return nil
+	}
+	fmt.Fprintf(out, "\n%d series have a timestamp older than %s, their samples may be dropped at ingestion\n",
+		len(report.Stale), staleAfter)
+	stale := report.Stale
+	if top > 0 && len(stale) > top {
+		stale = stale[:top]
+	}
+	tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERIES\tAGE")
+	for _, s := range stale {
+		fmt.Fprintf(tw, "%s\t%s\n", s.Series, s.Age.Round(time.Second))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/go-kit/log"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+const (
+	sortByCardinality = "cardinality"
+	sortByMemory      = "memory"
+
+	groupByMetric = "metric"
+	groupByPrefix = "prefix"
+
+	topOutputText = "text"
+	topOutputCSV  = "csv"
+	topOutputJSON = "json"
+)
+
+type topOptions struct {
+	Options
+	Limit          int
+	SortBy         string
+	GroupBy        string
+	PrefixSegments int
+	Output         string
+}
+
+func (o *topOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addCacheFlags(app)
+
+	app.Flag("limit", "Number of rows to print, 0 for all").
+		Default("20").
+		IntVar(&o.Limit)
+
+	app.Flag("sort-by", "Sort metric families by series count or by estimated memory").
+		Default(sortByCardinality).
+		EnumVar(&o.SortBy, sortByCardinality, sortByMemory)
+
+	app.Flag("group-by", "Report individual metric families, or sum them by name prefix").
+		Default(groupByMetric).
+		EnumVar(&o.GroupBy, groupByMetric, groupByPrefix)
+
+	app.Flag("prefix-segments", "Number of underscore-delimited name segments forming a prefix with --group-by=prefix").
+		Default("1").
+		IntVar(&o.PrefixSegments)
+
+	app.Flag("output", "Output format: "+topOutputText+", or "+topOutputCSV+" or "+topOutputJSON+
+		" to export the rows, the metric families or the prefixes of --group-by=prefix").
+		Default(topOutputText).
+		EnumVar(&o.Output, topOutputText, topOutputCSV, topOutputJSON)
+}
+
+func registerTopCommand(app *extkingpin.App) {
+	cmd := app.Command("top", "Print the metric families with the highest cardinality and exit.")
+	opts := &topOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			result, err := scraper.Scrape(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to scrape target")
+			}
+			if opts.Output != topOutputText {
+				return exportTop(os.Stdout, opts, result)
+			}
+			if result.Truncated {
+				fmt.Fprintf(os.Stdout, "Partial result, parsing stopped at --max-series=%d\n", opts.MaxSeries)
+			}
+			if result.Timing.Total > 0 {
+				fmt.Fprintf(os.Stdout, "Scrape took %s\n", result.Timing)
+				if slow := opts.slowScrape(result.Timing); slow != "" {
+					fmt.Fprintln(os.Stdout, slow)
+				}
+			}
+			return printTop(os.Stdout, opts, result.Series, previousResult(scraper))
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+// printTop prints the metric families, annotated with their change since the previous run when known.
+func printTop(out io.Writer, opts *topOptions, series scrape.SeriesMap, previous *scrape.Result) error {
+	if opts.GroupBy == groupByPrefix {
+		return printTopPrefixes(out, opts, series)
+	}
+
+	rows, memory, total := topRows(opts, series)
+	fmt.Fprintf(out, "%d metric families, %d series\n", len(series), total)
+	if usage := formatExemplarUsage(series.ExemplarUsage()); usage != "" {
+		fmt.Fprintln(out, usage)
+	}
+	var trends map[string]scrape.FamilyTrend
+	if previous != nil {
+		var gone []string
+		trends, gone = scrape.CompareFamilies(previous.Series, series)
+		printTrendSummary(out, previous, trends, gone)
+	}
+	fmt.Fprintln(out)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := "METRIC\tTYPE\tSERIES\tSHARE\tEST. MEMORY\tLABELS"
+	if trends != nil {
+		header += "\tCHANGE"
+	}
+	fmt.Fprintln(tw, header)
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s",
+			r.Name, r.Type, r.Cardinality, formatShare(r.Cardinality, total),
+			units.BytesSize(float64(memory[r.Name])), strings.ReplaceAll(r.Labels, "|", ", "))
+		if trends != nil {
+			fmt.Fprintf(tw, "\t%s", formatTrend(trends[r.Name]))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// printTrendSummary prints how many metric families changed since the previous run.
+func printTrendSummary(out io.Writer, previous *scrape.Result, trends map[string]scrape.FamilyTrend, gone []string) {
+	counts := make(map[scrape.Trend]int, 4)
+	for _, t := range trends {
+		counts[t.Trend]++
+	}
+	fmt.Fprintf(out, "Since the previous run at %s: %d new, %d grew, %d shrank, %d unchanged, %d gone\n",
+		previous.Time.Format(time.DateTime), counts[scrape.TrendNew], counts[scrape.TrendGrew],
+		counts[scrape.TrendShrank], counts[scrape.TrendUnchanged], len(gone))
+	if len(gone) > 0 {
+		fmt.Fprintf(out, "Gone: %s\n", strings.Join(gone, ", "))
+	}
+}
+
+// formatTrend formats the change of a metric family, with the series added or removed.
+func formatTrend(t scrape.FamilyTrend) string {
+	switch t.Trend {
+	case scrape.TrendGrew, scrape.TrendShrank:
+		return fmt.Sprintf("%s %+d", t.Trend, t.Delta)
+	default:
+		return string(t.Trend)
+	}
+}
+
+// topRows returns the metric families of the report, sorted and limited by the flags, with the estimated memory
+// of every family and the series of the scrape.
+func topRows(opts *topOptions, series scrape.SeriesMap) (rows []scrape.SeriesInfo, memory map[string]int, total int) {
+	rows = series.AsRows()
+	memory = make(map[string]int, len(rows))
+	for name, set := range series {
+		memory[name] = set.EstimatedMemory()
+		total += set.Cardinality()
+	}
+	if opts.SortBy == sortByMemory {
+		slices.SortStableFunc(rows, func(i, j scrape.SeriesInfo) int { return memory[j.Name] - memory[i.Name] })
+	}
+	if opts.Limit > 0 && len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+	}
+	return rows, memory, total
+}
+
+// topPrefixes returns the prefixes of the report, limited by the flags.
+func topPrefixes(opts *topOptions, prefixes []scrape.PrefixInfo) []scrape.PrefixInfo {
+	if opts.Limit > 0 && len(prefixes) > opts.Limit {
+		return prefixes[:opts.Limit]
+	}
+	return prefixes
+}
+
+func printTopPrefixes(out io.Writer, opts *topOptions, series scrape.SeriesMap) error {
+	all := series.ByPrefix(opts.PrefixSegments)
+	total := 0
+	for _, p := range all {
+		total += p.Cardinality
+	}
+	fmt.Fprintf(out, "%d prefixes, %d metric families, %d series\n\n", len(all), len(series), total)
+	prefixes := topPrefixes(opts, all)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PREFIX\tMETRICS\tSERIES\tSHARE")
+	for _, p := range prefixes {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", p.Prefix, p.Metrics, p.Cardinality, formatShare(p.Cardinality, total))
+	}
+	return tw.Flush()
+}
+
+// exportTop writes the rows of the top report in the csv or json --output format, like the export of the TUI.
+func exportTop(out io.Writer, opts *topOptions, result *scrape.Result) error {
+	series := result.Series
+	var (
+		header  []string
+		records [][]string
+	)
+	if opts.GroupBy == groupByPrefix {
+		header = []string{"prefix", "metrics", "series"}
+		for _, p := range topPrefixes(opts, series.ByPrefix(opts.PrefixSegments)) {
+			records = append(records, []string{p.Prefix, strconv.Itoa(p.Metrics), strconv.Itoa(p.Cardinality)})
+		}
+	} else {
+		header = []string{"metric", "type", "series", "memory_bytes", "labels"}
+		rows, memory, _ := topRows(opts, series)
+		for _, r := range rows {
+			records = append(records, []string{r.Name, r.Type, strconv.Itoa(r.Cardinality),
+				strconv.Itoa(memory[r.Name]), strings.ReplaceAll(r.Labels, "|", " ")})
+		}
+	}
+	header, records = markTruncated(header, records, result.Truncated)
+	if opts.Output == topOutputJSON {
+		return writeJSON(out, header, records)
+	}
+	return writeCSV(out, header, records)
+}
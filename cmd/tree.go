@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+var treeColumnLayout = []columnLayout{
+	{title: "Prefix", width: 80, flex: true},
+	{title: "Cardinality", width: 16},
+	{title: "Metrics", width: 10},
+	{title: "Share", width: 8},
+}
+
+// setTreeRows fills the table with the visible nodes of the prefix tree of the metrics passing the filter.
+// Every node is expanded while searching so all matches are shown.
+func (m *seriesTable) setTreeRows(filter func(info scrape.SeriesInfo) bool) {
+	var infos []scrape.SeriesInfo
+	for _, r := range m.metricRows() {
+		if filter == nil || filter(r.info) {
+			infos = append(infos, r.info)
+		}
+	}
+	m.treeRoot = scrape.NewPrefixTree(infos)
+	m.treeNodes = m.treeNodes[:0]
+
+	expandAll := m.searchQuery() != ""
+	var rows []table.Row
+	var walk func(n *scrape.PrefixNode, depth int)
+	walk = func(n *scrape.PrefixNode, depth int) {
+		for _, c := range n.Children {
+			marker := "  "
+			expanded := expandAll || m.expanded[c.Prefix]
+			if len(c.Children) > 0 {
+				marker = "▸ "
+				if expanded {
+					marker = "▾ "
+				}
+			}
+			rows = append(rows, table.Row{
+				strings.Repeat("  ", depth) + marker + c.Prefix,
+				strconv.Itoa(c.Cardinality),
+				strconv.Itoa(c.Metrics),
+				formatShare(c.Cardinality, m.treeRoot.Cardinality),
+			})
+			m.treeNodes = append(m.treeNodes, c)
+			if expanded {
+				walk(c, depth+1)
+			}
+		}
+	}
+	walk(m.treeRoot, 0)
+
+	m.table.SetRows(rows)
+}
+
+// formatShare renders part as a percentage of total.
+func formatShare(part, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(float64(part)*100/float64(total), 'f', 1, 64) + "%"
+}
+
+// setTreeView switches between the flat metric table and the prefix tree.
+func (m *seriesTable) setTreeView(tree bool) {
+	m.treeView = tree
+	if tree {
+		m.groupByOrigin = false
+	}
+	m.resetColumns()
+	m.table.SetCursor(0)
+}
+
+// selectedTreeNode returns the prefix tree node under the cursor, nil outside of the tree view.
+func (m *seriesTable) selectedTreeNode() *scrape.PrefixNode {
+	i := m.table.Cursor()
+	if !m.treeView || i < 0 || i >= len(m.treeNodes) {
+		return nil
+	}
+	return m.treeNodes[i]
+}
+
+// setExpanded expands or collapses the selected subtree. Collapsing a leaf, or an already collapsed
+// node, collapses its parent instead.
+func (m *seriesTable) setExpanded(expand bool) {
+	n := m.selectedTreeNode()
+	if n == nil {
+		return
+	}
+	target := n
+	if !expand && (len(n.Children) == 0 || !m.expanded[n.Prefix]) {
+		target = m.treeParent(n)
+		if target == nil {
+			return
+		}
+	}
+	if expand {
+		if len(n.Children) == 0 {
+			return
+		}
+		m.expanded[target.Prefix] = true
+	} else {
+		delete(m.expanded, target.Prefix)
+	}
+
+	m.setTableRows(m.currentFilter())
+	for i, node := range m.treeNodes {
+		if node == target {
+			m.table.SetCursor(i)
+			break
+		}
+	}
+}
+
+// treeParent returns the parent of the node among the visible nodes, nil for top level nodes.
+func (m *seriesTable) treeParent(n *scrape.PrefixNode) *scrape.PrefixNode {
+	for _, node := range m.treeNodes {
+		for _, c := range node.Children {
+			if c == n {
+				return node
+			}
+		}
+	}
+	return nil
+}
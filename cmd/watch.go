@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extkingpin"
+	"github.com/thanos-io/thanos/pkg/tracing"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/notify"
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type watchOptions struct {
+	Options
+	Interval time.Duration
+	Count    int
+
+	// Notifications of cardinality thresholds crossed between scrapes.
+	WebhookURL            string
+	WebhookFormat         string
+	NotifyMaxSeries       int
+	NotifyMaxGrowth       float64
+	NotifyMetricMaxSeries []string
+	NotifyMetricMaxGrowth []string
+}
+
+func (o *watchOptions) addFlags(app extkingpin.AppClause) {
+	o.AddFlags(app)
+	o.addThrottleFlags(app)
+
+	app.Flag("interval", "Interval between scrapes").
+		Default("15s").
+		DurationVar(&o.Interval)
+
+	app.Flag("count", "Number of scrapes to take before printing the report, 0 to run until interrupted").
+		Default("0").
+		IntVar(&o.Count)
+
+	app.Flag("notify.webhook-url", "Webhook to post to when a cardinality threshold is crossed, "+
+		"the thresholds are logged only when not set").
+		StringVar(&o.WebhookURL)
+
+	app.Flag("notify.format", "Payload of the webhook: "+notify.FormatJSON+" for the event as JSON, or "+
+		notify.FormatSlack+" for a Slack compatible message").
+		Default(notify.FormatJSON).
+		EnumVar(&o.WebhookFormat, notify.FormatJSON, notify.FormatSlack)
+
+	app.Flag("notify.max-series", "Notify when the total series of the target go above this, 0 to disable").
+		Default("0").
+		IntVar(&o.NotifyMaxSeries)
+
+	app.Flag("notify.max-growth", "Notify when the total series grow by more than this percentage between "+
+		"scrapes, 0 to disable").
+		Default("0").
+		FloatVar(&o.NotifyMaxGrowth)
+
+	app.Flag("notify.metric-max-series", "Notify when the series of a metric family go above a threshold, "+
+		"as 'metric=series', can be repeated").
+		StringsVar(&o.NotifyMetricMaxSeries)
+
+	app.Flag("notify.metric-max-growth", "Notify when the series of a metric family grow by more than a "+
+		"percentage between scrapes, as 'metric=percentage', can be repeated").
+		StringsVar(&o.NotifyMetricMaxGrowth)
+}
+
+// thresholds returns the cardinality thresholds of the notification flags, the one of the whole target first.
+func (o *watchOptions) thresholds() ([]scrape.Threshold, error) {
+	var thresholds []scrape.Threshold
+	if o.NotifyMaxSeries > 0 || o.NotifyMaxGrowth > 0 {
+		thresholds = append(thresholds, scrape.Threshold{MaxSeries: o.NotifyMaxSeries, MaxGrowth: o.NotifyMaxGrowth / 100})
+	}
+
+	byMetric := make(map[string]*scrape.Threshold)
+	metric := func(name string) *scrape.Threshold {
+		t, ok := byMetric[name]
+		if !ok {
+			t = &scrape.Threshold{Metric: name}
+			byMetric[name] = t
+		}
+		return t
+	}
+	for _, f := range o.NotifyMetricMaxSeries {
+		name, v, _ := strings.Cut(f, "=")
+		n, err := strconv.Atoi(v)
+		if name == "" || err != nil || n <= 0 {
+			return nil, errors.Errorf("invalid series threshold %q, expected 'metric=series'", f)
+		}
+		metric(name).MaxSeries = n
+	}
+	for _, f := range o.NotifyMetricMaxGrowth {
+		name, v, _ := strings.Cut(f, "=")
+		pct, err := strconv.ParseFloat(v, 64)
+		if name == "" || err != nil || pct <= 0 {
+			return nil, errors.Errorf("invalid growth threshold %q, expected 'metric=percentage'", f)
+		}
+		metric(name).MaxGrowth = pct / 100
+	}
+	for _, name := range slices.Sorted(maps.Keys(byMetric)) {
+		thresholds = append(thresholds, *byMetric[name])
+	}
+
+	if o.WebhookURL != "" && len(thresholds) == 0 {
+		return nil, errors.New("--notify.webhook-url needs at least one threshold, e.g. --notify.max-growth")
+	}
+	return thresholds, nil
+}
+
+func registerWatchCommand(app *extkingpin.App) {
+	cmd := app.Command("watch", "Scrape a target repeatedly and report series churn per metric family.")
+	opts := &watchOptions{}
+	opts.addFlags(cmd)
+	cmd.Setup(func(
+		g *run.Group,
+		logger log.Logger,
+		reg *prometheus.Registry,
+		tracer opentracing.Tracer,
+		_ <-chan struct{},
+		_ bool,
+	) error {
+		scraper, err := opts.Scraper(logger, scrape.WithMetrics(scrape.NewMetrics(reg)))
+		if err != nil {
+			return err
+		}
+		thresholds, err := opts.thresholds()
+		if err != nil {
+			return err
+		}
+		var webhook *notify.Webhook
+		if opts.WebhookURL != "" {
+			webhook, err = notify.NewWebhook(opts.WebhookURL, notify.WithFormat(opts.WebhookFormat))
+			if err != nil {
+				return err
+			}
+		}
+		canary := &cardinalityCanary{
+			target:     opts.redactedScrapeURL(),
+			thresholds: thresholds,
+			webhook:    webhook,
+			logger:     logger,
+		}
+
+		ctx, cancel := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracer))
+		g.Add(func() error {
+			return runWatch(ctx, opts, scraper, canary, logger, os.Stdout)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	})
+}
+
+func runWatch(
+	ctx context.Context,
+	opts *watchOptions,
+	scraper scrape.Scraper,
+	canary *cardinalityCanary,
+	logger log.Logger,
+	out io.Writer,
+) error {
+	tracker := scrape.NewChurnTracker()
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	throttle := opts.throttle()
+
+	for {
+		if throttle != nil && throttle.Wait(ctx) != nil {
+			return printChurnReport(out, tracker)
+		}
+		result, err := scraper.Scrape(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return printChurnReport(out, tracker)
+		case err != nil:
+			// A single failed scrape should not end a long watch session.
+			level.Warn(logger).Log("msg", "scrape failed", "url", opts.ScrapeURL, "err", err)
+		default:
+			canary.observe(ctx, result)
+			tracker.Observe(result.Series)
+			level.Info(logger).Log(
+				"msg", "scrape complete",
+				"scrape", tracker.Scrapes(),
+				"metric_families", len(result.Series),
+			)
+		}
+
+		if opts.Count > 0 && tracker.Scrapes() >= opts.Count {
+			return printChurnReport(out, tracker)
+		}
+
+		select {
+		case <-ctx.Done():
+			return printChurnReport(out, tracker)
+		case <-ticker.C:
+		}
+	}
+}
+
+// cardinalityCanary notifies when the scrapes of a watched target cross a cardinality threshold.
+type cardinalityCanary struct {
+	target     string
+	thresholds []scrape.Threshold
+	// webhook is nil when the breaches are logged only.
+	webhook *notify.Webhook
+	logger  log.Logger
+	prev    scrape.SeriesMap
+}
+
+func (c *cardinalityCanary) observe(ctx context.Context, result *scrape.Result) {
+	defer func() { c.prev = result.Series }()
+	for _, t := range c.thresholds {
+		for _, b := range t.Check(c.prev, result.Series) {
+			level.Warn(c.logger).Log("msg", "cardinality threshold crossed", "reason", b.Reason)
+			if c.webhook == nil {
+				continue
+			}
+			err := c.webhook.Notify(ctx, notify.Event{
+				Target:         c.target,
+				Metric:         t.Metric,
+				PreviousSeries: b.Previous,
+				Series:         b.Series,
+				Message:        b.Reason,
+				Time:           result.Time,
+			})
+			if err != nil {
+				// A webhook that is down should not end a long watch session either.
+				level.Warn(c.logger).Log("msg", "failed to notify webhook", "err", err)
+			}
+		}
+	}
+}
+
+func printChurnReport(out io.Writer, tracker *scrape.ChurnTracker) error {
+	report := tracker.Report()
+	fmt.Fprintf(out, "Churn report over %d scrapes, %d metric families with events\n\n", tracker.Scrapes(), len(report))
+	if len(report) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tTYPE\tADDED\tREPLACED\tVANISHED\tREAPPEARED\tCOUNTER RESETS\tGROWTH\tCHURN\tCHANGED LABELS")
+	for _, f := range report {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%+d\t%s\t%s\n",
+			f.Name, f.Type, f.Added, f.Replaced, f.Vanished, f.Reappeared, f.CounterResets, f.Growth,
+			churnCategories[f.Category()], formatChangedLabels(f.ChangedLabels))
+	}
+	return tw.Flush()
+}
+
+// churnCategories describes the churn categories in the report.
+var churnCategories = map[string]string{
+	scrape.ChurnRollout: "expected rollout churn",
+	scrape.ChurnGrowth:  "unbounded growth",
+	"":                  "-",
+}
+
+// formatChangedLabels lists the changed labels, the most changed first, e.g. "version (12), pod (3)".
+func formatChangedLabels(changed map[string]int) string {
+	if len(changed) == 0 {
+		return "-"
+	}
+	names := slices.SortedFunc(maps.Keys(changed), func(a, b string) int {
+		if d := changed[b] - changed[a]; d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%d)", name, changed[name])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,178 @@
+package mimir
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	labelNamesPath  = "/api/v1/cardinality/label_names"
+	labelValuesPath = "/api/v1/cardinality/label_values"
+	tenantHeader    = "X-Scope-OrgID"
+)
+
+// LabelNamesResponse is the body returned by Mimir's cardinality label names endpoint.
+type LabelNamesResponse struct {
+	LabelValuesCountTotal int                    `json:"label_values_count_total"`
+	LabelNamesCount       int                    `json:"label_names_count"`
+	Cardinality           []LabelNameCardinality `json:"cardinality"`
+}
+
+type LabelNameCardinality struct {
+	LabelName        string `json:"label_name"`
+	LabelValuesCount int    `json:"label_values_count"`
+}
+
+// LabelValuesResponse is the body returned by Mimir's cardinality label values endpoint.
+type LabelValuesResponse struct {
+	SeriesCountTotal int                  `json:"series_count_total"`
+	Labels           []LabelValuesSummary `json:"labels"`
+}
+
+type LabelValuesSummary struct {
+	LabelName        string                  `json:"label_name"`
+	LabelValuesCount int                     `json:"label_values_count"`
+	SeriesCount      int                     `json:"series_count"`
+	Cardinality      []LabelValueCardinality `json:"cardinality"`
+}
+
+type LabelValueCardinality struct {
+	LabelValue  string `json:"label_value"`
+	SeriesCount int    `json:"series_count"`
+}
+
+type Client struct {
+	address string
+	tenant  string
+	timeout time.Duration
+	logger  log.Logger
+	client  *http.Client
+}
+
+type clientOpts struct {
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+type ClientOption func(*clientOpts)
+
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(opts *clientOpts) {
+		opts.timeout = timeout
+	}
+}
+
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(opts *clientOpts) {
+		opts.httpClient = client
+	}
+}
+
+// NewClient creates a client for the cardinality API of the Mimir (or Cortex) instance at address,
+// issuing every request on behalf of tenant.
+func NewClient(address, tenant string, logger log.Logger, opts ...ClientOption) *Client {
+	cOpts := &clientOpts{
+		timeout:    30 * time.Second,
+		httpClient: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(cOpts)
+	}
+
+	return &Client{
+		address: strings.TrimSuffix(address, "/"),
+		tenant:  tenant,
+		timeout: cOpts.timeout,
+		logger:  logger,
+		client:  cOpts.httpClient,
+	}
+}
+
+// LabelNames returns the label names of the tenant, sorted by their number of distinct values.
+// An empty selector means all series of the tenant.
+func (c *Client) LabelNames(ctx context.Context, selector string, limit int) (*LabelNamesResponse, error) {
+	params := url.Values{}
+	if selector != "" {
+		params.Set("selector", selector)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp LabelNamesResponse
+	if err := c.get(ctx, labelNamesPath, params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// LabelValues returns the series count of the top values for each of the given label names.
+func (c *Client) LabelValues(
+	ctx context.Context,
+	labelNames []string,
+	selector string,
+	limit int,
+) (*LabelValuesResponse, error) {
+	params := url.Values{}
+	for _, name := range labelNames {
+		params.Add("label_names[]", name)
+	}
+	if selector != "" {
+		params.Set("selector", selector)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp LabelValuesResponse
+	if err := c.get(ctx, labelValuesPath, params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values, into any) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	u := c.address + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if c.tenant != "" {
+		req.Header.Set(tenantHeader, c.tenant)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	level.Debug(c.logger).Log("msg", "querying cardinality API", "url", u, "tenant", c.tenant)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cardinality API returned HTTP status %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+		return fmt.Errorf("failed to decode cardinality API response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package mimir_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/mimir"
+)
+
+func TestClient_LabelNames(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/prometheus/api/v1/cardinality/label_names", r.URL.Path)
+		require.Equal(t, "team-a", r.Header.Get("X-Scope-OrgID"))
+		require.Equal(t, `{job="api"}`, r.URL.Query().Get("selector"))
+		require.Equal(t, "5", r.URL.Query().Get("limit"))
+		_, _ = w.Write([]byte(`{
+			"label_values_count_total": 12,
+			"label_names_count": 2,
+			"cardinality": [
+				{"label_name": "pod", "label_values_count": 10},
+				{"label_name": "job", "label_values_count": 2}
+			]
+		}`))
+	}))
+	defer srv.Close()
+
+	c := mimir.NewClient(srv.URL+"/prometheus/", "team-a", log.NewNopLogger())
+	resp, err := c.LabelNames(context.Background(), `{job="api"}`, 5)
+	require.NoError(t, err)
+	require.Equal(t, 12, resp.LabelValuesCountTotal)
+	require.Len(t, resp.Cardinality, 2)
+	require.Equal(t, "pod", resp.Cardinality[0].LabelName)
+}
+
+func TestClient_ErrorStatus(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := mimir.NewClient(srv.URL, "", log.NewNopLogger())
+	_, err := c.LabelValues(context.Background(), []string{"pod"}, "", 0)
+	require.Error(t, err)
+}
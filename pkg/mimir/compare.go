@@ -0,0 +1,75 @@
+package mimir
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// LabelComparison holds the distinct value counts of a label as seen in a single scrape and in the tenant.
+type LabelComparison struct {
+	Name         string
+	ScrapeValues uint
+	TenantValues int
+	// TenantSeries is the number of the tenant's series carrying the label, when known.
+	TenantSeries int
+	// TenantShare is the fraction of all label values of the tenant that belong to this label.
+	TenantShare float64
+	// ScrapeShare is the fraction of this label's tenant values that the scrape exposes.
+	ScrapeShare float64
+}
+
+// InTenant reports whether the label was returned by the cardinality API.
+func (l LabelComparison) InTenant() bool {
+	return l.TenantValues > 0
+}
+
+// Compare joins the label stats of a scrape with the tenant's label names cardinality.
+// Only labels present in the scrape are returned, ordered by the tenant's distinct value count so the
+// labels dominating the tenant's budget come first.
+func Compare(scrapeStats scrape.LabelStatsSlice, tenant *LabelNamesResponse) []LabelComparison {
+	tenantValues := make(map[string]int, len(tenant.Cardinality))
+	for _, c := range tenant.Cardinality {
+		tenantValues[c.LabelName] = c.LabelValuesCount
+	}
+
+	comparison := make([]LabelComparison, 0, len(scrapeStats))
+	for _, ls := range scrapeStats {
+		c := LabelComparison{
+			Name:         ls.Name,
+			ScrapeValues: ls.DistinctValues,
+			TenantValues: tenantValues[ls.Name],
+		}
+		if tenant.LabelValuesCountTotal > 0 {
+			c.TenantShare = float64(c.TenantValues) / float64(tenant.LabelValuesCountTotal)
+		}
+		if c.TenantValues > 0 {
+			c.ScrapeShare = float64(c.ScrapeValues) / float64(c.TenantValues)
+		}
+		comparison = append(comparison, c)
+	}
+
+	slices.SortFunc(comparison, func(i, j LabelComparison) int {
+		if d := j.TenantValues - i.TenantValues; d != 0 {
+			return d
+		}
+		if d := int(j.ScrapeValues) - int(i.ScrapeValues); d != 0 {
+			return d
+		}
+		return strings.Compare(i.Name, j.Name)
+	})
+
+	return comparison
+}
+
+// AddSeriesCounts fills the tenant series count of the compared labels from a label values response.
+func AddSeriesCounts(comparison []LabelComparison, values *LabelValuesResponse) {
+	seriesCount := make(map[string]int, len(values.Labels))
+	for _, l := range values.Labels {
+		seriesCount[l.LabelName] = l.SeriesCount
+	}
+	for i := range comparison {
+		comparison[i].TenantSeries = seriesCount[comparison[i].Name]
+	}
+}
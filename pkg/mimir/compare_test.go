@@ -0,0 +1,49 @@
+package mimir_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/mimir"
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+	scrapeStats := scrape.LabelStatsSlice{
+		{Name: "instance", DistinctValues: 1},
+		{Name: "path", DistinctValues: 50},
+		{Name: "pod", DistinctValues: 1},
+	}
+	tenant := &mimir.LabelNamesResponse{
+		LabelValuesCountTotal: 1000,
+		LabelNamesCount:       3,
+		Cardinality: []mimir.LabelNameCardinality{
+			{LabelName: "pod", LabelValuesCount: 600},
+			{LabelName: "path", LabelValuesCount: 100},
+			{LabelName: "job", LabelValuesCount: 10},
+		},
+	}
+
+	got := mimir.Compare(scrapeStats, tenant)
+
+	require.Len(t, got, 3, "Compare() should only return labels present in the scrape")
+	require.Equal(t, "pod", got[0].Name)
+	require.InDelta(t, 0.6, got[0].TenantShare, 0.0001)
+	require.Equal(t, "path", got[1].Name)
+	require.InDelta(t, 0.5, got[1].ScrapeShare, 0.0001)
+	require.Equal(t, "instance", got[2].Name)
+	require.False(t, got[2].InTenant(), "labels missing from the tenant should be reported as such")
+}
+
+func TestAddSeriesCounts(t *testing.T) {
+	t.Parallel()
+	comparison := []mimir.LabelComparison{{Name: "pod"}, {Name: "path"}}
+	mimir.AddSeriesCounts(comparison, &mimir.LabelValuesResponse{
+		Labels: []mimir.LabelValuesSummary{{LabelName: "pod", SeriesCount: 42}},
+	})
+
+	require.Equal(t, 42, comparison[0].TenantSeries)
+	require.Equal(t, 0, comparison[1].TenantSeries)
+}
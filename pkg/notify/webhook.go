@@ -0,0 +1,126 @@
+// Package notify posts cardinality notifications to webhooks, either as generic JSON or as Slack incoming
+// webhook messages.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Payload formats of a webhook.
+const (
+	FormatJSON  = "json"
+	FormatSlack = "slack"
+)
+
+// Event is a cardinality threshold crossed by a target, posted as is in the JSON format.
+type Event struct {
+	Target string `json:"target"`
+	// Metric is the metric family that crossed the threshold, empty for the whole target.
+	Metric         string    `json:"metric,omitempty"`
+	PreviousSeries int       `json:"previous_series"`
+	Series         int       `json:"series"`
+	Message        string    `json:"message"`
+	Time           time.Time `json:"time"`
+}
+
+// slackMessage is the body of a Slack incoming webhook, also accepted by Mattermost and Rocket.Chat.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+type Webhook struct {
+	url     string
+	format  string
+	timeout time.Duration
+	client  *http.Client
+}
+
+type webhookOpts struct {
+	format     string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+type WebhookOption func(*webhookOpts)
+
+// WithFormat sets the payload format, FormatJSON by default.
+func WithFormat(format string) WebhookOption {
+	return func(opts *webhookOpts) {
+		opts.format = format
+	}
+}
+
+func WithTimeout(timeout time.Duration) WebhookOption {
+	return func(opts *webhookOpts) {
+		opts.timeout = timeout
+	}
+}
+
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(opts *webhookOpts) {
+		opts.httpClient = client
+	}
+}
+
+// NewWebhook creates a notifier posting events to url.
+func NewWebhook(url string, opts ...WebhookOption) (*Webhook, error) {
+	wOpts := &webhookOpts{
+		format:     FormatJSON,
+		timeout:    10 * time.Second,
+		httpClient: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(wOpts)
+	}
+	if wOpts.format != FormatJSON && wOpts.format != FormatSlack {
+		return nil, fmt.Errorf("unknown webhook format %q", wOpts.format)
+	}
+
+	return &Webhook{
+		url:     url,
+		format:  wOpts.format,
+		timeout: wOpts.timeout,
+		client:  wOpts.httpClient,
+	}, nil
+}
+
+// Notify posts the event to the webhook.
+func (w *Webhook) Notify(ctx context.Context, e Event) error {
+	var payload any = e
+	if w.format == FormatSlack {
+		payload = slackMessage{Text: fmt.Sprintf(":warning: Cardinality of %s: %s", e.Target, e.Message)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain the body so that the connection is reused.
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned HTTP status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/notify"
+)
+
+func TestWebhook_Notify(t *testing.T) {
+	t.Parallel()
+	event := notify.Event{
+		Target:         "http://api:8080/metrics",
+		Metric:         "requests_total",
+		PreviousSeries: 100,
+		Series:         150,
+		Message:        "requests_total grew by 50.0% from 100 to 150 series, over 20.0%",
+		Time:           time.Unix(1700000000, 0).UTC(),
+	}
+
+	for _, tc := range []struct {
+		format string
+		want   string
+	}{
+		{
+			format: notify.FormatJSON,
+			want: `{"target":"http://api:8080/metrics","metric":"requests_total","previous_series":100,` +
+				`"series":150,"message":"` + event.Message + `","time":"2023-11-14T22:13:20Z"}`,
+		},
+		{
+			format: notify.FormatSlack,
+			want:   `{"text":":warning: Cardinality of http://api:8080/metrics: ` + event.Message + `"}`,
+		},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			t.Parallel()
+			var got json.RawMessage
+			srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			}))
+			defer srv.Close()
+
+			w, err := notify.NewWebhook(srv.URL, notify.WithFormat(tc.format))
+			require.NoError(t, err)
+			require.NoError(t, w.Notify(context.Background(), event))
+			require.JSONEq(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestWebhook_ErrorStatus(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	w, err := notify.NewWebhook(srv.URL)
+	require.NoError(t, err)
+	require.Error(t, w.Notify(context.Background(), notify.Event{}))
+
+	_, err = notify.NewWebhook(srv.URL, notify.WithFormat("teams"))
+	require.Error(t, err)
+}
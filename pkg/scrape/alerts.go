@@ -0,0 +1,121 @@
+package scrape
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// RuleGroups is a Prometheus rule file.
+type RuleGroups struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string      `yaml:"name"`
+	Rules []AlertRule `yaml:"rules"`
+}
+
+// AlertRule is an alerting rule of a rule file.
+type AlertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         model.Duration    `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// AlertOptions calibrates the alerting rules suggested by SuggestAlerts.
+type AlertOptions struct {
+	// Job is the job label of the target in Prometheus.
+	Job string
+	// Headroom is the growth over the observed baseline tolerated before alerting, e.g. 0.5 for 50%.
+	Headroom float64
+	// TopFamilies is the number of metric families, the largest first, that get a cardinality alert of their own.
+	TopFamilies int
+	// For is how long a condition must hold before the alert fires.
+	For time.Duration
+	// Severity is the severity label of the alerts, none when empty.
+	Severity string
+}
+
+// SuggestAlerts returns alerting rules protecting the target from cardinality growth, with thresholds calibrated
+// from its observed scrape:
+//   - scrape_samples_scraped above the observed series plus headroom;
+//   - scrape_series_added over an hour above the headroom share of the observed series, i.e. churn;
+//   - scrape_body_size_bytes above the observed body plus headroom, which needs Prometheus to run with
+//     --enable-feature=extra-scrape-metrics;
+//   - the series of the largest metric families above their observed cardinality plus headroom.
+func SuggestAlerts(r *Result, o AlertOptions) RuleGroups {
+	job := labels.MustNewMatcher(labels.MatchEqual, "job", o.Job).String()
+	observed := fmt.Sprintf("observed on %s", r.Time.UTC().Format(time.DateOnly))
+	ruleLabels := map[string]string(nil)
+	if o.Severity != "" {
+		ruleLabels = map[string]string{"severity": o.Severity}
+	}
+	rule := func(alert, expr, summary, description string) AlertRule {
+		return AlertRule{
+			Alert:       alert,
+			Expr:        expr,
+			For:         model.Duration(o.For),
+			Labels:      ruleLabels,
+			Annotations: map[string]string{"summary": summary, "description": description},
+		}
+	}
+
+	series := 0
+	for _, set := range r.Series {
+		series += set.Cardinality()
+	}
+	churn := max(1, int(math.Ceil(float64(series)*o.Headroom)))
+	rules := []AlertRule{
+		rule("ScrapeSamplesAboveBaseline",
+			fmt.Sprintf("scrape_samples_scraped{%s} > %d", job, withHeadroom(series, o.Headroom)),
+			"Target {{ $labels.instance }} of job "+o.Job+" exposes {{ $value }} samples.",
+			fmt.Sprintf("The baseline is %d samples per scrape, %s.", series, observed)),
+		rule("ScrapeSeriesChurn",
+			fmt.Sprintf("sum_over_time(scrape_series_added{%s}[1h]) > %d", job, churn),
+			"Target {{ $labels.instance }} of job "+o.Job+" added {{ $value }} series in the last hour.",
+			fmt.Sprintf("The target exposes %d series, %s, adding more than %d within an hour means their labels "+
+				"churn.", series, observed, churn)),
+	}
+	if r.BodyBytes > 0 {
+		rules = append(rules, rule("ScrapeBodySizeAboveBaseline",
+			fmt.Sprintf("scrape_body_size_bytes{%s} > %d", job, withHeadroom(r.BodyBytes, o.Headroom)),
+			"Target {{ $labels.instance }} of job "+o.Job+" answers scrapes with {{ $value | humanize1024 }}B.",
+			fmt.Sprintf("The baseline is %d bytes, %s. Needs --enable-feature=extra-scrape-metrics.",
+				r.BodyBytes, observed)))
+	}
+
+	rows := r.Series.AsRows()
+	slices.SortFunc(rows, func(a, b SeriesInfo) int {
+		if d := b.Cardinality - a.Cardinality; d != 0 {
+			return d
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, f := range rows[:min(o.TopFamilies, len(rows))] {
+		name := labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, f.Name).String()
+		familyRule := rule("MetricCardinalityAboveBaseline",
+			fmt.Sprintf("count by (job, instance) ({%s, %s}) > %d",
+				name, job, withHeadroom(f.Cardinality, o.Headroom)),
+			"Metric "+f.Name+" of {{ $labels.instance }} has {{ $value }} series.",
+			fmt.Sprintf("The baseline is %d series, %s.", f.Cardinality, observed))
+		familyRule.Labels = map[string]string{"metric": f.Name}
+		maps.Copy(familyRule.Labels, ruleLabels)
+		rules = append(rules, familyRule)
+	}
+
+	return RuleGroups{Groups: []RuleGroup{{Name: "scrape-cardinality-" + o.Job, Rules: rules}}}
+}
+
+// withHeadroom returns n grown by the headroom ratio, rounded up and at least one.
+func withHeadroom(n int, headroom float64) int {
+	return max(1, int(math.Ceil(float64(n)*(1+headroom))))
+}
@@ -0,0 +1,53 @@
+package scrape_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestSuggestAlerts(t *testing.T) {
+	t.Parallel()
+	r := &scrape.Result{
+		Series: scrape.SeriesMap{
+			"requests_total": replicaSet("requests_total", 100),
+			"errors_total":   replicaSet("errors_total", 20),
+			"up":             replicaSet("up", 1),
+		},
+		Time:      time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		BodyBytes: 4000,
+	}
+
+	rules := scrape.SuggestAlerts(r, scrape.AlertOptions{
+		Job:         "api",
+		Headroom:    0.5,
+		TopFamilies: 2,
+		For:         15 * time.Minute,
+		Severity:    "warning",
+	})
+	require.Len(t, rules.Groups, 1)
+	require.Equal(t, "scrape-cardinality-api", rules.Groups[0].Name)
+
+	var exprs []string
+	for _, rule := range rules.Groups[0].Rules {
+		_, err := parser.ParseExpr(rule.Expr)
+		require.NoError(t, err, rule.Expr)
+		require.Equal(t, model.Duration(15*time.Minute), rule.For)
+		require.Equal(t, "warning", rule.Labels["severity"])
+		exprs = append(exprs, rule.Expr)
+	}
+	require.Equal(t, []string{
+		`scrape_samples_scraped{job="api"} > 182`,
+		`sum_over_time(scrape_series_added{job="api"}[1h]) > 61`,
+		`scrape_body_size_bytes{job="api"} > 6000`,
+		`count by (job, instance) ({__name__="requests_total", job="api"}) > 150`,
+		`count by (job, instance) ({__name__="errors_total", job="api"}) > 30`,
+	}, exprs)
+	require.Equal(t, "requests_total", rules.Groups[0].Rules[3].Labels["metric"])
+	require.Contains(t, rules.Groups[0].Rules[0].Annotations["description"], "observed on 2024-03-01")
+}
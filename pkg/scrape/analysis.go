@@ -0,0 +1,133 @@
+package scrape
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// analysisPrefix prefixes the names of the metrics describing an analysis.
+const analysisPrefix = "scrape_analyzer_"
+
+// AnalysisFamilies describes the analysis of a scrape as Prometheus metrics, e.g.
+// scrape_analyzer_metric_cardinality{metric="http_requests_total"} 5123, so that the cardinality of a target can
+// be trended through the textfile collector or a Pushgateway. A non-empty target is added as a label of every
+// metric. The totals cover the whole scrape, the cardinality and label values only the given metric families,
+// all of them when metrics is nil.
+func AnalysisFamilies(r *Result, target string, metrics []string) []*dto.MetricFamily {
+	var targetLabels []*dto.LabelPair
+	if target != "" {
+		targetLabels = []*dto.LabelPair{labelPair("target", target)}
+	}
+	gauge := func(name, help string, value float64) *dto.MetricFamily {
+		return &dto.MetricFamily{
+			Name: proto.String(analysisPrefix + name),
+			Help: proto.String(help),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{{
+				Label: targetLabels,
+				Gauge: &dto.Gauge{Value: proto.Float64(value)},
+			}},
+		}
+	}
+
+	series := 0
+	for _, set := range r.Series {
+		series += set.Cardinality()
+	}
+	truncated, parseFailed := 0.0, 0.0
+	if r.Truncated {
+		truncated = 1
+	}
+	if r.ParseFailed {
+		parseFailed = 1
+	}
+	families := []*dto.MetricFamily{
+		gauge("series", "Number of series exposed by the target.", float64(series)),
+		gauge("metric_families", "Number of metric families exposed by the target.", float64(len(r.Series))),
+		gauge("body_bytes", "Size of the scrape body, decompressed.", float64(r.BodyBytes)),
+		gauge("parse_failed", "Whether parsing stopped at an entry of the scrape body that failed to parse.", parseFailed),
+		gauge("truncated", "Whether parsing stopped at the series limit.", truncated),
+	}
+	if r.Timing.Total > 0 {
+		duration := &dto.MetricFamily{
+			Name: proto.String(analysisPrefix + "scrape_duration_seconds"),
+			Help: proto.String("Time spent in a phase of the scrape request, the total being the whole request."),
+			Type: dto.MetricType_GAUGE.Enum(),
+		}
+		for _, phase := range []struct {
+			name string
+			d    time.Duration
+		}{
+			{"connect", r.Timing.Connect},
+			{"dns", r.Timing.DNS},
+			{"tls", r.Timing.TLS},
+			{"total", r.Timing.Total},
+			{"ttfb", r.Timing.TTFB},
+		} {
+			duration.Metric = append(duration.Metric, &dto.Metric{
+				Label: sortedLabelPairs(targetLabels, labelPair("phase", phase.name)),
+				Gauge: &dto.Gauge{Value: proto.Float64(phase.d.Seconds())},
+			})
+		}
+		families = append(families, duration)
+	}
+	if !r.Time.IsZero() {
+		families = append(families, gauge("scrape_timestamp_seconds", "Time of the analyzed scrape.",
+			float64(r.Time.UnixMilli())/1000))
+	}
+
+	cardinality := &dto.MetricFamily{
+		Name: proto.String(analysisPrefix + "metric_cardinality"),
+		Help: proto.String("Number of series of a metric family."),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	labelValues := &dto.MetricFamily{
+		Name: proto.String(analysisPrefix + "label_values"),
+		Help: proto.String("Number of distinct values of a label of a metric family."),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	if metrics == nil {
+		metrics = slices.Collect(maps.Keys(r.Series))
+	}
+	for _, name := range slices.Sorted(slices.Values(metrics)) {
+		set, ok := r.Series[name]
+		if !ok {
+			continue
+		}
+		cardinality.Metric = append(cardinality.Metric, &dto.Metric{
+			Label: sortedLabelPairs(targetLabels, labelPair("metric", name), labelPair("type", set.MetricTypeString())),
+			Gauge: &dto.Gauge{Value: proto.Float64(float64(set.Cardinality()))},
+		})
+		stats := set.LabelStats()
+		slices.SortFunc(stats, func(a, b LabelStats) int { return strings.Compare(a.Name, b.Name) })
+		for _, l := range stats {
+			labelValues.Metric = append(labelValues.Metric, &dto.Metric{
+				Label: sortedLabelPairs(targetLabels, labelPair("label", l.Name), labelPair("metric", name)),
+				Gauge: &dto.Gauge{Value: proto.Float64(float64(l.DistinctValues))},
+			})
+		}
+	}
+	// A family without metrics cannot be encoded, e.g. when no metric family is left to describe.
+	for _, f := range []*dto.MetricFamily{cardinality, labelValues} {
+		if len(f.Metric) > 0 {
+			families = append(families, f)
+		}
+	}
+	return families
+}
+
+func labelPair(name, value string) *dto.LabelPair {
+	return &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)}
+}
+
+// sortedLabelPairs returns the label pairs of both lists sorted by name.
+func sortedLabelPairs(a []*dto.LabelPair, b ...*dto.LabelPair) []*dto.LabelPair {
+	pairs := slices.Concat(a, b)
+	slices.SortFunc(pairs, func(x, y *dto.LabelPair) int { return strings.Compare(x.GetName(), y.GetName()) })
+	return pairs
+}
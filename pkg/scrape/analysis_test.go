@@ -0,0 +1,65 @@
+package scrape_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestAnalysisFamilies(t *testing.T) {
+	t.Parallel()
+	result := &scrape.Result{
+		Series:      scrape.SeriesMap{"requests_total": replicaSet("requests_total", 3)},
+		Time:        time.Unix(1700000000, 0),
+		BodyBytes:   120,
+		ParseFailed: true,
+	}
+
+	var buf bytes.Buffer
+	families := scrape.AnalysisFamilies(result, "http://api:8080/metrics", nil)
+	require.NoError(t, scrape.EncodeFamilies(&buf, families, expfmt.NewFormat(expfmt.TypeTextPlain)))
+	out := buf.String()
+	require.Contains(t, out, `scrape_analyzer_series{target="http://api:8080/metrics"} 3`)
+	require.Contains(t, out, `scrape_analyzer_parse_failed{target="http://api:8080/metrics"} 1`)
+	require.Contains(t, out, `scrape_analyzer_scrape_timestamp_seconds{target="http://api:8080/metrics"} 1.7e+09`)
+	require.Contains(t, out,
+		`scrape_analyzer_metric_cardinality{metric="requests_total",target="http://api:8080/metrics",type="unknown"} 3`)
+	require.Contains(t, out,
+		`scrape_analyzer_label_values{label="id",metric="requests_total",target="http://api:8080/metrics"} 3`)
+
+	// The analysis can be parsed back.
+	parsed, err := scrape.DecodeFamilies(buf.Bytes(), string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+	require.NoError(t, err)
+	require.Len(t, parsed, len(families))
+}
+
+func TestAnalysisFamilies_NoMetrics(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		result  *scrape.Result
+		metrics []string
+	}{
+		"empty target": {result: &scrape.Result{Series: scrape.SeriesMap{}}},
+		"nothing left by the filters": {
+			result:  &scrape.Result{Series: scrape.SeriesMap{"requests_total": replicaSet("requests_total", 3)}},
+			metrics: []string{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			families := scrape.AnalysisFamilies(tc.result, "", tc.metrics)
+			require.NoError(t, scrape.EncodeFamilies(&buf, families, expfmt.NewFormat(expfmt.TypeTextPlain)))
+			require.NotContains(t, buf.String(), "scrape_analyzer_metric_cardinality")
+			// The totals describe the whole scrape, whatever the metric families described.
+			require.Contains(t, buf.String(),
+				fmt.Sprintf("scrape_analyzer_series %d\n", len(tc.result.Series["requests_total"])))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package scrape
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// archiveTimeFormat names the archived bodies so that they sort by scrape time.
+const archiveTimeFormat = "20060102T150405.000Z"
+
+// BodyMetadata describes an archived response body, it is written next to the body as JSON.
+type BodyMetadata struct {
+	// URL is the scraped URL, without its password.
+	URL             string      `json:"url"`
+	Time            time.Time   `json:"time"`
+	ContentType     string      `json:"content_type"`
+	ContentEncoding string      `json:"content_encoding,omitempty"`
+	Header          http.Header `json:"header"`
+	// WireBytes is the size of the body as transferred, BodyBytes once decompressed, as archived.
+	WireBytes int64  `json:"wire_bytes"`
+	BodyBytes int    `json:"body_bytes"`
+	Timing    Timing `json:"timing"`
+	// Body is the name of the file holding the body, in the directory of the metadata.
+	Body string `json:"body"`
+}
+
+// WithBodyArchive writes the body of every response to dir, created if needed, along with the response headers
+// and the timing of the request, e.g. to keep forensic copies of what an exporter served when a cardinality
+// incident started. See ArchiveBody.
+func WithBodyArchive(dir string) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.archiveDir = dir
+	}
+}
+
+// ArchiveBody writes the body of the fetch of target to dir, in a file named after the scrape time, the target
+// and an extension of its content type, and the BodyMetadata to a .json file of the same name. It returns the
+// path of the body.
+func ArchiveBody(dir, target string, fetch *Fetch, at time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create body archive directory: %w", err)
+	}
+	at = at.UTC()
+	base := at.Format(archiveTimeFormat) + "-" + archiveName(target)
+	body := base + bodyExtension(fetch.ContentType)
+
+	if err := os.WriteFile(filepath.Join(dir, body), fetch.Body, 0o644); err != nil {
+		return "", fmt.Errorf("failed to archive body: %w", err)
+	}
+	redacted := target
+	if u, err := url.Parse(target); err == nil {
+		redacted = u.Redacted()
+	}
+	meta, err := json.MarshalIndent(BodyMetadata{
+		URL:             redacted,
+		Time:            at,
+		ContentType:     fetch.ContentType,
+		ContentEncoding: fetch.ContentEncoding,
+		Header:          fetch.Header,
+		WireBytes:       fetch.WireBytes,
+		BodyBytes:       len(fetch.Body),
+		Timing:          fetch.Timing,
+		Body:            body,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, base+".json"), append(meta, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("failed to archive body metadata: %w", err)
+	}
+	return filepath.Join(dir, body), nil
+}
+
+// archiveName turns the host and path of the target into a file name, e.g. api_8080_metrics.
+func archiveName(target string) string {
+	name := target
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		name = u.Host + u.Path
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.Trim(name, "/"))
+	if name == "" {
+		return "target"
+	}
+	return name
+}
+
+// bodyExtension returns the file extension of the exposition format of the content type.
+func bodyExtension(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case protobufMediaType:
+		return ".pb"
+	case openMetricsMediaType:
+		return ".om.txt"
+	case "text/plain":
+		return ".prom"
+	default:
+		return ".body"
+	}
+}
@@ -0,0 +1,48 @@
+package scrape_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestPromScraper_BodyArchive(t *testing.T) {
+	t.Parallel()
+	const body = "# TYPE up gauge\nup 1\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Header().Set("X-Exporter-Version", "1.2.3")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	dir := filepath.Join(t.TempDir(), "bodies")
+	ps := scrape.NewPromScraper(srv.URL+"/metrics", log.NewNopLogger(), scrape.WithBodyArchive(dir))
+	_, err := ps.Scrape(context.Background())
+	require.NoError(t, err)
+
+	metas, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, metas, 1)
+	b, err := os.ReadFile(metas[0])
+	require.NoError(t, err)
+	var meta scrape.BodyMetadata
+	require.NoError(t, json.Unmarshal(b, &meta))
+	require.Equal(t, srv.URL+"/metrics", meta.URL)
+	require.Equal(t, "1.2.3", meta.Header.Get("X-Exporter-Version"))
+	require.Equal(t, len(body), meta.BodyBytes)
+	require.Equal(t, ".prom", filepath.Ext(meta.Body))
+
+	archived, err := os.ReadFile(filepath.Join(dir, meta.Body))
+	require.NoError(t, err)
+	require.Equal(t, body, string(archived))
+}
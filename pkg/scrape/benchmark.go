@@ -0,0 +1,262 @@
+package scrape
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+// BenchmarkSample is the outcome of a single benchmarked scrape.
+type BenchmarkSample struct {
+	ContentType   string
+	BodyBytes     int
+	WireBytes     int64
+	Duration      time.Duration
+	ParseDuration time.Duration
+	Err           error
+}
+
+// Benchmark scrapes the target n times with up to concurrency requests in flight, parsing every body.
+// Samples are returned in completion order, it stops early when ctx is canceled.
+func Benchmark(ctx context.Context, ps *PromScraper, n, concurrency int) []BenchmarkSample {
+	var (
+		mu      sync.Mutex
+		samples = make([]BenchmarkSample, 0, n)
+		wg      sync.WaitGroup
+		work    = make(chan struct{})
+	)
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range work {
+				sample := benchmarkOnce(ctx, ps)
+				mu.Lock()
+				samples = append(samples, sample)
+				mu.Unlock()
+			}
+		}()
+	}
+
+loop:
+	for range n {
+		select {
+		case work <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(work)
+	wg.Wait()
+	return samples
+}
+
+func benchmarkOnce(ctx context.Context, ps *PromScraper) BenchmarkSample {
+	fetch, err := ps.FetchRawWithContext(ctx)
+	if err != nil {
+		return BenchmarkSample{Err: err}
+	}
+	sample := BenchmarkSample{
+		ContentType: fetch.ContentType,
+		BodyBytes:   len(fetch.Body),
+		WireBytes:   fetch.WireBytes,
+		Duration:    fetch.Duration,
+	}
+
+	start := time.Now()
+	_, sample.Err = ps.Parse(fetch.Body, fetch.ContentType)
+	sample.ParseDuration = time.Since(start)
+	return sample
+}
+
+// LatencySummary holds the percentiles of a set of durations.
+type LatencySummary struct {
+	Min, Mean, P50, P90, P99, Max time.Duration
+}
+
+// ContentTypeSummary aggregates the bodies served with a content type.
+type ContentTypeSummary struct {
+	ContentType string
+	Scrapes     int
+	// AvgBodyBytes and AvgWireBytes are the average sizes after and before decompression.
+	AvgBodyBytes int
+	AvgWireBytes int
+}
+
+// CompressionRatio is the decompressed size over the transferred size, 1 when uncompressed.
+func (s ContentTypeSummary) CompressionRatio() float64 {
+	if s.AvgWireBytes == 0 {
+		return 1
+	}
+	return float64(s.AvgBodyBytes) / float64(s.AvgWireBytes)
+}
+
+// BenchmarkReport summarizes benchmark samples.
+type BenchmarkReport struct {
+	Scrapes  int
+	Failures int
+	// Response and Parse only account for successful scrapes.
+	Response     LatencySummary
+	Parse        LatencySummary
+	ContentTypes []ContentTypeSummary
+}
+
+// SummarizeBenchmark computes the report of the given samples.
+func SummarizeBenchmark(samples []BenchmarkSample) BenchmarkReport {
+	report := BenchmarkReport{Scrapes: len(samples)}
+
+	var response, parse []time.Duration
+	byType := make(map[string]*ContentTypeSummary)
+	for _, s := range samples {
+		if s.Err != nil {
+			report.Failures++
+			continue
+		}
+		response = append(response, s.Duration)
+		parse = append(parse, s.ParseDuration)
+
+		ct, ok := byType[s.ContentType]
+		if !ok {
+			ct = &ContentTypeSummary{ContentType: s.ContentType}
+			byType[s.ContentType] = ct
+		}
+		ct.Scrapes++
+		// Sum the sizes, they are averaged below.
+		ct.AvgBodyBytes += s.BodyBytes
+		ct.AvgWireBytes += int(s.WireBytes)
+	}
+	report.Response = summarizeLatency(response)
+	report.Parse = summarizeLatency(parse)
+
+	for _, ct := range byType {
+		ct.AvgBodyBytes /= ct.Scrapes
+		ct.AvgWireBytes /= ct.Scrapes
+		report.ContentTypes = append(report.ContentTypes, *ct)
+	}
+	slices.SortFunc(report.ContentTypes, func(a, b ContentTypeSummary) int {
+		return cmp.Or(b.Scrapes-a.Scrapes, strings.Compare(a.ContentType, b.ContentType))
+	})
+	return report
+}
+
+func summarizeLatency(durations []time.Duration) LatencySummary {
+	if len(durations) == 0 {
+		return LatencySummary{}
+	}
+	slices.Sort(durations)
+
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+	return LatencySummary{
+		Min:  durations[0],
+		Mean: sum / time.Duration(len(durations)),
+		P50:  percentile(durations, 0.5),
+		P90:  percentile(durations, 0.9),
+		P99:  percentile(durations, 0.99),
+		Max:  durations[len(durations)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// ParseFormats are the exposition formats BenchmarkParsers converts the exposition to, one per parser of the
+// scraper.
+var ParseFormats = []expfmt.Format{
+	expfmt.NewFormat(expfmt.TypeTextPlain),
+	expfmt.NewFormat(expfmt.TypeOpenMetrics),
+	expfmt.NewFormat(expfmt.TypeProtoDelim),
+}
+
+// ParseBenchmark is the cost of parsing an exposition with one of the parsers of the scraper.
+type ParseBenchmark struct {
+	ContentType string
+	BodyBytes   int
+	Series      int
+	Iterations  int
+	// NsPerSeries, AllocsPerSeries and BytesPerSeries are the time and heap allocations taken to parse a series,
+	// averaged over the iterations.
+	NsPerSeries     float64
+	AllocsPerSeries float64
+	BytesPerSeries  float64
+}
+
+// BenchmarkParsers converts the exposition to every format of ParseFormats and parses each of them iterations
+// times with ps, e.g. to track the performance of the parsers across upgrades of the Prometheus libraries. It
+// stops early when ctx is canceled.
+func BenchmarkParsers(
+	ctx context.Context,
+	ps *PromScraper,
+	body []byte,
+	contentType string,
+	iterations int,
+) ([]ParseBenchmark, error) {
+	families, err := DecodeFamilies(body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	benchmarks := make([]ParseBenchmark, 0, len(ParseFormats))
+	for _, format := range ParseFormats {
+		var buf bytes.Buffer
+		if err := EncodeFamilies(&buf, families, format); err != nil {
+			return nil, err
+		}
+		b, err := benchmarkParser(ctx, ps, buf.Bytes(), string(format), max(iterations, 1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", format, err)
+		}
+		benchmarks = append(benchmarks, b)
+	}
+	return benchmarks, nil
+}
+
+func benchmarkParser(
+	ctx context.Context,
+	ps *PromScraper,
+	body []byte,
+	contentType string,
+	iterations int,
+) (ParseBenchmark, error) {
+	b := ParseBenchmark{ContentType: contentType, BodyBytes: len(body)}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for range iterations {
+		if err := ctx.Err(); err != nil {
+			return b, err
+		}
+		result, err := ps.Parse(body, contentType)
+		if err != nil {
+			return b, err
+		}
+		b.Series = 0
+		for _, set := range result.Series {
+			b.Series += set.Cardinality()
+		}
+		b.Iterations++
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	if parsed := float64(b.Series * b.Iterations); parsed > 0 {
+		b.NsPerSeries = float64(elapsed.Nanoseconds()) / parsed
+		b.AllocsPerSeries = float64(after.Mallocs-before.Mallocs) / parsed
+		b.BytesPerSeries = float64(after.TotalAlloc-before.TotalAlloc) / parsed
+	}
+	return b, nil
+}
@@ -0,0 +1,104 @@
+package scrape_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestBenchmark(t *testing.T) {
+	t.Parallel()
+	body := strings.Repeat("# TYPE up gauge\nup 1\n", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(body))
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger())
+	samples := scrape.Benchmark(context.Background(), ps, 10, 3)
+	require.Len(t, samples, 10)
+
+	report := scrape.SummarizeBenchmark(samples)
+	require.Equal(t, 10, report.Scrapes)
+	require.Zero(t, report.Failures)
+	require.Len(t, report.ContentTypes, 1)
+
+	ct := report.ContentTypes[0]
+	require.Equal(t, "text/plain; version=0.0.4", ct.ContentType)
+	require.Equal(t, len(body), ct.AvgBodyBytes)
+	require.Less(t, ct.AvgWireBytes, ct.AvgBodyBytes)
+	require.Greater(t, ct.CompressionRatio(), 1.0)
+}
+
+func TestSummarizeBenchmark(t *testing.T) {
+	t.Parallel()
+	var samples []scrape.BenchmarkSample
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, scrape.BenchmarkSample{
+			ContentType:   "text/plain",
+			BodyBytes:     100,
+			WireBytes:     100,
+			Duration:      time.Duration(i) * time.Millisecond,
+			ParseDuration: time.Duration(i) * time.Microsecond,
+		})
+	}
+	samples = append(samples, scrape.BenchmarkSample{Err: errors.New("connection refused")})
+
+	report := scrape.SummarizeBenchmark(samples)
+	require.Equal(t, 101, report.Scrapes)
+	require.Equal(t, 1, report.Failures)
+	require.Equal(t, scrape.LatencySummary{
+		Min:  time.Millisecond,
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P90:  90 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}, report.Response)
+	require.Equal(t, 99*time.Microsecond, report.Parse.P99)
+	require.Equal(t, []scrape.ContentTypeSummary{
+		{ContentType: "text/plain", Scrapes: 100, AvgBodyBytes: 100, AvgWireBytes: 100},
+	}, report.ContentTypes)
+	require.InDelta(t, 1.0, report.ContentTypes[0].CompressionRatio(), 1e-9)
+}
+
+func TestBenchmarkParsers(t *testing.T) {
+	t.Parallel()
+	body := []byte(`# TYPE http_requests_total counter
+http_requests_total{code="200"} 10
+http_requests_total{code="500"} 1
+# TYPE up gauge
+up 1
+`)
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	benchmarks, err := scrape.BenchmarkParsers(context.Background(), ps, body, "text/plain; version=0.0.4", 3)
+	require.NoError(t, err)
+	require.Len(t, benchmarks, len(scrape.ParseFormats))
+	for i, b := range benchmarks {
+		require.Equal(t, string(scrape.ParseFormats[i]), b.ContentType)
+		require.Equal(t, 3, b.Series, b.ContentType)
+		require.Equal(t, 3, b.Iterations)
+		require.Positive(t, b.BodyBytes)
+		require.Positive(t, b.NsPerSeries)
+		require.Positive(t, b.AllocsPerSeries)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = scrape.BenchmarkParsers(ctx, ps, body, "text/plain; version=0.0.4", 3)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,130 @@
+package scrape
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// CachingScraper serves the first scrape of a target from an on-disk cache of its last result, when that result
+// is recent enough, so that invocations of the tool in a row do not scrape the target every time. The next
+// scrapes always reach the target, and every scrape refreshes the cache. The result of the run before is kept
+// too, see Previous.
+type CachingScraper struct {
+	scraper  Scraper
+	path     string
+	ttl      time.Duration
+	logger   log.Logger
+	scraped  bool
+	previous *Result
+	// rotated is set once the result cached by the run before was kept as the previous one.
+	rotated bool
+}
+
+var _ Scraper = (*CachingScraper)(nil)
+
+// NewCachingScraper caches the results of the scraper of target in dir, created if needed, for ttl. The target
+// is the cache key only, it is not logged as it may carry credentials.
+func NewCachingScraper(scraper Scraper, target, dir string, ttl time.Duration, logger log.Logger) *CachingScraper {
+	return &CachingScraper{
+		scraper: scraper,
+		path:    CachePath(dir, target),
+		ttl:     ttl,
+		logger:  logger,
+	}
+}
+
+// CachePath returns the file caching the last result of the target in dir.
+func CachePath(dir, target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// previousPath returns the file keeping the result cached before the one in path.
+func previousPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".previous.json"
+}
+
+// Scrape returns the cached result of the target on the first call if it is younger than the TTL, otherwise it
+// scrapes the target and caches the result.
+func (c *CachingScraper) Scrape(ctx context.Context) (*Result, error) {
+	var last *Result
+	if !c.scraped {
+		c.scraped = true
+		last = c.load(c.path)
+		if last != nil && c.fresh(last) {
+			c.previous = c.load(previousPath(c.path))
+			return last, nil
+		}
+	}
+
+	result, err := c.scraper.Scrape(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		c.previous = last
+	}
+	if err := c.store(result); err != nil {
+		// The cache is an optimization, the scrape is still good.
+		level.Warn(c.logger).Log("msg", "failed to cache scrape", "path", c.path, "err", err)
+	}
+	return result, nil
+}
+
+// Previous returns the result of the run of the tool before the one of the last Scrape, nil if unknown. It is
+// updated on the first Scrape only, so that a run compares against the previous run and not its own scrapes.
+func (c *CachingScraper) Previous() *Result {
+	return c.previous
+}
+
+func (c *CachingScraper) load(path string) *Result {
+	result, err := LoadResult(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		level.Warn(c.logger).Log("msg", "ignoring unreadable scrape cache", "path", path, "err", err)
+		return nil
+	}
+	return result
+}
+
+func (c *CachingScraper) fresh(result *Result) bool {
+	age := time.Since(result.Time)
+	if age > c.ttl || age < 0 {
+		return false
+	}
+	level.Info(c.logger).Log("msg", "using cached scrape", "path", c.path, "age", age.Round(time.Second))
+	return true
+}
+
+// store writes the result to a temporary file first, so that concurrent invocations never read a partial cache.
+func (c *CachingScraper) store(r *Result) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	// The result cached by the run before becomes the previous one, the later scrapes of this run replace the
+	// cached result only.
+	if !c.rotated {
+		if err := os.Rename(c.path, previousPath(c.path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		c.rotated = true
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", c.path, os.Getpid())
+	if err := SaveResult(tmp, r); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,95 @@
+package scrape_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// countingScraper returns a result with as many up series as it was called.
+type countingScraper struct {
+	calls int
+}
+
+func (c *countingScraper) Scrape(context.Context) (*scrape.Result, error) {
+	c.calls++
+	return &scrape.Result{
+		Series: scrape.SeriesMap{"up": replicaSet("up", c.calls)},
+		Time:   time.Now().Truncate(time.Millisecond),
+	}, nil
+}
+
+func TestCachingScraper(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	const target = "http://api:8080/metrics"
+	ctx := context.Background()
+
+	inner := &countingScraper{}
+	first := scrape.NewCachingScraper(inner, target, dir, time.Minute, log.NewNopLogger())
+	r, err := first.Scrape(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, r.Series["up"].Cardinality())
+
+	// A later invocation gets the cached result first, then scrapes the target.
+	second := scrape.NewCachingScraper(inner, target, dir, time.Minute, log.NewNopLogger())
+	r, err = second.Scrape(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, inner.calls)
+	require.Equal(t, 1, r.Series["up"].Cardinality())
+	r, err = second.Scrape(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, r.Series["up"].Cardinality())
+
+	// Other targets and expired results are not served from the cache.
+	other := scrape.NewCachingScraper(inner, "http://db:9104/metrics", dir, time.Minute, log.NewNopLogger())
+	r, err = other.Scrape(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, r.Series["up"].Cardinality())
+
+	expired := scrape.NewCachingScraper(inner, target, dir, 0, log.NewNopLogger())
+	r, err = expired.Scrape(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, r.Series["up"].Cardinality())
+}
+
+func TestCachingScraper_Previous(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	const target = "http://api:8080/metrics"
+	ctx := context.Background()
+	inner := &countingScraper{}
+
+	first := scrape.NewCachingScraper(inner, target, dir, 0, log.NewNopLogger())
+	_, err := first.Scrape(ctx)
+	require.NoError(t, err)
+	require.Nil(t, first.Previous())
+
+	second := scrape.NewCachingScraper(inner, target, dir, 0, log.NewNopLogger())
+	_, err = second.Scrape(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, second.Previous().Series["up"].Cardinality())
+
+	// A cached result is compared against the run before it.
+	cached := scrape.NewCachingScraper(inner, target, dir, time.Minute, log.NewNopLogger())
+	r, err := cached.Scrape(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, r.Series["up"].Cardinality())
+	require.Equal(t, 1, cached.Previous().Series["up"].Cardinality())
+
+	// The scrapes of a run replace its cached result, the previous one stays the result of the run before.
+	_, err = cached.Scrape(ctx)
+	require.NoError(t, err)
+	_, err = cached.Scrape(ctx)
+	require.NoError(t, err)
+	next := scrape.NewCachingScraper(inner, target, dir, time.Minute, log.NewNopLogger())
+	r, err = next.Scrape(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, r.Series["up"].Cardinality())
+	require.Equal(t, 2, next.Previous().Series["up"].Cardinality())
+}
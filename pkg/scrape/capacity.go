@@ -0,0 +1,97 @@
+package scrape
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBytesPerSample is the typical size of a sample once compressed in TSDB chunks, the Prometheus
+	// documentation puts it at 1-2 bytes.
+	DefaultBytesPerSample = 1.3
+	// DefaultIndexBytesPerSeries is the typical size of a series in the index of a block: its label and chunk
+	// references and its share of the postings. The symbol table is left out, label values are mostly shared.
+	DefaultIndexBytesPerSeries = 200
+	// DefaultRetention is the default retention of Prometheus.
+	DefaultRetention = 15 * 24 * time.Hour
+)
+
+// Bounds of the range of compacted blocks, Prometheus compacts up to a tenth of the retention within them.
+const (
+	minBlockRange = 2 * time.Hour
+	maxBlockRange = 31 * 24 * time.Hour
+)
+
+// CapacityModel holds the inputs of a storage estimate.
+type CapacityModel struct {
+	Interval            time.Duration
+	Retention           time.Duration
+	BytesPerSample      float64
+	IndexBytesPerSeries float64
+	// Instances multiplies the series, and so the samples, of the scrape, for a target running as several
+	// replicas serving the same series. It defaults to 1.
+	Instances int
+}
+
+// Blocks is the number of blocks the retention is stored in once compacted, every block indexes its series again.
+func (m CapacityModel) Blocks() int {
+	blockRange := min(max(m.Retention/10, minBlockRange), maxBlockRange)
+	return max(int((m.Retention+blockRange-1)/blockRange), 1)
+}
+
+// FamilyCapacity is the estimated storage of a metric family over the retention.
+type FamilyCapacity struct {
+	Name          string
+	Series        int
+	SamplesPerDay float64
+	// ChunkBytes is the size of the samples kept over the retention, IndexBytes the size of the index of
+	// the series in every block.
+	ChunkBytes float64
+	IndexBytes float64
+}
+
+// StorageBytes is the size of the family on disk over the retention.
+func (f FamilyCapacity) StorageBytes() float64 {
+	return f.ChunkBytes + f.IndexBytes
+}
+
+// CapacityReport is the estimated storage of the series of a scrape.
+type CapacityReport struct {
+	Model CapacityModel
+	// Families is sorted by storage, the largest first.
+	Families []FamilyCapacity
+	Total    FamilyCapacity
+}
+
+// EstimateCapacity estimates the samples per day and the storage over the retention of the series of a scrape
+// collected as the model describes, the series being stable over the retention, without churn.
+func EstimateCapacity(sm SeriesMap, model CapacityModel) CapacityReport {
+	model.Instances = max(model.Instances, 1)
+	report := CapacityReport{Model: model}
+	samplesPerSeries := float64(24*time.Hour) / float64(model.Interval)
+	days := model.Retention.Hours() / 24
+	blocks := float64(model.Blocks())
+
+	for name, set := range sm {
+		series := set.Cardinality() * model.Instances
+		fc := FamilyCapacity{
+			Name:          name,
+			Series:        series,
+			SamplesPerDay: float64(series) * samplesPerSeries,
+		}
+		fc.ChunkBytes = fc.SamplesPerDay * days * model.BytesPerSample
+		fc.IndexBytes = float64(series) * model.IndexBytesPerSeries * blocks
+		report.Families = append(report.Families, fc)
+
+		report.Total.Series += fc.Series
+		report.Total.SamplesPerDay += fc.SamplesPerDay
+		report.Total.ChunkBytes += fc.ChunkBytes
+		report.Total.IndexBytes += fc.IndexBytes
+	}
+	slices.SortFunc(report.Families, func(a, b FamilyCapacity) int {
+		return cmp.Or(cmp.Compare(b.StorageBytes(), a.StorageBytes()), strings.Compare(a.Name, b.Name))
+	})
+	return report
+}
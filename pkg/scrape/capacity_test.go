@@ -0,0 +1,57 @@
+package scrape_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestEstimateCapacity(t *testing.T) {
+	t.Parallel()
+	sm := scrape.SeriesMap{
+		"requests_total": replicaSet("requests_total", 900),
+		"up":             replicaSet("up", 100),
+	}
+	model := scrape.CapacityModel{
+		Interval:            15 * time.Second,
+		Retention:           10 * 24 * time.Hour,
+		Instances:           2,
+		BytesPerSample:      2,
+		IndexBytesPerSeries: 100,
+	}
+
+	// 10 days are compacted in blocks of a day.
+	require.Equal(t, 10, model.Blocks())
+	report := scrape.EstimateCapacity(sm, model)
+	require.Equal(t, 2000, report.Total.Series)
+	require.InDelta(t, 2000*5760.0, report.Total.SamplesPerDay, 1e-6)
+	require.InDelta(t, 2000*5760*10*2.0, report.Total.ChunkBytes, 1e-6)
+	require.InDelta(t, 2000*100*10.0, report.Total.IndexBytes, 1e-6)
+	require.Equal(t, "requests_total", report.Families[0].Name)
+	require.InDelta(t, 0.9*report.Total.StorageBytes(), report.Families[0].StorageBytes(), 1e-6)
+
+	// Scraping half as often halves the samples, not the index.
+	model.Interval = 30 * time.Second
+	slower := scrape.EstimateCapacity(sm, model)
+	require.InDelta(t, report.Total.SamplesPerDay/2, slower.Total.SamplesPerDay, 1e-6)
+	require.InDelta(t, report.Total.IndexBytes, slower.Total.IndexBytes, 1e-6)
+}
+
+func TestCapacityModel_Blocks(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		retention time.Duration
+		blocks    int
+	}{
+		{retention: time.Hour, blocks: 1},
+		{retention: 12 * time.Hour, blocks: 6},
+		{retention: scrape.DefaultRetention, blocks: 10},
+		{retention: 365 * 24 * time.Hour, blocks: 12},
+	} {
+		model := scrape.CapacityModel{Retention: tc.retention}
+		require.Equal(t, tc.blocks, model.Blocks(), tc.retention)
+	}
+}
@@ -0,0 +1,161 @@
+package scrape
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/testutil/promlint"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Kinds of checks.
+const (
+	LintCheck       = "lint"
+	BudgetCheck     = "budget"
+	ConventionCheck = "conventions"
+)
+
+// Severities of a problem.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Rules of the lint and budget checks, the convention rules have IDs of their own.
+const (
+	PromlintRule        = "promlint"
+	MaxSeriesRule       = "max-series"
+	MaxFamilySeriesRule = "max-family-series"
+	MaxLabelValuesRule  = "max-label-values"
+)
+
+// Problem is a problem found by a check.
+type Problem struct {
+	// Rule is the rule that found the problem.
+	Rule     string
+	Severity string
+	Text     string
+	// Label is the label the problem is about, empty when it is about the metric.
+	Label string
+}
+
+// CheckResult is the outcome of a check of a metric family.
+type CheckResult struct {
+	// Check is the kind of check, LintCheck, BudgetCheck or ConventionCheck.
+	Check string
+	// Metric is the metric family checked, empty for the checks of the whole scrape.
+	Metric string
+	// Problems holds the problems found, empty when the check passed.
+	Problems []Problem
+}
+
+// Failed reports whether the check found a problem of error severity, warnings do not fail a check.
+func (c CheckResult) Failed() bool {
+	return slices.ContainsFunc(c.Problems, func(p Problem) bool { return p.Severity == SeverityError })
+}
+
+// MergeResults merges the results of the same check of the same metric family, e.g. of the lint rules run
+// separately. The results are kept in the order they first appear.
+func MergeResults(results []CheckResult) []CheckResult {
+	type key struct{ check, metric string }
+	index := make(map[key]int, len(results))
+	merged := make([]CheckResult, 0, len(results))
+	for _, r := range results {
+		k := key{r.Check, r.Metric}
+		if i, ok := index[k]; ok {
+			merged[i].Problems = append(merged[i].Problems, r.Problems...)
+			continue
+		}
+		index[k] = len(merged)
+		r.Problems = slices.Clone(r.Problems)
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// Lint checks every metric family with the linter of `promtool check metrics`, e.g. for missing help, counters
+// without the _total suffix or non base units. There is one result per family, in name order.
+func Lint(families []*dto.MetricFamily) ([]CheckResult, error) {
+	problems, err := promlint.NewWithMetricFamilies(families).Lint()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lint metrics: %w", err)
+	}
+	byMetric := make(map[string][]Problem, len(families))
+	for _, mf := range families {
+		byMetric[mf.GetName()] = nil
+	}
+	for _, p := range problems {
+		byMetric[p.Metric] = append(byMetric[p.Metric], Problem{Rule: PromlintRule, Severity: SeverityError, Text: p.Text})
+	}
+
+	results := make([]CheckResult, 0, len(byMetric))
+	for _, name := range slices.Sorted(maps.Keys(byMetric)) {
+		results = append(results, CheckResult{Check: LintCheck, Metric: name, Problems: byMetric[name]})
+	}
+	return results, nil
+}
+
+// Budget bounds the cardinality of a target, zero disables a bound.
+type Budget struct {
+	// MaxSeries bounds the series of the whole scrape.
+	MaxSeries int
+	// MaxFamilySeries bounds the series of every metric family.
+	MaxFamilySeries int
+	// MaxLabelValues bounds the distinct values of every label of a metric family.
+	MaxLabelValues int
+}
+
+// CheckBudget checks the scrape against the budget: one result for the whole scrape when MaxSeries is set,
+// then one per family in name order.
+func CheckBudget(sm SeriesMap, b Budget) []CheckResult {
+	var results []CheckResult
+	if b.MaxSeries > 0 {
+		total := CheckResult{Check: BudgetCheck}
+		series := 0
+		for _, set := range sm {
+			series += set.Cardinality()
+		}
+		if series > b.MaxSeries {
+			total.Problems = append(total.Problems, Problem{
+				Rule:     MaxSeriesRule,
+				Severity: SeverityError,
+				Text:     fmt.Sprintf("%d series exceed the budget of %d", series, b.MaxSeries),
+			})
+		}
+		results = append(results, total)
+	}
+	if b.MaxFamilySeries <= 0 && b.MaxLabelValues <= 0 {
+		return results
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(sm)) {
+		set := sm[name]
+		result := CheckResult{Check: BudgetCheck, Metric: name}
+		if b.MaxFamilySeries > 0 && set.Cardinality() > b.MaxFamilySeries {
+			result.Problems = append(result.Problems, Problem{
+				Rule:     MaxFamilySeriesRule,
+				Severity: SeverityError,
+				Text:     fmt.Sprintf("%d series exceed the budget of %d per metric", set.Cardinality(), b.MaxFamilySeries),
+			})
+		}
+		if b.MaxLabelValues > 0 {
+			stats := set.LabelStats()
+			slices.SortFunc(stats, func(a, b LabelStats) int { return strings.Compare(a.Name, b.Name) })
+			for _, l := range stats {
+				if int(l.DistinctValues) > b.MaxLabelValues {
+					result.Problems = append(result.Problems, Problem{
+						Rule:     MaxLabelValuesRule,
+						Severity: SeverityError,
+						Text: fmt.Sprintf("label %s has %d values, over the budget of %d",
+							l.Name, l.DistinctValues, b.MaxLabelValues),
+						Label: l.Name,
+					})
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,83 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+	families, err := scrape.DecodeFamilies([]byte(`# HELP requests Requests served.
+# TYPE requests counter
+requests 1
+# HELP up Whether the target is up.
+# TYPE up gauge
+up 1
+`), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+
+	results, err := scrape.Lint(families)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "requests", results[0].Metric)
+	require.True(t, results[0].Failed())
+	require.Equal(t, scrape.PromlintRule, results[0].Problems[0].Rule)
+	require.Contains(t, results[0].Problems[0].Text, "_total")
+	require.Equal(t, scrape.CheckResult{Check: scrape.LintCheck, Metric: "up"}, results[1])
+}
+
+func TestCheckBudget(t *testing.T) {
+	t.Parallel()
+	sm := scrape.SeriesMap{
+		"requests_total": replicaSet("requests_total", 50),
+		"up":             replicaSet("up", 1),
+	}
+
+	require.Empty(t, scrape.CheckBudget(sm, scrape.Budget{}))
+
+	results := scrape.CheckBudget(sm, scrape.Budget{MaxSeries: 40, MaxFamilySeries: 20, MaxLabelValues: 10})
+	require.Len(t, results, 3)
+	require.Equal(t, scrape.CheckResult{
+		Check: scrape.BudgetCheck,
+		Problems: []scrape.Problem{{
+			Rule:     scrape.MaxSeriesRule,
+			Severity: scrape.SeverityError,
+			Text:     "51 series exceed the budget of 40",
+		}},
+	}, results[0])
+	require.Equal(t, "requests_total", results[1].Metric)
+	require.Equal(t, []scrape.Problem{
+		{
+			Rule:     scrape.MaxFamilySeriesRule,
+			Severity: scrape.SeverityError,
+			Text:     "50 series exceed the budget of 20 per metric",
+		},
+		{
+			Rule:     scrape.MaxLabelValuesRule,
+			Severity: scrape.SeverityError,
+			Text:     "label id has 50 values, over the budget of 10",
+			Label:    "id",
+		},
+	}, results[1].Problems)
+	require.False(t, results[2].Failed())
+}
+
+func TestMergeResults(t *testing.T) {
+	t.Parallel()
+	promlint := scrape.Problem{Rule: scrape.PromlintRule, Severity: scrape.SeverityError, Text: "no help text"}
+	long := scrape.Problem{Rule: scrape.LongLabelValuesRule, Severity: scrape.SeverityWarning, Text: "long", Label: "url"}
+	merged := scrape.MergeResults([]scrape.CheckResult{
+		{Check: scrape.LintCheck, Metric: "a", Problems: []scrape.Problem{promlint}},
+		{Check: scrape.LintCheck, Metric: "b"},
+		{Check: scrape.LintCheck, Metric: "a", Problems: []scrape.Problem{long}},
+		{Check: scrape.BudgetCheck, Metric: "a"},
+	})
+	require.Equal(t, []scrape.CheckResult{
+		{Check: scrape.LintCheck, Metric: "a", Problems: []scrape.Problem{promlint, long}},
+		{Check: scrape.LintCheck, Metric: "b"},
+		{Check: scrape.BudgetCheck, Metric: "a"},
+	}, merged)
+}
@@ -0,0 +1,240 @@
+package scrape
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// Categories of the churn of a metric family.
+const (
+	// ChurnRollout is churn where new series replace vanished ones, e.g. on version bumps or pod restarts.
+	ChurnRollout = "rollout"
+	// ChurnGrowth is churn where new series add up, e.g. with a label holding user IDs.
+	ChurnGrowth = "growth"
+)
+
+// FamilyChurn counts the lifecycle events observed for the series of a metric family across scrapes.
+type FamilyChurn struct {
+	Name string
+	Type string
+	// Added is the number of series that appeared after the first scrape.
+	Added int
+	// Replaced is the number of the added series that replaced a series vanished in the same scrape, matched by
+	// their labels differing in a single value.
+	Replaced int
+	// ChangedLabels counts the labels whose value changed between the replaced series and their replacements.
+	ChangedLabels map[string]int
+	// Vanished is the number of series that disappeared, they would go stale in Prometheus.
+	Vanished int
+	// Reappeared is the number of series that came back after having vanished.
+	Reappeared int
+	// CounterResets is the number of times a cumulative series decreased.
+	CounterResets int
+	// Growth is the difference between the series of the family in the last and the first scrape it was seen in.
+	Growth int
+}
+
+// Total returns the number of events recorded for the family.
+func (f FamilyChurn) Total() int {
+	return f.Added + f.Vanished + f.Reappeared + f.CounterResets
+}
+
+// Category returns ChurnGrowth when series that replaced none appeared and the family grew, ChurnRollout when
+// series were replaced, and empty otherwise. Raw added and vanished counts conflate both.
+func (f FamilyChurn) Category() string {
+	switch {
+	case f.Added > f.Replaced && f.Growth > 0:
+		return ChurnGrowth
+	case f.Replaced > 0:
+		return ChurnRollout
+	default:
+		return ""
+	}
+}
+
+// ChurnTracker compares consecutive scrapes of the same target.
+type ChurnTracker struct {
+	prev     SeriesMap
+	vanished map[string]map[uint64]struct{}
+	families map[string]*FamilyChurn
+	// first is the cardinality of every family in the first scrape it was seen in.
+	first   map[string]int
+	scrapes int
+}
+
+// NewChurnTracker returns a tracker that has not observed any scrape yet.
+func NewChurnTracker() *ChurnTracker {
+	return &ChurnTracker{
+		vanished: make(map[string]map[uint64]struct{}),
+		families: make(map[string]*FamilyChurn),
+		first:    make(map[string]int),
+	}
+}
+
+// Scrapes returns the number of scrapes observed.
+func (c *ChurnTracker) Scrapes() int {
+	return c.scrapes
+}
+
+// Observe compares a scrape against the previous one and records the lifecycle events found.
+func (c *ChurnTracker) Observe(sm SeriesMap) {
+	defer func() {
+		c.prev = sm
+		c.scrapes++
+	}()
+	for name, set := range sm {
+		if _, ok := c.first[name]; !ok {
+			c.first[name] = set.Cardinality()
+		}
+	}
+	if c.prev == nil {
+		return
+	}
+
+	added := make(map[string][]Series)
+	gone := make(map[string][]Series)
+	for name, set := range sm {
+		prevSet := c.prev[name]
+		for hash, series := range set {
+			prevSeries, existed := prevSet[hash]
+			if !existed {
+				f := c.family(name, series.Type)
+				if _, ok := c.vanished[name][hash]; ok {
+					f.Reappeared++
+					delete(c.vanished[name], hash)
+				} else {
+					f.Added++
+					added[name] = append(added[name], series)
+				}
+				continue
+			}
+			if isCumulative(series) && series.Value < prevSeries.Value {
+				c.family(name, series.Type).CounterResets++
+			}
+		}
+	}
+
+	for name, prevSet := range c.prev {
+		set := sm[name]
+		for hash, series := range prevSet {
+			if _, ok := set[hash]; ok {
+				continue
+			}
+			c.family(name, series.Type).Vanished++
+			gone[name] = append(gone[name], series)
+			if _, ok := c.vanished[name]; !ok {
+				c.vanished[name] = make(map[uint64]struct{})
+			}
+			c.vanished[name][hash] = struct{}{}
+		}
+	}
+
+	for name, series := range added {
+		changed := matchReplacements(series, gone[name])
+		if len(changed) == 0 {
+			continue
+		}
+		f := c.families[name]
+		f.Replaced += len(changed)
+		if f.ChangedLabels == nil {
+			f.ChangedLabels = make(map[string]int)
+		}
+		for _, label := range changed {
+			f.ChangedLabels[label]++
+		}
+	}
+}
+
+// Report returns the churn of every family with at least one event, most churning first.
+func (c *ChurnTracker) Report() []FamilyChurn {
+	report := make([]FamilyChurn, 0, len(c.families))
+	for name, f := range c.families {
+		churn := *f
+		churn.Growth = c.prev[name].Cardinality() - c.first[name]
+		churn.ChangedLabels = maps.Clone(f.ChangedLabels)
+		report = append(report, churn)
+	}
+	slices.SortFunc(report, func(i, j FamilyChurn) int {
+		if d := j.Total() - i.Total(); d != 0 {
+			return d
+		}
+		return strings.Compare(i.Name, j.Name)
+	})
+	return report
+}
+
+func (c *ChurnTracker) family(name, typ string) *FamilyChurn {
+	f, ok := c.families[name]
+	if !ok {
+		f = &FamilyChurn{Name: name, Type: typ}
+		c.families[name] = f
+	}
+	return f
+}
+
+// isCumulative reports whether the series value is expected to only go up between resets.
+func isCumulative(s Series) bool {
+	switch s.Type {
+	case "counter":
+		return true
+	case "histogram", "summary":
+		return strings.HasSuffix(s.Name, "_count") ||
+			strings.HasSuffix(s.Name, "_sum") ||
+			strings.HasSuffix(s.Name, "_bucket")
+	default:
+		return false
+	}
+}
+
+// replacementKey identifies a series but for the value of one of its labels.
+type replacementKey struct {
+	metric string
+	label  string
+	hash   uint64
+}
+
+// matchReplacements pairs the series added to a family with the series that vanished from it in the same scrape
+// and differ from them by the value of a single label, each vanished series replaced at most once. It returns
+// the changed label of every pair.
+func matchReplacements(added, vanished []Series) []string {
+	candidates := make(map[replacementKey][]int)
+	for i, s := range vanished {
+		for _, key := range replacementKeys(s) {
+			candidates[key] = append(candidates[key], i)
+		}
+	}
+	replaced := make([]bool, len(vanished))
+
+	var changed []string
+	for _, s := range added {
+	keys:
+		for _, key := range replacementKeys(s) {
+			for _, i := range candidates[key] {
+				if !replaced[i] {
+					replaced[i] = true
+					changed = append(changed, key.label)
+					break keys
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// replacementKeys returns a key per label of the series, the metric name excluded, hashing the others.
+func replacementKeys(s Series) []replacementKey {
+	keys := make([]replacementKey, 0, s.Labels.Len())
+	var buf []byte
+	s.Labels.Range(func(l labels.Label) {
+		if l.Name == labels.MetricName {
+			return
+		}
+		var hash uint64
+		hash, buf = s.Labels.HashWithoutLabels(buf, l.Name)
+		keys = append(keys, replacementKey{metric: s.Name, label: l.Name, hash: hash})
+	})
+	return keys
+}
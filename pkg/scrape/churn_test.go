@@ -0,0 +1,80 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestChurnTracker(t *testing.T) {
+	t.Parallel()
+	counter := func(v float64) scrape.Series {
+		return scrape.Series{Name: "requests_total", Type: "counter", Value: v}
+	}
+	gauge := scrape.Series{Name: "queue_size", Type: "gauge", Value: 1}
+
+	c := scrape.NewChurnTracker()
+	c.Observe(scrape.SeriesMap{
+		"requests_total": {1: counter(10), 2: counter(5)},
+		"queue_size":     {1: gauge},
+	})
+	c.Observe(scrape.SeriesMap{
+		"requests_total": {1: counter(2), 2: counter(6), 3: counter(1)},
+	})
+	c.Observe(scrape.SeriesMap{
+		"requests_total": {1: counter(3), 2: counter(7), 3: counter(2)},
+		"queue_size":     {1: gauge},
+	})
+
+	require.Equal(t, 3, c.Scrapes())
+	require.Equal(t, []scrape.FamilyChurn{
+		{Name: "queue_size", Type: "gauge", Vanished: 1, Reappeared: 1},
+		{Name: "requests_total", Type: "counter", Added: 1, CounterResets: 1, Growth: 1},
+	}, c.Report())
+}
+
+func TestChurnTracker_Category(t *testing.T) {
+	t.Parallel()
+	series := func(lbls ...string) scrape.Series {
+		lset := labels.FromStrings(lbls...)
+		return scrape.Series{Name: lset.Get("__name__"), Type: "gauge", Labels: lset}
+	}
+	set := func(series ...scrape.Series) scrape.SeriesSet {
+		s := make(scrape.SeriesSet, len(series))
+		for _, v := range series {
+			s[v.Labels.Hash()] = v
+		}
+		return s
+	}
+
+	c := scrape.NewChurnTracker()
+	c.Observe(scrape.SeriesMap{
+		"build_info": set(series("__name__", "build_info", "pod", "api-0", "version", "1.0")),
+		"sessions":   set(series("__name__", "sessions", "user", "a")),
+	})
+	c.Observe(scrape.SeriesMap{
+		"build_info": set(series("__name__", "build_info", "pod", "api-0", "version", "1.1")),
+		"sessions":   set(series("__name__", "sessions", "user", "a"), series("__name__", "sessions", "user", "b")),
+	})
+	c.Observe(scrape.SeriesMap{
+		"build_info": set(series("__name__", "build_info", "pod", "api-1", "version", "1.1")),
+		"sessions": set(series("__name__", "sessions", "user", "a"), series("__name__", "sessions", "user", "b"),
+			series("__name__", "sessions", "user", "c")),
+	})
+
+	report := c.Report()
+	require.Equal(t, scrape.FamilyChurn{
+		Name:          "build_info",
+		Type:          "gauge",
+		Added:         2,
+		Replaced:      2,
+		ChangedLabels: map[string]int{"pod": 1, "version": 1},
+		Vanished:      2,
+	}, report[0])
+	require.Equal(t, scrape.ChurnRollout, report[0].Category())
+	require.Equal(t, scrape.FamilyChurn{Name: "sessions", Type: "gauge", Added: 2, Growth: 2}, report[1])
+	require.Equal(t, scrape.ChurnGrowth, report[1].Category())
+}
@@ -0,0 +1,64 @@
+package scrape
+
+import "strings"
+
+// Categories of metric families, the standard instrumentation of the Prometheus client libraries apart from the
+// metrics of the application.
+const (
+	CategoryGoRuntime   = "go runtime"
+	CategoryProcess     = "process"
+	CategoryPromHTTP    = "promhttp"
+	CategoryBuildInfo   = "build info"
+	CategoryApplication = "application"
+)
+
+// Categories lists the categories of metric families, the application last.
+var Categories = []string{CategoryGoRuntime, CategoryProcess, CategoryPromHTTP, CategoryBuildInfo, CategoryApplication}
+
+// Classify returns the category of a metric family from its name: the Go runtime and process collectors, the
+// promhttp handler metrics, the build info metrics exposed by client libraries and applications alike, and the
+// metrics of the application.
+func Classify(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_build_info"):
+		return CategoryBuildInfo
+	case strings.HasPrefix(name, "go_"):
+		return CategoryGoRuntime
+	case strings.HasPrefix(name, "process_"):
+		return CategoryProcess
+	case strings.HasPrefix(name, "promhttp_"):
+		return CategoryPromHTTP
+	default:
+		return CategoryApplication
+	}
+}
+
+// CategoryTotal is the number of metric families and series of a category.
+type CategoryTotal struct {
+	Category string
+	Families int
+	Series   int
+}
+
+// CategoryTotals sums the metric families and series of the scrape per category, in the order of Categories.
+// The categories without metrics are left out.
+func (s SeriesMap) CategoryTotals() []CategoryTotal {
+	totals := make(map[string]*CategoryTotal)
+	for name, set := range s {
+		c := Classify(name)
+		t, ok := totals[c]
+		if !ok {
+			t = &CategoryTotal{Category: c}
+			totals[c] = t
+		}
+		t.Families++
+		t.Series += set.Cardinality()
+	}
+	result := make([]CategoryTotal, 0, len(totals))
+	for _, c := range Categories {
+		if t, ok := totals[c]; ok {
+			result = append(result, *t)
+		}
+	}
+	return result
+}
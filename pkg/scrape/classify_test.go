@@ -0,0 +1,40 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+	for name, category := range map[string]string{
+		"go_gc_duration_seconds_count":   scrape.CategoryGoRuntime,
+		"go_memstats_alloc_bytes":        scrape.CategoryGoRuntime,
+		"go_build_info":                  scrape.CategoryBuildInfo,
+		"prometheus_build_info":          scrape.CategoryBuildInfo,
+		"process_resident_memory_bytes":  scrape.CategoryProcess,
+		"promhttp_metric_handler_errors": scrape.CategoryPromHTTP,
+		"http_requests_total":            scrape.CategoryApplication,
+		"gopher_count":                   scrape.CategoryApplication,
+	} {
+		require.Equal(t, category, scrape.Classify(name), name)
+	}
+}
+
+func TestSeriesMap_CategoryTotals(t *testing.T) {
+	t.Parallel()
+	sm := scrape.SeriesMap{
+		"go_goroutines":                 replicaSet("go_goroutines", 1),
+		"go_gc_duration_seconds":        replicaSet("go_gc_duration_seconds", 5),
+		"process_resident_memory_bytes": replicaSet("process_resident_memory_bytes", 1),
+		"http_requests_total":           replicaSet("http_requests_total", 10),
+	}
+	require.Equal(t, []scrape.CategoryTotal{
+		{Category: scrape.CategoryGoRuntime, Families: 2, Series: 6},
+		{Category: scrape.CategoryProcess, Families: 1, Series: 1},
+		{Category: scrape.CategoryApplication, Families: 1, Series: 10},
+	}, sm.CategoryTotals())
+}
@@ -0,0 +1,90 @@
+package scrape
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// LabelCollision is a label that Prometheus sets on the ingested series, as a target or an external label, and
+// that the scraped series already carry.
+type LabelCollision struct {
+	Name string
+	// Value is the value Prometheus sets.
+	Value string
+	// External is set for external labels. They never override the labels of a series, honor_labels or not.
+	External bool
+	// Series is the number of scraped series carrying the label, SameValue the ones carrying the value
+	// Prometheus sets.
+	Series    int
+	SameValue int
+	// Values is the number of distinct values scraped.
+	Values int
+	// Families are the metric families carrying the label, in name order.
+	Families []string
+}
+
+// ExportedName returns the label the scraped values are renamed to under honor_labels: false.
+func (c LabelCollision) ExportedName() string {
+	return model.ExportedLabelPrefix + c.Name
+}
+
+// LabelCollisions returns the target and external labels the scraped series already carry, most series first.
+// Under honor_labels: false the scraped values are renamed to exported_<name>, adding a label with as many values
+// to the ingested series. Under honor_labels: true the scraped values win and the target value is lost, so that
+// the series of targets exposing the same values end up colliding. An external label also set as target label
+// is ignored, the target label wins.
+func LabelCollisions(sm SeriesMap, target, external labels.Labels) []LabelCollision {
+	set := make(map[string]LabelCollision)
+	target.Range(func(l labels.Label) {
+		set[l.Name] = LabelCollision{Name: l.Name, Value: l.Value}
+	})
+	external.Range(func(l labels.Label) {
+		if _, ok := set[l.Name]; !ok {
+			set[l.Name] = LabelCollision{Name: l.Name, Value: l.Value, External: true}
+		}
+	})
+
+	values := make(map[string]map[string]struct{})
+	families := make(map[string]map[string]struct{})
+	for family, series := range sm {
+		for _, s := range series {
+			s.Labels.Range(func(l labels.Label) {
+				c, ok := set[l.Name]
+				if !ok {
+					return
+				}
+				c.Series++
+				if l.Value == c.Value {
+					c.SameValue++
+				}
+				set[l.Name] = c
+				if values[l.Name] == nil {
+					values[l.Name] = make(map[string]struct{})
+					families[l.Name] = make(map[string]struct{})
+				}
+				values[l.Name][l.Value] = struct{}{}
+				families[l.Name][family] = struct{}{}
+			})
+		}
+	}
+
+	collisions := make([]LabelCollision, 0, len(values))
+	for name := range values {
+		c := set[name]
+		c.Values = len(values[name])
+		c.Families = slices.Sorted(maps.Keys(families[name]))
+		collisions = append(collisions, c)
+	}
+	slices.SortFunc(collisions, func(a, b LabelCollision) int {
+		if c := cmp.Compare(b.Series, a.Series); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return collisions
+}
@@ -0,0 +1,56 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestLabelCollisions(t *testing.T) {
+	t.Parallel()
+	set := func(lsets ...labels.Labels) scrape.SeriesSet {
+		s := make(scrape.SeriesSet, len(lsets))
+		for _, lset := range lsets {
+			s[lset.Hash()] = scrape.Series{Name: lset.Get("__name__"), Labels: lset}
+		}
+		return s
+	}
+	sm := scrape.SeriesMap{
+		"up": set(
+			labels.FromStrings("__name__", "up", "instance", "db-0:9104", "job", "mysql"),
+			labels.FromStrings("__name__", "up", "instance", "db-1:9104", "job", "mysql"),
+		),
+		"federated_requests_total": set(
+			labels.FromStrings("__name__", "federated_requests_total", "cluster", "eu-1", "job", "exporter"),
+		),
+		"requests_total": set(labels.FromStrings("__name__", "requests_total", "path", "/")),
+	}
+
+	collisions := scrape.LabelCollisions(sm,
+		labels.FromStrings("instance", "exporter:9100", "job", "exporter"),
+		labels.FromStrings("cluster", "eu-2", "job", "global"),
+	)
+	require.Equal(t, []scrape.LabelCollision{
+		{
+			Name:      "job",
+			Value:     "exporter",
+			Series:    3,
+			SameValue: 1,
+			Values:    2,
+			Families:  []string{"federated_requests_total", "up"},
+		},
+		{Name: "instance", Value: "exporter:9100", Series: 2, Values: 2, Families: []string{"up"}},
+		{
+			Name:     "cluster",
+			Value:    "eu-2",
+			External: true,
+			Series:   1,
+			Values:   1,
+			Families: []string{"federated_requests_total"},
+		},
+	}, collisions)
+	require.Equal(t, "exported_instance", collisions[1].ExportedName())
+}
@@ -0,0 +1,91 @@
+package scrape
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Content encodings of the scrape requests, zstd is not supported by the scraper yet.
+const (
+	EncodingIdentity = "identity"
+	EncodingGzip     = "gzip"
+)
+
+// Encodings are the content encodings MeasureEncodings compares by default.
+var Encodings = []string{EncodingIdentity, EncodingGzip}
+
+// EncodingSize is the size of the body of a target in a content encoding.
+type EncodingSize struct {
+	Encoding string
+	// Served is set when the target answered in the encoding. WireBytes is then the size it transferred,
+	// otherwise the size of the body compressed by the analyzer with the default settings of the encoding.
+	Served    bool
+	WireBytes int64
+	BodyBytes int
+	// Duration is the time of the request, from sending it to reading the whole body.
+	Duration time.Duration
+}
+
+// Ratio returns how many times smaller than the body the transferred bytes are.
+func (e EncodingSize) Ratio() float64 {
+	if e.WireBytes == 0 {
+		return 0
+	}
+	return float64(e.BodyBytes) / float64(e.WireBytes)
+}
+
+// MeasureEncodings fetches the target once per encoding, asking for it in the Accept-Encoding header, and
+// reports the size of the body in each. The encodings the target does not serve are measured by compressing
+// the body locally, to tell whether enabling them on the handler would be worth it.
+func (ps *PromScraper) MeasureEncodings(ctx context.Context, encodings ...string) ([]EncodingSize, error) {
+	sizes := make([]EncodingSize, 0, len(encodings))
+	for _, encoding := range encodings {
+		scraper := *ps
+		scraper.acceptEncoding = encoding
+		fetch, err := scraper.FetchRawWithContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the target with %s encoding: %w", encoding, err)
+		}
+		size := EncodingSize{
+			Encoding:  encoding,
+			WireBytes: fetch.WireBytes,
+			BodyBytes: len(fetch.Body),
+			Duration:  fetch.Duration,
+		}
+		served := fetch.ContentEncoding
+		if served == "" {
+			served = EncodingIdentity
+		}
+		size.Served = served == encoding
+		if !size.Served {
+			if size.WireBytes, err = compressedSize(fetch.Body, encoding); err != nil {
+				return nil, err
+			}
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+// compressedSize returns the size of body compressed with encoding.
+func compressedSize(body []byte, encoding string) (int64, error) {
+	switch encoding {
+	case EncodingIdentity:
+		return int64(len(body)), nil
+	case EncodingGzip:
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(body); err != nil {
+			return 0, err
+		}
+		if err := gz.Close(); err != nil {
+			return 0, err
+		}
+		return int64(buf.Len()), nil
+	default:
+		return 0, fmt.Errorf("unsupported encoding %q, expected one of %v", encoding, Encodings)
+	}
+}
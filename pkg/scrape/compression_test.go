@@ -0,0 +1,59 @@
+package scrape_test
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestPromScraper_MeasureEncodings(t *testing.T) {
+	t.Parallel()
+	body := strings.Repeat("http_requests_total{code=\"200\",path=\"/api/v1/query\"} 1\n", 100)
+	for _, tc := range []struct {
+		name     string
+		compress bool
+	}{
+		{name: "gzip handler", compress: true},
+		{name: "uncompressed handler"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+				if !tc.compress || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+					_, _ = w.Write([]byte(body))
+					return
+				}
+				w.Header().Set("Content-Encoding", "gzip")
+				gz := gzip.NewWriter(w)
+				_, _ = gz.Write([]byte(body))
+				_ = gz.Close()
+			}))
+			defer srv.Close()
+
+			ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger())
+			sizes, err := ps.MeasureEncodings(context.Background(), scrape.Encodings...)
+			require.NoError(t, err)
+			require.Len(t, sizes, 2)
+
+			identity, gz := sizes[0], sizes[1]
+			require.Equal(t, scrape.EncodingIdentity, identity.Encoding)
+			require.True(t, identity.Served)
+			require.Equal(t, int64(len(body)), identity.WireBytes)
+			require.InDelta(t, 1, identity.Ratio(), 0.001)
+
+			require.Equal(t, scrape.EncodingGzip, gz.Encoding)
+			require.Equal(t, tc.compress, gz.Served)
+			require.Equal(t, len(body), gz.BodyBytes)
+			require.Greater(t, gz.Ratio(), 10.0)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package scrape
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/relabel"
+	"gopkg.in/yaml.v3"
+)
+
+// Types of convention rules.
+const (
+	// RequiredPrefixRule requires metric names to start with one of the prefixes, e.g. the one of the team.
+	RequiredPrefixRule = "required_prefix"
+	// RequiredSuffixRule requires metric names to end with one of the suffixes, e.g. a base unit.
+	RequiredSuffixRule = "required_suffix"
+	// NamePatternRule requires metric names to match the pattern.
+	NamePatternRule = "name_pattern"
+	// ForbiddenLabelRule forbids the label names matching one of the patterns, e.g. env when the convention is
+	// environment.
+	ForbiddenLabelRule = "forbidden_label"
+)
+
+// ConventionRules is a set of naming conventions, as read from YAML:
+//
+//	rules:
+//	  - id: team-prefix
+//	    type: required_prefix
+//	    prefixes: [payments_, checkout_]
+//	    suppress: [legacy_.*]
+//	  - id: environment-label
+//	    type: forbidden_label
+//	    labels: [env]
+//	    message: use environment instead
+//	    severity: warning
+//	  - id: duration-unit
+//	    type: required_suffix
+//	    metrics: .*_duration.*
+//	    suffixes: [_seconds, _seconds_total]
+type ConventionRules struct {
+	Rules []ConventionRule `yaml:"rules"`
+}
+
+// ConventionRule is a naming convention metric families are checked against.
+type ConventionRule struct {
+	// ID identifies the rule in the problems found, it must be unique.
+	ID string `yaml:"id"`
+	// Type is one of RequiredPrefixRule, RequiredSuffixRule, NamePatternRule or ForbiddenLabelRule.
+	Type string `yaml:"type"`
+	// Severity is SeverityError, the default, or SeverityWarning which does not fail the check.
+	Severity string `yaml:"severity,omitempty"`
+	// Message is appended to the problems found, e.g. to point to the documentation of the convention.
+	Message string `yaml:"message,omitempty"`
+	// Metrics is an anchored regular expression selecting the metric families the rule applies to, all of them
+	// when empty.
+	Metrics string `yaml:"metrics,omitempty"`
+	// Suppress lists anchored regular expressions of the metric families exempted from the rule.
+	Suppress []string `yaml:"suppress,omitempty"`
+
+	Prefixes []string `yaml:"prefixes,omitempty"`
+	Suffixes []string `yaml:"suffixes,omitempty"`
+	Pattern  string   `yaml:"pattern,omitempty"`
+	// Labels are anchored regular expressions of the forbidden label names.
+	Labels []string `yaml:"labels,omitempty"`
+
+	metrics  *relabel.Regexp
+	suppress []relabel.Regexp
+	pattern  relabel.Regexp
+	labels   []relabel.Regexp
+}
+
+// LoadConventionRules reads and parses a convention rules file.
+func LoadConventionRules(path string) (*ConventionRules, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read convention rules: %w", err)
+	}
+	rules, err := ParseConventionRules(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid convention rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseConventionRules parses and validates convention rules.
+func ParseConventionRules(b []byte) (*ConventionRules, error) {
+	rules := &ConventionRules{}
+	if err := yaml.Unmarshal(b, rules); err != nil {
+		return nil, err
+	}
+	ids := make(map[string]struct{}, len(rules.Rules))
+	for i := range rules.Rules {
+		r := &rules.Rules[i]
+		if r.ID == "" {
+			return nil, fmt.Errorf("rule %d has no id", i+1)
+		}
+		if _, ok := ids[r.ID]; ok {
+			return nil, fmt.Errorf("duplicate rule id %q", r.ID)
+		}
+		ids[r.ID] = struct{}{}
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
+	return rules, nil
+}
+
+func (r *ConventionRule) compile() error {
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityError
+	case SeverityError, SeverityWarning:
+	default:
+		return fmt.Errorf("unknown severity %q", r.Severity)
+	}
+
+	var err error
+	switch r.Type {
+	case RequiredPrefixRule:
+		if len(r.Prefixes) == 0 {
+			return errors.New("prefixes are required")
+		}
+	case RequiredSuffixRule:
+		if len(r.Suffixes) == 0 {
+			return errors.New("suffixes are required")
+		}
+	case NamePatternRule:
+		if r.Pattern == "" {
+			return errors.New("pattern is required")
+		}
+		if r.pattern, err = relabel.NewRegexp(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case ForbiddenLabelRule:
+		if len(r.Labels) == 0 {
+			return errors.New("labels are required")
+		}
+		if r.labels, err = compileRegexps(r.Labels); err != nil {
+			return fmt.Errorf("invalid labels: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown type %q", r.Type)
+	}
+
+	if r.Metrics != "" {
+		re, err := relabel.NewRegexp(r.Metrics)
+		if err != nil {
+			return fmt.Errorf("invalid metrics: %w", err)
+		}
+		r.metrics = &re
+	}
+	if r.suppress, err = compileRegexps(r.Suppress); err != nil {
+		return fmt.Errorf("invalid suppress: %w", err)
+	}
+	return nil
+}
+
+func compileRegexps(patterns []string) ([]relabel.Regexp, error) {
+	regexps := make([]relabel.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := relabel.NewRegexp(p)
+		if err != nil {
+			return nil, err
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
+
+func matchesAny(regexps []relabel.Regexp, s string) bool {
+	return slices.ContainsFunc(regexps, func(re relabel.Regexp) bool { return re.MatchString(s) })
+}
+
+// appliesTo reports whether the rule applies to the metric family, neither unselected nor suppressed.
+func (r *ConventionRule) appliesTo(name string) bool {
+	if r.metrics != nil && !r.metrics.MatchString(name) {
+		return false
+	}
+	return !matchesAny(r.suppress, name)
+}
+
+// check returns the problems of the metric family with the rule.
+func (r *ConventionRule) check(mf *dto.MetricFamily) []Problem {
+	name := mf.GetName()
+	problem := func(label, text string) Problem {
+		if r.Message != "" {
+			text += ": " + r.Message
+		}
+		return Problem{Rule: r.ID, Severity: r.Severity, Text: text, Label: label}
+	}
+
+	switch r.Type {
+	case RequiredPrefixRule:
+		if !slices.ContainsFunc(r.Prefixes, func(p string) bool { return strings.HasPrefix(name, p) }) {
+			return []Problem{problem("", "name should start with "+strings.Join(r.Prefixes, " or "))}
+		}
+	case RequiredSuffixRule:
+		if !slices.ContainsFunc(r.Suffixes, func(s string) bool { return strings.HasSuffix(name, s) }) {
+			return []Problem{problem("", "name should end with "+strings.Join(r.Suffixes, " or "))}
+		}
+	case NamePatternRule:
+		if !r.pattern.MatchString(name) {
+			return []Problem{problem("", "name should match "+r.Pattern)}
+		}
+	case ForbiddenLabelRule:
+		forbidden := make(map[string]struct{})
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if matchesAny(r.labels, l.GetName()) {
+					forbidden[l.GetName()] = struct{}{}
+				}
+			}
+		}
+		problems := make([]Problem, 0, len(forbidden))
+		for _, label := range slices.Sorted(maps.Keys(forbidden)) {
+			problems = append(problems, problem(label, "label "+label+" is forbidden"))
+		}
+		return problems
+	}
+	return nil
+}
+
+// CheckConventions checks every metric family against the convention rules. There is one result per family, in
+// name order, with the problems in the order of the rules.
+func CheckConventions(families []*dto.MetricFamily, rules *ConventionRules) []CheckResult {
+	sorted := slices.Clone(families)
+	slices.SortFunc(sorted, func(a, b *dto.MetricFamily) int { return strings.Compare(a.GetName(), b.GetName()) })
+
+	results := make([]CheckResult, 0, len(sorted))
+	for _, mf := range sorted {
+		result := CheckResult{Check: ConventionCheck, Metric: mf.GetName()}
+		for i := range rules.Rules {
+			r := &rules.Rules[i]
+			if r.appliesTo(mf.GetName()) {
+				result.Problems = append(result.Problems, r.check(mf)...)
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,74 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestCheckConventions(t *testing.T) {
+	t.Parallel()
+	rules, err := scrape.ParseConventionRules([]byte(`
+rules:
+  - id: team-prefix
+    type: required_prefix
+    prefixes: [payments_]
+    suppress: [legacy_.*]
+  - id: environment-label
+    type: forbidden_label
+    labels: [env]
+    message: use environment instead
+    severity: warning
+  - id: duration-unit
+    type: required_suffix
+    metrics: .*_duration.*
+    suffixes: [_seconds]
+`))
+	require.NoError(t, err)
+
+	families, err := scrape.DecodeFamilies([]byte(`# TYPE payments_request_duration_ms gauge
+payments_request_duration_ms{env="prod"} 1
+# TYPE legacy_up gauge
+legacy_up 1
+# TYPE payments_request_duration_seconds gauge
+payments_request_duration_seconds{environment="prod"} 1
+`), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+
+	results := scrape.CheckConventions(families, rules)
+	require.Equal(t, []scrape.CheckResult{
+		{Check: scrape.ConventionCheck, Metric: "legacy_up"},
+		{
+			Check:  scrape.ConventionCheck,
+			Metric: "payments_request_duration_ms",
+			Problems: []scrape.Problem{
+				{
+					Rule:     "environment-label",
+					Severity: scrape.SeverityWarning,
+					Text:     "label env is forbidden: use environment instead",
+					Label:    "env",
+				},
+				{Rule: "duration-unit", Severity: scrape.SeverityError, Text: "name should end with _seconds"},
+			},
+		},
+		{Check: scrape.ConventionCheck, Metric: "payments_request_duration_seconds"},
+	}, results)
+	require.True(t, results[1].Failed())
+}
+
+func TestParseConventionRules_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, rules := range []string{
+		`rules: [{type: required_prefix, prefixes: [a_]}]`,
+		`rules: [{id: a, type: required_prefix}]`,
+		`rules: [{id: a, type: unknown}]`,
+		`rules: [{id: a, type: name_pattern, pattern: "("}]`,
+		`rules: [{id: a, type: forbidden_label, labels: [env], severity: fatal}]`,
+		`rules: [{id: a, type: forbidden_label, labels: [env]}, {id: a, type: forbidden_label, labels: [dc]}]`,
+	} {
+		_, err := scrape.ParseConventionRules([]byte(rules))
+		require.Error(t, err, rules)
+	}
+}
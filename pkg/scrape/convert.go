@@ -0,0 +1,305 @@
+package scrape
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const openMetricsMediaType = "application/openmetrics-text"
+
+// DetectContentType guesses the content type of an exposition read without HTTP headers, e.g. from a file.
+func DetectContentType(body []byte) string {
+	switch {
+	case bytes.HasPrefix(body, []byte("# EOF")) || bytes.Contains(body, []byte("\n# EOF")):
+		return config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0]
+	case isText(body):
+		return config.ScrapeProtocolsHeaders[config.PrometheusText0_0_4]
+	default:
+		return config.ScrapeProtocolsHeaders[config.PrometheusProto]
+	}
+}
+
+// isText reports whether the body is valid UTF-8 without the control characters that length delimited
+// protobuf messages are full of.
+func isText(body []byte) bool {
+	if !utf8.Valid(body) {
+		return false
+	}
+	return !bytes.ContainsFunc(body, func(r rune) bool {
+		return r < ' ' && r != '\n' && r != '\t' && r != '\r'
+	})
+}
+
+// DecodeFamilies decodes an exposition in any of the formats the scraper negotiates.
+func DecodeFamilies(body []byte, contentType string) ([]*dto.MetricFamily, error) {
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == openMetricsMediaType {
+		return decodeOpenMetrics(body, contentType)
+	}
+
+	var families []*dto.MetricFamily
+	dec := expfmt.NewDecoder(bytes.NewReader(body), expfmt.Format(contentType))
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("failed to decode exposition: %w", err)
+			}
+			break
+		}
+		families = append(families, mf)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "text/plain" {
+		// The text decoder yields families in random order, sort them to keep the output stable.
+		slices.SortFunc(families, func(a, b *dto.MetricFamily) int { return strings.Compare(a.GetName(), b.GetName()) })
+	}
+	return families, nil
+}
+
+// EncodeFamilies writes the families in the given format, e.g. expfmt.NewFormat(expfmt.TypeOpenMetrics).
+func EncodeFamilies(w io.Writer, families []*dto.MetricFamily, format expfmt.Format) error {
+	enc := expfmt.NewEncoder(w, format)
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", mf.GetName(), err)
+		}
+	}
+	if closer, ok := enc.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// openMetricsDecoder rebuilds metric families from the flat samples of an OpenMetrics exposition,
+// which expfmt cannot parse.
+type openMetricsDecoder struct {
+	families []*dto.MetricFamily
+	// byName indexes the families by their OpenMetrics name, cur is the family being decoded.
+	byName  map[string]*dto.MetricFamily
+	cur     string
+	metrics map[string]*dto.Metric
+}
+
+func decodeOpenMetrics(body []byte, contentType string) ([]*dto.MetricFamily, error) {
+	parser, err := textparse.New(body, contentType, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parser: %w", err)
+	}
+	d := &openMetricsDecoder{
+		byName:  make(map[string]*dto.MetricFamily),
+		metrics: make(map[string]*dto.Metric),
+	}
+
+	var lset labels.Labels
+	for {
+		entry, err := parser.Next()
+		if errors.Is(err, io.EOF) {
+			return d.families, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse exposition: %w", err)
+		}
+
+		switch entry {
+		case textparse.EntryHelp:
+			name, help := parser.Help()
+			d.family(string(name)).Help = proto.String(string(help))
+		case textparse.EntryType:
+			name, typ := parser.Type()
+			d.family(string(name)).Type = familyType(typ).Enum()
+		case textparse.EntryUnit:
+			name, unit := parser.Unit()
+			d.family(string(name)).Unit = proto.String(string(unit))
+		case textparse.EntrySeries:
+			parser.Metric(&lset)
+			_, ts, v := parser.Series()
+			if err := d.addSample(parser, lset, ts, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// family returns the family with the given OpenMetrics name, creating it as untyped if needed.
+func (d *openMetricsDecoder) family(name string) *dto.MetricFamily {
+	d.cur = name
+	if mf, ok := d.byName[name]; ok {
+		return mf
+	}
+	mf := &dto.MetricFamily{Name: proto.String(name), Type: dto.MetricType_UNTYPED.Enum()}
+	d.byName[name] = mf
+	d.families = append(d.families, mf)
+	return mf
+}
+
+func (d *openMetricsDecoder) addSample(parser textparse.Parser, lset labels.Labels, ts *int64, v float64) error {
+	name := lset.Get(labels.MetricName)
+	familyName := d.cur
+	if familyName == "" || !belongsToFamily(familyName, name) {
+		familyName = name
+	}
+	mf := d.family(familyName)
+	suffix := strings.TrimPrefix(name, familyName)
+
+	switch mf.GetType() {
+	case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+		m := d.metric(mf, familyName, lset, "le", ts)
+		if m.Histogram == nil {
+			m.Histogram = &dto.Histogram{}
+		}
+		switch suffix {
+		case "_bucket":
+			upperBound, err := strconv.ParseFloat(lset.Get("le"), 64)
+			if err != nil {
+				return fmt.Errorf("invalid le label on %s: %w", lset, err)
+			}
+			bucket := &dto.Bucket{UpperBound: proto.Float64(upperBound), CumulativeCount: proto.Uint64(uint64(v))}
+			var e exemplar.Exemplar
+			if parser.Exemplar(&e) {
+				bucket.Exemplar = toExemplar(e)
+			}
+			if math.IsInf(upperBound, 1) && bucket.Exemplar == nil {
+				// The +Inf bucket is implied by the sample count, it is kept like client_golang does only to
+				// carry an exemplar.
+				return nil
+			}
+			m.Histogram.Bucket = append(m.Histogram.Bucket, bucket)
+		case "_sum", "_gsum":
+			m.Histogram.SampleSum = proto.Float64(v)
+		case "_count", "_gcount":
+			m.Histogram.SampleCount = proto.Uint64(uint64(v))
+		case "_created":
+			m.Histogram.CreatedTimestamp = createdTimestamp(v)
+		}
+	case dto.MetricType_SUMMARY:
+		m := d.metric(mf, familyName, lset, "quantile", ts)
+		if m.Summary == nil {
+			m.Summary = &dto.Summary{}
+		}
+		switch suffix {
+		case "":
+			quantile, err := strconv.ParseFloat(lset.Get("quantile"), 64)
+			if err != nil {
+				return fmt.Errorf("invalid quantile label on %s: %w", lset, err)
+			}
+			m.Summary.Quantile = append(m.Summary.Quantile,
+				&dto.Quantile{Quantile: proto.Float64(quantile), Value: proto.Float64(v)})
+		case "_sum":
+			m.Summary.SampleSum = proto.Float64(v)
+		case "_count":
+			m.Summary.SampleCount = proto.Uint64(uint64(v))
+		case "_created":
+			m.Summary.CreatedTimestamp = createdTimestamp(v)
+		}
+	case dto.MetricType_COUNTER:
+		m := d.metric(mf, familyName, lset, "", ts)
+		if m.Counter == nil {
+			m.Counter = &dto.Counter{}
+		}
+		if suffix == "_created" {
+			m.Counter.CreatedTimestamp = createdTimestamp(v)
+			return nil
+		}
+		// The Prometheus formats name counters after their samples, e.g. foo_total.
+		mf.Name = proto.String(name)
+		m.Counter.Value = proto.Float64(v)
+		var e exemplar.Exemplar
+		if parser.Exemplar(&e) {
+			m.Counter.Exemplar = toExemplar(e)
+		}
+	case dto.MetricType_GAUGE:
+		// Info metrics are exposed as gauges named after their samples, e.g. foo_info.
+		mf.Name = proto.String(name)
+		d.metric(mf, familyName, lset, "", ts).Gauge = &dto.Gauge{Value: proto.Float64(v)}
+	default:
+		mf.Name = proto.String(name)
+		d.metric(mf, familyName, lset, "", ts).Untyped = &dto.Untyped{Value: proto.Float64(v)}
+	}
+	return nil
+}
+
+// metric returns the metric of the family with the labels of the sample, the metric name and the
+// given bucket label excluded, creating it if needed. The samples of a metric are expected to share
+// the same timestamp.
+func (d *openMetricsDecoder) metric(
+	mf *dto.MetricFamily,
+	familyName string,
+	lset labels.Labels,
+	bucketLabel string,
+	ts *int64,
+) *dto.Metric {
+	b := labels.NewBuilder(lset).Del(labels.MetricName)
+	if bucketLabel != "" {
+		b.Del(bucketLabel)
+	}
+	ls := b.Labels()
+
+	key := familyName + ls.String()
+	m, ok := d.metrics[key]
+	if !ok {
+		m = &dto.Metric{Label: toLabelPairs(ls)}
+		d.metrics[key] = m
+		mf.Metric = append(mf.Metric, m)
+	}
+	if ts != nil {
+		m.TimestampMs = proto.Int64(*ts)
+	}
+	return m
+}
+
+func familyType(typ model.MetricType) dto.MetricType {
+	switch typ {
+	case model.MetricTypeCounter:
+		return dto.MetricType_COUNTER
+	case model.MetricTypeGauge, model.MetricTypeInfo, model.MetricTypeStateset:
+		return dto.MetricType_GAUGE
+	case model.MetricTypeHistogram:
+		return dto.MetricType_HISTOGRAM
+	case model.MetricTypeGaugeHistogram:
+		return dto.MetricType_GAUGE_HISTOGRAM
+	case model.MetricTypeSummary:
+		return dto.MetricType_SUMMARY
+	default:
+		return dto.MetricType_UNTYPED
+	}
+}
+
+func toLabelPairs(ls labels.Labels) []*dto.LabelPair {
+	pairs := make([]*dto.LabelPair, 0, ls.Len())
+	ls.Range(func(l labels.Label) {
+		pairs = append(pairs, &dto.LabelPair{Name: proto.String(l.Name), Value: proto.String(l.Value)})
+	})
+	return pairs
+}
+
+// createdTimestamp converts the value of a _created sample, in seconds since the epoch.
+func createdTimestamp(v float64) *timestamppb.Timestamp {
+	sec, frac := math.Modf(v)
+	return timestamppb.New(time.Unix(int64(sec), int64(frac*1e9)))
+}
+
+func toExemplar(e exemplar.Exemplar) *dto.Exemplar {
+	ex := &dto.Exemplar{Label: toLabelPairs(e.Labels), Value: proto.Float64(e.Value)}
+	if e.HasTs {
+		ex.Timestamp = timestamppb.New(model.Time(e.Ts).Time())
+	}
+	return ex
+}
@@ -0,0 +1,95 @@
+package scrape_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+const convertOpenMetrics = `# HELP requests Requests served.
+# TYPE requests counter
+requests_total{code="200"} 10
+requests_created{code="200"} 1.7e+09
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 3
+latency_seconds_bucket{le="+Inf"} 4
+latency_seconds_sum 0.5
+latency_seconds_count 4
+# TYPE build info
+build_info{version="1.0"} 1
+# EOF
+`
+
+const convertText = `# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 3
+latency_seconds_bucket{le="+Inf"} 4
+latency_seconds_sum 0.5
+latency_seconds_count 4
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{code="200"} 10
+`
+
+func TestDecodeFamilies_OpenMetricsToText(t *testing.T) {
+	t.Parallel()
+	contentType := config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0]
+	families, err := scrape.DecodeFamilies([]byte(convertOpenMetrics), contentType)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, scrape.EncodeFamilies(&buf, families, expfmt.NewFormat(expfmt.TypeTextPlain)))
+	require.Equal(t, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{code="200"} 10
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 3
+latency_seconds_bucket{le="+Inf"} 4
+latency_seconds_sum 0.5
+latency_seconds_count 4
+# TYPE build_info gauge
+build_info{version="1.0"} 1
+`, buf.String())
+	require.NotNil(t, families[0].GetMetric()[0].GetCounter().GetCreatedTimestamp())
+}
+
+func TestDecodeFamilies_RoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, format := range []expfmt.Format{
+		expfmt.NewFormat(expfmt.TypeOpenMetrics),
+		expfmt.NewFormat(expfmt.TypeProtoDelim),
+		expfmt.NewFormat(expfmt.TypeTextPlain),
+	} {
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+			families, err := scrape.DecodeFamilies([]byte(convertText), string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+			require.NoError(t, err)
+
+			var encoded bytes.Buffer
+			require.NoError(t, scrape.EncodeFamilies(&encoded, families, format))
+			decoded, err := scrape.DecodeFamilies(encoded.Bytes(), scrape.DetectContentType(encoded.Bytes()))
+			require.NoError(t, err)
+
+			var text bytes.Buffer
+			require.NoError(t, scrape.EncodeFamilies(&text, decoded, expfmt.NewFormat(expfmt.TypeTextPlain)))
+			require.Equal(t, convertText, text.String())
+		})
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0],
+		scrape.DetectContentType([]byte(convertOpenMetrics)))
+	require.Equal(t, config.ScrapeProtocolsHeaders[config.PrometheusText0_0_4],
+		scrape.DetectContentType([]byte(convertText)))
+	require.Equal(t, config.ScrapeProtocolsHeaders[config.PrometheusProto],
+		scrape.DetectContentType([]byte{0x1a, 0x0a, 0x0e, 'r', 'e', 'q'}))
+}
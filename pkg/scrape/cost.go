@@ -0,0 +1,91 @@
+package scrape
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Pricing is a pricing model of a hosted metrics vendor, billing active series by the month.
+type Pricing struct {
+	Name string
+	// UnitSeries is the number of active series a unit of the price covers, e.g. 1000.
+	UnitSeries int
+	// PricePerUnit is the monthly price of a unit, in dollars.
+	PricePerUnit float64
+	// IncludedDPM is the number of data points per minute of a series covered by its price, a series sampled
+	// more often is billed as several. Zero when the price does not depend on the scrape interval.
+	IncludedDPM float64
+}
+
+// Pricings are approximations of public list prices, to be adjusted to the actual contract.
+var Pricings = map[string]Pricing{
+	// Grafana Cloud bills active series at 1 DPM, i.e. scraped every minute.
+	"grafana-cloud": {Name: "grafana-cloud", UnitSeries: 1000, PricePerUnit: 8, IncludedDPM: 1},
+	// Datadog bills custom metrics, every distinct series, per 100 whatever their resolution.
+	"datadog": {Name: "datadog", UnitSeries: 100, PricePerUnit: 5},
+}
+
+// FamilyCost is the estimated ingestion cost of a metric family.
+type FamilyCost struct {
+	Name   string
+	Series int
+	// DPM is the number of data points per minute the series produce.
+	DPM float64
+	// BillableSeries is the number of series billed, more than Series when the DPM exceeds the included one.
+	BillableSeries float64
+	MonthlyCost    float64
+}
+
+// CostReport is the estimated ingestion cost of a scrape.
+type CostReport struct {
+	Pricing  Pricing
+	Interval time.Duration
+	// Instances is the number of instances exposing the same series the estimate is multiplied by.
+	Instances int
+	// Families is sorted by cost, the most expensive first.
+	Families []FamilyCost
+	Total    FamilyCost
+}
+
+// EstimateCost converts the series of a scrape collected every interval, by the given number of instances,
+// into data points per minute and the monthly cost of the pricing model.
+func EstimateCost(sm SeriesMap, interval time.Duration, instances int, p Pricing) CostReport {
+	instances = max(instances, 1)
+	report := CostReport{Pricing: p, Interval: interval, Instances: instances}
+	dpmPerSeries := float64(time.Minute) / float64(interval)
+	billablePerSeries := 1.0
+	if p.IncludedDPM > 0 {
+		billablePerSeries = max(dpmPerSeries/p.IncludedDPM, 1)
+	}
+
+	for name, set := range sm {
+		series := set.Cardinality() * instances
+		fc := FamilyCost{
+			Name:           name,
+			Series:         series,
+			DPM:            float64(series) * dpmPerSeries,
+			BillableSeries: float64(series) * billablePerSeries,
+		}
+		if p.UnitSeries > 0 {
+			fc.MonthlyCost = fc.BillableSeries / float64(p.UnitSeries) * p.PricePerUnit
+		}
+		report.Families = append(report.Families, fc)
+
+		report.Total.Series += fc.Series
+		report.Total.DPM += fc.DPM
+		report.Total.BillableSeries += fc.BillableSeries
+		report.Total.MonthlyCost += fc.MonthlyCost
+	}
+	slices.SortFunc(report.Families, func(a, b FamilyCost) int {
+		if c := cmp.Compare(b.MonthlyCost, a.MonthlyCost); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Series, a.Series); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return report
+}
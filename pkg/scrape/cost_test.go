@@ -0,0 +1,36 @@
+package scrape_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestEstimateCost(t *testing.T) {
+	t.Parallel()
+	sm := scrape.SeriesMap{
+		"requests_total": replicaSet("requests_total", 900),
+		"up":             replicaSet("up", 100),
+	}
+
+	// Scraped every 15s, a series produces 4 DPM and is billed four times at 1 included DPM.
+	report := scrape.EstimateCost(sm, 15*time.Second, 2, scrape.Pricings["grafana-cloud"])
+	require.Equal(t, 2000, report.Total.Series)
+	require.InDelta(t, 8000.0, report.Total.DPM, 1e-9)
+	require.InDelta(t, 8000.0, report.Total.BillableSeries, 1e-9)
+	require.InDelta(t, 64.0, report.Total.MonthlyCost, 1e-9)
+	require.Equal(t, "requests_total", report.Families[0].Name)
+	require.InDelta(t, 57.6, report.Families[0].MonthlyCost, 1e-9)
+
+	// Scraped every 2m, series are billed once.
+	report = scrape.EstimateCost(sm, 2*time.Minute, 1, scrape.Pricings["grafana-cloud"])
+	require.InDelta(t, 500.0, report.Total.DPM, 1e-9)
+	require.InDelta(t, 8.0, report.Total.MonthlyCost, 1e-9)
+
+	// Per series pricing ignores the interval.
+	report = scrape.EstimateCost(sm, 15*time.Second, 1, scrape.Pricings["datadog"])
+	require.InDelta(t, 50.0, report.Total.MonthlyCost, 1e-9)
+}
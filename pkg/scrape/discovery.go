@@ -0,0 +1,65 @@
+package scrape
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// targetsAPIPath is the path of the targets API of Prometheus.
+const targetsAPIPath = "/api/v1/targets"
+
+// ActiveTarget is a target Prometheus scrapes, as listed by its targets API.
+type ActiveTarget struct {
+	// ScrapeURL is the URL Prometheus scrapes, with the scheme, path and params of the scrape config.
+	ScrapeURL  string            `json:"scrapeUrl"`
+	ScrapePool string            `json:"scrapePool"`
+	Labels     map[string]string `json:"labels"`
+	Health     string            `json:"health"`
+	LastError  string            `json:"lastError"`
+}
+
+// targetsResponse is the body returned by the targets API.
+type targetsResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ActiveTargets []ActiveTarget `json:"activeTargets"`
+	} `json:"data"`
+}
+
+// JobTargets returns the active targets of job, by job label or scrape pool, listed by the targets API of the
+// Prometheus server at prometheusURL, in the order of the API.
+func JobTargets(ctx context.Context, client *http.Client, prometheusURL, job string) ([]ActiveTarget, error) {
+	u := strings.TrimSuffix(prometheusURL, "/") + targetsAPIPath + "?" + url.Values{"state": {"active"}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body targetsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode targets API response (HTTP status %s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Status != "success" {
+		return nil, fmt.Errorf("targets API returned HTTP status %s: %s", resp.Status, body.Error)
+	}
+
+	var targets []ActiveTarget
+	for _, t := range body.Data.ActiveTargets {
+		if t.Labels["job"] == job || t.ScrapePool == job {
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
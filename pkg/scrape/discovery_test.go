@@ -0,0 +1,59 @@
+package scrape_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestJobTargets(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/prometheus/api/v1/targets", r.URL.Path)
+		require.Equal(t, "active", r.URL.Query().Get("state"))
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"activeTargets": [
+			{"scrapePool": "node", "scrapeUrl": "http://node-1:9100/metrics", "labels": {"job": "node"}, "health": "up"},
+			{"scrapePool": "api", "scrapeUrl": "https://api-1:8443/metrics?debug=1", "labels": {"job": "api"},
+			 "health": "up"},
+			{"scrapePool": "api", "scrapeUrl": "https://api-2:8443/metrics?debug=1", "labels": {"job": "api-canary"},
+			 "health": "down", "lastError": "connection refused"},
+			{"scrapePool": "blackbox", "scrapeUrl": "http://bb:9115/probe?target=api", "labels": {"job": "api"},
+			 "health": "up"}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	targets, err := scrape.JobTargets(context.Background(), srv.Client(), srv.URL+"/prometheus/", "api")
+	require.NoError(t, err)
+	urls := make([]string, 0, len(targets))
+	for _, target := range targets {
+		urls = append(urls, target.ScrapeURL)
+	}
+	require.Equal(t, []string{
+		"https://api-1:8443/metrics?debug=1",
+		"https://api-2:8443/metrics?debug=1",
+		"http://bb:9115/probe?target=api",
+	}, urls, "targets should match by job label or scrape pool")
+	require.Equal(t, "connection refused", targets[1].LastError)
+
+	targets, err = scrape.JobTargets(context.Background(), srv.Client(), srv.URL+"/prometheus", "missing")
+	require.NoError(t, err)
+	require.Empty(t, targets)
+}
+
+func TestJobTargets_Error(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"status": "error", "error": "not ready"}`))
+	}))
+	defer srv.Close()
+
+	_, err := scrape.JobTargets(context.Background(), srv.Client(), srv.URL, "api")
+	require.ErrorContains(t, err, "not ready")
+}
@@ -0,0 +1,81 @@
+package scrape
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// FamilyDivergence compares the cardinality of a metric family across the scrapes of replicas of a service.
+type FamilyDivergence struct {
+	Name string
+	// Cardinalities holds the number of series of the family in every scrape, in the order of the scrapes,
+	// zero where the family is missing.
+	Cardinalities []int
+	Min           int
+	Median        int
+	Max           int
+	// Outlier is the index of the scrape with the most series of the family.
+	Outlier int
+	// Ratio is Max over Median, a median of zero counting as one. Replicas of a healthy service stay close
+	// to 1, a leak on one instance shows as a high ratio.
+	Ratio float64
+	// Shared is the number of series exposed with the same labels by every scrape, e.g. series without an
+	// instance specific label. They would be duplicates once the replicas are aggregated.
+	Shared int
+}
+
+// Divergence compares every metric family across scrapes of replicas of the same service, the most divergent
+// first. The median of an even number of scrapes is the lower middle one, so that with two replicas the
+// ratio compares the larger to the smaller.
+func Divergence(scrapes []SeriesMap) []FamilyDivergence {
+	names := make(map[string]struct{})
+	for _, sm := range scrapes {
+		for name := range sm {
+			names[name] = struct{}{}
+		}
+	}
+
+	divergences := make([]FamilyDivergence, 0, len(names))
+	for name := range names {
+		d := FamilyDivergence{Name: name, Cardinalities: make([]int, len(scrapes))}
+		for i, sm := range scrapes {
+			d.Cardinalities[i] = sm[name].Cardinality()
+			if d.Cardinalities[i] > d.Cardinalities[d.Outlier] {
+				d.Outlier = i
+			}
+		}
+		sorted := slices.Clone(d.Cardinalities)
+		slices.Sort(sorted)
+		d.Min, d.Median, d.Max = sorted[0], sorted[(len(sorted)-1)/2], sorted[len(sorted)-1]
+		d.Ratio = float64(d.Max) / float64(max(d.Median, 1))
+		d.Shared = sharedSeries(scrapes, name)
+		divergences = append(divergences, d)
+	}
+
+	slices.SortFunc(divergences, func(a, b FamilyDivergence) int {
+		if c := cmp.Compare(b.Ratio, a.Ratio); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return divergences
+}
+
+// sharedSeries counts the series of the family present with the same labels in every scrape.
+func sharedSeries(scrapes []SeriesMap, name string) int {
+	shared := 0
+	for hash := range scrapes[0][name] {
+		inAll := true
+		for _, sm := range scrapes[1:] {
+			if _, ok := sm[name][hash]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			shared++
+		}
+	}
+	return shared
+}
@@ -0,0 +1,54 @@
+package scrape_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// replicaSet returns a set of n series of the metric, each with a distinct id label.
+func replicaSet(metric string, n int) scrape.SeriesSet {
+	set := make(scrape.SeriesSet, n)
+	for i := range n {
+		lset := labels.FromStrings("__name__", metric, "id", strconv.Itoa(i))
+		set[lset.Hash()] = scrape.Series{Name: metric, Labels: lset}
+	}
+	return set
+}
+
+func TestDivergence(t *testing.T) {
+	t.Parallel()
+	scrapes := []scrape.SeriesMap{
+		{"requests_total": replicaSet("requests_total", 10), "up": replicaSet("up", 1)},
+		{"requests_total": replicaSet("requests_total", 100), "up": replicaSet("up", 1)},
+		{"requests_total": replicaSet("requests_total", 12), "up": replicaSet("up", 1), "leak": replicaSet("leak", 5)},
+	}
+
+	divergences := scrape.Divergence(scrapes)
+	require.Len(t, divergences, 3)
+
+	require.Equal(t, scrape.FamilyDivergence{
+		Name:          "requests_total",
+		Cardinalities: []int{10, 100, 12},
+		Min:           10,
+		Median:        12,
+		Max:           100,
+		Outlier:       1,
+		Ratio:         100.0 / 12,
+		Shared:        10,
+	}, divergences[0])
+
+	// A family missing from most replicas has a zero median.
+	require.Equal(t, "leak", divergences[1].Name)
+	require.Equal(t, 2, divergences[1].Outlier)
+	require.InDelta(t, 5.0, divergences[1].Ratio, 1e-9)
+	require.Zero(t, divergences[1].Shared)
+
+	require.Equal(t, "up", divergences[2].Name)
+	require.InDelta(t, 1.0, divergences[2].Ratio, 1e-9)
+	require.Equal(t, 1, divergences[2].Shared)
+}
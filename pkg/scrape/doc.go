@@ -0,0 +1,17 @@
+// Package scrape scrapes Prometheus exposition endpoints and analyzes the cardinality of the series they
+// expose. It has no dependency on the command line tool and can be used as a library, e.g. to check the
+// output of an exporter in its tests:
+//
+//	scraper := scrape.NewPromScraper("http://localhost:9100/metrics", log.NewNopLogger())
+//	result, err := scraper.Scrape(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	for _, family := range result.Series.AsRows() {
+//		fmt.Println(family.Name, family.Cardinality, family.Labels)
+//	}
+//	fmt.Println("estimated memory:", result.Series.EstimatedMemory())
+//
+// A body fetched by other means is analyzed with PromScraper.Parse. Scraper abstracts the source of the
+// results so that callers can substitute fakes in tests.
+package scrape
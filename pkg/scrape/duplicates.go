@@ -0,0 +1,155 @@
+package scrape
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// LabelDuplicates are the series of a family that differ only by a label without adding information: either
+// the label never changes the value of the series, or the same value is exposed under two label names, e.g.
+// pod and pod_name after a half done rename. Both silently multiply the cardinality of the family.
+type LabelDuplicates struct {
+	Family string
+	// Label is the label the duplicate series differ by. Other is the second name of the label when the same
+	// value is exposed under two names, Label then being the one sorting first.
+	Label string
+	Other string
+	// Groups is the number of groups of series differing only by the label, Series the number of series in
+	// them and Redundant the number of series consolidating the groups would remove.
+	Groups    int
+	Series    int
+	Redundant int
+	// Example is a duplicate series, the first in label order.
+	Example string
+}
+
+// labelEntry is a series of a family with one of its labels left out, see Duplicates.
+type labelEntry struct {
+	label, value string
+	sample       float64
+	series       labels.Labels
+}
+
+// labelGroups aggregates the groups of series differing only by a label, or by a pair of label names.
+type labelGroups struct {
+	groups, identical, series, redundant int
+	example                              labels.Labels
+}
+
+func (g *labelGroups) add(series, redundant int, example labels.Labels) {
+	g.groups++
+	g.series += series
+	g.redundant += redundant
+	if g.example.IsEmpty() || labels.Compare(example, g.example) < 0 {
+		g.example = example
+	}
+}
+
+// Duplicates finds the labels of every family whose series only duplicate each other, the most redundant
+// series first. Families whose series all have the same value, e.g. info metrics, are only checked for labels
+// exposed under two names, their values telling nothing.
+func Duplicates(sm SeriesMap) []LabelDuplicates {
+	var (
+		result []LabelDuplicates
+		buf    []byte
+	)
+	for name, set := range sm {
+		var (
+			constant = true
+			first    = math.NaN()
+			groups   = make(map[uint64][]labelEntry)
+		)
+		for _, s := range set {
+			if math.IsNaN(first) {
+				first = s.Value
+			} else if s.Value != first {
+				constant = false
+			}
+			s.Labels.Range(func(l labels.Label) {
+				if l.Name == labels.MetricName {
+					return
+				}
+				var key uint64
+				key, buf = s.Labels.HashWithoutLabels(buf, l.Name)
+				groups[key] = append(groups[key], labelEntry{
+					label: l.Name, value: l.Value, sample: s.Value, series: s.Labels,
+				})
+			})
+		}
+
+		byLabel := make(map[string]*labelGroups)
+		byPair := make(map[[2]string]*labelGroups)
+		for _, entries := range groups {
+			if len(entries) < 2 {
+				continue
+			}
+			perLabel := make(map[string][]labelEntry)
+			for _, e := range entries {
+				perLabel[e.label] = append(perLabel[e.label], e)
+			}
+			for label, es := range perLabel {
+				if len(es) < 2 {
+					continue
+				}
+				g := byLabel[label]
+				if g == nil {
+					g = &labelGroups{}
+					byLabel[label] = g
+				}
+				if !slices.ContainsFunc(es, func(e labelEntry) bool { return e.sample != es[0].sample }) {
+					g.identical++
+				}
+				g.add(len(es), len(es)-1, minSeries(es))
+			}
+			for i, a := range entries {
+				for _, b := range entries[i+1:] {
+					if a.label == b.label || a.value != b.value {
+						continue
+					}
+					pair := [2]string{min(a.label, b.label), max(a.label, b.label)}
+					g := byPair[pair]
+					if g == nil {
+						g = &labelGroups{}
+						byPair[pair] = g
+					}
+					g.add(2, 1, minSeries([]labelEntry{a, b}))
+				}
+			}
+		}
+
+		if !constant {
+			for label, g := range byLabel {
+				if g.identical == g.groups {
+					result = append(result, LabelDuplicates{
+						Family: name, Label: label,
+						Groups: g.groups, Series: g.series, Redundant: g.redundant, Example: g.example.String(),
+					})
+				}
+			}
+		}
+		for pair, g := range byPair {
+			result = append(result, LabelDuplicates{
+				Family: name, Label: pair[0], Other: pair[1],
+				Groups: g.groups, Series: g.series, Redundant: g.redundant, Example: g.example.String(),
+			})
+		}
+	}
+	slices.SortFunc(result, func(a, b LabelDuplicates) int {
+		return cmp.Or(
+			cmp.Compare(b.Redundant, a.Redundant),
+			strings.Compare(a.Family, b.Family),
+			strings.Compare(a.Label, b.Label),
+			strings.Compare(a.Other, b.Other),
+		)
+	})
+	return result
+}
+
+// minSeries returns the first series of the entries in label order.
+func minSeries(entries []labelEntry) labels.Labels {
+	return slices.MinFunc(entries, func(a, b labelEntry) int { return labels.Compare(a.series, b.series) }).series
+}
@@ -0,0 +1,41 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestDuplicates(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte(`# TYPE http_requests_total counter
+http_requests_total{code="200",replica="a"} 5
+http_requests_total{code="200",replica="b"} 5
+http_requests_total{code="500",replica="a"} 1
+http_requests_total{code="500",replica="b"} 1
+# TYPE container_memory_bytes gauge
+container_memory_bytes{pod="api"} 10
+container_memory_bytes{pod_name="api"} 10
+container_memory_bytes{pod="db"} 20
+container_memory_bytes{pod_name="db"} 20
+# TYPE build_info gauge
+build_info{version="1",commit="a"} 1
+build_info{version="2",commit="a"} 1
+`), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+
+	require.Equal(t, []scrape.LabelDuplicates{
+		{
+			Family: "container_memory_bytes", Label: "pod", Other: "pod_name",
+			Groups: 2, Series: 4, Redundant: 2, Example: `{__name__="container_memory_bytes", pod="api"}`,
+		},
+		{
+			Family: "http_requests_total", Label: "replica",
+			Groups: 2, Series: 4, Redundant: 2, Example: `{__name__="http_requests_total", code="200", replica="a"}`,
+		},
+	}, scrape.Duplicates(result.Series))
+}
@@ -0,0 +1,28 @@
+package scrape_test
+
+import (
+	"fmt"
+
+	"github.com/go-kit/log"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func ExamplePromScraper_Parse() {
+	body := []byte(`# TYPE http_requests_total counter
+http_requests_total{code="200",method="GET"} 10
+http_requests_total{code="500",method="GET"} 1
+http_requests_total{code="200",method="POST"} 3
+`)
+	result, err := scrape.NewPromScraper("", log.NewNopLogger()).Parse(body, "text/plain; version=0.0.4")
+	if err != nil {
+		panic(err)
+	}
+	for _, family := range result.Series.AsRows() {
+		fmt.Println(family.Name, family.Type, family.Cardinality, family.Labels)
+	}
+	fmt.Println(result.Series.LabelStats())
+	// Output:
+	// http_requests_total counter 3 code(2)|method(2)
+	// code(2)|method(2)
+}
@@ -0,0 +1,232 @@
+package scrape
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// MaxExemplarLabelsLength is the limit of OpenMetrics on the combined length of the label names and values of an
+// exemplar, in characters. Prometheus rejects the exemplars above it.
+const MaxExemplarLabelsLength = 128
+
+// ageBuckets are the upper bounds of the age distributions of an ExemplarReport and a TimestampReport.
+var ageBuckets = []model.Duration{
+	model.Duration(time.Minute),
+	model.Duration(5 * time.Minute),
+	model.Duration(15 * time.Minute),
+	model.Duration(time.Hour),
+}
+
+// FamilyExemplars sums up the exemplars of a metric family.
+type FamilyExemplars struct {
+	Name string `json:"name"`
+	// Series is the number of series of the family carrying at least one exemplar.
+	Series    int `json:"series"`
+	Exemplars int `json:"exemplars"`
+	// TraceIDs is the number of distinct trace IDs of the exemplars of the family.
+	TraceIDs int `json:"trace_ids"`
+}
+
+// ExemplarAge counts the exemplars up to an age.
+type ExemplarAge struct {
+	// UpTo is the upper bound of the age, zero for the exemplars older than every bound.
+	UpTo  model.Duration `json:"up_to"`
+	Count int            `json:"count"`
+}
+
+// OversizedExemplar is an exemplar whose labels exceed MaxExemplarLabelsLength.
+type OversizedExemplar struct {
+	// Series are the labels of the series the exemplar is attached to.
+	Series string `json:"series"`
+	Labels string `json:"labels"`
+	Length int    `json:"length"`
+}
+
+// ExemplarReport sums up the exemplars of a scrape, e.g. to verify that trace IDs are propagated.
+type ExemplarReport struct {
+	Exemplars int `json:"exemplars"`
+	// Series is the number of series carrying at least one exemplar, a histogram counting as one.
+	Series int `json:"series"`
+	// TraceIDs is the number of distinct trace IDs.
+	TraceIDs int `json:"trace_ids"`
+	// WithoutTraceID and WithoutTimestamp count the exemplars lacking a trace ID or a timestamp.
+	WithoutTraceID   int `json:"without_trace_id"`
+	WithoutTimestamp int `json:"without_timestamp"`
+	// Ages is the distribution of the age of the exemplars with a timestamp, at the time of the scrape.
+	Ages []ExemplarAge `json:"ages"`
+	// Families lists the families with exemplars, the most exemplars first.
+	Families  []FamilyExemplars   `json:"families"`
+	Oversized []OversizedExemplar `json:"oversized,omitempty"`
+}
+
+// ExemplarStats analyzes the exemplars of the metric families decoded from a scrape taken at now. Only the
+// OpenMetrics and protobuf formats carry exemplars.
+func ExemplarStats(families []*dto.MetricFamily, now time.Time) ExemplarReport {
+	report := ExemplarReport{Ages: make([]ExemplarAge, len(ageBuckets)+1)}
+	for i, upTo := range ageBuckets {
+		report.Ages[i].UpTo = upTo
+	}
+	traceIDs := make(map[string]struct{})
+
+	for _, mf := range families {
+		family := FamilyExemplars{Name: mf.GetName()}
+		familyTraceIDs := make(map[string]struct{})
+		for _, m := range mf.GetMetric() {
+			exemplars := metricExemplars(m)
+			if len(exemplars) == 0 {
+				continue
+			}
+			family.Series++
+			family.Exemplars += len(exemplars)
+			for _, ex := range exemplars {
+				if id := exemplarTraceID(ex); id != "" {
+					traceIDs[id] = struct{}{}
+					familyTraceIDs[id] = struct{}{}
+				} else {
+					report.WithoutTraceID++
+				}
+				if ex.GetTimestamp() == nil {
+					report.WithoutTimestamp++
+				} else {
+					report.Ages[ageBucket(now.Sub(ex.GetTimestamp().AsTime()))].Count++
+				}
+				if length := exemplarLabelsLength(ex); length > MaxExemplarLabelsLength {
+					report.Oversized = append(report.Oversized, OversizedExemplar{
+						Series: pairsToLabels(mf.GetName(), m.GetLabel()).String(),
+						Labels: pairsToLabels("", ex.GetLabel()).String(),
+						Length: length,
+					})
+				}
+			}
+		}
+		if family.Exemplars == 0 {
+			continue
+		}
+		family.TraceIDs = len(familyTraceIDs)
+		report.Families = append(report.Families, family)
+		report.Series += family.Series
+		report.Exemplars += family.Exemplars
+	}
+	report.TraceIDs = len(traceIDs)
+
+	slices.SortFunc(report.Families, func(a, b FamilyExemplars) int {
+		if d := b.Exemplars - a.Exemplars; d != 0 {
+			return d
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return report
+}
+
+// metricExemplars returns the exemplars of a counter, or of the buckets of a histogram.
+func metricExemplars(m *dto.Metric) []*dto.Exemplar {
+	var exemplars []*dto.Exemplar
+	if ex := m.GetCounter().GetExemplar(); ex != nil {
+		exemplars = append(exemplars, ex)
+	}
+	for _, b := range m.GetHistogram().GetBucket() {
+		if ex := b.GetExemplar(); ex != nil {
+			exemplars = append(exemplars, ex)
+		}
+	}
+	// Native histograms carry their exemplars apart from the buckets.
+	return append(exemplars, m.GetHistogram().GetExemplars()...)
+}
+
+func exemplarTraceID(ex *dto.Exemplar) string {
+	for _, name := range traceIDLabels {
+		for _, l := range ex.GetLabel() {
+			if l.GetName() == name && l.GetValue() != "" {
+				return l.GetValue()
+			}
+		}
+	}
+	return ""
+}
+
+// exemplarLabelsLength returns the combined length of the label names and values of an exemplar, in characters.
+func exemplarLabelsLength(ex *dto.Exemplar) int {
+	length := 0
+	for _, l := range ex.GetLabel() {
+		length += utf8.RuneCountInString(l.GetName()) + utf8.RuneCountInString(l.GetValue())
+	}
+	return length
+}
+
+func ageBucket(age time.Duration) int {
+	for i, upTo := range ageBuckets {
+		if age <= time.Duration(upTo) {
+			return i
+		}
+	}
+	return len(ageBuckets)
+}
+
+// pairsToLabels converts label pairs to labels, with the metric name when not empty.
+func pairsToLabels(name string, pairs []*dto.LabelPair) labels.Labels {
+	b := labels.NewScratchBuilder(len(pairs) + 1)
+	if name != "" {
+		b.Add(labels.MetricName, name)
+	}
+	for _, l := range pairs {
+		b.Add(l.GetName(), l.GetValue())
+	}
+	b.Sort()
+	return b.Labels()
+}
+
+// ExemplarUsage sums up the exemplars kept with the series of a scrape and the memory they take in the analyzer.
+type ExemplarUsage struct {
+	Exemplars int
+	// Series is the number of series carrying at least one exemplar.
+	Series int
+	// Bytes estimates the memory taken by the trace IDs of the exemplars, TotalBytes by the series along with
+	// them. Interned label strings are counted once per series, as in EstimatedMemory.
+	Bytes      int
+	TotalBytes int
+}
+
+// Share returns the share of the memory of the series taken by their exemplars, between 0 and 1.
+func (u ExemplarUsage) Share() float64 {
+	if u.TotalBytes == 0 {
+		return 0
+	}
+	return float64(u.Bytes) / float64(u.TotalBytes)
+}
+
+// ExemplarUsage estimates the memory the exemplars of the series take, to decide whether to skip them with
+// WithSkipExemplars.
+func (s SeriesMap) ExemplarUsage() ExemplarUsage {
+	var (
+		usage      ExemplarUsage
+		seriesSize = int(unsafe.Sizeof(Series{}))
+		stringSize = int(unsafe.Sizeof(""))
+	)
+	for _, set := range s {
+		for _, v := range set {
+			usage.TotalBytes += seriesSize
+			for _, l := range v.Labels {
+				usage.TotalBytes += len(l.Name) + len(l.Value)
+			}
+			if v.Exemplars == 0 {
+				continue
+			}
+			usage.Exemplars += v.Exemplars
+			usage.Series++
+			bytes := cap(v.TraceIDs) * stringSize
+			for _, id := range v.TraceIDs {
+				bytes += len(id)
+			}
+			usage.Bytes += bytes
+			usage.TotalBytes += bytes
+		}
+	}
+	return usage
+}
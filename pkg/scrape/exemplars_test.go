@@ -0,0 +1,75 @@
+package scrape_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestExemplarStats(t *testing.T) {
+	t.Parallel()
+	long := strings.Repeat("x", scrape.MaxExemplarLabelsLength)
+	families, err := scrape.DecodeFamilies([]byte(`# TYPE requests counter
+requests_total{path="/a"} 1 # {trace_id="abc"} 1 1700000000
+requests_total{path="/b"} 2 # {trace_id="abc"} 1 1699999000
+requests_total{path="/c"} 3
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1 # {traceID="def"} 0.05 1699990000
+latency_seconds_bucket{le="+Inf"} 2 # {span_id="`+long+`"} 0.5
+latency_seconds_sum 0.55
+latency_seconds_count 2
+# TYPE up gauge
+up 1
+# EOF
+`), "application/openmetrics-text; version=1.0.0")
+	require.NoError(t, err)
+
+	report := scrape.ExemplarStats(families, time.Unix(1700000030, 0))
+	require.Equal(t, 4, report.Exemplars)
+	require.Equal(t, 3, report.Series)
+	require.Equal(t, 2, report.TraceIDs)
+	require.Equal(t, 1, report.WithoutTraceID)
+	require.Equal(t, 1, report.WithoutTimestamp)
+	require.Equal(t, []scrape.ExemplarAge{
+		{UpTo: model.Duration(time.Minute), Count: 1},
+		{UpTo: model.Duration(5 * time.Minute), Count: 0},
+		{UpTo: model.Duration(15 * time.Minute), Count: 0},
+		{UpTo: model.Duration(time.Hour), Count: 1},
+		{Count: 1},
+	}, report.Ages)
+	require.Equal(t, []scrape.FamilyExemplars{
+		{Name: "latency_seconds", Series: 1, Exemplars: 2, TraceIDs: 1},
+		{Name: "requests_total", Series: 2, Exemplars: 2, TraceIDs: 1},
+	}, report.Families)
+	require.Len(t, report.Oversized, 1)
+	require.Equal(t, `{__name__="latency_seconds"}`, report.Oversized[0].Series)
+	require.Equal(t, scrape.MaxExemplarLabelsLength+len("span_id"), report.Oversized[0].Length)
+}
+
+func TestSeriesMap_ExemplarUsage(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte(`# TYPE requests counter
+requests_total{path="/a"} 1 # {trace_id="4bf92f3577b34da6"} 1
+requests_total{path="/b"} 2 # {span_id="b7ad6b71"} 1
+requests_total{path="/c"} 3
+# EOF
+`), "application/openmetrics-text; version=1.0.0")
+	require.NoError(t, err)
+
+	usage := result.Series.ExemplarUsage()
+	require.Equal(t, 2, usage.Exemplars)
+	require.Equal(t, 2, usage.Series)
+	// A single trace ID, its string header and its 16 bytes.
+	require.Equal(t, 32, usage.Bytes)
+	require.Greater(t, usage.TotalBytes, usage.Bytes)
+	require.InDelta(t, float64(usage.Bytes)/float64(usage.TotalBytes), usage.Share(), 1e-9)
+
+	require.Zero(t, scrape.SeriesMap{}.ExemplarUsage().Share())
+}
@@ -0,0 +1,34 @@
+package scrape
+
+import (
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ExtractFamilies returns the families named by names, in the order of the exposition, along with the names
+// matching none of them. A name matches its family by the family name or the name of one of its series, e.g.
+// both `http_duration_seconds` and `http_duration_seconds_bucket` extract the whole histogram.
+func ExtractFamilies(families []*dto.MetricFamily, names []string) ([]*dto.MetricFamily, []string) {
+	found := make([]bool, len(names))
+	var extracted []*dto.MetricFamily
+	for _, mf := range families {
+		matched := false
+		for i, name := range names {
+			// OpenMetrics counters are decoded under the name of their _total series.
+			if belongsToFamily(mf.GetName(), name) || belongsToFamily(name, mf.GetName()) {
+				found[i] = true
+				matched = true
+			}
+		}
+		if matched {
+			extracted = append(extracted, mf)
+		}
+	}
+
+	var missing []string
+	for i, name := range names {
+		if !found[i] {
+			missing = append(missing, name)
+		}
+	}
+	return extracted, missing
+}
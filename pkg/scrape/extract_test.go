@@ -0,0 +1,35 @@
+package scrape_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestExtractFamilies(t *testing.T) {
+	t.Parallel()
+	contentType := config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0]
+	families, err := scrape.DecodeFamilies([]byte(convertOpenMetrics), contentType)
+	require.NoError(t, err)
+
+	extracted, missing := scrape.ExtractFamilies(families, []string{"latency_seconds_bucket", "requests", "absent"})
+	require.Equal(t, []string{"absent"}, missing)
+
+	var buf bytes.Buffer
+	require.NoError(t, scrape.EncodeFamilies(&buf, extracted, expfmt.NewFormat(expfmt.TypeTextPlain)))
+	require.Equal(t, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{code="200"} 10
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 3
+latency_seconds_bucket{le="+Inf"} 4
+latency_seconds_sum 0.5
+latency_seconds_count 4
+`, buf.String())
+}
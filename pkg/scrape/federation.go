@@ -0,0 +1,92 @@
+package scrape
+
+import (
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	federatePath = "/federate"
+
+	jobLabel      = "job"
+	instanceLabel = "instance"
+
+	// NoJob is the job reported for federated series that carry no job label.
+	NoJob = "(no job)"
+)
+
+// IsFederationURL reports whether the scrape URL points to a Prometheus federation endpoint.
+func IsFederationURL(scrapeURL string) bool {
+	u, err := url.Parse(scrapeURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(path.Clean("/"+u.Path), federatePath)
+}
+
+// OriginInfo summarizes the series a federated scrape received from a single origin job.
+type OriginInfo struct {
+	Job                  string
+	Instances            int
+	Cardinality          int
+	Metrics              int
+	TopMetric            string
+	TopMetricCardinality int
+}
+
+// ByOrigin groups the series by their job label, as exposed by a federation endpoint, and returns
+// the per-job breakdown sorted by cardinality.
+func (s SeriesMap) ByOrigin() []OriginInfo {
+	type origin struct {
+		instances map[string]struct{}
+		metrics   map[string]int
+		series    int
+	}
+	origins := make(map[string]*origin)
+
+	for name, set := range s {
+		for _, series := range set {
+			job := series.Labels.Get(jobLabel)
+			if job == "" {
+				job = NoJob
+			}
+			o, ok := origins[job]
+			if !ok {
+				o = &origin{instances: make(map[string]struct{}), metrics: make(map[string]int)}
+				origins[job] = o
+			}
+			o.instances[series.Labels.Get(instanceLabel)] = struct{}{}
+			o.metrics[name]++
+			o.series++
+		}
+	}
+
+	infos := make([]OriginInfo, 0, len(origins))
+	for job, o := range origins {
+		info := OriginInfo{
+			Job:         job,
+			Instances:   len(o.instances),
+			Cardinality: o.series,
+			Metrics:     len(o.metrics),
+		}
+		for name, count := range o.metrics {
+			if count > info.TopMetricCardinality ||
+				(count == info.TopMetricCardinality && name < info.TopMetric) {
+				info.TopMetric = name
+				info.TopMetricCardinality = count
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	slices.SortFunc(infos, func(i, j OriginInfo) int {
+		if c := j.Cardinality - i.Cardinality; c != 0 {
+			return c
+		}
+		return strings.Compare(i.Job, j.Job)
+	})
+
+	return infos
+}
@@ -0,0 +1,46 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestIsFederationURL(t *testing.T) {
+	t.Parallel()
+	require.True(t, scrape.IsFederationURL("http://prometheus:9090/federate?match[]={job=~\".+\"}"))
+	require.True(t, scrape.IsFederationURL("http://prometheus:9090/prom/federate/"))
+	require.False(t, scrape.IsFederationURL("http://localhost:8080/metrics"))
+}
+
+func TestSeriesMap_ByOrigin(t *testing.T) {
+	t.Parallel()
+	seriesMap := scrape.SeriesMap{
+		"http_requests_total": {
+			1: {Labels: labels.FromStrings("job", "api", "instance", "a:80", "code", "200")},
+			2: {Labels: labels.FromStrings("job", "api", "instance", "b:80", "code", "200")},
+			3: {Labels: labels.FromStrings("job", "db", "instance", "c:80", "code", "200")},
+		},
+		"up": {
+			4: {Labels: labels.FromStrings("job", "api", "instance", "a:80")},
+			5: {Labels: labels.FromStrings("instance", "d:80")},
+		},
+	}
+
+	origins := seriesMap.ByOrigin()
+
+	require.Len(t, origins, 3)
+	require.Equal(t, scrape.OriginInfo{
+		Job:                  "api",
+		Instances:            2,
+		Cardinality:          3,
+		Metrics:              2,
+		TopMetric:            "http_requests_total",
+		TopMetricCardinality: 2,
+	}, origins[0])
+	require.Equal(t, scrape.NoJob, origins[1].Job)
+	require.Equal(t, "db", origins[2].Job)
+}
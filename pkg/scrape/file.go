@@ -0,0 +1,117 @@
+package scrape
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+const fileScheme = "file"
+
+// FileScraper reads the exposition of a target from a file, e.g. one saved with curl, or a snapshot
+// written by SaveResult. The format is detected from the content.
+type FileScraper struct {
+	path   string
+	parser *PromScraper
+}
+
+var (
+	_ StreamScraper = (*FileScraper)(nil)
+	_ RawScraper    = (*FileScraper)(nil)
+)
+
+// NewFileScraper returns a scraper of a file:// URL or of a plain path. Only the parsing options, such as
+// WithMaxSeries, and WithMaxBodySize apply.
+func NewFileScraper(target string, logger log.Logger, opts ...ScraperOption) (*FileScraper, error) {
+	path := target
+	if u, err := url.Parse(target); err == nil && u.Scheme == fileScheme {
+		if u.Host != "" && u.Host != "localhost" {
+			return nil, fmt.Errorf("file URL %s must not have a host", target)
+		}
+		path = u.Path
+	}
+	return &FileScraper{path: path, parser: NewPromScraper(target, logger, opts...)}, nil
+}
+
+// Scrape reads and parses the file.
+func (fs *FileScraper) Scrape(ctx context.Context) (*Result, error) {
+	body, err := fs.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if isSnapshot(body) {
+		return DecodeResult(bytes.NewReader(body))
+	}
+	result, err := fs.parser.Parse(body, DetectContentType(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", fs.path, err)
+	}
+	result.Time = time.Now()
+	return result, nil
+}
+
+// ScrapeStream reads the file and passes every series to fn as it is parsed.
+func (fs *FileScraper) ScrapeStream(ctx context.Context, fn func(Series) error) error {
+	body, err := fs.read(ctx)
+	if err != nil {
+		return err
+	}
+	if !isSnapshot(body) {
+		return fs.parser.ParseStream(ctx, body, DetectContentType(body), fn)
+	}
+	result, err := DecodeResult(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(result.Series)) {
+		for _, s := range result.Series[name].Sorted() {
+			if err := fn(s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FetchWithContext reads the file and detects its format. Snapshots have no exposition and are rejected.
+func (fs *FileScraper) FetchWithContext(ctx context.Context) (string, []byte, error) {
+	body, err := fs.read(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if isSnapshot(body) {
+		return "", nil, fmt.Errorf("%s is a snapshot, it holds no exposition", fs.path)
+	}
+	return DetectContentType(body), body, nil
+}
+
+// Parse parses an exposition read from the file.
+func (fs *FileScraper) Parse(body []byte, contentType string) (*Result, error) {
+	result, err := fs.parser.Parse(body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	result.Time = time.Now()
+	return result, nil
+}
+
+func (fs *FileScraper) read(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(fs.path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > fs.parser.maxBodySize {
+		return nil, fmt.Errorf("file %s exceeds the size limit of %d bytes", fs.path, fs.parser.maxBodySize)
+	}
+	return os.ReadFile(fs.path)
+}
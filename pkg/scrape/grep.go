@@ -0,0 +1,155 @@
+package scrape
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+)
+
+const protobufMediaType = "application/vnd.google.protobuf"
+
+// familySuffixes are the suffixes of the series belonging to a family of the same name, e.g. the
+// buckets of a histogram.
+var familySuffixes = []string{"_bucket", "_sum", "_count", "_total", "_created", "_info", "_gcount", "_gsum"}
+
+// GrepFilter selects the series kept by Grep, a nil Metric and no Matchers keep everything.
+type GrepFilter struct {
+	// Metric is matched against the family and series name, e.g. both `http_duration_seconds` and
+	// `http_duration_seconds_bucket`.
+	Metric *regexp.Regexp
+	// Matchers must all match the series, a matcher on the metric name accepts the family name as well.
+	Matchers []*labels.Matcher
+}
+
+func (f GrepFilter) matches(family, name string, lset labels.Labels) bool {
+	if f.Metric != nil && !f.Metric.MatchString(family) && !f.Metric.MatchString(name) {
+		return false
+	}
+	for _, m := range f.Matchers {
+		if m.Name == labels.MetricName {
+			if !m.Matches(family) && !m.Matches(name) {
+				return false
+			}
+			continue
+		}
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Grep writes the lines of a text exposition whose series match the filter, along with the HELP,
+// TYPE and UNIT lines of their families. It returns the number of series written.
+func Grep(w io.Writer, body []byte, contentType string, filter GrepFilter) (int, error) {
+	type family struct {
+		name    string
+		header  []string
+		written bool
+	}
+	var (
+		cur     *family
+		matched int
+		eof     bool
+		lset    labels.Labels
+	)
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == protobufMediaType {
+		return 0, fmt.Errorf("grep needs a text exposition, got %s", contentType)
+	}
+	// The series lines are matched with the series entries of a single parser of the body, in order, the
+	// lines themselves are written as they are.
+	parser, err := textparse.New(body, contentType, false, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create parser: %w", err)
+	}
+	bw := bufio.NewWriter(w)
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) == 2 && fields[1] == "EOF" {
+				eof = true
+				continue
+			}
+			if len(fields) < 3 || !slices.Contains([]string{"HELP", "TYPE", "UNIT"}, fields[1]) {
+				continue
+			}
+			if cur == nil || cur.name != fields[2] {
+				cur = &family{name: fields[2]}
+			}
+			cur.header = append(cur.header, line)
+			continue
+		}
+
+		name := line
+		if i := strings.IndexAny(line, "{ "); i >= 0 {
+			name = line[:i]
+		}
+		if cur == nil || !belongsToFamily(cur.name, name) {
+			cur = &family{name: name}
+		}
+
+		if err := nextSeries(parser); err != nil {
+			return matched, fmt.Errorf("failed to parse %q: %w", line, err)
+		}
+		parser.Metric(&lset)
+
+		if !filter.matches(cur.name, name, lset) {
+			continue
+		}
+		if !cur.written {
+			for _, h := range cur.header {
+				fmt.Fprintln(bw, h)
+			}
+			cur.written = true
+		}
+		fmt.Fprintln(bw, line)
+		matched++
+	}
+	if err := scanner.Err(); err != nil {
+		return matched, err
+	}
+	if eof {
+		fmt.Fprintln(bw, "# EOF")
+	}
+	return matched, bw.Flush()
+}
+
+// nextSeries advances the parser to its next series, past the metadata and comments.
+func nextSeries(parser textparse.Parser) error {
+	for {
+		entry, err := parser.Next()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		if entry == textparse.EntrySeries {
+			return nil
+		}
+	}
+}
+
+func belongsToFamily(family, name string) bool {
+	if name == family {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(name, family)
+	return ok && slices.Contains(familySuffixes, suffix)
+}
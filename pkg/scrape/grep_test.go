@@ -0,0 +1,105 @@
+package scrape_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+const grepExposition = `# HELP http_request_duration_seconds Request latency.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{handler="/api",le="0.1"} 3
+http_request_duration_seconds_bucket{handler="/api",le="+Inf"} 4
+http_request_duration_seconds_sum{handler="/api"} 0.5
+http_request_duration_seconds_count{handler="/api"} 4
+http_request_duration_seconds_bucket{handler="/metrics",le="0.1"} 1
+http_request_duration_seconds_bucket{handler="/metrics",le="+Inf"} 1
+http_request_duration_seconds_sum{handler="/metrics"} 0.01
+http_request_duration_seconds_count{handler="/metrics"} 1
+# HELP up Whether the target is up.
+# TYPE up gauge
+up 1
+`
+
+func TestGrep(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		filter   scrape.GrepFilter
+		expected string
+		matched  int
+	}{
+		{
+			name:   "metric regex keeps the whole histogram",
+			filter: scrape.GrepFilter{Metric: regexp.MustCompile("^http_request_duration_seconds$")},
+			expected: `# HELP http_request_duration_seconds Request latency.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{handler="/api",le="0.1"} 3
+http_request_duration_seconds_bucket{handler="/api",le="+Inf"} 4
+http_request_duration_seconds_sum{handler="/api"} 0.5
+http_request_duration_seconds_count{handler="/api"} 4
+http_request_duration_seconds_bucket{handler="/metrics",le="0.1"} 1
+http_request_duration_seconds_bucket{handler="/metrics",le="+Inf"} 1
+http_request_duration_seconds_sum{handler="/metrics"} 0.01
+http_request_duration_seconds_count{handler="/metrics"} 1
+`,
+			matched: 8,
+		},
+		{
+			name: "label matcher",
+			filter: scrape.GrepFilter{Matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "http_request_duration_seconds"),
+				labels.MustNewMatcher(labels.MatchEqual, "handler", "/metrics"),
+				labels.MustNewMatcher(labels.MatchNotEqual, "le", "0.1"),
+			}},
+			expected: `# HELP http_request_duration_seconds Request latency.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{handler="/metrics",le="+Inf"} 1
+http_request_duration_seconds_sum{handler="/metrics"} 0.01
+http_request_duration_seconds_count{handler="/metrics"} 1
+`,
+			matched: 3,
+		},
+		{
+			name:   "no match",
+			filter: scrape.GrepFilter{Metric: regexp.MustCompile("^nope")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			matched, err := scrape.Grep(&out, []byte(grepExposition), "text/plain; version=0.0.4", tc.filter)
+			require.NoError(t, err)
+			require.Equal(t, tc.matched, matched)
+			require.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestGrep_OpenMetrics(t *testing.T) {
+	t.Parallel()
+	body := "# TYPE requests counter\n" +
+		"requests_total{code=\"200\"} 1 # {trace_id=\"abc\"} 1\n" +
+		"requests_created{code=\"200\"} 1.7e9\n# EOF\n"
+
+	var out bytes.Buffer
+	matched, err := scrape.Grep(&out, []byte(body), "application/openmetrics-text; version=1.0.0",
+		scrape.GrepFilter{Matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "code", "200")}})
+	require.NoError(t, err)
+	require.Equal(t, 2, matched)
+	require.Equal(t, body, out.String())
+}
+
+func TestGrep_ParseError(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	matched, err := scrape.Grep(&out, []byte("# TYPE up gauge\nup 1\nbroken{ 2\nother 3\n"),
+		"text/plain; version=0.0.4", scrape.GrepFilter{})
+	require.ErrorContains(t, err, `failed to parse "broken{ 2"`)
+	require.Equal(t, 1, matched)
+}
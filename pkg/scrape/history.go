@@ -0,0 +1,61 @@
+package scrape
+
+// History keeps the cardinality of every metric over the last scrapes of a session.
+type History struct {
+	size    int
+	scrapes int
+	samples map[string][]int
+}
+
+// NewHistory creates a history keeping at most size scrapes per metric.
+func NewHistory(size int) *History {
+	return &History{
+		size:    max(size, 2),
+		samples: make(map[string][]int),
+	}
+}
+
+// Add records the cardinality of every metric of a scrape. Metrics missing from the scrape are recorded
+// with a cardinality of zero, so vanishing metrics show up in their trend.
+func (h *History) Add(sm SeriesMap) {
+	for name := range h.samples {
+		if _, ok := sm[name]; !ok {
+			h.append(name, 0)
+		}
+	}
+	for name, set := range sm {
+		if _, ok := h.samples[name]; !ok && h.scrapes > 0 {
+			// Backfill the scrapes in which the metric was not present.
+			h.samples[name] = make([]int, min(h.scrapes, h.size-1))
+		}
+		h.append(name, set.Cardinality())
+	}
+	h.scrapes++
+}
+
+func (h *History) append(name string, cardinality int) {
+	samples := append(h.samples[name], cardinality)
+	if len(samples) > h.size {
+		samples = samples[len(samples)-h.size:]
+	}
+	h.samples[name] = samples
+}
+
+// Len returns the number of scrapes recorded so far.
+func (h *History) Len() int {
+	return h.scrapes
+}
+
+// Cardinalities returns the recorded cardinalities of a metric, oldest first.
+func (h *History) Cardinalities(name string) []int {
+	return h.samples[name]
+}
+
+// Delta returns the cardinality change of a metric between the last two scrapes.
+func (h *History) Delta(name string) int {
+	samples := h.samples[name]
+	if len(samples) < 2 {
+		return 0
+	}
+	return samples[len(samples)-1] - samples[len(samples)-2]
+}
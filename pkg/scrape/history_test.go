@@ -0,0 +1,26 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+	h := scrape.NewHistory(3)
+
+	h.Add(scrape.SeriesMap{"a": {1: {}, 2: {}}})
+	h.Add(scrape.SeriesMap{"a": {1: {}, 2: {}, 3: {}}, "b": {1: {}}})
+	h.Add(scrape.SeriesMap{"b": {1: {}, 2: {}}})
+	h.Add(scrape.SeriesMap{"a": {1: {}}, "b": {1: {}, 2: {}}})
+
+	require.Equal(t, 4, h.Len())
+	require.Equal(t, []int{3, 0, 1}, h.Cardinalities("a"), "history should be capped to its size")
+	require.Equal(t, []int{1, 2, 2}, h.Cardinalities("b"), "new metrics should be backfilled with zero")
+	require.Equal(t, 1, h.Delta("a"))
+	require.Equal(t, 0, h.Delta("b"))
+	require.Equal(t, 0, h.Delta("missing"))
+}
@@ -0,0 +1,162 @@
+package scrape
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+// IgnoreFile is the default name of the file listing the problems to ignore.
+const IgnoreFile = ".scrape-analyzer-ignore"
+
+// IgnoreEntry suppresses the problems matching all of its fields, e.g.
+//
+//	rule=max-label-values metric=http_requests_total label=path expires=2025-06-30
+type IgnoreEntry struct {
+	// Rule is the ID of the rule of the problems, any rule when empty.
+	Rule string
+	// Metric and Label are anchored regular expressions of the metric family and the label of the problems, any
+	// when nil. A label pattern only matches problems about a label.
+	Metric *relabel.Regexp
+	Label  *relabel.Regexp
+	// Expires is the day from which the entry no longer applies, never when zero.
+	Expires time.Time
+	// Line is the line of the entry in its file.
+	Line int
+	// Text is the entry as written.
+	Text string
+}
+
+// Expired reports whether the entry no longer applies at t.
+func (e IgnoreEntry) Expired(t time.Time) bool {
+	return !e.Expires.IsZero() && !t.Before(e.Expires)
+}
+
+func (e IgnoreEntry) matches(metric string, p Problem) bool {
+	if e.Rule != "" && e.Rule != p.Rule {
+		return false
+	}
+	if e.Metric != nil && !e.Metric.MatchString(metric) {
+		return false
+	}
+	return e.Label == nil || (p.Label != "" && e.Label.MatchString(p.Label))
+}
+
+// IgnoreList is a list of known problems that should not fail checks, e.g. legacy violations being worked on.
+type IgnoreList struct {
+	Entries []IgnoreEntry
+}
+
+// LoadIgnoreList reads and parses an ignore file.
+func LoadIgnoreList(path string) (*IgnoreList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer f.Close()
+	l, err := ParseIgnoreList(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ignore file %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// ParseIgnoreList parses an ignore file: one entry per line made of rule=, metric=, label= and expires= fields
+// separated by spaces, expires being a YYYY-MM-DD date. Empty lines and lines starting with # are skipped.
+func ParseIgnoreList(r io.Reader) (*IgnoreList, error) {
+	l := &IgnoreList{}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		e, err := parseIgnoreEntry(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		e.Line = n
+		l.Entries = append(l.Entries, e)
+	}
+	return l, sc.Err()
+}
+
+func parseIgnoreEntry(text string) (IgnoreEntry, error) {
+	e := IgnoreEntry{Text: text}
+	for _, field := range strings.Fields(text) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return e, fmt.Errorf("invalid field %q, expected 'key=value'", field)
+		}
+		switch key {
+		case "rule":
+			e.Rule = value
+		case "metric", "label":
+			re, err := relabel.NewRegexp(value)
+			if err != nil {
+				return e, fmt.Errorf("invalid %s pattern: %w", key, err)
+			}
+			if key == "metric" {
+				e.Metric = &re
+			} else {
+				e.Label = &re
+			}
+		case "expires":
+			t, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				return e, fmt.Errorf("invalid expiry date: %w", err)
+			}
+			e.Expires = t
+		default:
+			return e, fmt.Errorf("unknown field %q", key)
+		}
+	}
+	if e.Rule == "" && e.Metric == nil && e.Label == nil {
+		return e, errors.New("an entry needs a rule, metric or label")
+	}
+	return e, nil
+}
+
+// Apply removes from the results the problems matched by an entry of the list that has not expired at now. It
+// returns the number of problems suppressed and the expired entries, so that they can be reported and cleaned up.
+func (l *IgnoreList) Apply(results []CheckResult, now time.Time) (int, []IgnoreEntry) {
+	var active, expired []IgnoreEntry
+	for _, e := range l.Entries {
+		if e.Expired(now) {
+			expired = append(expired, e)
+		} else {
+			active = append(active, e)
+		}
+	}
+
+	suppressed := 0
+	for i := range results {
+		r := &results[i]
+		kept := r.Problems[:0]
+		for _, p := range r.Problems {
+			ignored := false
+			for _, e := range active {
+				if e.matches(r.Metric, p) {
+					ignored = true
+					break
+				}
+			}
+			if ignored {
+				suppressed++
+				continue
+			}
+			kept = append(kept, p)
+		}
+		if len(kept) == 0 {
+			kept = nil
+		}
+		r.Problems = kept
+	}
+	return suppressed, expired
+}
@@ -0,0 +1,62 @@
+package scrape_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestIgnoreList_Apply(t *testing.T) {
+	t.Parallel()
+	l, err := scrape.ParseIgnoreList(strings.NewReader(`
+# Legacy metrics, see the migration plan.
+rule=promlint metric=legacy_.*
+rule=max-label-values metric=requests_total label=path expires=2024-06-30
+metric=old_metric expires=2024-01-01
+`))
+	require.NoError(t, err)
+	require.Len(t, l.Entries, 3)
+	require.Equal(t, 5, l.Entries[2].Line)
+
+	promlint := scrape.Problem{Rule: scrape.PromlintRule, Severity: scrape.SeverityError, Text: "no help text"}
+	pathValues := scrape.Problem{
+		Rule:     scrape.MaxLabelValuesRule,
+		Severity: scrape.SeverityError,
+		Text:     "label path has 500 values, over the budget of 100",
+		Label:    "path",
+	}
+	results := []scrape.CheckResult{
+		{Check: scrape.LintCheck, Metric: "legacy_up", Problems: []scrape.Problem{promlint}},
+		{Check: scrape.LintCheck, Metric: "requests_total", Problems: []scrape.Problem{promlint}},
+		{Check: scrape.BudgetCheck, Metric: "requests_total", Problems: []scrape.Problem{pathValues}},
+		{Check: scrape.LintCheck, Metric: "old_metric", Problems: []scrape.Problem{promlint}},
+	}
+
+	suppressed, expired := l.Apply(results, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.Equal(t, 2, suppressed)
+	require.Len(t, expired, 1)
+	require.Equal(t, "metric=old_metric expires=2024-01-01", expired[0].Text)
+	require.False(t, results[0].Failed())
+	require.True(t, results[1].Failed())
+	require.False(t, results[2].Failed())
+	require.True(t, results[3].Failed())
+}
+
+func TestParseIgnoreList_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, line := range []string{
+		"promlint",
+		"rule=",
+		"owner=team-a",
+		"metric=(",
+		"rule=promlint expires=30/06/2024",
+		"expires=2024-06-30",
+	} {
+		_, err := scrape.ParseIgnoreList(strings.NewReader(line))
+		require.Error(t, err, line)
+	}
+}
@@ -0,0 +1,74 @@
+package scrape
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+const (
+	// InfoType and StatesetType are the OpenMetrics types without a Prometheus text format equivalent.
+	InfoType     = "info"
+	StatesetType = "stateset"
+)
+
+// InfoMetric describes a metric family carrying information in its labels rather than in its value.
+type InfoMetric struct {
+	Name        string
+	Type        string
+	Cardinality int
+	// Labels is the highest number of labels, the metric name excluded, of a series of the family.
+	Labels int
+}
+
+// IsInfoLike reports whether the set is an info metric: either typed as such by OpenMetrics, or a
+// gauge following the `_info`/`_labels` naming convention whose series all have the value 1.
+func (s SeriesSet) IsInfoLike(name string) bool {
+	types := s.Types()
+	if slices.Contains(types, InfoType) {
+		return true
+	}
+	if !slices.Equal(types, []string{"gauge"}) && !slices.Equal(types, []string{"unknown"}) {
+		return false
+	}
+	if !strings.HasSuffix(name, "_info") && !strings.HasSuffix(name, "_labels") {
+		return false
+	}
+	for _, series := range s {
+		if series.Value != 1 {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+// OversizedInfoMetrics returns the info-like metrics with series carrying more than maxLabels labels,
+// sorted by their number of labels.
+func (s SeriesMap) OversizedInfoMetrics(maxLabels int) []InfoMetric {
+	var infos []InfoMetric
+	for name, set := range s {
+		if !set.IsInfoLike(name) {
+			continue
+		}
+		info := InfoMetric{Name: name, Type: set.MetricTypeString(), Cardinality: set.Cardinality()}
+		for _, series := range set {
+			n := series.Labels.Len()
+			if series.Labels.Has(labels.MetricName) {
+				n--
+			}
+			info.Labels = max(info.Labels, n)
+		}
+		if info.Labels > maxLabels {
+			infos = append(infos, info)
+		}
+	}
+
+	slices.SortFunc(infos, func(i, j InfoMetric) int {
+		if c := j.Labels - i.Labels; c != 0 {
+			return c
+		}
+		return strings.Compare(i.Name, j.Name)
+	})
+	return infos
+}
@@ -0,0 +1,84 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestSeriesSet_IsInfoLike(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		metric   string
+		set      scrape.SeriesSet
+		expected bool
+	}{
+		{
+			name:     "openmetrics info type",
+			metric:   "build_info",
+			set:      scrape.SeriesSet{1: {Type: "info", Value: 1}},
+			expected: true,
+		},
+		{
+			name:     "gauge following the naming convention",
+			metric:   "kube_pod_labels",
+			set:      scrape.SeriesSet{1: {Type: "gauge", Value: 1}, 2: {Type: "gauge", Value: 1}},
+			expected: true,
+		},
+		{
+			name:     "gauge with values other than one",
+			metric:   "node_info",
+			set:      scrape.SeriesSet{1: {Type: "gauge", Value: 1}, 2: {Type: "gauge", Value: 3}},
+			expected: false,
+		},
+		{
+			name:     "counter",
+			metric:   "requests_info",
+			set:      scrape.SeriesSet{1: {Type: "counter", Value: 1}},
+			expected: false,
+		},
+		{
+			name:     "stateset",
+			metric:   "feature",
+			set:      scrape.SeriesSet{1: {Type: "stateset", Value: 1}},
+			expected: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, tc.set.IsInfoLike(tc.metric))
+		})
+	}
+}
+
+func TestSeriesMap_OversizedInfoMetrics(t *testing.T) {
+	t.Parallel()
+	seriesMap := scrape.SeriesMap{
+		"kube_pod_labels": {
+			1: {Type: "gauge", Value: 1, Labels: labels.FromStrings(
+				"__name__", "kube_pod_labels", "pod", "a", "label_app", "x", "label_team", "y", "label_tier", "z")},
+			2: {Type: "gauge", Value: 1, Labels: labels.FromStrings("__name__", "kube_pod_labels", "pod", "b")},
+		},
+		"build_info": {
+			1: {Type: "info", Value: 1, Labels: labels.FromStrings("__name__", "build_info", "version", "1", "rev", "a")},
+		},
+		"target_info": {
+			1: {Type: "info", Value: 1, Labels: labels.FromStrings(
+				"__name__", "target_info", "a", "1", "b", "2", "c", "3", "d", "4", "e", "5")},
+		},
+		"http_requests_total": {
+			1: {Type: "counter", Value: 5, Labels: labels.FromStrings(
+				"__name__", "http_requests_total", "a", "1", "b", "2", "c", "3", "d", "4")},
+		},
+	}
+
+	infos := seriesMap.OversizedInfoMetrics(3)
+	require.Equal(t, []scrape.InfoMetric{
+		{Name: "target_info", Type: "info", Cardinality: 1, Labels: 5},
+		{Name: "kube_pod_labels", Type: "gauge", Cardinality: 2, Labels: 4},
+	}, infos)
+}
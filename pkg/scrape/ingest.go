@@ -0,0 +1,403 @@
+package scrape
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"gopkg.in/yaml.v3"
+)
+
+// PromConfig is the part of a Prometheus (or Prometheus Agent) configuration file that decides the labels of
+// the ingested series. The rest of the file, service discovery included, is ignored so that any configuration
+// can be loaded without the discovery mechanisms it uses.
+type PromConfig struct {
+	Global        PromGlobalConfig `yaml:"global"`
+	ScrapeConfigs []*JobConfig     `yaml:"scrape_configs"`
+}
+
+// PromGlobalConfig holds the global settings the scrape configs inherit from.
+type PromGlobalConfig struct {
+	ScrapeInterval model.Duration    `yaml:"scrape_interval"`
+	ScrapeTimeout  model.Duration    `yaml:"scrape_timeout"`
+	ExternalLabels map[string]string `yaml:"external_labels"`
+}
+
+// JobConfig is a scrape config of a Prometheus configuration.
+type JobConfig struct {
+	JobName              string            `yaml:"job_name"`
+	HonorLabels          bool              `yaml:"honor_labels"`
+	ScrapeInterval       model.Duration    `yaml:"scrape_interval"`
+	ScrapeTimeout        model.Duration    `yaml:"scrape_timeout"`
+	MetricsPath          string            `yaml:"metrics_path"`
+	Scheme               string            `yaml:"scheme"`
+	Params               url.Values        `yaml:"params"`
+	StaticConfigs        []StaticConfig    `yaml:"static_configs"`
+	RelabelConfigs       []*relabel.Config `yaml:"relabel_configs"`
+	MetricRelabelConfigs []*relabel.Config `yaml:"metric_relabel_configs"`
+}
+
+// StaticConfig is a group of statically configured targets sharing the same labels.
+type StaticConfig struct {
+	Targets []string          `yaml:"targets"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+// Defaults of the Prometheus configuration.
+const (
+	defaultScrapeInterval = model.Duration(time.Minute)
+	defaultScrapeTimeout  = model.Duration(10 * time.Second)
+	defaultMetricsPath    = "/metrics"
+	defaultScheme         = "http"
+)
+
+// LoadPromConfig reads and parses a Prometheus configuration file.
+func LoadPromConfig(path string) (*PromConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Prometheus config: %w", err)
+	}
+	cfg, err := ParsePromConfig(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Prometheus config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParsePromConfig parses a Prometheus configuration, applying the defaults Prometheus applies and validating
+// the relabel configs.
+func ParsePromConfig(b []byte) (*PromConfig, error) {
+	var cfg PromConfig
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Global.ScrapeInterval == 0 {
+		cfg.Global.ScrapeInterval = defaultScrapeInterval
+	}
+	if cfg.Global.ScrapeTimeout == 0 {
+		cfg.Global.ScrapeTimeout = min(defaultScrapeTimeout, cfg.Global.ScrapeInterval)
+	}
+
+	jobs := make(map[string]struct{}, len(cfg.ScrapeConfigs))
+	for _, job := range cfg.ScrapeConfigs {
+		if job == nil || job.JobName == "" {
+			return nil, errors.New("scrape config without job_name")
+		}
+		if _, ok := jobs[job.JobName]; ok {
+			return nil, fmt.Errorf("duplicate job_name %q", job.JobName)
+		}
+		jobs[job.JobName] = struct{}{}
+
+		if job.ScrapeInterval == 0 {
+			job.ScrapeInterval = cfg.Global.ScrapeInterval
+		}
+		if job.ScrapeTimeout == 0 {
+			job.ScrapeTimeout = min(cfg.Global.ScrapeTimeout, job.ScrapeInterval)
+		}
+		if job.MetricsPath == "" {
+			job.MetricsPath = defaultMetricsPath
+		}
+		if job.Scheme == "" {
+			job.Scheme = defaultScheme
+		}
+		for _, rc := range slices.Concat(job.RelabelConfigs, job.MetricRelabelConfigs) {
+			if rc == nil {
+				return nil, fmt.Errorf("empty relabel config in job %q", job.JobName)
+			}
+			if err := rc.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid relabel config in job %q: %w", job.JobName, err)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// Job returns the scrape config of the named job.
+func (c *PromConfig) Job(name string) (*JobConfig, error) {
+	for _, job := range c.ScrapeConfigs {
+		if job.JobName == name {
+			return job, nil
+		}
+	}
+	return nil, fmt.Errorf("no job %q in the Prometheus config", name)
+}
+
+// MatchTarget returns the job statically configured to scrape the URL, along with the labels of its target
+// group. A target matches on its address, the metrics path and scheme break ties between jobs, an error is
+// returned when they do not.
+func (c *PromConfig) MatchTarget(scrapeURL string) (*JobConfig, map[string]string, error) {
+	u, err := url.Parse(scrapeURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid target URL: %w", err)
+	}
+	var (
+		best       *JobConfig
+		bestLabels map[string]string
+		bestScore  = -1
+		tied       []string
+	)
+	for _, job := range c.ScrapeConfigs {
+		for _, group := range job.StaticConfigs {
+			if !slices.ContainsFunc(group.Targets, func(t string) bool { return sameAddress(t, u) }) {
+				continue
+			}
+			score := 0
+			if job.MetricsPath == u.Path {
+				score += 2
+			}
+			if job.Scheme == u.Scheme {
+				score++
+			}
+			switch {
+			case score > bestScore:
+				best, bestLabels, bestScore = job, group.Labels, score
+				tied = []string{job.JobName}
+			case score == bestScore && job != best:
+				tied = append(tied, job.JobName)
+			}
+		}
+	}
+	if best == nil {
+		return nil, nil, fmt.Errorf("no static target of the Prometheus config matches %s", u.Host)
+	}
+	if len(tied) > 1 {
+		return nil, nil, fmt.Errorf("%s is a target of several jobs: %s", u.Host, strings.Join(tied, ", "))
+	}
+	return best, bestLabels, nil
+}
+
+// sameAddress reports whether a target address points at the host of the URL, default ports included.
+func sameAddress(address string, u *url.URL) bool {
+	if address == u.Host {
+		return true
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	host, targetPort, err := net.SplitHostPort(address)
+	if err != nil {
+		host, targetPort = address, port
+	}
+	return host == u.Hostname() && targetPort == port
+}
+
+// TargetLabels computes the labels of a target the way Prometheus does: the job, the scrape settings and the
+// given labels, e.g. the ones of the target group or discovered __meta_* labels, go through the
+// relabel_configs of the job. Labels starting with __ are removed afterwards and instance defaults to the
+// address. It returns false when the relabel_configs drop the target.
+func (j *JobConfig) TargetLabels(address string, extra map[string]string) (labels.Labels, bool, error) {
+	lb := labels.NewBuilder(labels.EmptyLabels())
+	lb.Set(model.AddressLabel, address)
+	for name, value := range extra {
+		lb.Set(name, value)
+	}
+	for _, l := range []labels.Label{
+		{Name: model.JobLabel, Value: j.JobName},
+		{Name: model.ScrapeIntervalLabel, Value: j.ScrapeInterval.String()},
+		{Name: model.ScrapeTimeoutLabel, Value: j.ScrapeTimeout.String()},
+		{Name: model.MetricsPathLabel, Value: j.MetricsPath},
+		{Name: model.SchemeLabel, Value: j.Scheme},
+	} {
+		if lb.Get(l.Name) == "" {
+			lb.Set(l.Name, l.Value)
+		}
+	}
+	for name, values := range j.Params {
+		if len(values) > 0 {
+			lb.Set(model.ParamLabelPrefix+name, values[0])
+		}
+	}
+
+	if !relabel.ProcessBuilder(lb, j.RelabelConfigs...) {
+		return labels.EmptyLabels(), false, nil
+	}
+	address = lb.Get(model.AddressLabel)
+	if address == "" {
+		return labels.EmptyLabels(), false, fmt.Errorf("relabel_configs of job %q leave the target without address",
+			j.JobName)
+	}
+	if lb.Get(model.InstanceLabel) == "" {
+		lb.Set(model.InstanceLabel, address)
+	}
+	lb.Range(func(l labels.Label) {
+		if strings.HasPrefix(l.Name, model.ReservedLabelPrefix) {
+			lb.Del(l.Name)
+		}
+	})
+	return lb.Labels(), true, nil
+}
+
+// IngestedSeries is the fate of a scraped series once ingested.
+type IngestedSeries struct {
+	Scraped labels.Labels
+	// Ingested are the labels the series is stored with, empty when metric_relabel_configs drop it.
+	Ingested labels.Labels
+	// Exported lists the scraped labels renamed to exported_<name> because the target has a label of the same
+	// name, Overridden the target labels ignored because of honor_labels.
+	Exported   []string
+	Overridden []string
+	// Duplicate is set when an earlier series is ingested with the same labels, Prometheus rejects the samples
+	// of all but one of them.
+	Duplicate bool
+}
+
+// Dropped reports whether the metric_relabel_configs drop the series.
+func (s IngestedSeries) Dropped() bool {
+	return s.Ingested.IsEmpty()
+}
+
+// IngestFamily sums up the fate of the series of a metric family.
+type IngestFamily struct {
+	Name       string
+	Scraped    int
+	Ingested   int
+	Dropped    int
+	Duplicates int
+	Exported   int
+	Overridden int
+}
+
+// IngestReport is the outcome of simulating the ingestion of a scrape by Prometheus.
+type IngestReport struct {
+	Job string
+	// Target holds the labels of the target after the relabel_configs, see JobConfig.TargetLabels.
+	Target labels.Labels
+	// ExternalLabels are added to the series leaving Prometheus through remote write or federation, when the
+	// series does not have them already.
+	ExternalLabels labels.Labels
+	// Series are sorted by family name then labels, the first of duplicate series is not marked.
+	Series []IngestedSeries
+	// Families is sorted by the number of scraped series, the largest first.
+	Families []IngestFamily
+	Total    IngestFamily
+}
+
+// SimulateIngest applies to every scraped series what Prometheus applies before storing it: the target labels
+// honoring or not the scraped ones, the metric_relabel_configs of the job and the external labels. Collisions
+// are reported: scraped labels renamed to exported_<name>, target labels overridden, and series ending up with
+// the same labels.
+func SimulateIngest(cfg *PromConfig, job *JobConfig, target labels.Labels, sm SeriesMap) IngestReport {
+	report := IngestReport{
+		Job:            job.JobName,
+		Target:         target,
+		ExternalLabels: labels.FromMap(cfg.Global.ExternalLabels),
+	}
+
+	seen := make(map[uint64]struct{})
+	families := make([]IngestFamily, 0, len(sm))
+	for _, name := range slices.Sorted(maps.Keys(sm)) {
+		family := IngestFamily{Name: name}
+		for _, s := range sm[name].Sorted() {
+			is := ingestSeries(s.Labels, target, job, report.ExternalLabels)
+			family.Scraped++
+			switch {
+			case is.Dropped():
+				family.Dropped++
+			default:
+				family.Ingested++
+				h := is.Ingested.Hash()
+				if _, ok := seen[h]; ok {
+					is.Duplicate = true
+					family.Duplicates++
+				}
+				seen[h] = struct{}{}
+			}
+			if len(is.Exported) > 0 {
+				family.Exported++
+			}
+			if len(is.Overridden) > 0 {
+				family.Overridden++
+			}
+			report.Series = append(report.Series, is)
+		}
+		families = append(families, family)
+	}
+
+	slices.SortFunc(families, func(a, b IngestFamily) int {
+		if c := cmp.Compare(b.Scraped, a.Scraped); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, f := range families {
+		report.Total.Scraped += f.Scraped
+		report.Total.Ingested += f.Ingested
+		report.Total.Dropped += f.Dropped
+		report.Total.Duplicates += f.Duplicates
+		report.Total.Exported += f.Exported
+		report.Total.Overridden += f.Overridden
+	}
+	report.Families = families
+	return report
+}
+
+// ingestSeries mirrors the label handling of the Prometheus scrape loop for a single series.
+func ingestSeries(lset, target labels.Labels, job *JobConfig, external labels.Labels) IngestedSeries {
+	is := IngestedSeries{Scraped: lset}
+	lb := labels.NewBuilder(lset)
+	if job.HonorLabels {
+		target.Range(func(l labels.Label) {
+			if lset.Has(l.Name) {
+				is.Overridden = append(is.Overridden, l.Name)
+				return
+			}
+			lb.Set(l.Name, l.Value)
+		})
+	} else {
+		var conflicts []labels.Label
+		target.Range(func(l labels.Label) {
+			if v := lset.Get(l.Name); v != "" {
+				conflicts = append(conflicts, labels.Label{Name: l.Name, Value: v})
+			}
+			lb.Set(l.Name, l.Value)
+		})
+		// Shorter names are renamed first, as Prometheus does, so that exported_exported_<name> only shows up
+		// when both <name> and exported_<name> collide.
+		slices.SortStableFunc(conflicts, func(a, b labels.Label) int { return len(a.Name) - len(b.Name) })
+		for _, l := range conflicts {
+			name := l.Name
+			for {
+				name = model.ExportedLabelPrefix + name
+				if lb.Get(name) == "" {
+					lb.Set(name, l.Value)
+					break
+				}
+			}
+			is.Exported = append(is.Exported, l.Name)
+		}
+	}
+
+	res := lb.Labels()
+	if len(job.MetricRelabelConfigs) > 0 {
+		var keep bool
+		if res, keep = relabel.Process(res, job.MetricRelabelConfigs...); !keep {
+			is.Ingested = labels.EmptyLabels()
+			return is
+		}
+	}
+	if !external.IsEmpty() {
+		lb.Reset(res)
+		external.Range(func(l labels.Label) {
+			if !res.Has(l.Name) {
+				lb.Set(l.Name, l.Value)
+			}
+		})
+		res = lb.Labels()
+	}
+	is.Ingested = res
+	return is
+}
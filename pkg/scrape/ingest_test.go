@@ -0,0 +1,143 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+const ingestConfig = `
+global:
+  scrape_interval: 30s
+  external_labels:
+    cluster: eu-1
+    region: eu
+scrape_configs:
+  - job_name: other
+    metrics_path: /other
+    static_configs:
+      - targets: [api:8080]
+  - job_name: api
+    metrics_path: /metrics
+    kubernetes_sd_configs:
+      - role: pod
+    static_configs:
+      - targets: [api:8080]
+        labels:
+          team: payments
+    relabel_configs:
+      - source_labels: [__meta_kubernetes_pod_name]
+        target_label: pod
+      - source_labels: [__address__]
+        regex: 'api:.*'
+        target_label: instance
+        replacement: api
+    metric_relabel_configs:
+      - source_labels: [__name__]
+        regex: debug_.*
+        action: drop
+      - regex: path
+        action: labeldrop
+`
+
+func ingestSeriesMap(series ...labels.Labels) scrape.SeriesMap {
+	sm := make(scrape.SeriesMap)
+	for _, lset := range series {
+		name := lset.Get("__name__")
+		if sm[name] == nil {
+			sm[name] = make(scrape.SeriesSet)
+		}
+		sm[name][lset.Hash()] = scrape.Series{Name: name, Labels: lset}
+	}
+	return sm
+}
+
+func TestSimulateIngest(t *testing.T) {
+	t.Parallel()
+	cfg, err := scrape.ParsePromConfig([]byte(ingestConfig))
+	require.NoError(t, err)
+
+	job, groupLabels, err := cfg.MatchTarget("http://api:8080/metrics")
+	require.NoError(t, err)
+	require.Equal(t, "api", job.JobName)
+	require.Equal(t, map[string]string{"team": "payments"}, groupLabels)
+
+	_, _, err = cfg.MatchTarget("http://unknown:8080/metrics")
+	require.Error(t, err)
+	_, _, err = cfg.MatchTarget("http://api:8080/probe")
+	require.ErrorContains(t, err, "several jobs: other, api")
+
+	groupLabels["__meta_kubernetes_pod_name"] = "api-0"
+	target, ok, err := job.TargetLabels("api:8080", groupLabels)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, labels.FromStrings("instance", "api", "job", "api", "pod", "api-0", "team", "payments"), target)
+
+	sm := ingestSeriesMap(
+		labels.FromStrings("__name__", "requests_total", "path", "/a", "job", "exporter"),
+		labels.FromStrings("__name__", "requests_total", "path", "/b", "job", "exporter"),
+		labels.FromStrings("__name__", "debug_info", "version", "1"),
+		labels.FromStrings("__name__", "up_info", "region", "us"),
+	)
+	report := scrape.SimulateIngest(cfg, job, target, sm)
+
+	require.Equal(t, scrape.IngestFamily{Scraped: 4, Ingested: 3, Dropped: 1, Duplicates: 1, Exported: 2},
+		report.Total)
+	require.Equal(t, "requests_total", report.Families[0].Name)
+	require.Equal(t, 1, report.Families[0].Duplicates)
+
+	// The scraped job label is renamed, dropping path makes both requests_total series identical, and the
+	// scraped region label wins over the external one.
+	ingested := make(map[string]scrape.IngestedSeries)
+	for _, s := range report.Series {
+		ingested[s.Scraped.String()] = s
+	}
+	a := ingested[`{__name__="requests_total", job="exporter", path="/a"}`]
+	require.Equal(t, labels.FromStrings("__name__", "requests_total", "cluster", "eu-1", "exported_job", "exporter",
+		"instance", "api", "job", "api", "pod", "api-0", "region", "eu", "team", "payments"), a.Ingested)
+	require.Equal(t, []string{"job"}, a.Exported)
+	require.True(t, ingested[`{__name__="requests_total", job="exporter", path="/b"}`].Duplicate)
+	require.True(t, ingested[`{__name__="debug_info", version="1"}`].Dropped())
+	require.Equal(t, "us", ingested[`{__name__="up_info", region="us"}`].Ingested.Get("region"))
+
+	// With honor_labels the scraped job label is kept.
+	job.HonorLabels = true
+	report = scrape.SimulateIngest(cfg, job, target, sm)
+	require.Equal(t, 2, report.Total.Overridden)
+	require.Zero(t, report.Total.Exported)
+	for _, s := range report.Series {
+		if s.Scraped.Get("job") != "" {
+			require.Equal(t, "exporter", s.Ingested.Get("job"))
+		}
+	}
+}
+
+func TestTargetLabels_Dropped(t *testing.T) {
+	t.Parallel()
+	cfg, err := scrape.ParsePromConfig([]byte(`
+scrape_configs:
+  - job_name: api
+    relabel_configs:
+      - source_labels: [__meta_keep]
+        regex: "true"
+        action: keep
+`))
+	require.NoError(t, err)
+	job, err := cfg.Job("api")
+	require.NoError(t, err)
+
+	_, ok, err := job.TargetLabels("api:8080", nil)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	target, ok, err := job.TargetLabels("api:8080", map[string]string{"__meta_keep": "true"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, labels.FromStrings("instance", "api:8080", "job", "api"), target)
+
+	_, err = scrape.ParsePromConfig([]byte("scrape_configs:\n  - job_name: a\n  - job_name: a\n"))
+	require.Error(t, err)
+}
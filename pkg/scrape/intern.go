@@ -0,0 +1,47 @@
+package scrape
+
+import (
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// interner deduplicates the label strings of a parse, so the series of a scrape share the storage of their
+// common label names and values instead of each one retaining its own copy of its exposition line.
+type interner struct {
+	strings map[string]string
+	builder labels.ScratchBuilder
+}
+
+func newInterner() *interner {
+	return &interner{strings: make(map[string]string)}
+}
+
+// intern returns the stored copy of s, storing a clone of it on first use since s usually points into a
+// buffer of the parser.
+func (in *interner) intern(s string) string {
+	if v, ok := in.strings[s]; ok {
+		return v
+	}
+	s = strings.Clone(s)
+	in.strings[s] = s
+	return s
+}
+
+// labels returns a copy of lset made of interned strings.
+func (in *interner) labels(lset labels.Labels) labels.Labels {
+	in.builder.Reset()
+	lset.Range(func(l labels.Label) {
+		in.builder.Add(in.intern(l.Name), in.intern(l.Value))
+	})
+	return in.builder.Labels()
+}
+
+// cloneLabels returns a copy of lset that does not share the storage of its strings.
+func cloneLabels(lset labels.Labels) labels.Labels {
+	var b labels.ScratchBuilder
+	lset.Range(func(l labels.Label) {
+		b.Add(strings.Clone(l.Name), strings.Clone(l.Value))
+	})
+	return b.Labels()
+}
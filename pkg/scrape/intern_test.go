@@ -0,0 +1,37 @@
+package scrape_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestPromScraper_ParseInternsLabels(t *testing.T) {
+	t.Parallel()
+	var body strings.Builder
+	body.WriteString("# TYPE http_requests_total counter\n")
+	for i := range 3 {
+		fmt.Fprintf(&body, "http_requests_total{job=\"api\",code=\"%d\"} 1\n", 200+i)
+	}
+
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte(body.String()), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+
+	var jobs []string
+	for _, s := range result.Series["http_requests_total"] {
+		require.Equal(t, unsafe.StringData(s.Name), unsafe.StringData(s.Labels.Get(labels.MetricName)))
+		jobs = append(jobs, s.Labels.Get("job"))
+	}
+	require.Len(t, jobs, 3)
+	for _, job := range jobs[1:] {
+		require.Equal(t, unsafe.StringData(jobs[0]), unsafe.StringData(job), "label values should share storage")
+	}
+}
@@ -0,0 +1,144 @@
+package scrape
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// ksmPrefix is the prefix of the metrics of kube-state-metrics, followed by the kind of the object they describe.
+const ksmPrefix = "kube_"
+
+// ksmObjectLabels are the labels naming the object of the kinds whose label is not the kind itself.
+var ksmObjectLabels = map[string]string{
+	// job is the label of the scrape job in Prometheus.
+	"job": "job_name",
+}
+
+// KSMKind is the cardinality of the metrics kube-state-metrics exposes about a Kubernetes object kind.
+type KSMKind struct {
+	Kind     string
+	Families int
+	Series   int
+	// Objects is the number of distinct objects of the kind, by namespace and the label named after the kind.
+	Objects int
+	// Labels and Annotations are the label_* and annotation_* labels of the kube_<kind>_labels and
+	// kube_<kind>_annotations metrics, as allowed by --metric-labels-allowlist and --metric-annotations-allowlist.
+	Labels      int
+	Annotations int
+}
+
+// SeriesPerObject is the number of series exposed per object of the kind, zero when the objects are unknown.
+func (k KSMKind) SeriesPerObject() float64 {
+	if k.Objects == 0 {
+		return 0
+	}
+	return float64(k.Series) / float64(k.Objects)
+}
+
+// KSMNamespace is the cardinality of the metrics of the objects of a namespace, the cluster-scoped objects are
+// in the namespace with an empty name.
+type KSMNamespace struct {
+	Namespace string
+	Series    int
+	Objects   int
+}
+
+// KSMReport breaks the series of kube-state-metrics down by object kind and namespace.
+type KSMReport struct {
+	// Kinds and Namespaces are sorted by series, the most first.
+	Kinds      []KSMKind
+	Namespaces []KSMNamespace
+	// Series is the number of series of kube-state-metrics, Other the number of series of other metrics, e.g.
+	// the Go runtime of kube-state-metrics itself.
+	Series int
+	Other  int
+}
+
+// KubeStateMetrics reports the cardinality of a kube-state-metrics scrape per object kind and namespace, which
+// is how its capacity is reasoned about rather than per metric family.
+func KubeStateMetrics(sm SeriesMap) KSMReport {
+	var report KSMReport
+	kinds := make(map[string]*KSMKind)
+	objects := make(map[string]map[string]struct{})
+	namespaces := make(map[string]*KSMNamespace)
+	nsObjects := make(map[string]map[string]struct{})
+
+	for name, set := range sm {
+		kind, metric, ok := ksmKind(name)
+		if !ok {
+			report.Other += set.Cardinality()
+			continue
+		}
+		k, ok := kinds[kind]
+		if !ok {
+			k = &KSMKind{Kind: kind}
+			kinds[kind] = k
+			objects[kind] = make(map[string]struct{})
+		}
+		k.Families++
+		k.Series += set.Cardinality()
+		report.Series += set.Cardinality()
+
+		objectLabel := cmp.Or(ksmObjectLabels[kind], kind)
+		for _, s := range set {
+			ns := s.Labels.Get("namespace")
+			n, ok := namespaces[ns]
+			if !ok {
+				n = &KSMNamespace{Namespace: ns}
+				namespaces[ns] = n
+				nsObjects[ns] = make(map[string]struct{})
+			}
+			n.Series++
+
+			if object := s.Labels.Get(objectLabel); object != "" {
+				objects[kind][ns+"/"+object] = struct{}{}
+				nsObjects[ns][kind+"/"+object] = struct{}{}
+			}
+			switch metric {
+			case "labels":
+				k.Labels = max(k.Labels, countLabelPrefix(s.Labels, "label_"))
+			case "annotations":
+				k.Annotations = max(k.Annotations, countLabelPrefix(s.Labels, "annotation_"))
+			}
+		}
+	}
+
+	for _, kind := range slices.Sorted(maps.Keys(kinds)) {
+		k := kinds[kind]
+		k.Objects = len(objects[kind])
+		report.Kinds = append(report.Kinds, *k)
+	}
+	slices.SortStableFunc(report.Kinds, func(a, b KSMKind) int { return cmp.Compare(b.Series, a.Series) })
+	for _, ns := range slices.Sorted(maps.Keys(namespaces)) {
+		n := namespaces[ns]
+		n.Objects = len(nsObjects[ns])
+		report.Namespaces = append(report.Namespaces, *n)
+	}
+	slices.SortStableFunc(report.Namespaces, func(a, b KSMNamespace) int { return cmp.Compare(b.Series, a.Series) })
+	return report
+}
+
+// ksmKind splits the name of a kube-state-metrics metric into the kind of object and the rest of the name, e.g.
+// kube_pod_container_info into pod and container_info.
+func ksmKind(name string) (kind, metric string, ok bool) {
+	rest, ok := strings.CutPrefix(name, ksmPrefix)
+	if !ok {
+		return "", "", false
+	}
+	kind, metric, ok = strings.Cut(rest, "_")
+	return kind, metric, ok && kind != ""
+}
+
+func countLabelPrefix(lset labels.Labels, prefix string) int {
+	n := 0
+	lset.Range(func(l labels.Label) {
+		if strings.HasPrefix(l.Name, prefix) {
+			n++
+		}
+	})
+	return n
+}
@@ -0,0 +1,49 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestKubeStateMetrics(t *testing.T) {
+	t.Parallel()
+	sm := scrape.SeriesMap{}
+	add := func(lset ...string) {
+		s := scrape.Series{Labels: labels.FromStrings(lset...)}
+		s.Name = s.Labels.Get(labels.MetricName)
+		if sm[s.Name] == nil {
+			sm[s.Name] = scrape.SeriesSet{}
+		}
+		sm[s.Name][s.Labels.Hash()] = s
+	}
+	for _, pod := range []string{"api-1", "api-2"} {
+		add("__name__", "kube_pod_info", "namespace", "prod", "pod", pod)
+		add("__name__", "kube_pod_labels", "namespace", "prod", "pod", pod, "label_app", "api", "label_team", "a")
+		for _, phase := range []string{"Running", "Pending", "Failed"} {
+			add("__name__", "kube_pod_status_phase", "namespace", "prod", "pod", pod, "phase", phase)
+		}
+	}
+	add("__name__", "kube_pod_info", "namespace", "dev", "pod", "api-1")
+	add("__name__", "kube_job_info", "namespace", "dev", "job_name", "backup")
+	add("__name__", "kube_node_info", "node", "node-1")
+	add("__name__", "go_goroutines")
+
+	report := scrape.KubeStateMetrics(sm)
+	require.Equal(t, 13, report.Series)
+	require.Equal(t, 1, report.Other)
+	require.Equal(t, []scrape.KSMKind{
+		{Kind: "pod", Families: 3, Series: 11, Objects: 3, Labels: 2},
+		{Kind: "job", Families: 1, Series: 1, Objects: 1},
+		{Kind: "node", Families: 1, Series: 1, Objects: 1},
+	}, report.Kinds)
+	require.InDelta(t, 11.0/3, report.Kinds[0].SeriesPerObject(), 1e-9)
+	require.Equal(t, []scrape.KSMNamespace{
+		{Namespace: "prod", Series: 10, Objects: 2},
+		{Namespace: "dev", Series: 2, Objects: 2},
+		{Namespace: "", Series: 1, Objects: 1},
+	}, report.Namespaces)
+}
@@ -0,0 +1,89 @@
+package scrape
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// DefaultMaxLabelValueLength is the length above which a label value is reported as long: full URLs, user
+// agents or JSON blobs bloat the index of the TSDB even at a modest cardinality.
+const DefaultMaxLabelValueLength = 100
+
+// LongLabelValuesRule is the lint rule reporting labels with long values.
+const LongLabelValuesRule = "long-label-values"
+
+// LabelValueLength is the length of the distinct values of a label.
+type LabelValueLength struct {
+	Name      string
+	Values    int
+	AvgLength float64
+	MaxLength int
+	// Longest is the longest value of the label.
+	Longest string
+}
+
+// LabelValueLengths returns the length of the distinct values of every label of the set, in name order.
+func (s SeriesSet) LabelValueLengths() []LabelValueLength {
+	values := make(map[string]map[string]struct{})
+	for _, v := range s {
+		v.Labels.Range(func(l labels.Label) {
+			if l.Name == labels.MetricName {
+				return
+			}
+			if values[l.Name] == nil {
+				values[l.Name] = make(map[string]struct{})
+			}
+			values[l.Name][l.Value] = struct{}{}
+		})
+	}
+
+	lengths := make([]LabelValueLength, 0, len(values))
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		l := LabelValueLength{Name: name, Values: len(values[name])}
+		total := 0
+		for value := range values[name] {
+			total += len(value)
+			if len(value) > l.MaxLength || (len(value) == l.MaxLength && value < l.Longest) {
+				l.MaxLength, l.Longest = len(value), value
+			}
+		}
+		l.AvgLength = float64(total) / float64(l.Values)
+		lengths = append(lengths, l)
+	}
+	return lengths
+}
+
+// CheckLabelValueLengths reports the labels of every metric family having values longer than maxLength, as
+// warnings of the lint check. There is one result per family, in name order.
+func CheckLabelValueLengths(sm SeriesMap, maxLength int) []CheckResult {
+	results := make([]CheckResult, 0, len(sm))
+	for _, name := range slices.Sorted(maps.Keys(sm)) {
+		result := CheckResult{Check: LintCheck, Metric: name}
+		for _, l := range sm[name].LabelValueLengths() {
+			if l.MaxLength <= maxLength {
+				continue
+			}
+			result.Problems = append(result.Problems, Problem{
+				Rule:     LongLabelValuesRule,
+				Severity: SeverityWarning,
+				Text: fmt.Sprintf("label %s has values of up to %d characters, %.0f on average, e.g. %q",
+					l.Name, l.MaxLength, l.AvgLength, abbreviate(l.Longest, 60)),
+				Label: l.Name,
+			})
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// abbreviate shortens s to n characters, marking the cut with an ellipsis.
+func abbreviate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(s[:n-3]) + "..."
+}
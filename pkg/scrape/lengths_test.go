@@ -0,0 +1,48 @@
+package scrape_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestSeriesSet_LabelValueLengths(t *testing.T) {
+	t.Parallel()
+	set := scrape.SeriesSet{
+		1: {Labels: labels.FromStrings("__name__", "views", "code", "200", "url", "/a")},
+		2: {Labels: labels.FromStrings("__name__", "views", "code", "200", "url", "/abcd")},
+		3: {Labels: labels.FromStrings("__name__", "views", "code", "500", "url", "/abcd")},
+	}
+	require.Equal(t, []scrape.LabelValueLength{
+		{Name: "code", Values: 2, AvgLength: 3, MaxLength: 3, Longest: "200"},
+		{Name: "url", Values: 2, AvgLength: 3.5, MaxLength: 5, Longest: "/abcd"},
+	}, set.LabelValueLengths())
+}
+
+func TestCheckLabelValueLengths(t *testing.T) {
+	t.Parallel()
+	long := "/" + strings.Repeat("a", 120)
+	sm := scrape.SeriesMap{
+		"views": {
+			1: {Labels: labels.FromStrings("__name__", "views", "url", long)},
+			2: {Labels: labels.FromStrings("__name__", "views", "url", "/b")},
+		},
+		"up": {3: {Labels: labels.FromStrings("__name__", "up", "job", "api")}},
+	}
+
+	results := scrape.CheckLabelValueLengths(sm, scrape.DefaultMaxLabelValueLength)
+	require.Len(t, results, 2)
+	require.Equal(t, "up", results[0].Metric)
+	require.Empty(t, results[0].Problems)
+	require.Equal(t, "views", results[1].Metric)
+	require.Len(t, results[1].Problems, 1)
+	p := results[1].Problems[0]
+	require.Equal(t, scrape.LongLabelValuesRule, p.Rule)
+	require.Equal(t, "url", p.Label)
+	require.Contains(t, p.Text, "up to 121 characters")
+	require.False(t, results[1].Failed())
+}
@@ -0,0 +1,141 @@
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Target is a target scraped by a Manager.
+type Target struct {
+	// Name identifies the target in the outcomes and errors, usually its URL.
+	Name    string
+	Scraper Scraper
+}
+
+// TargetResult is the outcome of scraping a target, Result is nil when Err is set.
+type TargetResult struct {
+	Target   string
+	Result   *Result
+	Err      error
+	Duration time.Duration
+}
+
+// Manager scrapes many targets concurrently with a bounded pool of workers.
+type Manager struct {
+	concurrency int
+	timeout     time.Duration
+	throttle    *Throttle
+}
+
+// ManagerOption configures a Manager.
+type ManagerOption func(*Manager)
+
+// WithConcurrency sets the number of targets scraped at once, 10 by default.
+func WithConcurrency(concurrency int) ManagerOption {
+	return func(m *Manager) {
+		m.concurrency = concurrency
+	}
+}
+
+// WithTargetTimeout bounds the scrape of every target, on top of the timeout of its scraper. Zero, the
+// default, leaves it to the scraper.
+func WithTargetTimeout(timeout time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.timeout = timeout
+	}
+}
+
+// WithThrottle delays the scrape of every target as the throttle says, e.g. to rate limit the scrapes. The
+// wait counts towards neither the target timeout nor the duration of the scrape.
+func WithThrottle(throttle *Throttle) ManagerOption {
+	return func(m *Manager) {
+		m.throttle = throttle
+	}
+}
+
+// NewManager returns a manager configured by opts.
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{concurrency: 10}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.concurrency = max(m.concurrency, 1)
+	return m
+}
+
+// Run scrapes the targets and calls fn with the outcome of every target as soon as it finishes, so callers
+// can show results incrementally. fn is called from a single goroutine. Targets not started when ctx is
+// done fail with its error. The returned error joins the errors of all failed targets.
+func (m *Manager) Run(ctx context.Context, targets []Target, fn func(TargetResult)) error {
+	return m.run(ctx, targets, func(_ int, outcome TargetResult) { fn(outcome) })
+}
+
+// ScrapeAll scrapes the targets and returns their outcomes in the order of the targets, along with the
+// joined errors of the failed targets.
+func (m *Manager) ScrapeAll(ctx context.Context, targets []Target) ([]TargetResult, error) {
+	outcomes := make([]TargetResult, len(targets))
+	err := m.run(ctx, targets, func(i int, outcome TargetResult) {
+		outcomes[i] = outcome
+	})
+	return outcomes, err
+}
+
+// run is Run passing fn the index of the target of every outcome.
+func (m *Manager) run(ctx context.Context, targets []Target, fn func(int, TargetResult)) error {
+	type outcome struct {
+		index  int
+		result TargetResult
+	}
+	jobs := make(chan int)
+	outcomes := make(chan outcome)
+
+	var wg sync.WaitGroup
+	for range min(m.concurrency, len(targets)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				outcomes <- outcome{index: i, result: m.scrape(ctx, targets[i])}
+			}
+		}()
+	}
+	go func() {
+		for i := range targets {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	var errs []error
+	for o := range outcomes {
+		if o.result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", o.result.Target, o.result.Err))
+		}
+		fn(o.index, o.result)
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) scrape(ctx context.Context, t Target) TargetResult {
+	if err := ctx.Err(); err != nil {
+		return TargetResult{Target: t.Name, Err: err}
+	}
+	if m.throttle != nil {
+		if err := m.throttle.Wait(ctx); err != nil {
+			return TargetResult{Target: t.Name, Err: err}
+		}
+	}
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	result, err := t.Scraper.Scrape(ctx)
+	return TargetResult{Target: t.Name, Result: result, Err: err, Duration: time.Since(start)}
+}
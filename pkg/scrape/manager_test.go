@@ -0,0 +1,117 @@
+package scrape_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// scraperFunc adapts a function to the Scraper interface.
+type scraperFunc func(ctx context.Context) (*scrape.Result, error)
+
+func (f scraperFunc) Scrape(ctx context.Context) (*scrape.Result, error) {
+	return f(ctx)
+}
+
+func TestManager_ScrapeAll(t *testing.T) {
+	t.Parallel()
+	var running, peak atomic.Int32
+	ok := scraperFunc(func(context.Context) (*scrape.Result, error) {
+		peak.Store(max(peak.Load(), running.Add(1)))
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return &scrape.Result{Series: scrape.SeriesMap{}}, nil
+	})
+	slow := scraperFunc(func(ctx context.Context) (*scrape.Result, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	failing := scraperFunc(func(context.Context) (*scrape.Result, error) {
+		return nil, errors.New("connection refused")
+	})
+	targets := []scrape.Target{
+		{Name: "a", Scraper: ok},
+		{Name: "slow", Scraper: slow},
+		{Name: "b", Scraper: ok},
+		{Name: "failing", Scraper: failing},
+		{Name: "c", Scraper: ok},
+	}
+
+	m := scrape.NewManager(scrape.WithConcurrency(2), scrape.WithTargetTimeout(50*time.Millisecond))
+	outcomes, err := m.ScrapeAll(context.Background(), targets)
+	require.ErrorContains(t, err, "slow: context deadline exceeded")
+	require.ErrorContains(t, err, "failing: connection refused")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.Len(t, outcomes, len(targets))
+	for i, outcome := range outcomes {
+		require.Equal(t, targets[i].Name, outcome.Target)
+		switch outcome.Target {
+		case "slow", "failing":
+			require.Error(t, outcome.Err)
+			require.Nil(t, outcome.Result)
+		default:
+			require.NoError(t, outcome.Err)
+			require.NotNil(t, outcome.Result)
+		}
+	}
+	require.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestManager_RunCanceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var finished []string
+	err := scrape.NewManager().Run(ctx, []scrape.Target{{Name: "a"}, {Name: "b"}}, func(r scrape.TargetResult) {
+		finished = append(finished, r.Target)
+		require.ErrorIs(t, r.Err, context.Canceled)
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.ElementsMatch(t, []string{"a", "b"}, finished)
+}
+
+func TestManager_Throttle(t *testing.T) {
+	t.Parallel()
+	var (
+		mtx    sync.Mutex
+		starts []time.Time
+	)
+	ok := scraperFunc(func(context.Context) (*scrape.Result, error) {
+		mtx.Lock()
+		starts = append(starts, time.Now())
+		mtx.Unlock()
+		return &scrape.Result{Series: scrape.SeriesMap{}}, nil
+	})
+	targets := []scrape.Target{{Name: "a", Scraper: ok}, {Name: "b", Scraper: ok}, {Name: "c", Scraper: ok}}
+
+	// 50 scrapes per second start every 20ms, whatever the concurrency.
+	m := scrape.NewManager(scrape.WithConcurrency(3), scrape.WithThrottle(scrape.NewThrottle(50, 0)))
+	begin := time.Now()
+	_, err := m.ScrapeAll(context.Background(), targets)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(begin), 40*time.Millisecond)
+	require.Len(t, starts, 3)
+}
+
+func TestThrottle_Canceled(t *testing.T) {
+	t.Parallel()
+	throttle := scrape.NewThrottle(0.001, 0)
+	require.NoError(t, throttle.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, throttle.Wait(ctx), context.DeadlineExceeded)
+
+	// Jitter alone never waits longer than its bound.
+	begin := time.Now()
+	require.NoError(t, scrape.NewThrottle(0, 5*time.Millisecond).Wait(context.Background()))
+	require.Less(t, time.Since(begin), time.Second)
+}
@@ -0,0 +1,53 @@
+package scrape
+
+import (
+	"maps"
+	"slices"
+)
+
+// LabelMatrix holds the number of distinct values of every label name in every metric family of a scrape, to
+// see at a glance, e.g. as a heatmap in a spreadsheet, where the label values explode.
+type LabelMatrix struct {
+	// Families are the metric families, the rows of the matrix, and Series their number of series.
+	Families []string `json:"families"`
+	Series   []int    `json:"series"`
+	// Labels are the label names carried by any of the families, the columns of the matrix, in name order.
+	Labels []string `json:"labels"`
+	// Values holds the distinct values of Labels[j] in Families[i] at Values[i][j], zero when the family does not
+	// carry the label.
+	Values [][]int `json:"values"`
+	// Truncated is set when the scrape was only parsed up to the series limit.
+	Truncated bool `json:"truncated"`
+}
+
+// NewLabelMatrix builds the matrix of the given families of the scrape, in their order. The families missing
+// from the scrape are left out.
+func NewLabelMatrix(sm SeriesMap, families []string) LabelMatrix {
+	m := LabelMatrix{}
+	stats := make([]map[string]int, 0, len(families))
+	names := make(map[string]struct{})
+	for _, family := range families {
+		set, ok := sm[family]
+		if !ok {
+			continue
+		}
+		values := make(map[string]int)
+		for _, l := range set.LabelStats() {
+			values[l.Name] = int(l.DistinctValues)
+			names[l.Name] = struct{}{}
+		}
+		m.Families = append(m.Families, family)
+		m.Series = append(m.Series, set.Cardinality())
+		stats = append(stats, values)
+	}
+
+	m.Labels = slices.Sorted(maps.Keys(names))
+	m.Values = make([][]int, len(stats))
+	for i, values := range stats {
+		m.Values[i] = make([]int, len(m.Labels))
+		for j, name := range m.Labels {
+			m.Values[i][j] = values[name]
+		}
+	}
+	return m
+}
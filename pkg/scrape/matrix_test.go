@@ -0,0 +1,37 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestNewLabelMatrix(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte(`# TYPE http_requests_total counter
+http_requests_total{code="200",path="/a"} 5
+http_requests_total{code="500",path="/a"} 1
+http_requests_total{code="200",path="/b"} 1
+# TYPE queue_length gauge
+queue_length{queue="a"} 3
+# TYPE up gauge
+up 1
+`), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+
+	m := scrape.NewLabelMatrix(result.Series, []string{"http_requests_total", "missing", "queue_length", "up"})
+	require.Equal(t, scrape.LabelMatrix{
+		Families: []string{"http_requests_total", "queue_length", "up"},
+		Series:   []int{3, 1, 1},
+		Labels:   []string{"code", "path", "queue"},
+		Values: [][]int{
+			{2, 2, 0},
+			{0, 0, 1},
+			{0, 0, 0},
+		},
+	}, m)
+}
@@ -0,0 +1,47 @@
+package scrape
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics holds the self-instrumentation of the scraper. A single instance should be created per
+// registry and shared by every scraper, so repeated scrapes do not register the collectors twice.
+type Metrics struct {
+	scrapes        prometheus.Counter
+	scrapeFailures prometheus.Counter
+	scrapeRetries  prometheus.Counter
+	parseErrors    prometheus.Counter
+	bytesProcessed prometheus.Counter
+	seriesParsed   prometheus.Counter
+}
+
+// NewMetrics creates the metrics of the scraper and registers them with reg, which may be nil.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	return &Metrics{
+		scrapes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "prom_scrape_analyzer_scrapes_total",
+			Help: "Total number of scrapes performed.",
+		}),
+		scrapeFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "prom_scrape_analyzer_scrape_failures_total",
+			Help: "Total number of scrapes that failed.",
+		}),
+		scrapeRetries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "prom_scrape_analyzer_scrape_retries_total",
+			Help: "Total number of scrape attempts retried after a transient failure.",
+		}),
+		parseErrors: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "prom_scrape_analyzer_parse_errors_total",
+			Help: "Total number of entries that could not be parsed.",
+		}),
+		bytesProcessed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "prom_scrape_analyzer_processed_bytes_total",
+			Help: "Total number of uncompressed response body bytes processed.",
+		}),
+		seriesParsed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "prom_scrape_analyzer_parsed_series_total",
+			Help: "Total number of series parsed from scrape responses.",
+		}),
+	}
+}
@@ -0,0 +1,158 @@
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+
+	"github.com/prometheus/prometheus/config"
+)
+
+// Media types of the exposition formats.
+const (
+	mediaTypeText        = "text/plain"
+	mediaTypeOpenMetrics = "application/openmetrics-text"
+	mediaTypeProto       = "application/vnd.google.protobuf"
+)
+
+// NegotiationProbe is an Accept header sent to a target, with the media types a target negotiating correctly
+// may answer it with.
+type NegotiationProbe struct {
+	Name   string
+	Accept string
+	// Expected are the acceptable media types of the answer, a fallback to the text format included.
+	Expected []string
+}
+
+// NegotiationProbes are edge cases of content negotiation: single formats, quality ordering, escaping schemes
+// and malformed headers. A target should answer all of them with a format it was asked for, or fall back to
+// the text format.
+var NegotiationProbes = []NegotiationProbe{
+	{
+		Name:     "prometheus default",
+		Accept:   acceptHeader(config.DefaultScrapeProtocols),
+		Expected: []string{mediaTypeOpenMetrics, mediaTypeText},
+	},
+	{
+		Name:     "protobuf first",
+		Accept:   acceptHeader(config.DefaultProtoFirstScrapeProtocols),
+		Expected: []string{mediaTypeProto, mediaTypeOpenMetrics, mediaTypeText},
+	},
+	{
+		Name:     "text only",
+		Accept:   config.ScrapeProtocolsHeaders[config.PrometheusText0_0_4],
+		Expected: []string{mediaTypeText},
+	},
+	{
+		Name:     "openmetrics only",
+		Accept:   config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0],
+		Expected: []string{mediaTypeOpenMetrics, mediaTypeText},
+	},
+	{
+		Name:     "protobuf only",
+		Accept:   config.ScrapeProtocolsHeaders[config.PrometheusProto],
+		Expected: []string{mediaTypeProto, mediaTypeText},
+	},
+	{
+		// A target ignoring the quality values answers with the first format listed.
+		Name: "quality ordering",
+		Accept: config.ScrapeProtocolsHeaders[config.PrometheusProto] + ";q=0.1," +
+			config.ScrapeProtocolsHeaders[config.PrometheusText0_0_4] + ";q=0.9",
+		Expected: []string{mediaTypeText},
+	},
+	{
+		Name:     "escaping scheme",
+		Accept:   config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0] + ";escaping=underscores",
+		Expected: []string{mediaTypeOpenMetrics, mediaTypeText},
+	},
+	{
+		Name:     "invalid escaping scheme",
+		Accept:   config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0] + ";escaping=bogus",
+		Expected: []string{mediaTypeOpenMetrics, mediaTypeText},
+	},
+	{
+		Name:     "malformed parameters",
+		Accept:   "application/openmetrics-text;version=1.0.0;;q=abc,text/plain;version=",
+		Expected: []string{mediaTypeOpenMetrics, mediaTypeText},
+	},
+	{
+		Name:     "unsupported format",
+		Accept:   "application/json",
+		Expected: []string{mediaTypeText},
+	},
+	{
+		Name:     "empty",
+		Accept:   "",
+		Expected: []string{mediaTypeText},
+	},
+}
+
+// NegotiationResult is the answer of a target to a NegotiationProbe.
+type NegotiationResult struct {
+	Probe       NegotiationProbe
+	ContentType string
+	// Series is the number of series parsed from the body as ContentType.
+	Series int
+	// Problem describes why the answer is wrong, empty when it is right.
+	Problem string
+}
+
+// ProbeNegotiation sends every probe to the target and checks that the answer is in an expected format and
+// parses as the content type it claims, as a protobuf body labeled as text fails to parse in Prometheus.
+func (ps *PromScraper) ProbeNegotiation(ctx context.Context, probes []NegotiationProbe) ([]NegotiationResult, error) {
+	results := make([]NegotiationResult, 0, len(probes))
+	for _, probe := range probes {
+		scraper := *ps
+		scraper.headers = ps.headers.Clone()
+		if scraper.headers == nil {
+			scraper.headers = http.Header{}
+		}
+		scraper.headers.Set("Accept", probe.Accept)
+
+		result := NegotiationResult{Probe: probe}
+		fetch, err := scraper.FetchRawWithContext(ctx)
+		var se *statusError
+		switch {
+		case errors.As(err, &se):
+			result.Problem = "answered with HTTP status " + se.status
+			results = append(results, result)
+			continue
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to fetch the target for probe %q: %w", probe.Name, err)
+		}
+
+		result.ContentType = fetch.ContentType
+		result.Problem, result.Series = checkNegotiated(&scraper, probe, fetch)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// checkNegotiated checks the answer to a probe, returning the problem found and the series parsed.
+func checkNegotiated(ps *PromScraper, probe NegotiationProbe, fetch *Fetch) (string, int) {
+	mediaType, _, err := mime.ParseMediaType(fetch.ContentType)
+	if err != nil {
+		return fmt.Sprintf("invalid content type %q", fetch.ContentType), 0
+	}
+	if !slices.Contains(probe.Expected, mediaType) {
+		return fmt.Sprintf("answered with %s, expected one of %v", mediaType, probe.Expected), 0
+	}
+	result, err := ps.Parse(fetch.Body, fetch.ContentType)
+	if err != nil {
+		return "body does not parse as " + mediaType + ": " + err.Error(), 0
+	}
+	series := 0
+	for _, set := range result.Series {
+		series += set.Cardinality()
+	}
+	if result.ParseFailed || (series == 0 && len(fetch.Body) > 0) {
+		return "body does not parse as " + mediaType, series
+	}
+	return "", series
+}
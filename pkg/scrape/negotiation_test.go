@@ -0,0 +1,86 @@
+package scrape_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestPromScraper_ProbeNegotiation(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "Requests."},
+		[]string{"code"})
+	requests.WithLabelValues("200").Inc()
+	reg.MustRegister(requests)
+	handler := promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true})
+
+	t.Run("client_golang", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewServer(handler)
+		defer srv.Close()
+
+		results, err := scrape.NewPromScraper(srv.URL, log.NewNopLogger()).
+			ProbeNegotiation(context.Background(), scrape.NegotiationProbes)
+		require.NoError(t, err)
+		require.Len(t, results, len(scrape.NegotiationProbes))
+		for _, r := range results {
+			require.Empty(t, r.Problem, r.Probe.Name)
+			require.Equal(t, 1, r.Series, r.Probe.Name)
+		}
+	})
+
+	t.Run("protobuf labeled as text", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Header.Set("Accept", "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;"+
+				"encoding=delimited")
+			handler.ServeHTTP(&textContentType{w}, r)
+		}))
+		defer srv.Close()
+
+		results, err := scrape.NewPromScraper(srv.URL, log.NewNopLogger()).
+			ProbeNegotiation(context.Background(), scrape.NegotiationProbes[2:3])
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "text only", results[0].Probe.Name)
+		require.Contains(t, results[0].Problem, "does not parse as text/plain")
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotAcceptable)
+		}))
+		defer srv.Close()
+
+		results, err := scrape.NewPromScraper(srv.URL, log.NewNopLogger()).
+			ProbeNegotiation(context.Background(), scrape.NegotiationProbes[:1])
+		require.NoError(t, err)
+		require.Equal(t, "answered with HTTP status 406 Not Acceptable", results[0].Problem)
+	})
+}
+
+// textContentType labels every response as the text format, whatever its body.
+type textContentType struct {
+	http.ResponseWriter
+}
+
+func (w *textContentType) WriteHeader(code int) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *textContentType) Write(b []byte) (int, error) {
+	// Without effect once the header is written.
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,193 @@
+package scrape
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// CustomBucketsSchema is the schema of native histograms with custom buckets (NHCB), the representation
+// Prometheus 3 converts classic histograms to with convert_classic_histograms_to_nhcb.
+const CustomBucketsSchema = -53
+
+// Kinds of histograms.
+const (
+	ClassicHistogram       = "classic"
+	ExponentialHistogram   = "exponential"
+	CustomBucketsHistogram = "nhcb"
+)
+
+// HistogramInfo describes how the histograms of a family are exposed and, for classic histograms, whether
+// they would convert cleanly to native histograms with custom buckets.
+type HistogramInfo struct {
+	Family string
+	// Kind is ClassicHistogram, ExponentialHistogram or CustomBucketsHistogram.
+	Kind string
+	// Schemas are the distinct schemas of the native histograms of the family, in increasing order.
+	Schemas []int32
+	// Histograms is the number of histograms of the family, one per label set.
+	Histograms int
+	// Series is the number of series of the family, the bucket, sum and count series of classic histograms.
+	// Converted to NHCB, a classic histogram is a single series.
+	Series int
+	// Bounds are the upper bounds of the buckets of the classic histograms, +Inf excluded, of the layout most of
+	// them share. Layouts is the number of distinct layouts.
+	Bounds  []float64
+	Layouts int
+	// Problem explains why the classic histograms would not convert cleanly, empty when they would.
+	Problem string
+}
+
+// Convertible reports whether the family is made of classic histograms that would convert cleanly to NHCB.
+func (h HistogramInfo) Convertible() bool {
+	return h.Kind == ClassicHistogram && h.Problem == ""
+}
+
+// classicHistogram gathers the series of a classic histogram, one label set of its family.
+type classicHistogram struct {
+	labels  labels.Labels
+	buckets map[string]float64
+	count   float64
+	counted bool
+}
+
+// Histograms describes the histogram families of the scrape, in name order.
+func Histograms(sm SeriesMap) []HistogramInfo {
+	infos := make(map[string]*HistogramInfo)
+	classic := make(map[string]map[uint64]*classicHistogram)
+	b := labels.NewBuilder(labels.EmptyLabels())
+	for _, set := range sm {
+		for _, s := range set {
+			if s.Type != "native_histogram" && s.Type != "histogram" {
+				continue
+			}
+			family, suffix := s.Name, ""
+			if s.Type == "histogram" {
+				for _, sfx := range []string{"_bucket", "_sum", "_count"} {
+					if f, ok := strings.CutSuffix(s.Name, sfx); ok {
+						family, suffix = f, sfx
+						break
+					}
+				}
+			}
+			info, ok := infos[family]
+			if !ok {
+				info = &HistogramInfo{Family: family}
+				infos[family] = info
+			}
+			info.Series++
+
+			if s.Type == "native_histogram" {
+				info.Histograms++
+				if !slices.Contains(info.Schemas, s.Schema) {
+					info.Schemas = append(info.Schemas, s.Schema)
+				}
+				continue
+			}
+			b.Reset(s.Labels)
+			b.Del(labels.MetricName, labels.BucketLabel)
+			lset := b.Labels()
+			if classic[family] == nil {
+				classic[family] = make(map[uint64]*classicHistogram)
+			}
+			h, ok := classic[family][lset.Hash()]
+			if !ok {
+				h = &classicHistogram{labels: lset, buckets: make(map[string]float64)}
+				classic[family][lset.Hash()] = h
+			}
+			switch suffix {
+			case "_bucket":
+				h.buckets[s.Labels.Get(labels.BucketLabel)] = s.Value
+			case "_count":
+				h.count, h.counted = s.Value, true
+			}
+		}
+	}
+
+	result := make([]HistogramInfo, 0, len(infos))
+	for _, name := range slices.Sorted(maps.Keys(infos)) {
+		info := infos[name]
+		if len(info.Schemas) > 0 {
+			slices.Sort(info.Schemas)
+			info.Kind = ExponentialHistogram
+			if slices.Contains(info.Schemas, CustomBucketsSchema) {
+				info.Kind = CustomBucketsHistogram
+			}
+		} else {
+			info.classify(classic[name])
+		}
+		result = append(result, *info)
+	}
+	return result
+}
+
+// classify describes the bucket layouts of the classic histograms of the family and checks they convert.
+func (info *HistogramInfo) classify(classic map[uint64]*classicHistogram) {
+	info.Kind = ClassicHistogram
+	info.Histograms = len(classic)
+	layouts := make(map[string]int)
+	bounds := make(map[string][]float64)
+	histograms := slices.Collect(maps.Values(classic))
+	slices.SortFunc(histograms, func(a, b *classicHistogram) int { return labels.Compare(a.labels, b.labels) })
+	for _, h := range histograms {
+		hb, problem := h.convert()
+		if problem != "" && info.Problem == "" {
+			info.Problem = h.labels.String() + ": " + problem
+		}
+		key := fmt.Sprint(hb)
+		layouts[key]++
+		bounds[key] = hb
+	}
+	info.Layouts = len(layouts)
+	common := slices.MaxFunc(slices.Sorted(maps.Keys(layouts)), func(a, b string) int {
+		return cmp.Compare(layouts[a], layouts[b])
+	})
+	info.Bounds = bounds[common]
+}
+
+// convert checks the histogram converts cleanly to NHCB, and returns its bucket bounds, +Inf excluded, along
+// with the reason why it does not.
+func (h *classicHistogram) convert() ([]float64, string) {
+	type bucket struct {
+		le    float64
+		count float64
+	}
+	buckets := make([]bucket, 0, len(h.buckets))
+	for le, count := range h.buckets {
+		bound, err := strconv.ParseFloat(strings.TrimSpace(le), 64)
+		if err != nil || math.IsNaN(bound) {
+			return nil, fmt.Sprintf("invalid bucket bound le=%q", le)
+		}
+		buckets = append(buckets, bucket{bound, count})
+	}
+	slices.SortFunc(buckets, func(a, b bucket) int { return cmp.Compare(a.le, b.le) })
+
+	bounds := make([]float64, 0, len(buckets))
+	for _, b := range buckets {
+		if !math.IsInf(b.le, 1) {
+			bounds = append(bounds, b.le)
+		}
+	}
+	switch {
+	case len(buckets) == 0:
+		return bounds, "no buckets"
+	case !math.IsInf(buckets[len(buckets)-1].le, 1):
+		return bounds, "no +Inf bucket"
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i].count < buckets[i-1].count {
+			return bounds, fmt.Sprintf("bucket counts decrease at le=%s, they must be cumulative",
+				strconv.FormatFloat(buckets[i].le, 'g', -1, 64))
+		}
+	}
+	if inf := buckets[len(buckets)-1].count; h.counted && h.count != inf {
+		return bounds, fmt.Sprintf("the count %g differs from the +Inf bucket %g", h.count, inf)
+	}
+	return bounds, ""
+}
@@ -0,0 +1,85 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestHistograms(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte(`# TYPE latency_seconds histogram
+latency_seconds_bucket{path="/a",le="0.1"} 3
+latency_seconds_bucket{path="/a",le="1"} 4
+latency_seconds_bucket{path="/a",le="+Inf"} 4
+latency_seconds_sum{path="/a"} 0.5
+latency_seconds_count{path="/a"} 4
+latency_seconds_bucket{path="/b",le="0.1"} 1
+latency_seconds_bucket{path="/b",le="1"} 1
+latency_seconds_bucket{path="/b",le="+Inf"} 2
+latency_seconds_sum{path="/b"} 0.5
+latency_seconds_count{path="/b"} 2
+latency_seconds_bucket{path="/c",le="0.5"} 1
+latency_seconds_bucket{path="/c",le="+Inf"} 1
+latency_seconds_sum{path="/c"} 0.2
+latency_seconds_count{path="/c"} 1
+# TYPE size_bytes histogram
+size_bytes_bucket{le="100"} 5
+size_bytes_bucket{le="1000"} 4
+size_bytes_bucket{le="+Inf"} 5
+size_bytes_sum 1200
+size_bytes_count 5
+# TYPE up gauge
+up 1
+`), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+
+	native := func(name string, schema int32) scrape.SeriesSet {
+		lset := labels.FromStrings(labels.MetricName, name)
+		return scrape.SeriesSet{lset.Hash(): {Name: name, Labels: lset, Type: "native_histogram", Schema: schema}}
+	}
+	result.Series["rpc_seconds"] = native("rpc_seconds", scrape.CustomBucketsSchema)
+	result.Series["queue_seconds"] = native("queue_seconds", 3)
+
+	infos := scrape.Histograms(result.Series)
+	require.Equal(t, []scrape.HistogramInfo{
+		{
+			Family:     "latency_seconds",
+			Kind:       scrape.ClassicHistogram,
+			Histograms: 3,
+			Series:     14,
+			Bounds:     []float64{0.1, 1},
+			Layouts:    2,
+		},
+		{
+			Family:     "queue_seconds",
+			Kind:       scrape.ExponentialHistogram,
+			Schemas:    []int32{3},
+			Histograms: 1,
+			Series:     1,
+		},
+		{
+			Family:     "rpc_seconds",
+			Kind:       scrape.CustomBucketsHistogram,
+			Schemas:    []int32{scrape.CustomBucketsSchema},
+			Histograms: 1,
+			Series:     1,
+		},
+		{
+			Family:     "size_bytes",
+			Kind:       scrape.ClassicHistogram,
+			Histograms: 1,
+			Series:     5,
+			Bounds:     []float64{100, 1000},
+			Layouts:    1,
+			Problem:    "{}: bucket counts decrease at le=1000, they must be cumulative",
+		},
+	}, infos)
+	require.True(t, infos[0].Convertible())
+	require.False(t, infos[3].Convertible())
+}
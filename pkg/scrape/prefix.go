@@ -0,0 +1,107 @@
+package scrape
+
+import (
+	"slices"
+	"strings"
+)
+
+// PrefixNode is a node of the tree grouping metric families by the underscore-delimited segments of
+// their names, e.g. kube_ → kube_pod_ → kube_pod_container_info.
+type PrefixNode struct {
+	// Prefix is the name prefix shared by every metric below the node, the full name for metrics.
+	Prefix string
+	// Metric is set when the node is a metric family, rather than only a prefix of other metrics.
+	Metric bool
+	// Cardinality and Metrics are aggregated over the node and all of its descendants.
+	Cardinality int
+	Metrics     int
+	Children    []*PrefixNode
+}
+
+// NewPrefixTree builds the prefix tree of the given metrics. Prefixes with a single child are
+// collapsed into it, the returned root has an empty prefix.
+func NewPrefixTree(rows []SeriesInfo) *PrefixNode {
+	root := &PrefixNode{}
+	for _, r := range rows {
+		node := root
+		for _, segment := range strings.SplitAfter(r.Name, "_") {
+			prefix := node.Prefix + segment
+			i := slices.IndexFunc(node.Children, func(c *PrefixNode) bool { return c.Prefix == prefix })
+			if i < 0 {
+				node.Children = append(node.Children, &PrefixNode{Prefix: prefix})
+				i = len(node.Children) - 1
+			}
+			node = node.Children[i]
+		}
+		node.Metric = true
+		node.Cardinality += r.Cardinality
+		node.Metrics++
+	}
+	root.aggregate()
+	root.compress()
+	return root
+}
+
+// aggregate sums up the cardinality of the descendants and sorts the children by cardinality.
+func (n *PrefixNode) aggregate() {
+	for _, c := range n.Children {
+		c.aggregate()
+		n.Cardinality += c.Cardinality
+		n.Metrics += c.Metrics
+	}
+	slices.SortFunc(n.Children, func(i, j *PrefixNode) int {
+		if c := j.Cardinality - i.Cardinality; c != 0 {
+			return c
+		}
+		return strings.Compare(i.Prefix, j.Prefix)
+	})
+}
+
+// compress replaces the children that are only a prefix of a single other node by that node.
+func (n *PrefixNode) compress() {
+	for i, c := range n.Children {
+		for !c.Metric && len(c.Children) == 1 {
+			c = c.Children[0]
+		}
+		c.compress()
+		n.Children[i] = c
+	}
+}
+
+// PrefixInfo is the cardinality of the metric families sharing a name prefix.
+type PrefixInfo struct {
+	Prefix      string
+	Cardinality int
+	Metrics     int
+}
+
+// ByPrefix sums up the cardinality of the metric families by the first segments of their
+// underscore-delimited names, e.g. with two segments `kube_pod_labels` counts towards `kube_pod`.
+// The result is sorted by cardinality.
+func (s SeriesMap) ByPrefix(segments int) []PrefixInfo {
+	segments = max(segments, 1)
+	prefixes := make(map[string]*PrefixInfo)
+	for name, set := range s {
+		parts := strings.SplitN(name, "_", segments+1)
+		prefix := strings.Join(parts[:min(segments, len(parts))], "_")
+		p, ok := prefixes[prefix]
+		if !ok {
+			p = &PrefixInfo{Prefix: prefix}
+			prefixes[prefix] = p
+		}
+		p.Cardinality += set.Cardinality()
+		p.Metrics++
+	}
+
+	infos := make([]PrefixInfo, 0, len(prefixes))
+	for _, p := range prefixes {
+		infos = append(infos, *p)
+	}
+	slices.SortFunc(infos, func(i, j PrefixInfo) int {
+		if c := j.Cardinality - i.Cardinality; c != 0 {
+			return c
+		}
+		return strings.Compare(i.Prefix, j.Prefix)
+	})
+	return infos
+}
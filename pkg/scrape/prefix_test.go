@@ -0,0 +1,67 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestNewPrefixTree(t *testing.T) {
+	t.Parallel()
+	root := scrape.NewPrefixTree([]scrape.SeriesInfo{
+		{Name: "kube_pod_labels", Cardinality: 10},
+		{Name: "kube_pod_container_info", Cardinality: 30},
+		{Name: "kube_pod_container_status_restarts_total", Cardinality: 30},
+		{Name: "kube_node_info", Cardinality: 2},
+		{Name: "up", Cardinality: 1},
+	})
+
+	require.Equal(t, "", root.Prefix)
+	require.Equal(t, 73, root.Cardinality)
+	require.Equal(t, 5, root.Metrics)
+	require.Len(t, root.Children, 2)
+
+	// The lone kube_node_ prefix is collapsed into its only metric.
+	kube := root.Children[0]
+	require.Equal(t, "kube_", kube.Prefix)
+	require.Equal(t, 72, kube.Cardinality)
+	require.Len(t, kube.Children, 2)
+	require.Equal(t, "kube_pod_", kube.Children[0].Prefix)
+	require.Equal(t, "kube_node_info", kube.Children[1].Prefix)
+	require.True(t, kube.Children[1].Metric)
+
+	pod := kube.Children[0]
+	require.Equal(t, 3, pod.Metrics)
+	require.Equal(t, "kube_pod_container_", pod.Children[0].Prefix)
+	require.Equal(t, 60, pod.Children[0].Cardinality)
+	require.Equal(t, []string{"kube_pod_container_info", "kube_pod_container_status_restarts_total"},
+		[]string{pod.Children[0].Children[0].Prefix, pod.Children[0].Children[1].Prefix})
+	require.Equal(t, "kube_pod_labels", pod.Children[1].Prefix)
+
+	require.Equal(t, "up", root.Children[1].Prefix)
+	require.True(t, root.Children[1].Metric)
+	require.Empty(t, root.Children[1].Children)
+}
+
+func TestSeriesMap_ByPrefix(t *testing.T) {
+	t.Parallel()
+	seriesMap := scrape.SeriesMap{
+		"kube_pod_labels":         {1: {}, 2: {}},
+		"kube_pod_container_info": {1: {}, 2: {}, 3: {}},
+		"kube_node_info":          {1: {}},
+		"up":                      {1: {}},
+	}
+
+	require.Equal(t, []scrape.PrefixInfo{
+		{Prefix: "kube", Cardinality: 6, Metrics: 3},
+		{Prefix: "up", Cardinality: 1, Metrics: 1},
+	}, seriesMap.ByPrefix(1))
+
+	require.Equal(t, []scrape.PrefixInfo{
+		{Prefix: "kube_pod", Cardinality: 5, Metrics: 2},
+		{Prefix: "kube_node", Cardinality: 1, Metrics: 1},
+		{Prefix: "up", Cardinality: 1, Metrics: 1},
+	}, seriesMap.ByPrefix(2))
+}
@@ -0,0 +1,92 @@
+package scrape
+
+import "time"
+
+// ProgressPhase is the phase of a scrape a Progress reports on.
+type ProgressPhase string
+
+const (
+	ProgressFetch ProgressPhase = "fetch"
+	ProgressParse ProgressPhase = "parse"
+)
+
+// Progress is a snapshot of a fetch or parse in progress.
+type Progress struct {
+	Phase ProgressPhase
+	// BytesRead is the size of the body read so far as transferred, TotalBytes its Content-Length, or -1
+	// when the target does not send one. Both are only set while fetching.
+	BytesRead  int64
+	TotalBytes int64
+	// Series is the number of series parsed so far.
+	Series int
+	// Elapsed is the time since the phase started.
+	Elapsed time.Duration
+}
+
+// ProgressFunc receives the progress of the scrapes, it is called from the goroutine scraping.
+type ProgressFunc func(Progress)
+
+// WithProgress reports the progress of fetches and parses to fn, at most once per interval. Scrapes
+// completing within the interval are not reported at all.
+func WithProgress(fn ProgressFunc, interval time.Duration) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.progress = fn
+		opts.progressInterval = interval
+	}
+}
+
+// parseProgressEvery is the number of series parsed between checks of the progress interval, so the
+// parse loop does not read the clock for every series.
+const parseProgressEvery = 1024
+
+// progressTracker throttles the progress reports of a phase, a nil tracker reports nothing.
+type progressTracker struct {
+	fn       ProgressFunc
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+	progress Progress
+}
+
+// newProgress starts tracking a phase, it returns nil when the scraper has no progress function.
+func (ps *PromScraper) newProgress(phase ProgressPhase, totalBytes int64) *progressTracker {
+	if ps.progress == nil {
+		return nil
+	}
+	now := time.Now()
+	return &progressTracker{
+		fn:       ps.progress,
+		interval: ps.progressInterval,
+		start:    now,
+		last:     now,
+		progress: Progress{Phase: phase, TotalBytes: totalBytes},
+	}
+}
+
+func (t *progressTracker) addBytes(n int) {
+	if t == nil {
+		return
+	}
+	t.progress.BytesRead += int64(n)
+	t.report()
+}
+
+func (t *progressTracker) addSeries() {
+	if t == nil {
+		return
+	}
+	t.progress.Series++
+	if t.progress.Series%parseProgressEvery == 0 {
+		t.report()
+	}
+}
+
+func (t *progressTracker) report() {
+	now := time.Now()
+	if now.Sub(t.last) < t.interval {
+		return
+	}
+	t.last = now
+	t.progress.Elapsed = now.Sub(t.start)
+	t.fn(t.progress)
+}
@@ -0,0 +1,71 @@
+package scrape_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestPromScraper_Progress(t *testing.T) {
+	t.Parallel()
+	var body strings.Builder
+	body.WriteString("# TYPE requests_total counter\n")
+	for i := range 3000 {
+		fmt.Fprintf(&body, "requests_total{id=\"%d\"} 1\n", i)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+		_, _ = w.Write([]byte(body.String()))
+	}))
+	defer srv.Close()
+
+	var reports []scrape.Progress
+	ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger(),
+		scrape.WithProgress(func(p scrape.Progress) { reports = append(reports, p) }, 0))
+	_, err := ps.Scrape(context.Background())
+	require.NoError(t, err)
+
+	var fetch, parse []scrape.Progress
+	for _, p := range reports {
+		switch p.Phase {
+		case scrape.ProgressFetch:
+			fetch = append(fetch, p)
+		case scrape.ProgressParse:
+			parse = append(parse, p)
+		}
+	}
+	require.NotEmpty(t, fetch)
+	last := fetch[len(fetch)-1]
+	require.Equal(t, int64(body.Len()), last.TotalBytes)
+	require.Equal(t, last.TotalBytes, last.BytesRead)
+
+	// The parse is reported every 1024 series.
+	require.Len(t, parse, 2)
+	require.Equal(t, 1024, parse[0].Series)
+	require.Equal(t, 2048, parse[1].Series)
+}
+
+func TestPromScraper_ProgressInterval(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("up 1\n"))
+	}))
+	defer srv.Close()
+
+	var reports int
+	ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger(),
+		scrape.WithProgress(func(scrape.Progress) { reports++ }, time.Hour))
+	_, err := ps.Scrape(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, reports)
+}
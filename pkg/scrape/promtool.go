@@ -0,0 +1,79 @@
+package scrape
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/prometheus/prometheus/config"
+)
+
+// PromtoolCheck is the kind of check comparing the lint with promtool.
+const PromtoolCheck = "promtool"
+
+// Rules of the promtool check.
+const (
+	PromtoolOnlyRule = "promtool-only"
+	LintOnlyRule     = "lint-only"
+)
+
+// ComparePromtool lints the body the way `promtool check metrics` does, reading it as the Prometheus text format
+// whatever its content type, and reports where promtool disagrees with the lint results of Lint: the problems
+// found by one of them only, and the bodies promtool cannot read at all. CI pipelines often run both tools, the
+// disagreements are warnings explaining why one fails and not the other. There is one result per family with
+// disagreements, in name order, after the result of the whole body when promtool cannot read it.
+func ComparePromtool(body []byte, lint []CheckResult) ([]CheckResult, error) {
+	families, err := DecodeFamilies(body, config.ScrapeProtocolsHeaders[config.PrometheusText0_0_4])
+	if err != nil {
+		return []CheckResult{{Check: PromtoolCheck, Problems: []Problem{{
+			Rule:     PromtoolOnlyRule,
+			Severity: SeverityWarning,
+			Text:     "promtool cannot read the exposition: " + err.Error(),
+		}}}}, nil
+	}
+	promtool, err := Lint(families)
+	if err != nil {
+		return nil, err
+	}
+
+	type finding struct{ metric, text string }
+	found := func(results []CheckResult) map[finding]bool {
+		m := make(map[finding]bool)
+		for _, r := range results {
+			for _, p := range r.Problems {
+				if p.Rule == PromlintRule {
+					m[finding{r.Metric, p.Text}] = true
+				}
+			}
+		}
+		return m
+	}
+	ours, theirs := found(lint), found(promtool)
+
+	byMetric := make(map[string][]Problem)
+	add := func(f finding, rule, by string) {
+		byMetric[f.metric] = append(byMetric[f.metric], Problem{
+			Rule:     rule,
+			Severity: SeverityWarning,
+			Text:     "only " + by + " reports: " + f.text,
+		})
+	}
+	for f := range theirs {
+		if !ours[f] {
+			add(f, PromtoolOnlyRule, "promtool")
+		}
+	}
+	for f := range ours {
+		if !theirs[f] {
+			add(f, LintOnlyRule, "the lint")
+		}
+	}
+
+	results := make([]CheckResult, 0, len(byMetric))
+	for _, name := range slices.Sorted(maps.Keys(byMetric)) {
+		problems := byMetric[name]
+		slices.SortFunc(problems, func(a, b Problem) int { return cmp.Compare(a.Text, b.Text) })
+		results = append(results, CheckResult{Check: PromtoolCheck, Metric: name, Problems: problems})
+	}
+	return results, nil
+}
@@ -0,0 +1,60 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestComparePromtool(t *testing.T) {
+	t.Parallel()
+	lintBody := func(body, contentType string) []scrape.CheckResult {
+		families, err := scrape.DecodeFamilies([]byte(body), contentType)
+		require.NoError(t, err)
+		results, err := scrape.Lint(families)
+		require.NoError(t, err)
+		return results
+	}
+
+	// Both read the text format the same way.
+	text := config.ScrapeProtocolsHeaders[config.PrometheusText0_0_4]
+	results, err := scrape.ComparePromtool([]byte(reduceText), lintBody(reduceText, text))
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	// promtool reads OpenMetrics as the text format, where the samples of a counter are not named after it.
+	const counter = `# HELP requests Requests served.
+# TYPE requests counter
+requests_total{code="200"} 10
+# EOF
+`
+	om := config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0]
+	lint := append(lintBody(counter, om), scrape.CheckResult{
+		Check:    scrape.LintCheck,
+		Metric:   "errors_total",
+		Problems: []scrape.Problem{{Rule: scrape.PromlintRule, Severity: scrape.SeverityError, Text: "no help text"}},
+	})
+	results, err = scrape.ComparePromtool([]byte(counter), lint)
+	require.NoError(t, err)
+	require.Equal(t, []scrape.CheckResult{
+		{Check: scrape.PromtoolCheck, Metric: "errors_total", Problems: []scrape.Problem{{
+			Rule:     scrape.LintOnlyRule,
+			Severity: scrape.SeverityWarning,
+			Text:     "only the lint reports: no help text",
+		}}},
+		{Check: scrape.PromtoolCheck, Metric: "requests_total", Problems: []scrape.Problem{{
+			Rule:     scrape.PromtoolOnlyRule,
+			Severity: scrape.SeverityWarning,
+			Text:     "only promtool reports: no help text",
+		}}},
+	}, results)
+
+	results, err = scrape.ComparePromtool([]byte("requests_total{code=\"200\" 10\n"), nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Empty(t, results[0].Metric)
+	require.Equal(t, scrape.PromtoolOnlyRule, results[0].Problems[0].Rule)
+}
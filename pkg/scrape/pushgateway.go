@@ -0,0 +1,129 @@
+package scrape
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+const (
+	// PushTimeMetric and PushFailureTimeMetric are exposed by a Pushgateway for every push group, with the
+	// grouping labels of the group.
+	PushTimeMetric        = "push_time_seconds"
+	PushFailureTimeMetric = "push_failure_time_seconds"
+)
+
+// PushGroup summarizes the series of a group of metrics pushed to a Pushgateway.
+type PushGroup struct {
+	// Labels are the grouping labels of the group, the job label included.
+	Labels labels.Labels
+	// PushTime is the time of the last successful push, FailureTime of the last failed one, zero when never.
+	PushTime             time.Time
+	FailureTime          time.Time
+	Cardinality          int
+	Metrics              int
+	TopMetric            string
+	TopMetricCardinality int
+}
+
+// Name identifies the group by its grouping labels, e.g. {instance="db-1", job="backup"}.
+func (g PushGroup) Name() string {
+	return g.Labels.String()
+}
+
+// Stale reports whether the group was last pushed more than after before now. Abandoned groups stay on the
+// Pushgateway, and are scraped, until deleted.
+func (g PushGroup) Stale(now time.Time, after time.Duration) bool {
+	return !g.PushTime.IsZero() && now.Sub(g.PushTime) > after
+}
+
+// IsPushgateway reports whether the scrape comes from a Pushgateway, from the push time metric it exposes.
+func (s SeriesMap) IsPushgateway() bool {
+	_, ok := s[PushTimeMetric]
+	return ok
+}
+
+// PushGroups groups the series of a Pushgateway scrape by the push group they belong to, the group with the
+// most grouping labels the series carries, and returns the groups sorted by cardinality. The push time
+// metrics count towards their group. Ungrouped is the number of series of no group, e.g. the metrics of the
+// Pushgateway itself.
+func (s SeriesMap) PushGroups() (groups []PushGroup, ungrouped int) {
+	type group struct {
+		PushGroup
+		metrics map[string]int
+	}
+	var pushed []*group
+	byLabels := make(map[uint64]*group)
+	b := labels.NewBuilder(labels.EmptyLabels())
+	for _, series := range s[PushTimeMetric] {
+		b.Reset(series.Labels)
+		b.Del(labels.MetricName)
+		g := &group{PushGroup: PushGroup{Labels: b.Labels(), PushTime: unixTime(series.Value)}}
+		g.metrics = make(map[string]int)
+		pushed = append(pushed, g)
+		byLabels[g.Labels.Hash()] = g
+	}
+	for _, series := range s[PushFailureTimeMetric] {
+		b.Reset(series.Labels)
+		b.Del(labels.MetricName)
+		if g, ok := byLabels[b.Labels().Hash()]; ok {
+			g.FailureTime = unixTime(series.Value)
+		}
+	}
+	// The most specific groups first, so that a series goes to the group with the most matching labels.
+	slices.SortFunc(pushed, func(a, b *group) int { return b.Labels.Len() - a.Labels.Len() })
+
+	for name, set := range s {
+		for _, series := range set {
+			i := slices.IndexFunc(pushed, func(g *group) bool { return carries(series.Labels, g.Labels) })
+			if i < 0 {
+				ungrouped++
+				continue
+			}
+			pushed[i].metrics[name]++
+			pushed[i].Cardinality++
+		}
+	}
+
+	groups = make([]PushGroup, 0, len(pushed))
+	for _, g := range pushed {
+		g.Metrics = len(g.metrics)
+		for name, count := range g.metrics {
+			if count > g.TopMetricCardinality || (count == g.TopMetricCardinality && name < g.TopMetric) {
+				g.TopMetric = name
+				g.TopMetricCardinality = count
+			}
+		}
+		groups = append(groups, g.PushGroup)
+	}
+	slices.SortFunc(groups, func(a, b PushGroup) int {
+		if c := b.Cardinality - a.Cardinality; c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return groups, ungrouped
+}
+
+// carries reports whether the series has every grouping label of a group, an empty value matching a missing label.
+func carries(series, grouping labels.Labels) bool {
+	ok := true
+	grouping.Range(func(l labels.Label) {
+		if series.Get(l.Name) != l.Value {
+			ok = false
+		}
+	})
+	return ok
+}
+
+// unixTime converts a timestamp in seconds, zero when unset.
+func unixTime(seconds float64) time.Time {
+	if seconds <= 0 || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
@@ -0,0 +1,65 @@
+package scrape_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestSeriesMap_PushGroups(t *testing.T) {
+	t.Parallel()
+	pushed := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	series := func(value float64, lset ...string) scrape.Series {
+		return scrape.Series{Labels: labels.FromStrings(lset...), Value: value}
+	}
+	seriesMap := scrape.SeriesMap{
+		scrape.PushTimeMetric: {
+			1: series(float64(pushed.Unix()), "__name__", "push_time_seconds", "job", "backup"),
+			2: series(float64(pushed.Add(-48*time.Hour).Unix()), "__name__", "push_time_seconds",
+				"job", "backup", "instance", "db-1"),
+			3: series(float64(pushed.Unix())+0.5, "__name__", "push_time_seconds", "job", "batch"),
+		},
+		scrape.PushFailureTimeMetric: {
+			4: series(float64(pushed.Unix()), "__name__", "push_failure_time_seconds", "job", "batch"),
+		},
+		"backup_files": {
+			5: series(1, "__name__", "backup_files", "job", "backup", "instance", "db-1", "dir", "/a"),
+			6: series(1, "__name__", "backup_files", "job", "backup", "instance", "db-1", "dir", "/b"),
+			7: series(1, "__name__", "backup_files", "job", "backup", "dir", "/c"),
+		},
+		"batch_last_success": {
+			8: series(1, "__name__", "batch_last_success", "job", "batch"),
+		},
+		"go_goroutines": {
+			9: series(1, "__name__", "go_goroutines"),
+		},
+	}
+	require.True(t, seriesMap.IsPushgateway())
+	require.False(t, scrape.SeriesMap{"up": {}}.IsPushgateway())
+
+	groups, ungrouped := seriesMap.PushGroups()
+	require.Equal(t, 1, ungrouped, "the metrics of the Pushgateway itself belong to no group")
+	require.Len(t, groups, 3)
+
+	// Series carrying the instance label go to the more specific group.
+	require.Equal(t, `{instance="db-1", job="backup"}`, groups[0].Name())
+	require.Equal(t, 3, groups[0].Cardinality)
+	require.Equal(t, 2, groups[0].Metrics)
+	require.Equal(t, "backup_files", groups[0].TopMetric)
+	require.Equal(t, 2, groups[0].TopMetricCardinality)
+	require.True(t, groups[0].Stale(pushed, 24*time.Hour))
+
+	require.Equal(t, `{job="batch"}`, groups[1].Name())
+	require.Equal(t, 3, groups[1].Cardinality)
+	require.Equal(t, pushed.Add(500*time.Millisecond), groups[1].PushTime)
+	require.Equal(t, pushed, groups[1].FailureTime)
+
+	require.Equal(t, `{job="backup"}`, groups[2].Name())
+	require.Equal(t, 2, groups[2].Cardinality)
+	require.False(t, groups[2].Stale(pushed, 24*time.Hour))
+	require.True(t, groups[2].FailureTime.IsZero())
+}
@@ -0,0 +1,149 @@
+package scrape
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/util/annotations"
+)
+
+// defaultQueryTimeout bounds the evaluation of a query over a scrape.
+const defaultQueryTimeout = 2 * time.Minute
+
+// queryEngine is shared by all queries, the engine only holds settings and metrics.
+var queryEngine = sync.OnceValue(func() *promql.Engine {
+	return promql.NewEngine(promql.EngineOpts{
+		MaxSamples:           50_000_000,
+		Timeout:              defaultQueryTimeout,
+		EnableAtModifier:     true,
+		EnableNegativeOffset: true,
+	})
+})
+
+// Query evaluates an instant PromQL query over the series of a scrape, as if they were stored by Prometheus
+// with a single sample at ts, e.g. `count by (path) (http_requests_total)`. Native histograms have no value
+// in a scrape and are left out, rates need more than one sample and return nothing.
+func Query(ctx context.Context, sm SeriesMap, ts time.Time, query string) (parser.Value, annotations.Annotations,
+	error) {
+	q, err := queryEngine().NewInstantQuery(ctx, newSnapshotQueryable(sm, ts), nil, query, ts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid query: %w", err)
+	}
+	defer q.Close()
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return nil, res.Warnings, res.Err
+	}
+	return res.Value, res.Warnings, nil
+}
+
+// snapshotQueryable is a storage holding one sample per float series of a scrape.
+type snapshotQueryable struct {
+	series []storage.Series
+}
+
+func newSnapshotQueryable(sm SeriesMap, ts time.Time) *snapshotQueryable {
+	t := ts.UnixMilli()
+	q := &snapshotQueryable{}
+	for _, set := range sm {
+		for _, s := range set {
+			if s.Type == "native_histogram" {
+				continue
+			}
+			q.series = append(q.series, storage.NewListSeries(s.Labels, []chunks.Sample{floatSample{t: t, f: s.Value}}))
+		}
+	}
+	slices.SortFunc(q.series, func(a, b storage.Series) int { return labels.Compare(a.Labels(), b.Labels()) })
+	return q
+}
+
+func (q *snapshotQueryable) Querier(int64, int64) (storage.Querier, error) {
+	return q, nil
+}
+
+func (q *snapshotQueryable) Select(_ context.Context, _ bool, _ *storage.SelectHints,
+	matchers ...*labels.Matcher) storage.SeriesSet {
+	return &listSeriesSet{series: q.matching(matchers), i: -1}
+}
+
+func (q *snapshotQueryable) LabelValues(_ context.Context, name string,
+	matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	values := make(map[string]struct{})
+	for _, s := range q.matching(matchers) {
+		if v := s.Labels().Get(name); v != "" {
+			values[v] = struct{}{}
+		}
+	}
+	return slices.Sorted(maps.Keys(values)), nil, nil
+}
+
+func (q *snapshotQueryable) LabelNames(_ context.Context,
+	matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	names := make(map[string]struct{})
+	for _, s := range q.matching(matchers) {
+		s.Labels().Range(func(l labels.Label) { names[l.Name] = struct{}{} })
+	}
+	return slices.Sorted(maps.Keys(names)), nil, nil
+}
+
+func (q *snapshotQueryable) Close() error {
+	return nil
+}
+
+// matching returns the series matching all the matchers, in label order.
+func (q *snapshotQueryable) matching(matchers []*labels.Matcher) []storage.Series {
+	var matched []storage.Series
+	for _, s := range q.series {
+		lset := s.Labels()
+		if !slices.ContainsFunc(matchers, func(m *labels.Matcher) bool { return !m.Matches(lset.Get(m.Name)) }) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// listSeriesSet iterates over a slice of series.
+type listSeriesSet struct {
+	series []storage.Series
+	i      int
+}
+
+func (s *listSeriesSet) Next() bool {
+	s.i++
+	return s.i < len(s.series)
+}
+
+func (s *listSeriesSet) At() storage.Series {
+	return s.series[s.i]
+}
+
+func (s *listSeriesSet) Err() error {
+	return nil
+}
+
+func (s *listSeriesSet) Warnings() annotations.Annotations {
+	return nil
+}
+
+// floatSample is the sample of a float series.
+type floatSample struct {
+	t int64
+	f float64
+}
+
+func (s floatSample) T() int64                      { return s.t }
+func (s floatSample) F() float64                    { return s.f }
+func (s floatSample) H() *histogram.Histogram       { return nil }
+func (s floatSample) FH() *histogram.FloatHistogram { return nil }
+func (s floatSample) Type() chunkenc.ValueType      { return chunkenc.ValFloat }
@@ -0,0 +1,49 @@
+package scrape_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte(`# TYPE http_requests_total counter
+http_requests_total{path="/a",code="200"} 10
+http_requests_total{path="/a",code="500"} 2
+http_requests_total{path="/b",code="200"} 5
+# TYPE up gauge
+up 1
+`), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+	ts := time.Unix(1700000000, 0)
+
+	value, _, err := scrape.Query(context.Background(), result.Series, ts,
+		`sort_desc(count by (path) (http_requests_total))`)
+	require.NoError(t, err)
+	vector, ok := value.(promql.Vector)
+	require.True(t, ok)
+	require.Len(t, vector, 2)
+	require.Equal(t, labels.FromStrings("path", "/a"), vector[0].Metric)
+	require.InDelta(t, 2.0, vector[0].F, 0)
+	require.Equal(t, ts.UnixMilli(), vector[0].T)
+
+	value, _, err = scrape.Query(context.Background(), result.Series, ts, `sum(http_requests_total{code="200"})`)
+	require.NoError(t, err)
+	require.InDelta(t, 15.0, value.(promql.Vector)[0].F, 0)
+
+	value, _, err = scrape.Query(context.Background(), result.Series, ts, `count(count by (__name__) ({__name__=~".+"}))`)
+	require.NoError(t, err)
+	require.InDelta(t, 2.0, value.(promql.Vector)[0].F, 0)
+
+	_, _, err = scrape.Query(context.Background(), result.Series, ts, `sum(`)
+	require.Error(t, err)
+}
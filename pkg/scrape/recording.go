@@ -0,0 +1,186 @@
+package scrape
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// recordingIndex is the file listing the scrapes of a recording directory, one JSON object per line.
+const recordingIndex = "index.jsonl"
+
+// RecordedScrape is the metadata of a scrape stored in a recording, its body is kept gzipped in File.
+type RecordedScrape struct {
+	Time        time.Time     `json:"time"`
+	URL         string        `json:"url"`
+	ContentType string        `json:"content_type"`
+	File        string        `json:"file"`
+	BodyBytes   int           `json:"body_bytes"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Recorder appends scrapes to a recording directory.
+type Recorder struct {
+	dir   string
+	url   string
+	count int
+}
+
+// NewRecorder creates the recording directory if needed. Scrapes are appended to an existing recording.
+func NewRecorder(dir, url string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	scrapes, err := readIndex(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return &Recorder{dir: dir, url: url, count: len(scrapes)}, nil
+}
+
+// Record stores the body of a fetch and appends its metadata to the index.
+func (r *Recorder) Record(fetch *Fetch, t time.Time) (RecordedScrape, error) {
+	rec := RecordedScrape{
+		Time:        t,
+		URL:         r.url,
+		ContentType: fetch.ContentType,
+		File:        fmt.Sprintf("%06d.gz", r.count+1),
+		BodyBytes:   len(fetch.Body),
+		Duration:    fetch.Duration,
+	}
+	if err := writeGzip(filepath.Join(r.dir, rec.File), fetch.Body); err != nil {
+		return rec, err
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return rec, err
+	}
+	f, err := os.OpenFile(filepath.Join(r.dir, recordingIndex), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return rec, fmt.Errorf("failed to open recording index: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return rec, fmt.Errorf("failed to append to recording index: %w", err)
+	}
+	r.count++
+	return rec, nil
+}
+
+// Count returns the number of scrapes in the recording.
+func (r *Recorder) Count() int {
+	return r.count
+}
+
+func writeGzip(path string, body []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write(body); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return gz.Close()
+}
+
+// Recording is a directory of scrapes written by a Recorder.
+type Recording struct {
+	Dir     string
+	Scrapes []RecordedScrape
+}
+
+// OpenRecording reads the index of a recording directory.
+func OpenRecording(dir string) (*Recording, error) {
+	scrapes, err := readIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(scrapes) == 0 {
+		return nil, fmt.Errorf("recording %s has no scrapes", dir)
+	}
+	return &Recording{Dir: dir, Scrapes: scrapes}, nil
+}
+
+// Body returns the decompressed body of the i-th scrape.
+func (r *Recording) Body(i int) ([]byte, error) {
+	f, err := os.Open(filepath.Join(r.Dir, r.Scrapes[i].File))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", r.Scrapes[i].File, err)
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+func readIndex(dir string) ([]RecordedScrape, error) {
+	f, err := os.Open(filepath.Join(dir, recordingIndex))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording index: %w", err)
+	}
+	defer f.Close()
+
+	var scrapes []RecordedScrape
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec RecordedScrape
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("invalid recording index entry on line %d: %w", line, err)
+		}
+		scrapes = append(scrapes, rec)
+	}
+	return scrapes, sc.Err()
+}
+
+// Replayer feeds the scrapes of a recording back, one per call to Next, as if the target was scraped live.
+type Replayer struct {
+	recording *Recording
+	parser    *PromScraper
+	next      int
+}
+
+// NewReplayer replays the recording, parsing the bodies as parser parses live scrapes.
+func NewReplayer(recording *Recording, parser *PromScraper) *Replayer {
+	return &Replayer{recording: recording, parser: parser}
+}
+
+// ErrEndOfRecording is returned by Next once every scrape has been replayed.
+var ErrEndOfRecording = errors.New("end of recording")
+
+// Next parses the next recorded scrape.
+func (r *Replayer) Next() (*Result, error) {
+	if r.next >= len(r.recording.Scrapes) {
+		return nil, ErrEndOfRecording
+	}
+	i := r.next
+	r.next++
+
+	body, err := r.recording.Body(i)
+	if err != nil {
+		return nil, err
+	}
+	rec := r.recording.Scrapes[i]
+	result, err := r.parser.Parse(body, rec.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	result.Time = rec.Time
+	return result, nil
+}
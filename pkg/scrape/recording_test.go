@@ -0,0 +1,64 @@
+package scrape_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestRecording(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	const url = "http://localhost:9090/metrics"
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	recorder, err := scrape.NewRecorder(dir, url)
+	require.NoError(t, err)
+	_, err = recorder.Record(&scrape.Fetch{
+		ContentType: "text/plain; version=0.0.4",
+		Body:        []byte("# TYPE up gauge\nup 1\n"),
+	}, start)
+	require.NoError(t, err)
+
+	// Recording again in the same directory appends to it.
+	recorder, err = scrape.NewRecorder(dir, url)
+	require.NoError(t, err)
+	rec, err := recorder.Record(&scrape.Fetch{
+		ContentType: "text/plain; version=0.0.4",
+		Body:        []byte("# TYPE up gauge\nup{instance=\"a\"} 1\nup{instance=\"b\"} 0\n"),
+	}, start.Add(15*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, "000002.gz", rec.File)
+	require.Equal(t, 2, recorder.Count())
+
+	recording, err := scrape.OpenRecording(dir)
+	require.NoError(t, err)
+	require.Len(t, recording.Scrapes, 2)
+	require.Equal(t, url, recording.Scrapes[0].URL)
+
+	replayer := scrape.NewReplayer(recording, scrape.NewPromScraper(url, log.NewNopLogger()))
+	for _, expected := range []struct {
+		time        time.Time
+		cardinality int
+	}{
+		{time: start, cardinality: 1},
+		{time: start.Add(15 * time.Second), cardinality: 2},
+	} {
+		result, err := replayer.Next()
+		require.NoError(t, err)
+		require.True(t, expected.time.Equal(result.Time))
+		require.Equal(t, expected.cardinality, result.Series["up"].Cardinality())
+	}
+	_, err = replayer.Next()
+	require.ErrorIs(t, err, scrape.ErrEndOfRecording)
+}
+
+func TestOpenRecording_Missing(t *testing.T) {
+	t.Parallel()
+	_, err := scrape.OpenRecording(t.TempDir())
+	require.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package scrape
+
+import (
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"google.golang.org/protobuf/proto"
+)
+
+// FamilyFilter reduces metric families the way the analysis reduces their series, so that the reduced exposition
+// can be written out, see ReduceFamilies.
+type FamilyFilter struct {
+	// Matchers must all match the series, a matcher on the metric name is matched against the family name.
+	Matchers []*labels.Matcher
+	// DropLabels are removed from the series, and when KeepLabels is set only they are kept, like
+	// WithLabelFilter does.
+	DropLabels []string
+	KeepLabels []string
+	// Relabel are metric relabel configs applied last, e.g. the metric_relabel_configs of a job. Series renamed
+	// by them move to a family of the new name.
+	Relabel []*relabel.Config
+}
+
+func (f FamilyFilter) matches(lset labels.Labels) bool {
+	for _, m := range f.Matchers {
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReduceFamilies returns the families reduced by the filter, the input is left untouched. Series made identical
+// by dropping labels are merged into the first of them, as an exposition with duplicate series is rejected by
+// Prometheus, and the number of series merged is returned. Families left without series are removed.
+func ReduceFamilies(families []*dto.MetricFamily, filter FamilyFilter) ([]*dto.MetricFamily, int) {
+	var (
+		reduced []*dto.MetricFamily
+		byName  = make(map[string]*dto.MetricFamily)
+		seen    = make(map[string]map[uint64]struct{})
+		merged  int
+		b       = labels.NewBuilder(labels.EmptyLabels())
+	)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			b.Reset(labels.EmptyLabels())
+			b.Set(labels.MetricName, mf.GetName())
+			for _, lp := range m.GetLabel() {
+				b.Set(lp.GetName(), lp.GetValue())
+			}
+			if !filter.matches(b.Labels()) {
+				continue
+			}
+			b.Del(filter.DropLabels...)
+			if len(filter.KeepLabels) > 0 {
+				b.Keep(append([]string{labels.MetricName}, filter.KeepLabels...)...)
+			}
+			if !relabel.ProcessBuilder(b, filter.Relabel...) {
+				continue
+			}
+			lset := b.Labels()
+
+			name := lset.Get(labels.MetricName)
+			if seen[name] == nil {
+				seen[name] = make(map[uint64]struct{})
+			}
+			if _, dup := seen[name][lset.Hash()]; dup {
+				merged++
+				continue
+			}
+			seen[name][lset.Hash()] = struct{}{}
+
+			family, ok := byName[name]
+			if !ok {
+				family = &dto.MetricFamily{Name: proto.String(name), Help: mf.Help, Type: mf.Type, Unit: mf.Unit}
+				byName[name] = family
+				reduced = append(reduced, family)
+			}
+			metric := proto.Clone(m).(*dto.Metric)
+			metric.Label = metric.Label[:0]
+			lset.Range(func(l labels.Label) {
+				if l.Name != labels.MetricName {
+					metric.Label = append(metric.Label, &dto.LabelPair{Name: proto.String(l.Name), Value: proto.String(l.Value)})
+				}
+			})
+			family.Metric = append(family.Metric, metric)
+		}
+	}
+	return reduced, merged
+}
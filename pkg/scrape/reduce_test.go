@@ -0,0 +1,71 @@
+package scrape_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+const reduceText = `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{code="200",pod="a"} 10
+requests_total{code="200",pod="b"} 20
+requests_total{code="500",pod="a"} 1
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{pod="a",le="0.1"} 3
+latency_seconds_bucket{pod="a",le="+Inf"} 4
+latency_seconds_sum{pod="a"} 0.5
+latency_seconds_count{pod="a"} 4
+# HELP debug_requests_total Debug requests.
+# TYPE debug_requests_total counter
+debug_requests_total 5
+`
+
+func TestReduceFamilies(t *testing.T) {
+	t.Parallel()
+	families, err := scrape.DecodeFamilies([]byte(reduceText), config.ScrapeProtocolsHeaders[config.PrometheusText0_0_4])
+	require.NoError(t, err)
+
+	rename := relabel.DefaultRelabelConfig
+	rename.SourceLabels = model.LabelNames{labels.MetricName}
+	rename.Regex = relabel.MustNewRegexp("debug_(.*)")
+	rename.TargetLabel = labels.MetricName
+	rename.Replacement = "legacy_$1"
+	reduced, merged := scrape.ReduceFamilies(families, scrape.FamilyFilter{
+		Matchers:   []*labels.Matcher{labels.MustNewMatcher(labels.MatchNotEqual, "code", "500")},
+		DropLabels: []string{"pod"},
+		Relabel:    []*relabel.Config{&rename},
+	})
+	require.Equal(t, 1, merged)
+	require.Len(t, families[2].GetMetric()[0].GetLabel(), 2, "the input is left untouched")
+
+	var buf bytes.Buffer
+	require.NoError(t, scrape.EncodeFamilies(&buf, reduced, expfmt.NewFormat(expfmt.TypeOpenMetrics)))
+	require.Equal(t, `# HELP legacy_requests Debug requests.
+# TYPE legacy_requests counter
+legacy_requests_total 5.0
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 3
+latency_seconds_bucket{le="+Inf"} 4
+latency_seconds_sum 0.5
+latency_seconds_count 4
+# HELP requests Requests served.
+# TYPE requests counter
+requests_total{code="200"} 10.0
+# EOF
+`, buf.String())
+
+	// The reduced exposition is valid OpenMetrics.
+	_, err = scrape.DecodeFamilies(buf.Bytes(), config.ScrapeProtocolsHeaders[config.OpenMetricsText1_0_0])
+	require.NoError(t, err)
+}
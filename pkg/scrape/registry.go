@@ -0,0 +1,88 @@
+package scrape
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+)
+
+// Factory builds the scraper of a target. Options a scraper has no use for are ignored.
+type Factory func(target string, logger log.Logger, opts ...ScraperOption) (Scraper, error)
+
+// Registry maps the URL schemes of targets to the factories of their scrapers, so that sources other than
+// HTTP can be plugged in without changing the callers.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// Register makes the factory build the scrapers of the targets with the scheme, replacing the factory
+// registered before, if any. Tests can use it to inject fakes.
+func (r *Registry) Register(scheme string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[strings.ToLower(scheme)] = factory
+}
+
+// Schemes returns the sorted schemes the registry has a factory for.
+func (r *Registry) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schemes := make([]string, 0, len(r.factories))
+	for scheme := range r.factories {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	return schemes
+}
+
+// New builds the scraper of the target with the factory registered for its scheme. A target without
+// scheme is a path and is read by the file factory.
+func (r *Registry) New(target string, logger log.Logger, opts ...ScraperOption) (Scraper, error) {
+	scheme := fileScheme
+	if u, err := url.Parse(target); err == nil && u.Scheme != "" {
+		scheme = strings.ToLower(u.Scheme)
+	}
+	r.mu.RLock()
+	factory, ok := r.factories[scheme]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no scraper for scheme %q of target %s, supported schemes: %s",
+			scheme, target, strings.Join(r.Schemes(), ", "))
+	}
+	return factory(target, logger, opts...)
+}
+
+// DefaultRegistry holds the built-in scrapers: PromScraper for http and https targets and FileScraper for
+// file targets.
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	httpFactory := func(target string, logger log.Logger, opts ...ScraperOption) (Scraper, error) {
+		return NewPromScraper(target, logger, opts...), nil
+	}
+	DefaultRegistry.Register("http", httpFactory)
+	DefaultRegistry.Register("https", httpFactory)
+	DefaultRegistry.Register(fileScheme, func(target string, logger log.Logger, opts ...ScraperOption) (Scraper, error) {
+		return NewFileScraper(target, logger, opts...)
+	})
+}
+
+// Register registers the factory of a scheme in the DefaultRegistry.
+func Register(scheme string, factory Factory) {
+	DefaultRegistry.Register(scheme, factory)
+}
+
+// NewScraper builds the scraper of the target from the DefaultRegistry.
+func NewScraper(target string, logger log.Logger, opts ...ScraperOption) (Scraper, error) {
+	return DefaultRegistry.New(target, logger, opts...)
+}
@@ -0,0 +1,65 @@
+package scrape_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+type fakeScraper struct {
+	result *scrape.Result
+}
+
+func (f fakeScraper) Scrape(context.Context) (*scrape.Result, error) {
+	return f.result, nil
+}
+
+func TestRegistry_New(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "metrics.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# TYPE up gauge\nup 1\nup{job=\"api\"} 0\n"), 0o600))
+
+	for _, target := range []string{path, "file://" + path} {
+		scraper, err := scrape.NewScraper(target, log.NewNopLogger())
+		require.NoError(t, err)
+		require.IsType(t, &scrape.FileScraper{}, scraper)
+		result, err := scraper.Scrape(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Series["up"].Cardinality())
+		require.False(t, result.Time.IsZero())
+	}
+
+	scraper, err := scrape.NewScraper("https://example.com/metrics", log.NewNopLogger())
+	require.NoError(t, err)
+	require.IsType(t, &scrape.PromScraper{}, scraper)
+
+	_, err = scrape.NewScraper("otlp://collector:4317", log.NewNopLogger())
+	require.ErrorContains(t, err, `no scraper for scheme "otlp"`)
+
+	scraper, err = scrape.NewScraper(path, log.NewNopLogger(), scrape.WithMaxBodySize(4))
+	require.NoError(t, err)
+	_, err = scraper.Scrape(context.Background())
+	require.ErrorContains(t, err, "exceeds the size limit")
+}
+
+func TestRegistry_Register(t *testing.T) {
+	t.Parallel()
+	want := &scrape.Result{Series: scrape.SeriesMap{"up": {}}}
+	registry := scrape.NewRegistry()
+	registry.Register("Fake", func(string, log.Logger, ...scrape.ScraperOption) (scrape.Scraper, error) {
+		return fakeScraper{result: want}, nil
+	})
+	require.Equal(t, []string{"fake"}, registry.Schemes())
+
+	scraper, err := registry.New("fake://target", log.NewNopLogger())
+	require.NoError(t, err)
+	result, err := scraper.Scrape(context.Background())
+	require.NoError(t, err)
+	require.Same(t, want, result)
+}
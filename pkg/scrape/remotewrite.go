@@ -0,0 +1,118 @@
+package scrape
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// DefaultMaxSamplesPerSend is the default max_samples_per_send of the Prometheus remote write queues.
+const DefaultMaxSamplesPerSend = 2000
+
+// FamilyBandwidth is the remote write traffic of a metric family.
+type FamilyBandwidth struct {
+	Name   string
+	Series int
+	// UncompressedBytes and CompressedBytes are the sizes of the write requests of a single scrape, before and
+	// after snappy compression.
+	UncompressedBytes int
+	CompressedBytes   int
+	// BytesPerSecond is the compressed traffic at the scrape interval.
+	BytesPerSecond float64
+}
+
+// RemoteWriteReport is the estimated remote write traffic of a target.
+type RemoteWriteReport struct {
+	Interval          time.Duration
+	MaxSamplesPerSend int
+	// Requests is the number of write requests sent per scrape.
+	Requests int
+	// Families is sorted by compressed size, the largest first. Their sizes come from requests holding only
+	// their series, so they do not add up exactly to Total, which batches all the series together as
+	// Prometheus does.
+	Families []FamilyBandwidth
+	Total    FamilyBandwidth
+}
+
+// EstimateRemoteWrite builds the remote write (1.0) requests a Prometheus server would send for every scrape of
+// the series, batched by maxSamplesPerSend, and measures them once snappy compressed. The traffic is the same
+// for every scrape as each sample carries all its labels.
+func EstimateRemoteWrite(sm SeriesMap, ts time.Time, interval time.Duration,
+	maxSamplesPerSend int) RemoteWriteReport {
+	if maxSamplesPerSend <= 0 {
+		maxSamplesPerSend = DefaultMaxSamplesPerSend
+	}
+	report := RemoteWriteReport{Interval: interval, MaxSamplesPerSend: maxSamplesPerSend}
+	t := ts.UnixMilli()
+
+	var all []prompb.TimeSeries
+	for _, name := range slices.Sorted(maps.Keys(sm)) {
+		series := writeSeries(sm[name], t)
+		all = append(all, series...)
+		fb := FamilyBandwidth{Name: name, Series: len(series)}
+		fb.UncompressedBytes, fb.CompressedBytes, _ = writeRequestSizes(series, maxSamplesPerSend)
+		fb.BytesPerSecond = bytesPerSecond(fb.CompressedBytes, interval)
+		report.Families = append(report.Families, fb)
+	}
+	slices.SortFunc(report.Families, func(a, b FamilyBandwidth) int {
+		if c := cmp.Compare(b.CompressedBytes, a.CompressedBytes); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	report.Total.Series = len(all)
+	report.Total.UncompressedBytes, report.Total.CompressedBytes, report.Requests = writeRequestSizes(all,
+		maxSamplesPerSend)
+	report.Total.BytesPerSecond = bytesPerSecond(report.Total.CompressedBytes, interval)
+	return report
+}
+
+// writeSeries converts the series of a family to remote write time series holding a sample at t. Native
+// histograms are sent as float samples, their buckets are not known from the scrape.
+func writeSeries(set SeriesSet, t int64) []prompb.TimeSeries {
+	sorted := set.Sorted()
+	series := make([]prompb.TimeSeries, 0, len(sorted))
+	for _, s := range sorted {
+		ts := prompb.TimeSeries{
+			Labels:  make([]prompb.Label, 0, s.Labels.Len()),
+			Samples: []prompb.Sample{{Value: s.Value, Timestamp: t}},
+		}
+		s.Labels.Range(func(l labels.Label) {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: l.Name, Value: l.Value})
+		})
+		series = append(series, ts)
+	}
+	return series
+}
+
+// writeRequestSizes returns the total size of the write requests sending the series in batches, before and
+// after compression, along with the number of requests.
+func writeRequestSizes(series []prompb.TimeSeries, batch int) (uncompressed, compressed, requests int) {
+	var buf []byte
+	for batchSeries := range slices.Chunk(series, batch) {
+		req := prompb.WriteRequest{Timeseries: batchSeries}
+		b, err := req.Marshal()
+		if err != nil {
+			continue
+		}
+		uncompressed += len(b)
+		buf = snappy.Encode(buf[:cap(buf)], b)
+		compressed += len(buf)
+		requests++
+	}
+	return uncompressed, compressed, requests
+}
+
+func bytesPerSecond(bytes int, interval time.Duration) float64 {
+	if interval <= 0 {
+		return 0
+	}
+	return float64(bytes) / interval.Seconds()
+}
@@ -0,0 +1,31 @@
+package scrape_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestEstimateRemoteWrite(t *testing.T) {
+	t.Parallel()
+	sm := scrape.SeriesMap{
+		"requests_total": replicaSet("requests_total", 5000),
+		"up":             replicaSet("up", 1),
+	}
+
+	report := scrape.EstimateRemoteWrite(sm, time.Unix(1700000000, 0), 30*time.Second, 0)
+	require.Equal(t, scrape.DefaultMaxSamplesPerSend, report.MaxSamplesPerSend)
+	require.Equal(t, 3, report.Requests)
+	require.Equal(t, 5001, report.Total.Series)
+	require.Positive(t, report.Total.CompressedBytes)
+	require.Less(t, report.Total.CompressedBytes, report.Total.UncompressedBytes)
+	require.InDelta(t, float64(report.Total.CompressedBytes)/30, report.Total.BytesPerSecond, 1e-9)
+
+	require.Len(t, report.Families, 2)
+	require.Equal(t, "requests_total", report.Families[0].Name)
+	require.Greater(t, report.Families[0].CompressedBytes, report.Families[1].CompressedBytes)
+	require.Equal(t, 1, report.Families[1].Series)
+}
@@ -2,21 +2,61 @@ package scrape
 
 import (
 	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/textparse"
 	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/thanos-io/thanos/pkg/tracing"
 )
 
+// Scraper scrapes a target and analyzes the series it exposes.
+type Scraper interface {
+	// Scrape scrapes the target, bounding the scrape by ctx.
+	Scrape(ctx context.Context) (*Result, error)
+}
+
+// StreamScraper is a Scraper that can also pass the series to a callback as they are parsed, without
+// collecting them in a Result.
+type StreamScraper interface {
+	Scraper
+	// ScrapeStream scrapes the target and calls fn with every series in exposition order. It stops at the
+	// first error returned by fn and returns it as is.
+	ScrapeStream(ctx context.Context, fn func(Series) error) error
+}
+
+var _ StreamScraper = (*PromScraper)(nil)
+
+// RawScraper is a Scraper that can also return the raw exposition of the target, e.g. to lint it, and parse
+// it separately.
+type RawScraper interface {
+	Scraper
+	// FetchWithContext returns the content type and the body of the exposition.
+	FetchWithContext(ctx context.Context) (string, []byte, error)
+	// Parse parses an exposition as Scrape does.
+	Parse(body []byte, contentType string) (*Result, error)
+}
+
+var _ RawScraper = (*PromScraper)(nil)
+
+// PromScraper scrapes a Prometheus exposition endpoint over HTTP. It is not safe for concurrent use, run
+// one scraper per goroutine.
 type PromScraper struct {
 	scrapeURL             string
 	timeout               time.Duration
@@ -24,31 +64,165 @@ type PromScraper struct {
 	series                map[string]SeriesSet
 	lastScrapeContentType string
 	maxBodySize           int64
+	metrics               *Metrics
+	protocols             []config.ScrapeProtocol
+	retries               int
+	retryBackoff          time.Duration
+	headers               http.Header
+	params                url.Values
+	client                *http.Client
+	userAgent             string
+	timeoutHeader         bool
+	progress              ProgressFunc
+	progressInterval      time.Duration
+	maxSeries             int
+	dropLabels            []string
+	keepLabels            []string
+	acceptEncoding        string
+	archiveDir            string
+	skipExemplars         bool
 }
 
 type scrapeOpts struct {
-	timeout     time.Duration
-	maxBodySize int64
+	timeout          time.Duration
+	maxBodySize      int64
+	metrics          *Metrics
+	protocols        []config.ScrapeProtocol
+	retries          int
+	retryBackoff     time.Duration
+	headers          http.Header
+	params           url.Values
+	client           *http.Client
+	userAgent        string
+	timeoutHeader    bool
+	progress         ProgressFunc
+	progressInterval time.Duration
+	maxSeries        int
+	dropLabels       []string
+	keepLabels       []string
+	archiveDir       string
+	skipExemplars    bool
 }
 
+// ScraperOption configures a PromScraper.
 type ScraperOption func(*scrapeOpts)
 
+// WithTimeout bounds every fetch of the target, 10s by default.
 func WithTimeout(timeout time.Duration) ScraperOption {
 	return func(opts *scrapeOpts) {
 		opts.timeout = timeout
 	}
 }
 
+// WithMaxBodySize limits the size of the body read from the target, 10MiB by default.
 func WithMaxBodySize(maxBodySize int64) ScraperOption {
 	return func(opts *scrapeOpts) {
 		opts.maxBodySize = maxBodySize
 	}
 }
 
+// WithMetrics instruments the scraper with the given metrics.
+func WithMetrics(metrics *Metrics) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.metrics = metrics
+	}
+}
+
+// WithProtocols sets the exposition formats offered to the target, in order of preference.
+func WithProtocols(protocols ...config.ScrapeProtocol) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.protocols = protocols
+	}
+}
+
+// WithRetries retries fetches failing with a transient error (connection refused or reset, timeout,
+// 5xx status) up to retries times. The first retry waits backoff, every further retry doubles it.
+func WithRetries(retries int, backoff time.Duration) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.retries = retries
+		opts.retryBackoff = backoff
+	}
+}
+
+// WithHeaders adds headers to every scrape request, overriding the ones set by the scraper. A Host
+// header overrides the host the request is sent with.
+func WithHeaders(headers http.Header) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.headers = headers
+	}
+}
+
+// WithParams adds params to the query of every scrape request, overriding the ones of the URL with the same name,
+// like the params of a Prometheus scrape config select the module and target of multi-target exporters.
+func WithParams(params url.Values) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.params = params
+	}
+}
+
+// WithHTTPClient sends the scrape requests with client, e.g. one configured with authentication or TLS.
+func WithHTTPClient(client *http.Client) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.client = client
+	}
+}
+
+// WithUserAgent sets the User-Agent of the scrape requests, e.g. to mimic Prometheus.
+func WithUserAgent(userAgent string) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.userAgent = userAgent
+	}
+}
+
+// WithTimeoutHeader controls whether the X-Prometheus-Scrape-Timeout-Seconds header is sent, it is by default.
+func WithTimeoutHeader(enabled bool) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.timeoutHeader = enabled
+	}
+}
+
+// WithMaxSeries stops parsing after maxSeries series, the result is then flagged as truncated. Zero, the
+// default, parses every series.
+func WithMaxSeries(maxSeries int) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.maxSeries = maxSeries
+	}
+}
+
+// WithLabelFilter removes the labels named in drop from every series as it is parsed, before it is hashed, and
+// when keep is not empty all the labels but the ones it names and the metric name. The series left with the
+// same labels are merged, giving the cardinality of the target without the removed labels.
+func WithLabelFilter(drop, keep []string) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.dropLabels = drop
+		opts.keepLabels = keep
+	}
+}
+
+// WithSkipExemplars skips the exemplars of the series as they are parsed, their count and trace IDs are left
+// empty. The trace IDs can take a large share of the memory on heavily instrumented targets, see ExemplarUsage.
+func WithSkipExemplars(skip bool) ScraperOption {
+	return func(opts *scrapeOpts) {
+		opts.skipExemplars = skip
+	}
+}
+
+// TextProtocols are the text exposition formats, for callers working on the raw body.
+var TextProtocols = []config.ScrapeProtocol{config.OpenMetricsText1_0_0, config.PrometheusText0_0_4}
+
+// NewPromScraper returns a scraper of scrapeURL. The URL may be empty for a scraper only used to Parse bodies.
 func NewPromScraper(scrapeURL string, logger log.Logger, opts ...ScraperOption) *PromScraper {
 	scOpts := &scrapeOpts{
 		timeout:     10 * time.Second,
 		maxBodySize: 10 * 1024 * 1024,
+		protocols: []config.ScrapeProtocol{
+			config.PrometheusProto,
+			config.OpenMetricsText1_0_0,
+			config.PrometheusText0_0_4,
+			config.OpenMetricsText0_0_1,
+		},
+		client:        http.DefaultClient,
+		timeoutHeader: true,
 	}
 
 	for _, opt := range opts {
@@ -60,61 +234,360 @@ func NewPromScraper(scrapeURL string, logger log.Logger, opts ...ScraperOption)
 		logger:      logger,
 		timeout:     scOpts.timeout,
 		maxBodySize: scOpts.maxBodySize,
+		metrics:     scOpts.metrics,
+		protocols:   scOpts.protocols,
+
+		retries:      scOpts.retries,
+		retryBackoff: scOpts.retryBackoff,
+		headers:      scOpts.headers,
+		params:       scOpts.params,
+		client:       scOpts.client,
+
+		userAgent:     scOpts.userAgent,
+		timeoutHeader: scOpts.timeoutHeader,
+
+		progress:         scOpts.progress,
+		progressInterval: scOpts.progressInterval,
+		maxSeries:        scOpts.maxSeries,
+		dropLabels:       scOpts.dropLabels,
+		keepLabels:       scOpts.keepLabels,
+		archiveDir:       scOpts.archiveDir,
+		skipExemplars:    scOpts.skipExemplars,
+
+		acceptEncoding: EncodingGzip,
 
 		series: make(map[string]SeriesSet),
 	}
 }
 
-func (ps *PromScraper) Scrape() (*Result, error) {
-	req, err := ps.setupRequest()
+// Scrape scrapes the target, bounding the request by ctx. When ctx carries a tracer (see
+// tracing.ContextWithTracer), the fetch and parse phases are recorded as spans.
+func (ps *PromScraper) Scrape(ctx context.Context) (*Result, error) {
+	span, ctx := tracing.StartSpan(ctx, "scrape")
+	span.SetTag("url", ps.scrapeURL)
+	defer span.Finish()
+
+	result, err := ps.scrape(ctx)
+	if err != nil {
+		ext.LogError(span, err)
+	}
+	if ps.metrics != nil {
+		ps.metrics.scrapes.Inc()
+		if err != nil {
+			ps.metrics.scrapeFailures.Inc()
+		}
+	}
+	return result, err
+}
+
+// ScrapeStream scrapes the target like Scrape but passes every series to fn as it is parsed, for consumers
+// that do not need the whole scrape in memory. It stops at the first error returned by fn and returns it
+// as is.
+func (ps *PromScraper) ScrapeStream(ctx context.Context, fn func(Series) error) error {
+	span, ctx := tracing.StartSpan(ctx, "scrape_stream")
+	span.SetTag("url", ps.scrapeURL)
+	defer span.Finish()
+
+	contentType, body, err := ps.FetchWithContext(ctx)
+	if err == nil {
+		ps.lastScrapeContentType = contentType
+		if ps.metrics != nil {
+			ps.metrics.bytesProcessed.Add(float64(len(body)))
+		}
+		err = ps.ParseStream(ctx, body, contentType, fn)
+	}
+	if err != nil {
+		ext.LogError(span, err)
+	}
+	if ps.metrics != nil {
+		ps.metrics.scrapes.Inc()
+		if err != nil {
+			ps.metrics.scrapeFailures.Inc()
+		}
+	}
+	return err
+}
+
+// ScrapeWithContext is Scrape.
+//
+// Deprecated: use Scrape.
+func (ps *PromScraper) ScrapeWithContext(ctx context.Context) (*Result, error) {
+	return ps.Scrape(ctx)
+}
+
+// FetchWithContext fetches the raw body of the target without parsing it, returning its content type.
+func (ps *PromScraper) FetchWithContext(ctx context.Context) (string, []byte, error) {
+	fetch, err := ps.FetchRawWithContext(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return fetch.ContentType, fetch.Body, nil
+}
+
+// Fetch is the raw response of the target.
+type Fetch struct {
+	ContentType string
+	// ContentEncoding is the encoding the body was transferred in, empty when uncompressed.
+	ContentEncoding string
+	// Header holds the headers of the response.
+	Header http.Header
+	Body   []byte
+	// WireBytes is the size of the body as transferred, before decompression.
+	WireBytes int64
+	// Duration is the time from sending the request to reading the whole body.
+	Duration time.Duration
+	// Timing breaks the duration down by phase of the request.
+	Timing Timing
+}
+
+// FetchRawWithContext is like FetchWithContext but also reports the transfer size and duration.
+func (ps *PromScraper) FetchRawWithContext(ctx context.Context) (*Fetch, error) {
+	backoff := ps.retryBackoff
+	for attempt := 1; ; attempt++ {
+		at := time.Now()
+		fetch, err := ps.fetch(ctx, attempt)
+		if err == nil && ps.archiveDir != "" {
+			ps.archive(fetch, at)
+		}
+		if err == nil || attempt > ps.retries || ctx.Err() != nil || !isTransient(err) {
+			return fetch, err
+		}
+
+		level.Warn(ps.logger).Log(
+			"msg", "scrape attempt failed, retrying",
+			"url", ps.scrapeURL,
+			"attempt", attempt,
+			"backoff", backoff,
+			"err", err,
+		)
+		if ps.metrics != nil {
+			ps.metrics.scrapeRetries.Inc()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// fetch performs a single fetch attempt, bounded by the scrape timeout.
+func (ps *PromScraper) fetch(ctx context.Context, attempt int) (*Fetch, error) {
+	fetch := &Fetch{}
+	err := tracing.DoInSpanWithErr(ctx, "scrape_fetch", func(ctx context.Context) error {
+		spanFromContext(ctx).SetTag("attempt", attempt)
+		if ps.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, ps.timeout)
+			defer cancel()
+		}
+
+		ctx, trace := withTimingTrace(ctx, time.Now())
+		req, err := ps.setupRequest(ctx)
+		if err != nil {
+			return err
+		}
+
+		resp, err := ps.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		fetch.ContentEncoding = resp.Header.Get("Content-Encoding")
+		fetch.Header = resp.Header.Clone()
+		wire := &countingReader{r: resp.Body, progress: ps.newProgress(ProgressFetch, resp.ContentLength)}
+		resp.Body = io.NopCloser(wire)
+		fetch.ContentType, fetch.Body, err = ps.readResponse(resp)
+		fetch.WireBytes = wire.n
+		fetch.Timing = trace.finish()
+		fetch.Duration = fetch.Timing.Total
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return fetch, nil
+}
+
+// archive writes the body of the fetch to the archive directory, a failure is logged but does not fail the
+// scrape.
+func (ps *PromScraper) archive(fetch *Fetch, at time.Time) {
+	path, err := ArchiveBody(ps.archiveDir, ps.scrapeURL, fetch, at)
+	if err != nil {
+		level.Warn(ps.logger).Log("msg", "failed to archive scrape body", "dir", ps.archiveDir, "err", err)
+		return
+	}
+	level.Debug(ps.logger).Log("msg", "archived scrape body", "path", path)
+}
+
+// spanFromContext returns the span of ctx, or a noop span when ctx carries no tracer.
+func spanFromContext(ctx context.Context) opentracing.Span {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		return span
+	}
+	return opentracing.NoopTracer{}.StartSpan("")
+}
+
+// statusError is returned when the target answers with a status other than 200.
+type statusError struct {
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return "server returned HTTP status " + e.status
+}
+
+// isTransient reports whether a failed fetch is worth retrying.
+func isTransient(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
 
-	resp, err := http.DefaultClient.Do(req)
+// Parse parses a body fetched from the target, as done by Scrape. The time of the result is
+// left to the caller.
+func (ps *PromScraper) Parse(body []byte, contentType string) (*Result, error) {
+	series := make(SeriesMap)
+	strs := newInterner()
+	stats, err := ps.parseSeries(context.Background(), body, contentType, strs, func(hash uint64, s Series) error {
+		set, ok := series[s.Name]
+		if !ok {
+			set = make(SeriesSet)
+			series[s.Name] = set
+		}
+		set[hash] = s
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	if stats.truncated {
+		ps.warnTruncated()
+	}
+	return &Result{
+		Series:          series,
+		UsedContentType: contentType,
+		Truncated:       stats.truncated,
+		BodyBytes:       len(body),
+		ParseFailed:     stats.failed,
+	}, nil
+}
 
-	contentType, body, err := ps.readResponse(resp)
+// ParseStream parses a body fetched from the target like Parse but passes every series to fn as it is
+// parsed, see ScrapeStream. Parsing stops early when ctx is done.
+func (ps *PromScraper) ParseStream(ctx context.Context, body []byte, contentType string, fn func(Series) error) error {
+	// The series are not retained, interning their strings would only grow the memory of the parse.
+	stats, err := ps.parseSeries(ctx, body, contentType, nil, func(_ uint64, s Series) error {
+		return fn(s)
+	})
+	if err == nil && stats.truncated {
+		ps.warnTruncated()
+	}
+	return err
+}
+
+func (ps *PromScraper) warnTruncated() {
+	level.Warn(ps.logger).Log(
+		"msg", "series limit reached, the result is partial",
+		"url", ps.scrapeURL,
+		"max_series", ps.maxSeries,
+	)
+}
+
+type countingReader struct {
+	r        io.Reader
+	n        int64
+	progress *progressTracker
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	c.progress.addBytes(n)
+	return n, err
+}
+
+func (ps *PromScraper) scrape(ctx context.Context) (*Result, error) {
+	scrapedAt := time.Now()
+	fetch, err := ps.FetchRawWithContext(ctx)
 	if err != nil {
 		return nil, err
 	}
+	contentType, body := fetch.ContentType, fetch.Body
 
 	ps.lastScrapeContentType = contentType
+	if ps.metrics != nil {
+		ps.metrics.bytesProcessed.Add(float64(len(body)))
+	}
 
-	metrics, err := ps.extractMetrics(body, contentType)
+	var result *Result
+	err = tracing.DoInSpanWithErr(ctx, "scrape_parse", func(ctx context.Context) error {
+		span := spanFromContext(ctx)
+		span.SetTag("content_type", contentType)
+		span.SetTag("body_bytes", len(body))
+
+		result, err = ps.Parse(body, contentType)
+		if err == nil {
+			span.SetTag("metric_families", len(result.Series))
+			span.SetTag("truncated", result.Truncated)
+		}
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Result{
-		Series:          metrics,
-		UsedContentType: contentType,
-	}, nil
+	result.Time = scrapedAt
+	result.Timing = fetch.Timing
+	return result, nil
 }
 
+// LastScrapeContentType returns the content type the target answered the last scrape with.
 func (ps *PromScraper) LastScrapeContentType() string {
 	return ps.lastScrapeContentType
 }
 
-func (ps *PromScraper) setupRequest() (*http.Request, error) {
+func (ps *PromScraper) setupRequest(ctx context.Context) (*http.Request, error) {
 	// Scrape the URL and analyze the cardinality.
-	req, err := http.NewRequest("GET", ps.scrapeURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", ps.scrapeURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	if len(ps.params) > 0 {
+		query := req.URL.Query()
+		for name, values := range ps.params {
+			query[name] = values
+		}
+		req.URL.RawQuery = query.Encode()
+	}
 
-	acceptHeader := acceptHeader([]config.ScrapeProtocol{
-		config.PrometheusProto,
-		config.OpenMetricsText1_0_0,
-		config.PrometheusText0_0_4,
-		config.OpenMetricsText0_0_1,
-	})
-	req.Header.Set("Accept", acceptHeader)
-	req.Header.Set("Accept-Encoding", "gzip")
-	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatInt(int64(ps.timeout.Seconds()), 10))
+	req.Header.Set("Accept", acceptHeader(ps.protocols))
+	req.Header.Set("Accept-Encoding", ps.acceptEncoding)
+	if ps.userAgent != "" {
+		req.Header.Set("User-Agent", ps.userAgent)
+	}
+	if ps.timeoutHeader {
+		req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatInt(int64(ps.timeout.Seconds()), 10))
+	}
+	for name, values := range ps.headers {
+		if http.CanonicalHeaderKey(name) == "Host" {
+			req.Host = values[len(values)-1]
+			continue
+		}
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
 	return req, nil
 }
 
@@ -125,7 +598,7 @@ func (ps *PromScraper) readResponse(resp *http.Response) (string, []byte, error)
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", nil, fmt.Errorf("server returned HTTP status %s", resp.Status)
+		return "", nil, &statusError{status: resp.Status, code: resp.StatusCode}
 	}
 
 	var reader io.Reader = resp.Body
@@ -156,27 +629,104 @@ func (ps *PromScraper) readResponse(resp *http.Response) (string, []byte, error)
 	return resp.Header.Get("Content-Type"), body, nil
 }
 
-func (ps *PromScraper) extractMetrics(body []byte, contentType string) (map[string]SeriesSet, error) {
-	metrics := make(map[string]SeriesSet)
+// traceIDLabels are the exemplar labels holding trace IDs, as set by the common instrumentation libraries.
+var traceIDLabels = []string{"trace_id", "traceID", "traceId"}
+
+// addExemplar counts an exemplar of the series and records its trace ID, if any.
+func (s *Series) addExemplar(ex exemplar.Exemplar) {
+	s.Exemplars++
+	for _, name := range traceIDLabels {
+		if id := ex.Labels.Get(name); id != "" {
+			// The label may point into the parser's buffer.
+			s.TraceIDs = append(s.TraceIDs, strings.Clone(id))
+			return
+		}
+	}
+}
+
+// parseStats sums up a parse, truncated is set when it stopped at the series limit and failed when it stopped
+// at an entry that failed to parse.
+type parseStats struct {
+	truncated bool
+	failed    bool
+}
+
+// parseSeries parses the series of body and passes them to fn with the hash of their labels. The label
+// strings are interned with strs, or cloned when strs is nil.
+func (ps *PromScraper) parseSeries(
+	ctx context.Context,
+	body []byte,
+	contentType string,
+	strs *interner,
+	fn func(hash uint64, s Series) error,
+) (parseStats, error) {
 	parser, err := textparse.New(body, contentType, false, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create parser: %w", err)
+		return parseStats{}, fmt.Errorf("failed to create parser: %w", err)
 	}
+	progress := ps.newProgress(ProgressParse, 0)
 
 	var (
 		lset        labels.Labels
+		ex          exemplar.Exemplar
 		currentType string
 		defTime     = timestamp.FromTime(time.Now())
+		parsed      int
+		entries     int
+		stats       parseStats
 	)
+	var (
+		filter *labels.Builder
+		keep   []string
+	)
+	if len(ps.dropLabels) > 0 || len(ps.keepLabels) > 0 {
+		filter = labels.NewBuilder(labels.EmptyLabels())
+	}
+	if len(ps.keepLabels) > 0 {
+		keep = append([]string{labels.MetricName}, ps.keepLabels...)
+	}
+	metric := func() {
+		_ = parser.Metric(&lset)
+		if filter == nil {
+			return
+		}
+		filter.Reset(lset)
+		filter.Del(ps.dropLabels...)
+		if keep != nil {
+			filter.Keep(keep...)
+		}
+		lset = filter.Labels()
+	}
+	copyLabels := func(name string) (string, labels.Labels) {
+		if strs == nil {
+			return strings.Clone(name), cloneLabels(lset)
+		}
+		return strs.intern(name), strs.labels(lset)
+	}
 
 	for {
+		if entries++; entries%parseProgressEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return stats, err
+			}
+		}
 		entry, err := parser.Next()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			level.Debug(ps.logger).Log("msg", "failed to parse entry", "err", err)
-			continue
+			// The parser cannot resynchronize after an error, keep the series parsed so far.
+			level.Warn(ps.logger).Log("msg", "failed to parse entry, the rest of the body is skipped", "err", err)
+			stats.failed = true
+			if ps.metrics != nil {
+				ps.metrics.parseErrors.Inc()
+			}
+			return stats, nil
+		}
+		if (entry == textparse.EntrySeries || entry == textparse.EntryHistogram) &&
+			ps.maxSeries > 0 && parsed == ps.maxSeries {
+			stats.truncated = true
+			return stats, nil
 		}
 
 		switch entry {
@@ -186,28 +736,26 @@ func (ps *PromScraper) extractMetrics(body []byte, contentType string) (map[stri
 			continue // Skip to next iteration as we don't need to process this entry further
 
 		case textparse.EntrySeries:
-			_ = parser.Metric(&lset)
+			metric()
 			metricName := lset.Get(labels.MetricName)
 			if metricName == "" {
 				level.Debug(ps.logger).Log("msg", "metric name not found in labels", "labels", lset.String())
 				continue
 			}
 
-			if _, ok := metrics[metricName]; !ok {
-				metrics[metricName] = make(SeriesSet)
-			}
-
 			hash := lset.Hash()
-			series := Series{
-				Name:   metricName,
-				Labels: lset.Copy(),
-				Type:   currentType, // clone type string
-			}
+			series := Series{Type: currentType}
+			series.Name, series.Labels = copyLabels(metricName)
 
-			_, ts, _ := parser.Series()
+			_, ts, v := parser.Series()
 			t := defTime
 			if ts != nil {
 				t = *ts
+				series.Timestamp = *ts
+			}
+			series.Value = v
+			for !ps.skipExemplars && parser.Exemplar(&ex) {
+				series.addExemplar(ex)
 			}
 
 			ctMs := parser.CreatedTimestamp()
@@ -216,7 +764,14 @@ func (ps *PromScraper) extractMetrics(body []byte, contentType string) (map[stri
 				level.Debug(ps.logger).Log("msg", "found CT zero sample", "metric", metricName, "ct", *ctMs)
 			}
 
-			metrics[metricName][hash] = series
+			if err := fn(hash, series); err != nil {
+				return stats, err
+			}
+			if ps.metrics != nil {
+				ps.metrics.seriesParsed.Inc()
+			}
+			progress.addSeries()
+			parsed++
 
 			level.Debug(ps.logger).Log(
 				"msg", "found series",
@@ -228,28 +783,31 @@ func (ps *PromScraper) extractMetrics(body []byte, contentType string) (map[stri
 			)
 
 		case textparse.EntryHistogram:
-			_ = parser.Metric(&lset)
+			metric()
 			metricName := lset.Get(labels.MetricName)
 			if metricName == "" {
 				level.Debug(ps.logger).Log("msg", "histogram metric name not found in labels", "labels", lset.String())
 				continue
 			}
 
-			if _, ok := metrics[metricName]; !ok {
-				metrics[metricName] = make(SeriesSet)
-			}
-
 			hash := lset.Hash()
-			series := Series{
-				Name:   metricName,
-				Labels: lset.Copy(),
-				Type:   "native_histogram",
-			}
+			series := Series{Type: "native_histogram"}
+			series.Name, series.Labels = copyLabels(metricName)
 
 			_, ts, h, fh := parser.Histogram()
 			t := defTime
 			if ts != nil {
 				t = *ts
+				series.Timestamp = *ts
+			}
+			switch {
+			case h != nil:
+				series.Schema = h.Schema
+			case fh != nil:
+				series.Schema = fh.Schema
+			}
+			for !ps.skipExemplars && parser.Exemplar(&ex) {
+				series.addExemplar(ex)
 			}
 
 			ctMs := parser.CreatedTimestamp()
@@ -262,7 +820,14 @@ func (ps *PromScraper) extractMetrics(body []byte, contentType string) (map[stri
 				)
 			}
 
-			metrics[metricName][hash] = series
+			if err := fn(hash, series); err != nil {
+				return stats, err
+			}
+			if ps.metrics != nil {
+				ps.metrics.seriesParsed.Inc()
+			}
+			progress.addSeries()
+			parsed++
 
 			if h != nil {
 				level.Debug(ps.logger).Log(
@@ -289,7 +854,7 @@ func (ps *PromScraper) extractMetrics(body []byte, contentType string) (map[stri
 		}
 	}
 
-	return metrics, nil
+	return stats, nil
 }
 
 // acceptHeader transforms preference from the options into specific header values as
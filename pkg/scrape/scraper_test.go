@@ -0,0 +1,377 @@
+package scrape_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+// flakyHandler answers with status until failures requests have been served, then serves a valid body.
+func flakyHandler(failures int32, status int) (http.Handler, *atomic.Int32) {
+	var requests atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte("# TYPE up gauge\nup 1\n"))
+	}), &requests
+}
+
+func TestPromScraper_Retries(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		status   int
+		failures int32
+		retries  int
+		requests int32
+		fails    bool
+	}{
+		{name: "retries 5xx until success", status: http.StatusBadGateway, failures: 2, retries: 3, requests: 3},
+		{name: "gives up after the retries", status: http.StatusServiceUnavailable, failures: 5, retries: 2, requests: 3,
+			fails: true},
+		{name: "does not retry 4xx", status: http.StatusNotFound, failures: 1, retries: 3, requests: 1, fails: true},
+		{name: "no retries by default", status: http.StatusInternalServerError, failures: 1, requests: 1, fails: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			handler, requests := flakyHandler(tc.failures, tc.status)
+			srv := httptest.NewServer(handler)
+			defer srv.Close()
+
+			ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger(),
+				scrape.WithRetries(tc.retries, time.Millisecond))
+			result, err := ps.Scrape(context.Background())
+			require.Equal(t, tc.requests, requests.Load())
+			if tc.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 1, result.Series["up"].Cardinality())
+		})
+	}
+}
+
+func TestPromScraper_RetriesTimeout(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// Hang until the scraper gives up on the attempt.
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte("up 1\n"))
+	}))
+	defer srv.Close()
+
+	ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger(),
+		scrape.WithTimeout(50*time.Millisecond),
+		scrape.WithRetries(1, time.Millisecond),
+	)
+	_, err := ps.Scrape(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(2), requests.Load())
+}
+
+func TestPromScraper_Headers(t *testing.T) {
+	t.Parallel()
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		_, _ = w.Write([]byte("up 1\n"))
+	}))
+	defer srv.Close()
+
+	headers := http.Header{}
+	headers.Add("x-scope-orgid", "tenant-1")
+	headers.Add("Accept", "text/plain")
+	headers.Add("Host", "exporter.example.com")
+	ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger(), scrape.WithHeaders(headers))
+	_, err := ps.Scrape(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, "tenant-1", got.Header.Get("X-Scope-OrgID"))
+	require.Equal(t, "text/plain", got.Header.Get("Accept"))
+	require.Equal(t, "exporter.example.com", got.Host)
+	require.Equal(t, "gzip", got.Header.Get("Accept-Encoding"))
+}
+
+func TestPromScraper_Params(t *testing.T) {
+	t.Parallel()
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		_, _ = w.Write([]byte("probe_success 1\n"))
+	}))
+	defer srv.Close()
+
+	params := url.Values{"module": {"http_2xx"}, "target": {"https://example.com"}}
+	ps := scrape.NewPromScraper(srv.URL+"/probe?target=ignored&debug=true", log.NewNopLogger(), scrape.WithParams(params))
+	_, err := ps.Scrape(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, url.Values{
+		"module": {"http_2xx"},
+		"target": {"https://example.com"},
+		"debug":  {"true"},
+	}, got, "params should override the ones of the URL with the same name")
+}
+
+func TestPromScraper_Timing(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("up 1\n"))
+	}))
+	defer srv.Close()
+
+	result, err := scrape.NewPromScraper(srv.URL, log.NewNopLogger()).Scrape(context.Background())
+	require.NoError(t, err)
+	timing := result.Timing
+	require.Positive(t, timing.Connect)
+	require.Zero(t, timing.TLS)
+	require.GreaterOrEqual(t, timing.TTFB, 20*time.Millisecond)
+	require.GreaterOrEqual(t, timing.Total, timing.DNS+timing.Connect+timing.TLS+timing.TTFB)
+	require.True(t, timing.SlowerThan(30*time.Millisecond, 0.5))
+	require.False(t, timing.SlowerThan(time.Minute, 0.5))
+	require.False(t, timing.SlowerThan(0, 0.5))
+}
+
+func TestPromScraper_Identity(t *testing.T) {
+	t.Parallel()
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		_, _ = w.Write([]byte("up 1\n"))
+	}))
+	defer srv.Close()
+
+	ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger(), scrape.WithTimeout(5*time.Second))
+	_, err := ps.Scrape(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "5", got.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"))
+
+	ps = scrape.NewPromScraper(srv.URL, log.NewNopLogger(),
+		scrape.WithUserAgent("Prometheus/2.53.0"),
+		scrape.WithTimeoutHeader(false),
+	)
+	_, err = ps.Scrape(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "Prometheus/2.53.0", got.Header.Get("User-Agent"))
+	require.Empty(t, got.Header.Values("X-Prometheus-Scrape-Timeout-Seconds"))
+}
+
+func TestPromScraper_MaxSeries(t *testing.T) {
+	t.Parallel()
+	body := []byte("# TYPE a counter\na{i=\"1\"} 1\na{i=\"2\"} 1\n# TYPE b gauge\nb 1\nb{i=\"3\"} 1\n")
+	for _, tc := range []struct {
+		name      string
+		maxSeries int
+		series    int
+		truncated bool
+	}{
+		{name: "no limit", series: 4},
+		{name: "limit above the series", maxSeries: 4, series: 4},
+		{name: "limit below the series", maxSeries: 3, series: 3, truncated: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ps := scrape.NewPromScraper("", log.NewNopLogger(), scrape.WithMaxSeries(tc.maxSeries))
+			result, err := ps.Parse(body, "text/plain; version=0.0.4")
+			require.NoError(t, err)
+			series := 0
+			for _, set := range result.Series {
+				series += set.Cardinality()
+			}
+			require.Equal(t, tc.series, series)
+			require.Equal(t, tc.truncated, result.Truncated)
+			require.Equal(t, len(body), result.BodyBytes)
+		})
+	}
+}
+
+func TestPromScraper_LabelFilter(t *testing.T) {
+	t.Parallel()
+	body := []byte(`# TYPE requests counter
+requests_total{path="/a",pod="p1",code="200"} 1
+requests_total{path="/a",pod="p2",code="200"} 1
+requests_total{path="/a",pod="p2",code="500"} 1
+requests_total{path="/b",pod="p1",code="200"} 1
+`)
+	for _, tc := range []struct {
+		name       string
+		drop, keep []string
+		series     int
+		labels     []string
+	}{
+		{name: "no filter", series: 4, labels: []string{"__name__", "code", "path", "pod"}},
+		{name: "drop", drop: []string{"pod"}, series: 3, labels: []string{"__name__", "code", "path"}},
+		{name: "keep", keep: []string{"path"}, series: 2, labels: []string{"__name__", "path"}},
+		{name: "drop kept", drop: []string{"path"}, keep: []string{"path", "code"}, series: 2,
+			labels: []string{"__name__", "code"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ps := scrape.NewPromScraper("", log.NewNopLogger(), scrape.WithLabelFilter(tc.drop, tc.keep))
+			result, err := ps.Parse(body, "text/plain; version=0.0.4")
+			require.NoError(t, err)
+			set := result.Series["requests_total"]
+			require.Equal(t, tc.series, set.Cardinality())
+			for _, s := range set {
+				var names []string
+				s.Labels.Range(func(l labels.Label) { names = append(names, l.Name) })
+				require.Equal(t, tc.labels, names)
+			}
+		})
+	}
+}
+
+func TestPromScraper_ParseExemplars(t *testing.T) {
+	t.Parallel()
+	body := []byte(`# TYPE http_requests counter
+http_requests_total{code="200"} 10 # {trace_id="abc"} 1.0
+http_requests_total{code="500"} 1
+# EOF
+`)
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse(body, "application/openmetrics-text; version=1.0.0")
+	require.NoError(t, err)
+
+	exemplars := map[string]int{}
+	for _, s := range result.Series["http_requests_total"] {
+		exemplars[s.Labels.Get("code")] = s.Exemplars
+	}
+	require.Equal(t, map[string]int{"200": 1, "500": 0}, exemplars)
+}
+
+func TestPromScraper_ParseExemplarTraceIDs(t *testing.T) {
+	t.Parallel()
+	body := []byte(`# TYPE rpc_duration_seconds histogram
+rpc_duration_seconds_bucket{le="0.1"} 4 # {trace_id="4bf92f3577b34da6"} 0.05
+rpc_duration_seconds_bucket{le="1"} 6 # {traceID="00f067aa0ba902b7"} 0.7
+rpc_duration_seconds_bucket{le="+Inf"} 7 # {span_id="b7ad6b71"} 3
+rpc_duration_seconds_sum 5.2
+rpc_duration_seconds_count 7
+# EOF
+`)
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse(body, "application/openmetrics-text; version=1.0.0")
+	require.NoError(t, err)
+
+	traceIDs := map[string][]string{}
+	for _, s := range result.Series["rpc_duration_seconds_bucket"] {
+		traceIDs[s.Labels.Get("le")] = s.TraceIDs
+	}
+	require.Equal(t, map[string][]string{
+		"0.1":  {"4bf92f3577b34da6"},
+		"1":    {"00f067aa0ba902b7"},
+		"+Inf": nil,
+	}, traceIDs)
+}
+
+func TestPromScraper_SkipExemplars(t *testing.T) {
+	t.Parallel()
+	body := []byte(`# TYPE http_requests counter
+http_requests_total{code="200"} 10 # {trace_id="abc"} 1.0
+# EOF
+`)
+	ps := scrape.NewPromScraper("", log.NewNopLogger(), scrape.WithSkipExemplars(true))
+	result, err := ps.Parse(body, "application/openmetrics-text; version=1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Series["http_requests_total"].Cardinality())
+	usage := result.Series.ExemplarUsage()
+	require.Zero(t, usage.Exemplars)
+	require.Zero(t, usage.Bytes)
+}
+
+func TestPromScraper_ScrapeStream(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte(`# TYPE up gauge
+up{job="a"} 1
+up{job="b"} 0
+# TYPE requests_total counter
+requests_total 7
+`))
+	}))
+	defer srv.Close()
+	ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger())
+
+	var got []string
+	err := ps.ScrapeStream(context.Background(), func(s scrape.Series) error {
+		got = append(got, s.Labels.String()+" "+s.Type)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`{__name__="up", job="a"} gauge`,
+		`{__name__="up", job="b"} gauge`,
+		`{__name__="requests_total"} counter`,
+	}, got)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = ps.ScrapeStream(context.Background(), func(scrape.Series) error {
+		calls++
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 1, calls)
+}
+
+func TestPromScraper_ParseError(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte("up 1\nother 3\n"), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+	require.False(t, result.ParseFailed)
+
+	result, err = ps.Parse([]byte("up 1\nbroken{ 2\nother 3\n"), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+	require.True(t, result.ParseFailed)
+	require.Equal(t, 1, result.Series["up"].Cardinality())
+	require.NotContains(t, result.Series, "other")
+}
+
+// The text parser keeps returning the same error once it failed, parsing must stop at the first one rather than
+// call it again forever.
+func TestPromScraper_ParseErrorStops(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte("up 1\nbroken{ 2\n"))
+	}))
+	defer srv.Close()
+	ps := scrape.NewPromScraper(srv.URL, log.NewNopLogger())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := ps.Scrape(ctx)
+	require.NoError(t, err)
+	require.True(t, result.ParseFailed)
+	require.Equal(t, 1, result.Series["up"].Cardinality())
+
+	streamed := 0
+	err = ps.ScrapeStream(ctx, func(scrape.Series) error {
+		streamed++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, streamed)
+}
@@ -9,40 +9,70 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 )
 
+// Series is a single series exposed by the target.
 type Series struct {
-	Name             string
-	Labels           labels.Labels
-	Type             string
+	// Name is the name of the metric family the series belongs to.
+	Name string
+	// Labels are the labels of the series, the metric name included.
+	Labels labels.Labels
+	// Type is the metric type of the family, e.g. counter or native_histogram, empty when unknown.
+	Type string
+	// CreatedTimestamp is the created timestamp of the series in milliseconds, zero when not exposed.
 	CreatedTimestamp int64
+	// Timestamp is the explicit timestamp of the sample in milliseconds, zero when exposed without one.
+	Timestamp int64
+	// Value is the sample value of float series, it is zero for native histograms.
+	Value float64
+	// Schema is the schema of native histograms, CustomBucketsSchema for native histograms with custom buckets.
+	Schema int32
+	// Exemplars is the number of exemplars exposed with the series.
+	Exemplars int
+	// TraceIDs are the trace IDs carried by the exemplars of the series, in exposition order.
+	TraceIDs []string
 }
 
+// SeriesSet holds the series of a metric family keyed by the hash of their labels.
 type SeriesSet map[uint64]Series
 
+// Cardinality returns the number of series in the set.
 func (s SeriesSet) Cardinality() int {
 	return len(s)
 }
 
-func (s SeriesSet) MetricTypeString() string {
-	if len(s) == 0 {
-		return ""
+// Sorted returns the series of the set ordered by their labels.
+func (s SeriesSet) Sorted() []Series {
+	series := make([]Series, 0, len(s))
+	for _, v := range s {
+		series = append(series, v)
 	}
-	typeStr := ""
-	lastType := ""
+	slices.SortFunc(series, func(a, b Series) int {
+		return labels.Compare(a.Labels, b.Labels)
+	})
+	return series
+}
+
+// MetricTypeString returns the types of the set joined by |, e.g. "counter" or "gauge|unknown".
+func (s SeriesSet) MetricTypeString() string {
+	return strings.Join(s.Types(), "|")
+}
+
+// Types returns the sorted distinct metric types of the set, "unknown" standing for series without type.
+func (s SeriesSet) Types() []string {
+	var types []string
 	for _, v := range s {
-		if v.Type == "" {
-			v.Type = "unknown"
+		t := v.Type
+		if t == "" {
+			t = "unknown"
 		}
-		if lastType != v.Type {
-			if typeStr != "" {
-				typeStr += "|"
-			}
-			typeStr += v.Type
-			lastType = v.Type
+		if !slices.Contains(types, t) {
+			types = append(types, t)
 		}
 	}
-	return typeStr
+	slices.Sort(types)
+	return types
 }
 
+// CreatedTS returns the created timestamp of any series of the set, zero for an empty set.
 func (s SeriesSet) CreatedTS() int64 {
 	for _, v := range s {
 		return v.CreatedTimestamp
@@ -50,6 +80,7 @@ func (s SeriesSet) CreatedTS() int64 {
 	return 0
 }
 
+// LabelNames returns the label names of the set, the metric name excluded, joined by | in no particular order.
 func (s SeriesSet) LabelNames() string {
 	if len(s) == 0 {
 		return ""
@@ -69,15 +100,75 @@ func (s SeriesSet) LabelNames() string {
 	return strings.Join(lbls, "|")
 }
 
+// labelValueEscaper escapes a label value in a selector like the text exposition format does, other runes are
+// kept as is.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Selector returns a PromQL selector for the metric, matching the labels that have the same value on
+// every series of the set, e.g. `up{job="api"}`.
+func (s SeriesSet) Selector(name string) string {
+	values := make(map[string]string)
+	counts := make(map[string]int)
+	varying := make(map[string]bool)
+	for _, v := range s {
+		for _, l := range v.Labels {
+			if l.Name == labels.MetricName {
+				continue
+			}
+			if prev, ok := values[l.Name]; ok && prev != l.Value {
+				varying[l.Name] = true
+			}
+			values[l.Name] = l.Value
+			counts[l.Name]++
+		}
+	}
+
+	var matchers []string
+	for label, value := range values {
+		if !varying[label] && counts[label] == len(s) {
+			matchers = append(matchers, label+`="`+labelValueEscaper.Replace(value)+`"`)
+		}
+	}
+	if len(matchers) == 0 {
+		return name
+	}
+	slices.Sort(matchers)
+	return name + "{" + strings.Join(matchers, ", ") + "}"
+}
+
+// SeriesMemoryOverhead is a rough estimate of the bytes a single series takes in the head of a
+// Prometheus server (series struct, index, postings and head chunks), its label strings excluded.
+const SeriesMemoryOverhead = 3 * 1024
+
+// EstimatedMemory estimates the bytes the set takes in the head of a Prometheus server.
+func (s SeriesSet) EstimatedMemory() int {
+	total := 0
+	for _, v := range s {
+		total += SeriesMemoryOverhead
+		for _, l := range v.Labels {
+			total += len(l.Name) + len(l.Value)
+		}
+	}
+	return total
+}
+
+// LabelStats returns the number of distinct values of every label of the set, in no particular order. Empty
+// values are not counted, a label with an empty value is the same as no label, as in LabelValues.
 func (s SeriesSet) LabelStats() LabelStatsSlice {
 	if len(s) == 0 {
 		return nil
 	}
 	labelValueSet := make(map[string]map[string]struct{})
+	s.addLabelValues(labelValueSet)
+	return labelStats(labelValueSet)
+}
 
+// addLabelValues adds the values of every label of the set to labelValueSet, keyed by label name. The metric
+// name and the empty values are skipped.
+func (s SeriesSet) addLabelValues(labelValueSet map[string]map[string]struct{}) {
 	for _, v := range s {
 		for _, l := range v.Labels {
-			if l.Name != "__name__" {
+			if l.Name != labels.MetricName && l.Value != "" {
 				// Initialize the inner map if it doesn't exist
 				if _, exists := labelValueSet[l.Name]; !exists {
 					labelValueSet[l.Name] = make(map[string]struct{})
@@ -87,7 +178,10 @@ func (s SeriesSet) LabelStats() LabelStatsSlice {
 			}
 		}
 	}
+}
 
+// labelStats counts the distinct values of every label of labelValueSet, in no particular order.
+func labelStats(labelValueSet map[string]map[string]struct{}) LabelStatsSlice {
 	var stats []LabelStats
 	for label, valueSet := range labelValueSet {
 		stats = append(stats, LabelStats{
@@ -98,17 +192,69 @@ func (s SeriesSet) LabelStats() LabelStatsSlice {
 	return stats
 }
 
+// LabelValueCount is a value of a label and the number of series carrying it.
+type LabelValueCount struct {
+	Value  string
+	Series int
+}
+
+// LabelValues returns the distinct values of a label across the set, the most common first. Series
+// without the label are not counted.
+func (s SeriesSet) LabelValues(name string) []LabelValueCount {
+	counts := make(map[string]int)
+	for _, v := range s {
+		if value := v.Labels.Get(name); value != "" {
+			counts[value]++
+		}
+	}
+	values := make([]LabelValueCount, 0, len(counts))
+	for value, n := range counts {
+		values = append(values, LabelValueCount{Value: value, Series: n})
+	}
+	slices.SortFunc(values, func(a, b LabelValueCount) int {
+		if a.Series != b.Series {
+			return b.Series - a.Series
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+	return values
+}
+
+// CardinalityWithout returns the number of series left when the named labels are aggregated away, as by a
+// sum without(...): the series only differing by them are merged.
+func (s SeriesSet) CardinalityWithout(names ...string) int {
+	if len(names) == 0 {
+		return len(s)
+	}
+	// HashWithoutLabels expects the names sorted.
+	names = slices.Sorted(slices.Values(names))
+	seen := make(map[uint64]struct{}, len(s))
+	var (
+		h   uint64
+		buf []byte
+	)
+	for _, v := range s {
+		h, buf = v.Labels.HashWithoutLabels(buf, names...)
+		seen[h] = struct{}{}
+	}
+	return len(seen)
+}
+
+// LabelStats is the number of distinct values of a label.
 type LabelStats struct {
 	Name           string
 	DistinctValues uint
 }
 
+// String renders the stats as name(values), e.g. code(5).
 func (l LabelStats) String() string {
 	return fmt.Sprintf("%s(%d)", l.Name, l.DistinctValues)
 }
 
+// LabelStatsSlice is the label stats of a set or a map.
 type LabelStatsSlice []LabelStats
 
+// String renders the stats joined by |, e.g. code(5)|method(2).
 func (l LabelStatsSlice) String() string {
 	var strBuf strings.Builder
 	for i, ls := range l {
@@ -120,21 +266,77 @@ func (l LabelStatsSlice) String() string {
 	return strBuf.String()
 }
 
+// SeriesMap holds the series of a scrape keyed by metric family name.
 type SeriesMap map[string]SeriesSet
 
+// LabelStats returns the number of distinct values of every label across all metrics in the map, sorted by
+// label name. Empty values are not counted, as in SeriesSet.LabelStats.
+func (s SeriesMap) LabelStats() LabelStatsSlice {
+	labelValueSet := make(map[string]map[string]struct{})
+	for _, set := range s {
+		set.addLabelValues(labelValueSet)
+	}
+
+	stats := labelStats(labelValueSet)
+	slices.SortFunc(stats, func(i, j LabelStats) int {
+		return strings.Compare(i.Name, j.Name)
+	})
+	return stats
+}
+
+// EstimatedMemory estimates the bytes all the series of the map take in the head of a Prometheus server.
+func (s SeriesMap) EstimatedMemory() int {
+	total := 0
+	for _, set := range s {
+		total += set.EstimatedMemory()
+	}
+	return total
+}
+
+// TypeCounts returns the number of metric families of each type. Families mixing types are counted
+// once for every type they contain.
+func (s SeriesMap) TypeCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, set := range s {
+		for _, t := range set.Types() {
+			counts[t]++
+		}
+	}
+	return counts
+}
+
+// Result is the outcome of a scrape.
 type Result struct {
-	Series          SeriesMap
+	Series SeriesMap
+	// UsedContentType is the content type the target answered with.
 	UsedContentType string
+	// Time is when the target was scraped.
+	Time time.Time
+	// Truncated is set when parsing stopped at the series limit, see WithMaxSeries.
+	Truncated bool
+	// BodyBytes is the size of the parsed body, decompressed.
+	BodyBytes int
+	// ParseFailed is set when parsing stopped at an entry of the body that failed to parse, the series parsed
+	// before it are kept and the rest of the body is missing.
+	ParseFailed bool
+	// Timing is the time spent in the phases of the scrape request, zero when the series were not scraped
+	// over HTTP.
+	Timing Timing
 }
 
+// SeriesInfo summarizes a metric family, as listed by AsRows.
 type SeriesInfo struct {
 	Name        string
 	Cardinality int
-	Type        string
-	Labels      string
-	CreatedTS   string
+	// Type is the MetricTypeString of the family.
+	Type string
+	// Labels is the label stats of the family, the most diverse label first.
+	Labels string
+	// CreatedTS is the created timestamp of the family, "_empty_" when not exposed.
+	CreatedTS string
 }
 
+// AsRows summarizes every family of the map, the highest cardinality first.
 func (s SeriesMap) AsRows() []SeriesInfo {
 	var rows []SeriesInfo
 	for name, s := range s {
@@ -70,6 +70,51 @@ func TestSeriesSet_LabelNames(t *testing.T) {
 	)
 }
 
+func TestSeriesSet_EstimatedMemory(t *testing.T) {
+	t.Parallel()
+	seriesSet := scrape.SeriesSet{
+		1: {Name: "up", Labels: labels.FromStrings("__name__", "up", "job", "api")},
+		2: {Name: "up", Labels: labels.FromStrings("__name__", "up", "job", "db")},
+	}
+
+	// Per series: the fixed overhead plus "__name__" (8) + "up" (2) + "job" (3) and the job value.
+	expected := 2*scrape.SeriesMemoryOverhead + 2*(8+2+3) + len("api") + len("db")
+	require.Equal(t, expected, seriesSet.EstimatedMemory())
+	require.Zero(t, scrape.SeriesSet{}.EstimatedMemory())
+}
+
+func TestSeriesMap_EstimatedMemory(t *testing.T) {
+	t.Parallel()
+	seriesMap := scrape.SeriesMap{
+		"up": {
+			1: {Name: "up", Labels: labels.FromStrings("__name__", "up", "job", "api")},
+		},
+		"build_info": {
+			1: {Name: "build_info", Labels: labels.FromStrings("__name__", "build_info", "version", "1.0")},
+			2: {Name: "build_info", Labels: labels.FromStrings("__name__", "build_info", "version", "1.1")},
+		},
+	}
+
+	expected := seriesMap["up"].EstimatedMemory() + seriesMap["build_info"].EstimatedMemory()
+	require.Equal(t, expected, seriesMap.EstimatedMemory())
+	require.Zero(t, scrape.SeriesMap{}.EstimatedMemory())
+}
+
+func TestSeriesSet_Selector(t *testing.T) {
+	t.Parallel()
+	seriesSet := scrape.SeriesSet{
+		1: {Labels: labels.FromStrings("__name__", "up", "job", "api", "instance", "a:80", "env", "prod")},
+		2: {Labels: labels.FromStrings("__name__", "up", "job", "api", "instance", "b:80")},
+		3: {Labels: labels.FromStrings("__name__", "up", "job", "api", "instance", "c:80", "env", "prod")},
+	}
+
+	require.Equal(t, `up{job="api"}`, seriesSet.Selector("up"))
+	require.Equal(t, "up", scrape.SeriesSet{1: {Labels: labels.FromStrings("__name__", "up")}}.Selector("up"))
+
+	quoted := scrape.SeriesSet{1: {Labels: labels.FromStrings("__name__", "up", "path", "/say \"héllo\"\\\n")}}
+	require.Equal(t, `up{path="/say \"héllo\"\\\n"}`, quoted.Selector("up"))
+}
+
 func TestSeriesSet_LabelStats(t *testing.T) {
 	t.Parallel()
 	seriesSet := scrape.SeriesSet{
@@ -114,3 +159,104 @@ func TestSeriesSet_AsRowOrdering(t *testing.T) {
 	require.Equal(t, "series3", rows[1].Name)
 	require.Equal(t, "series1", rows[2].Name)
 }
+
+func TestSeriesMap_TypeCounts(t *testing.T) {
+	t.Parallel()
+	seriesMap := scrape.SeriesMap{
+		"requests_total": {1: {Type: "counter"}, 2: {Type: "counter"}},
+		"queue_size":     {1: {Type: "gauge"}},
+		"mixed":          {1: {Type: "gauge"}, 2: {Type: ""}},
+	}
+
+	require.Equal(t, []string{"gauge", "unknown"}, seriesMap["mixed"].Types())
+	require.Equal(t, map[string]int{"counter": 1, "gauge": 2, "unknown": 1}, seriesMap.TypeCounts())
+}
+
+func TestSeriesSet_Sorted(t *testing.T) {
+	t.Parallel()
+	seriesSet := scrape.SeriesSet{
+		1: {Name: "up", Labels: labels.FromStrings("__name__", "up", "job", "b")},
+		2: {Name: "up", Labels: labels.FromStrings("__name__", "up", "job", "a", "zone", "eu")},
+		3: {Name: "up", Labels: labels.FromStrings("__name__", "up", "job", "a")},
+	}
+	var got []string
+	for _, s := range seriesSet.Sorted() {
+		got = append(got, s.Labels.String())
+	}
+	require.Equal(t, []string{
+		`{__name__="up", job="a"}`,
+		`{__name__="up", job="a", zone="eu"}`,
+		`{__name__="up", job="b"}`,
+	}, got)
+}
+
+func TestSeriesSet_LabelValues(t *testing.T) {
+	t.Parallel()
+	seriesSet := scrape.SeriesSet{
+		1: {Labels: labels.FromStrings("__name__", "req", "code", "200", "path", "/a")},
+		2: {Labels: labels.FromStrings("__name__", "req", "code", "200", "path", "/b")},
+		3: {Labels: labels.FromStrings("__name__", "req", "code", "500", "path", "/a")},
+		4: {Labels: labels.FromStrings("__name__", "req", "code", "200")},
+		5: {Labels: labels.FromStrings("__name__", "req", "code", "404")},
+	}
+	require.Equal(t, []scrape.LabelValueCount{
+		{Value: "200", Series: 3},
+		{Value: "404", Series: 1},
+		{Value: "500", Series: 1},
+	}, seriesSet.LabelValues("code"))
+	require.Equal(t, []scrape.LabelValueCount{
+		{Value: "/a", Series: 2},
+		{Value: "/b", Series: 1},
+	}, seriesSet.LabelValues("path"))
+	require.Empty(t, seriesSet.LabelValues("missing"))
+}
+
+func TestSeriesSet_EmptyLabelValues(t *testing.T) {
+	t.Parallel()
+	// An empty value is the same as no label, LabelStats and LabelValues agree on it.
+	seriesSet := scrape.SeriesSet{
+		1: {Labels: labels.Labels{{Name: "__name__", Value: "req"}, {Name: "foo", Value: ""}}},
+		2: {Labels: labels.Labels{{Name: "__name__", Value: "req"}, {Name: "code", Value: "200"}, {Name: "foo", Value: ""}}},
+	}
+	require.Equal(t, scrape.LabelStatsSlice{{Name: "code", DistinctValues: 1}}, seriesSet.LabelStats())
+	require.Empty(t, seriesSet.LabelValues("foo"))
+}
+
+func TestSeriesMap_LabelStats(t *testing.T) {
+	t.Parallel()
+	// The stats of the map count the distinct values across families, empty values not included as in the
+	// stats of each family.
+	lset := func(name string, ls ...labels.Label) labels.Labels {
+		return append(labels.Labels{{Name: "__name__", Value: name}}, ls...)
+	}
+	seriesMap := scrape.SeriesMap{
+		"req": {
+			1: {Labels: lset("req", labels.Label{Name: "code", Value: "200"}, labels.Label{Name: "foo", Value: ""})},
+			2: {Labels: lset("req", labels.Label{Name: "code", Value: "500"}, labels.Label{Name: "pod", Value: "p1"})},
+		},
+		"up": {
+			3: {Labels: lset("up", labels.Label{Name: "code", Value: "200"}, labels.Label{Name: "pod", Value: ""})},
+			4: {Labels: lset("up", labels.Label{Name: "pod", Value: "p2"})},
+		},
+	}
+	require.Equal(t, scrape.LabelStatsSlice{
+		{Name: "code", DistinctValues: 2},
+		{Name: "pod", DistinctValues: 2},
+	}, seriesMap.LabelStats())
+	require.Empty(t, scrape.SeriesMap{}.LabelStats())
+}
+
+func TestSeriesSet_CardinalityWithout(t *testing.T) {
+	t.Parallel()
+	seriesSet := scrape.SeriesSet{
+		1: {Labels: labels.FromStrings("__name__", "req", "code", "200", "path", "/a", "pod", "p1")},
+		2: {Labels: labels.FromStrings("__name__", "req", "code", "200", "path", "/a", "pod", "p2")},
+		3: {Labels: labels.FromStrings("__name__", "req", "code", "500", "path", "/a", "pod", "p1")},
+		4: {Labels: labels.FromStrings("__name__", "req", "code", "200", "path", "/b", "pod", "p1")},
+	}
+	require.Equal(t, 4, seriesSet.CardinalityWithout())
+	require.Equal(t, 3, seriesSet.CardinalityWithout("pod"))
+	require.Equal(t, 2, seriesSet.CardinalityWithout("pod", "code"))
+	require.Equal(t, 1, seriesSet.CardinalityWithout("pod", "code", "path"))
+	require.Equal(t, 4, seriesSet.CardinalityWithout("missing"))
+}
@@ -0,0 +1,77 @@
+package scrape
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// SharedLabelsRule is the lint rule reporting the labels shared by every series of the scrape.
+const SharedLabelsRule = "shared-labels"
+
+// SharedLabel is a label exposed with the same value on every series of a scrape, e.g. a version or a region
+// baked into every metric. It is better attached as a target label, or exposed once on an info metric that
+// queries join with.
+type SharedLabel struct {
+	Name  string
+	Value string
+}
+
+// SharedLabels returns the labels exposed with the same value on every series of the scrape, in name order. It
+// takes a whole scrape to tell them from the constant labels of a family, so a scrape of less than two
+// families has none.
+func SharedLabels(sm SeriesMap) []SharedLabel {
+	if len(sm) < 2 {
+		return nil
+	}
+	var shared map[string]string
+	for _, set := range sm {
+		for _, s := range set {
+			if shared == nil {
+				shared = make(map[string]string)
+				s.Labels.Range(func(l labels.Label) {
+					if l.Name != labels.MetricName {
+						shared[l.Name] = l.Value
+					}
+				})
+				continue
+			}
+			for name, value := range shared {
+				if s.Labels.Get(name) != value {
+					delete(shared, name)
+				}
+			}
+			if len(shared) == 0 {
+				return nil
+			}
+		}
+	}
+
+	result := make([]SharedLabel, 0, len(shared))
+	for _, name := range slices.Sorted(maps.Keys(shared)) {
+		result = append(result, SharedLabel{Name: name, Value: shared[name]})
+	}
+	return result
+}
+
+// CheckSharedLabels reports the labels shared by every series of the scrape as warnings of the lint check, in a
+// single result for the whole scrape.
+func CheckSharedLabels(sm SeriesMap) CheckResult {
+	series := 0
+	for _, set := range sm {
+		series += set.Cardinality()
+	}
+	result := CheckResult{Check: LintCheck}
+	for _, l := range SharedLabels(sm) {
+		result.Problems = append(result.Problems, Problem{
+			Rule:     SharedLabelsRule,
+			Severity: SeverityWarning,
+			Text: fmt.Sprintf("label %s=%q is on all the %d series, attach it as a target label or expose it "+
+				"once on an info metric", l.Name, l.Value, series),
+			Label: l.Name,
+		})
+	}
+	return result
+}
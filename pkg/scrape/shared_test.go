@@ -0,0 +1,37 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestSharedLabels(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte(`# TYPE http_requests_total counter
+http_requests_total{code="200",region="eu",version="1.2"} 5
+http_requests_total{code="500",region="eu",version="1.2"} 1
+# TYPE queue_length gauge
+queue_length{queue="a",region="eu",version="1.2",zone="b"} 3
+queue_length{queue="b",region="eu",version="1.3",zone="b"} 3
+`), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+
+	require.Equal(t, []scrape.SharedLabel{{Name: "region", Value: "eu"}}, scrape.SharedLabels(result.Series))
+
+	check := scrape.CheckSharedLabels(result.Series)
+	require.Equal(t, scrape.LintCheck, check.Check)
+	require.Empty(t, check.Metric)
+	require.Len(t, check.Problems, 1)
+	require.Equal(t, scrape.SharedLabelsRule, check.Problems[0].Rule)
+	require.Equal(t, "region", check.Problems[0].Label)
+	require.False(t, check.Failed())
+
+	// A single family has no shared labels, only constant ones.
+	delete(result.Series, "queue_length")
+	require.Empty(t, scrape.SharedLabels(result.Series))
+}
@@ -0,0 +1,75 @@
+package scrape
+
+// ValueCoverage is the number of most common values of a label carrying at least a share of its series.
+type ValueCoverage struct {
+	// Share is the share of the series, between 0 and 1.
+	Share  float64
+	Values int
+	// Series is the number of series carried by the values, at least Share of them.
+	Series int
+}
+
+// ValueBucket counts the values of a label carrying up to UpTo series, and more than the previous bucket.
+type ValueBucket struct {
+	UpTo   int
+	Values int
+	Series int
+}
+
+// ValueSkew summarizes how the series carrying a label are spread across its values. A skewed label, whose
+// series mostly share a few values, can often be salvaged by bounding its rare values, while a label spread
+// evenly over many values has to go.
+type ValueSkew struct {
+	Values int
+	Series int
+	// Coverage gives the values covering 50%, 90% and 99% of the series, the most common first.
+	Coverage []ValueCoverage
+	// Buckets counts the values by their number of series in powers of ten: 1, 2-10, 11-100 and so on, up to
+	// the bucket of the most common value.
+	Buckets []ValueBucket
+}
+
+// coverageShares are the shares of the series ValueSkew.Coverage reports.
+var coverageShares = []float64{0.5, 0.9, 0.99}
+
+// LabelValueSkew computes the skew of the values of a label, as returned by SeriesSet.LabelValues: the most
+// common first.
+func LabelValueSkew(values []LabelValueCount) ValueSkew {
+	skew := ValueSkew{Values: len(values)}
+	for _, v := range values {
+		skew.Series += v.Series
+	}
+	if skew.Series == 0 {
+		return skew
+	}
+
+	cumulative, next := 0, 0
+	for i, v := range values {
+		cumulative += v.Series
+		for next < len(coverageShares) && float64(cumulative) >= coverageShares[next]*float64(skew.Series) {
+			skew.Coverage = append(skew.Coverage, ValueCoverage{
+				Share:  coverageShares[next],
+				Values: i + 1,
+				Series: cumulative,
+			})
+			next++
+		}
+	}
+
+	upTo := 1
+	for upTo < values[0].Series {
+		upTo *= 10
+	}
+	for bound := 1; bound <= upTo; bound *= 10 {
+		skew.Buckets = append(skew.Buckets, ValueBucket{UpTo: bound})
+	}
+	for _, v := range values {
+		i := 0
+		for skew.Buckets[i].UpTo < v.Series {
+			i++
+		}
+		skew.Buckets[i].Values++
+		skew.Buckets[i].Series += v.Series
+	}
+	return skew
+}
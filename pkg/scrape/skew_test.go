@@ -0,0 +1,35 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestLabelValueSkew(t *testing.T) {
+	t.Parallel()
+	// 3 values carry 900 series, the 100 others one each.
+	values := []scrape.LabelValueCount{{Value: "a", Series: 500}, {Value: "b", Series: 300}, {Value: "c", Series: 100}}
+	for i := 0; i < 100; i++ {
+		values = append(values, scrape.LabelValueCount{Value: "rare", Series: 1})
+	}
+
+	skew := scrape.LabelValueSkew(values)
+	require.Equal(t, 103, skew.Values)
+	require.Equal(t, 1000, skew.Series)
+	require.Equal(t, []scrape.ValueCoverage{
+		{Share: 0.5, Values: 1, Series: 500},
+		{Share: 0.9, Values: 3, Series: 900},
+		{Share: 0.99, Values: 93, Series: 990},
+	}, skew.Coverage)
+	require.Equal(t, []scrape.ValueBucket{
+		{UpTo: 1, Values: 100, Series: 100},
+		{UpTo: 10},
+		{UpTo: 100, Values: 1, Series: 100},
+		{UpTo: 1000, Values: 2, Series: 800},
+	}, skew.Buckets)
+
+	require.Equal(t, scrape.ValueSkew{}, scrape.LabelValueSkew(nil))
+}
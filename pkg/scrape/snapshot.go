@@ -0,0 +1,161 @@
+package scrape
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// SnapshotVersion is the version of the snapshot format written by EncodeResult. DecodeResult reads every
+// version up to it, the version is bumped on every change older readers would misread.
+const SnapshotVersion = 1
+
+// snapshot is the on-disk representation of a Result. Families and series are sorted so that snapshots of
+// identical scrapes are identical.
+type snapshot struct {
+	Version     int              `json:"version"`
+	Time        time.Time        `json:"time"`
+	ContentType string           `json:"content_type,omitempty"`
+	Truncated   bool             `json:"truncated,omitempty"`
+	BodyBytes   int              `json:"body_bytes,omitempty"`
+	ParseFailed bool             `json:"parse_failed,omitempty"`
+	Timing      *Timing          `json:"timing,omitempty"`
+	Families    []snapshotFamily `json:"families"`
+}
+
+type snapshotFamily struct {
+	Name   string           `json:"name"`
+	Series []snapshotSeries `json:"series"`
+}
+
+type snapshotSeries struct {
+	Labels labels.Labels `json:"labels"`
+	Type   string        `json:"type,omitempty"`
+	// Value is formatted as a string, like in the Prometheus HTTP API, since JSON has no NaN and infinities.
+	Value            string   `json:"value"`
+	CreatedTimestamp int64    `json:"created_timestamp,omitempty"`
+	Timestamp        int64    `json:"timestamp,omitempty"`
+	Schema           int32    `json:"schema,omitempty"`
+	Exemplars        int      `json:"exemplars,omitempty"`
+	TraceIDs         []string `json:"trace_ids,omitempty"`
+}
+
+// EncodeResult writes the result to w as a versioned JSON snapshot, to be read back with DecodeResult.
+func EncodeResult(w io.Writer, r *Result) error {
+	s := snapshot{
+		Version:     SnapshotVersion,
+		Time:        r.Time,
+		ContentType: r.UsedContentType,
+		Truncated:   r.Truncated,
+		BodyBytes:   r.BodyBytes,
+		ParseFailed: r.ParseFailed,
+		Families:    make([]snapshotFamily, 0, len(r.Series)),
+	}
+	if r.Timing != (Timing{}) {
+		s.Timing = &r.Timing
+	}
+	for name, set := range r.Series {
+		family := snapshotFamily{Name: name, Series: make([]snapshotSeries, 0, len(set))}
+		for _, series := range set.Sorted() {
+			family.Series = append(family.Series, snapshotSeries{
+				Labels:           series.Labels,
+				Type:             series.Type,
+				Value:            strconv.FormatFloat(series.Value, 'g', -1, 64),
+				CreatedTimestamp: series.CreatedTimestamp,
+				Timestamp:        series.Timestamp,
+				Schema:           series.Schema,
+				Exemplars:        series.Exemplars,
+				TraceIDs:         series.TraceIDs,
+			})
+		}
+		s.Families = append(s.Families, family)
+	}
+	slices.SortFunc(s.Families, func(a, b snapshotFamily) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// DecodeResult reads a snapshot written by EncodeResult.
+func DecodeResult(r io.Reader) (*Result, error) {
+	var s snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if s.Version < 1 || s.Version > SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d, expected at most %d", s.Version, SnapshotVersion)
+	}
+
+	result := &Result{
+		Series:          make(SeriesMap, len(s.Families)),
+		UsedContentType: s.ContentType,
+		Time:            s.Time,
+		Truncated:       s.Truncated,
+		BodyBytes:       s.BodyBytes,
+		ParseFailed:     s.ParseFailed,
+	}
+	if s.Timing != nil {
+		result.Timing = *s.Timing
+	}
+	for _, family := range s.Families {
+		set := make(SeriesSet, len(family.Series))
+		for _, series := range family.Series {
+			value, err := strconv.ParseFloat(series.Value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of series %s: %w", series.Labels, err)
+			}
+			set[series.Labels.Hash()] = Series{
+				Name:             family.Name,
+				Labels:           series.Labels,
+				Type:             series.Type,
+				CreatedTimestamp: series.CreatedTimestamp,
+				Timestamp:        series.Timestamp,
+				Value:            value,
+				Schema:           series.Schema,
+				Exemplars:        series.Exemplars,
+				TraceIDs:         series.TraceIDs,
+			}
+		}
+		result.Series[family.Name] = set
+	}
+	return result, nil
+}
+
+// SaveResult writes the result to a snapshot file at path.
+func SaveResult(path string, r *Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := EncodeResult(f, r); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write snapshot %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// LoadResult reads the snapshot file at path.
+func LoadResult(path string) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeResult(f)
+}
+
+// isSnapshot reports whether a body read from a file is a snapshot rather than an exposition, which never
+// starts with a brace.
+func isSnapshot(body []byte) bool {
+	return strings.HasPrefix(strings.TrimSpace(string(body[:min(len(body), 64)])), "{")
+}
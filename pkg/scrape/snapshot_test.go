@@ -0,0 +1,77 @@
+package scrape_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestEncodeResult_RoundTrip(t *testing.T) {
+	t.Parallel()
+	up := labels.FromStrings("__name__", "up", "job", "api")
+	requests := labels.FromStrings("__name__", "requests_total", "code", "200")
+	result := &scrape.Result{
+		Series: scrape.SeriesMap{
+			"up": {up.Hash(): {Name: "up", Labels: up, Type: "gauge", Value: math.Inf(1)}},
+			"requests_total": {requests.Hash(): {
+				Name:             "requests_total",
+				Labels:           requests,
+				Type:             "counter",
+				Value:            42,
+				CreatedTimestamp: 1700000000000,
+				Timestamp:        1714564800000,
+				Exemplars:        2,
+				TraceIDs:         []string{"abc", "def"},
+			}},
+		},
+		UsedContentType: "text/plain; version=0.0.4",
+		Time:            time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Truncated:       true,
+		BodyBytes:       512,
+		ParseFailed:     true,
+		Timing:          scrape.Timing{Connect: time.Millisecond, TTFB: 50 * time.Millisecond, Total: time.Second},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, scrape.EncodeResult(&buf, result))
+	require.Contains(t, buf.String(), `"version": 1`)
+	require.Contains(t, buf.String(), `"value": "+Inf"`)
+
+	decoded, err := scrape.DecodeResult(&buf)
+	require.NoError(t, err)
+	require.Equal(t, result, decoded)
+}
+
+func TestDecodeResult_UnsupportedVersion(t *testing.T) {
+	t.Parallel()
+	_, err := scrape.DecodeResult(strings.NewReader(`{"version": 99, "families": []}`))
+	require.ErrorContains(t, err, "unsupported snapshot version 99")
+	_, err = scrape.DecodeResult(strings.NewReader(`{"families": []}`))
+	require.ErrorContains(t, err, "unsupported snapshot version 0")
+}
+
+func TestFileScraper_Snapshot(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte("# TYPE up gauge\nup{job=\"a\"} 1\nup{job=\"b\"} NaN\n"), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, scrape.SaveResult(path, result))
+
+	scraper, err := scrape.NewScraper(path, log.NewNopLogger())
+	require.NoError(t, err)
+	loaded, err := scraper.Scrape(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, result.Series.AsRows(), loaded.Series.AsRows())
+	require.True(t, math.IsNaN(loaded.Series["up"][labels.FromStrings("__name__", "up", "job", "b").Hash()].Value))
+}
@@ -0,0 +1,121 @@
+package scrape
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// QuantileLabel is the label holding the φ quantile of the quantile series of a summary.
+const QuantileLabel = "quantile"
+
+// SummaryInfo describes the quantiles exposed by the summaries of a family.
+type SummaryInfo struct {
+	Family string
+	// Summaries is the number of summaries of the family, one per label set.
+	Summaries int
+	// Series is the number of series of the family, the quantile, sum and count series of its summaries.
+	Series int
+	// Quantiles are the φ quantiles exposed by most of the summaries, in increasing order. QuantileSets is the
+	// number of distinct sets of quantiles exposed by the summaries of the family.
+	Quantiles    []float64
+	QuantileSets int
+	// Mismatch are the labels of the first summary, in label order, exposing other quantiles than Quantiles, and
+	// MismatchQuantiles the quantiles it exposes. Empty when every summary exposes the same quantiles.
+	Mismatch          string
+	MismatchQuantiles []float64
+}
+
+// Consistent reports whether every summary of the family exposes the same quantiles. Quantiles cannot be
+// aggregated across summaries, so exposing different ones per label set is usually an exporter bug.
+func (s SummaryInfo) Consistent() bool {
+	return s.QuantileSets <= 1
+}
+
+// summary gathers the quantiles of a summary, one label set of its family.
+type summary struct {
+	labels    labels.Labels
+	quantiles []float64
+}
+
+// Summaries describes the quantiles of the summary families of the scrape, in name order.
+func Summaries(sm SeriesMap) []SummaryInfo {
+	infos := make(map[string]*SummaryInfo)
+	summaries := make(map[string]map[uint64]*summary)
+	b := labels.NewBuilder(labels.EmptyLabels())
+	for _, set := range sm {
+		for _, s := range set {
+			if s.Type != "summary" {
+				continue
+			}
+			family, suffix := s.Name, ""
+			for _, sfx := range []string{"_sum", "_count"} {
+				if f, ok := strings.CutSuffix(s.Name, sfx); ok {
+					family, suffix = f, sfx
+					break
+				}
+			}
+			info, ok := infos[family]
+			if !ok {
+				info = &SummaryInfo{Family: family}
+				infos[family] = info
+				summaries[family] = make(map[uint64]*summary)
+			}
+			info.Series++
+
+			b.Reset(s.Labels)
+			b.Del(labels.MetricName, QuantileLabel)
+			lset := b.Labels()
+			sum, ok := summaries[family][lset.Hash()]
+			if !ok {
+				sum = &summary{labels: lset}
+				summaries[family][lset.Hash()] = sum
+			}
+			if suffix != "" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(s.Labels.Get(QuantileLabel), 64); err == nil {
+				sum.quantiles = append(sum.quantiles, q)
+			}
+		}
+	}
+
+	result := make([]SummaryInfo, 0, len(infos))
+	for _, name := range slices.Sorted(maps.Keys(infos)) {
+		info := infos[name]
+		info.classify(summaries[name])
+		result = append(result, *info)
+	}
+	return result
+}
+
+// classify finds the quantiles most summaries of the family expose and the first summary exposing others.
+func (info *SummaryInfo) classify(summaries map[uint64]*summary) {
+	info.Summaries = len(summaries)
+	sets := make(map[string]int)
+	quantiles := make(map[string][]float64)
+	sorted := slices.Collect(maps.Values(summaries))
+	slices.SortFunc(sorted, func(a, b *summary) int { return labels.Compare(a.labels, b.labels) })
+	for _, s := range sorted {
+		slices.Sort(s.quantiles)
+		key := fmt.Sprint(s.quantiles)
+		sets[key]++
+		quantiles[key] = s.quantiles
+	}
+	info.QuantileSets = len(sets)
+	common := slices.MaxFunc(slices.Sorted(maps.Keys(sets)), func(a, b string) int {
+		return cmp.Compare(sets[a], sets[b])
+	})
+	info.Quantiles = quantiles[common]
+	for _, s := range sorted {
+		if !slices.Equal(s.quantiles, info.Quantiles) {
+			info.Mismatch, info.MismatchQuantiles = s.labels.String(), s.quantiles
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestSummaries(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte(`# TYPE rpc_seconds summary
+rpc_seconds{method="get",quantile="0.5"} 0.1
+rpc_seconds{method="get",quantile="0.99"} 0.4
+rpc_seconds_sum{method="get"} 12
+rpc_seconds_count{method="get"} 40
+rpc_seconds{method="put",quantile="0.99"} 0.7
+rpc_seconds{method="put",quantile="0.5"} 0.2
+rpc_seconds_sum{method="put"} 3
+rpc_seconds_count{method="put"} 10
+rpc_seconds{method="del",quantile="0.9"} 0.3
+rpc_seconds_sum{method="del"} 1
+rpc_seconds_count{method="del"} 5
+# TYPE gc_seconds summary
+gc_seconds_sum 0.5
+gc_seconds_count 8
+# TYPE up gauge
+up 1
+`), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+
+	infos := scrape.Summaries(result.Series)
+	require.Equal(t, []scrape.SummaryInfo{
+		{
+			Family:       "gc_seconds",
+			Summaries:    1,
+			Series:       2,
+			QuantileSets: 1,
+		},
+		{
+			Family:            "rpc_seconds",
+			Summaries:         3,
+			Series:            11,
+			Quantiles:         []float64{0.5, 0.99},
+			QuantileSets:      2,
+			Mismatch:          `{method="del"}`,
+			MismatchQuantiles: []float64{0.9},
+		},
+	}, infos)
+	require.True(t, infos[0].Consistent())
+	require.False(t, infos[1].Consistent())
+}
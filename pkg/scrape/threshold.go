@@ -0,0 +1,76 @@
+package scrape
+
+import "fmt"
+
+// Threshold bounds the cardinality of a target watched over several scrapes.
+type Threshold struct {
+	// Metric is the metric family bounded, empty to bound the series of the whole scrape.
+	Metric string
+	// MaxSeries is crossed when the series go above it, zero disables it.
+	MaxSeries int
+	// MaxGrowth is crossed when the series grow by more than this ratio between consecutive scrapes, e.g. 0.2
+	// for 20%, zero disables it.
+	MaxGrowth float64
+}
+
+// Breach is a threshold crossed between two scrapes.
+type Breach struct {
+	Threshold Threshold
+	// Previous and Series are the series bounded by the threshold in the previous and the current scrape.
+	Previous int
+	Series   int
+	// Reason describes how the threshold was crossed.
+	Reason string
+}
+
+// Check compares a scrape with the previous one, nil for the first scrape, and returns how the threshold was
+// crossed. MaxSeries only breaches when the series go above it, not on every scrape that stays above it, so that
+// a canary notifies once per incident.
+func (t Threshold) Check(prev, cur SeriesMap) []Breach {
+	series := t.series(cur)
+	previous := 0
+	if prev != nil {
+		previous = t.series(prev)
+	}
+
+	var breaches []Breach
+	if t.MaxSeries > 0 && series > t.MaxSeries && (prev == nil || previous <= t.MaxSeries) {
+		breaches = append(breaches, Breach{
+			Threshold: t,
+			Previous:  previous,
+			Series:    series,
+			Reason:    fmt.Sprintf("%s went from %d to %d series, over %d", t.subject(), previous, series, t.MaxSeries),
+		})
+	}
+	// A family appearing from nothing has no growth ratio, MaxSeries covers it.
+	if t.MaxGrowth > 0 && prev != nil && previous > 0 {
+		if growth := float64(series-previous) / float64(previous); growth > t.MaxGrowth {
+			breaches = append(breaches, Breach{
+				Threshold: t,
+				Previous:  previous,
+				Series:    series,
+				Reason: fmt.Sprintf("%s grew by %.1f%% from %d to %d series, over %.1f%%",
+					t.subject(), growth*100, previous, series, t.MaxGrowth*100),
+			})
+		}
+	}
+	return breaches
+}
+
+func (t Threshold) series(sm SeriesMap) int {
+	if t.Metric != "" {
+		return sm[t.Metric].Cardinality()
+	}
+	total := 0
+	for _, set := range sm {
+		total += set.Cardinality()
+	}
+	return total
+}
+
+func (t Threshold) subject() string {
+	if t.Metric == "" {
+		return "the target"
+	}
+	return t.Metric
+}
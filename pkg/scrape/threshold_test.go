@@ -0,0 +1,43 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestThreshold_Check(t *testing.T) {
+	t.Parallel()
+	scrapes := []scrape.SeriesMap{
+		{"requests_total": replicaSet("requests_total", 10), "up": replicaSet("up", 1)},
+		{"requests_total": replicaSet("requests_total", 11), "up": replicaSet("up", 1)},
+		{"requests_total": replicaSet("requests_total", 20), "up": replicaSet("up", 1)},
+		{"requests_total": replicaSet("requests_total", 21), "up": replicaSet("up", 1)},
+	}
+
+	total := scrape.Threshold{MaxSeries: 15}
+	require.Empty(t, total.Check(nil, scrapes[0]))
+	require.Empty(t, total.Check(scrapes[0], scrapes[1]))
+	require.Equal(t, []scrape.Breach{{
+		Threshold: total,
+		Previous:  12,
+		Series:    21,
+		Reason:    "the target went from 12 to 21 series, over 15",
+	}}, total.Check(scrapes[1], scrapes[2]))
+	// Staying above the threshold does not breach it again.
+	require.Empty(t, total.Check(scrapes[2], scrapes[3]))
+	require.Len(t, total.Check(nil, scrapes[3]), 1)
+
+	growth := scrape.Threshold{Metric: "requests_total", MaxGrowth: 0.5}
+	require.Empty(t, growth.Check(nil, scrapes[0]))
+	require.Empty(t, growth.Check(scrapes[0], scrapes[1]))
+	breaches := growth.Check(scrapes[1], scrapes[2])
+	require.Len(t, breaches, 1)
+	require.Equal(t, "requests_total grew by 81.8% from 11 to 20 series, over 50.0%", breaches[0].Reason)
+
+	// A family appearing from nothing has no growth.
+	require.Empty(t, scrape.Threshold{Metric: "leak", MaxGrowth: 0.1}.Check(
+		scrape.SeriesMap{}, scrape.SeriesMap{"leak": replicaSet("leak", 5)}))
+}
@@ -0,0 +1,61 @@
+package scrape
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Throttle spaces out scrapes so that the analyzer does not stampede a fleet of exporters: it starts at most
+// rate scrapes per second, each delayed by a random jitter.
+type Throttle struct {
+	mtx      sync.Mutex
+	interval time.Duration
+	jitter   time.Duration
+	next     time.Time
+}
+
+// NewThrottle returns a throttle starting up to rate scrapes per second, unlimited when zero, each delayed by
+// up to jitter.
+func NewThrottle(rate float64, jitter time.Duration) *Throttle {
+	t := &Throttle{jitter: jitter}
+	if rate > 0 {
+		t.interval = time.Duration(float64(time.Second) / rate)
+	}
+	return t
+}
+
+// Wait blocks until the next scrape may start, or ctx is done.
+func (t *Throttle) Wait(ctx context.Context) error {
+	delay := t.reserve(time.Now())
+	if t.jitter > 0 {
+		delay += rand.N(t.jitter)
+	}
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve reserves the next slot of the rate limit and returns how long to wait for it.
+func (t *Throttle) reserve(now time.Time) time.Duration {
+	if t.interval == 0 {
+		return 0
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	at := now
+	if t.next.After(now) {
+		at = t.next
+	}
+	t.next = at.Add(t.interval)
+	return at.Sub(now)
+}
@@ -0,0 +1,34 @@
+package scrape
+
+// Timeline keeps the last results of a session, to look back at the series of past scrapes. Unlike History, it
+// keeps every series, so its size is bounded by the memory the results take.
+type Timeline struct {
+	size    int
+	results []*Result
+}
+
+// NewTimeline creates a timeline keeping at most size results, at least the latest one.
+func NewTimeline(size int) *Timeline {
+	return &Timeline{size: max(size, 1)}
+}
+
+// Add records a result, dropping the oldest one once the timeline is full.
+func (t *Timeline) Add(r *Result) {
+	t.results = append(t.results, r)
+	if len(t.results) > t.size {
+		t.results = t.results[len(t.results)-t.size:]
+	}
+}
+
+// Len returns the number of results kept.
+func (t *Timeline) Len() int {
+	return len(t.results)
+}
+
+// At returns the result back scrapes before the latest one, 0 for the latest, nil when it is not kept.
+func (t *Timeline) At(back int) *Result {
+	if back < 0 || back >= len(t.results) {
+		return nil
+	}
+	return t.results[len(t.results)-1-back]
+}
@@ -0,0 +1,31 @@
+package scrape_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestTimeline(t *testing.T) {
+	t.Parallel()
+	tl := scrape.NewTimeline(3)
+	require.Nil(t, tl.At(0))
+
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		tl.Add(&scrape.Result{Time: start.Add(time.Duration(i) * time.Minute)})
+	}
+	require.Equal(t, 3, tl.Len(), "timeline should be capped to its size")
+	require.Equal(t, start.Add(3*time.Minute), tl.At(0).Time)
+	require.Equal(t, start.Add(time.Minute), tl.At(2).Time)
+	require.Nil(t, tl.At(3))
+	require.Nil(t, tl.At(-1))
+
+	latest := scrape.NewTimeline(0)
+	latest.Add(&scrape.Result{Time: start})
+	latest.Add(&scrape.Result{Time: start.Add(time.Minute)})
+	require.Equal(t, 1, latest.Len(), "the latest result should always be kept")
+}
@@ -0,0 +1,74 @@
+package scrape
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/timestamp"
+)
+
+// DefaultStaleAfter is the age above which a sample timestamp is reported as stale, the default lookback delta
+// of Prometheus: older samples do not show in instant queries.
+const DefaultStaleAfter = 5 * time.Minute
+
+// TimestampAge counts the series whose timestamp is up to an age.
+type TimestampAge struct {
+	// UpTo is the upper bound of the age, zero for the series older than every bound.
+	UpTo  model.Duration
+	Count int
+}
+
+// StaleSeries is a series whose timestamp is older than the stale threshold.
+type StaleSeries struct {
+	Series string
+	Age    time.Duration
+}
+
+// TimestampReport sums up the explicit timestamps of the series of a scrape. Targets exposing old timestamps,
+// e.g. a Pushgateway or a caching proxy serving stale data, have their samples silently dropped at ingestion
+// as out of bounds or out of order.
+type TimestampReport struct {
+	Series int
+	// WithTimestamp is the number of series exposed with an explicit timestamp.
+	WithTimestamp int
+	// Future is the number of series whose timestamp is ahead of the scrape time, they are not part of Ages.
+	Future int
+	// Ages is the distribution of the age of the timestamps at the time of the scrape.
+	Ages []TimestampAge
+	// Stale lists the series whose timestamp is older than the stale threshold, the oldest first.
+	Stale []StaleSeries
+}
+
+// TimestampAges analyzes the explicit timestamps of the series of a scrape taken at at, reporting the series
+// older than staleAfter as stale.
+func TimestampAges(sm SeriesMap, at time.Time, staleAfter time.Duration) TimestampReport {
+	report := TimestampReport{Ages: make([]TimestampAge, len(ageBuckets)+1)}
+	for i, upTo := range ageBuckets {
+		report.Ages[i].UpTo = upTo
+	}
+	for _, set := range sm {
+		for _, s := range set {
+			report.Series++
+			if s.Timestamp == 0 {
+				continue
+			}
+			report.WithTimestamp++
+			age := at.Sub(timestamp.Time(s.Timestamp))
+			if age < 0 {
+				report.Future++
+				continue
+			}
+			report.Ages[ageBucket(age)].Count++
+			if age > staleAfter {
+				report.Stale = append(report.Stale, StaleSeries{Series: s.Labels.String(), Age: age})
+			}
+		}
+	}
+	slices.SortFunc(report.Stale, func(a, b StaleSeries) int {
+		return cmp.Or(cmp.Compare(b.Age, a.Age), strings.Compare(a.Series, b.Series))
+	})
+	return report
+}
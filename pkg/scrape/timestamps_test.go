@@ -0,0 +1,42 @@
+package scrape_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestTimestampAges(t *testing.T) {
+	t.Parallel()
+	ps := scrape.NewPromScraper("", log.NewNopLogger())
+	result, err := ps.Parse([]byte(`# TYPE jobs_last_success gauge
+jobs_last_success{job="backup"} 1 1700000000000
+jobs_last_success{job="cleanup"} 1 1699996400000
+jobs_last_success{job="report"} 1 1699999000000
+jobs_last_success{job="future"} 1 1700000100000
+# TYPE up gauge
+up 1
+`), "text/plain; version=0.0.4")
+	require.NoError(t, err)
+
+	report := scrape.TimestampAges(result.Series, time.UnixMilli(1700000030000), scrape.DefaultStaleAfter)
+	require.Equal(t, 5, report.Series)
+	require.Equal(t, 4, report.WithTimestamp)
+	require.Equal(t, 1, report.Future)
+	require.Equal(t, []scrape.TimestampAge{
+		{UpTo: model.Duration(time.Minute), Count: 1},
+		{UpTo: model.Duration(5 * time.Minute), Count: 0},
+		{UpTo: model.Duration(15 * time.Minute), Count: 0},
+		{UpTo: model.Duration(time.Hour), Count: 1},
+		{Count: 1},
+	}, report.Ages)
+	require.Equal(t, []scrape.StaleSeries{
+		{Series: `{__name__="jobs_last_success", job="cleanup"}`, Age: time.Hour + 30*time.Second},
+		{Series: `{__name__="jobs_last_success", job="report"}`, Age: 1030 * time.Second},
+	}, report.Stale)
+}
@@ -0,0 +1,106 @@
+package scrape
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing is the time spent in the phases of a scrape request, as traced by net/http/httptrace. The phases that
+// did not happen are zero, e.g. TLS for plain HTTP, or DNS and connect when a connection was reused. The phases
+// do not overlap, Total also covers preparing the request and reading the body.
+type Timing struct {
+	DNS     time.Duration `json:"dns,omitempty"`
+	Connect time.Duration `json:"connect,omitempty"`
+	TLS     time.Duration `json:"tls,omitempty"`
+	// TTFB is the time from writing the whole request to the first byte of the response, i.e. how long the
+	// target took to render the exposition.
+	TTFB time.Duration `json:"ttfb,omitempty"`
+	// Total is the time from preparing the request to reading the whole body.
+	Total time.Duration `json:"total,omitempty"`
+}
+
+// String formats the phases of the request, e.g. "1.2s (DNS 1ms, connect 2ms, TLS 10ms, TTFB 1.1s)".
+func (t Timing) String() string {
+	return fmt.Sprintf("%s (DNS %s, connect %s, TLS %s, TTFB %s)", round(t.Total), round(t.DNS),
+		round(t.Connect), round(t.TLS), round(t.TTFB))
+}
+
+// SlowerThan reports whether the request took more than fraction of budget, e.g. half of the scrape timeout.
+func (t Timing) SlowerThan(budget time.Duration, fraction float64) bool {
+	return budget > 0 && t.Total > time.Duration(float64(budget)*fraction)
+}
+
+// round rounds a duration to a precision readable next to the others of a request.
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(100 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+// timingTrace records the Timing of a request.
+type timingTrace struct {
+	mtx                                          sync.Mutex
+	start                                        time.Time
+	dnsStart, connectStart, tlsStart, wroteStart time.Time
+	timing                                       Timing
+}
+
+// withTimingTrace returns a context tracing the request sent with it, started at start.
+func withTimingTrace(ctx context.Context, start time.Time) (context.Context, *timingTrace) {
+	t := &timingTrace{start: start}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.begin(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.end(&t.dnsStart, &t.timing.DNS) },
+		// Dual-stack hosts connect to several addresses concurrently, the phase ends with the first connection.
+		ConnectStart: func(string, string) { t.begin(&t.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.end(&t.connectStart, &t.timing.Connect)
+			}
+		},
+		TLSHandshakeStart: func() { t.begin(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { t.end(&t.tlsStart, &t.timing.TLS) },
+		// The request is written again when a reused connection was closed by the target, the last write counts.
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.wrote() },
+		GotFirstResponseByte: func() { t.end(&t.wroteStart, &t.timing.TTFB) },
+	}), t
+}
+
+func (t *timingTrace) begin(at *time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if at.IsZero() {
+		*at = time.Now()
+	}
+}
+
+func (t *timingTrace) wrote() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.wroteStart = time.Now()
+}
+
+func (t *timingTrace) end(start *time.Time, phase *time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if *phase == 0 && !start.IsZero() {
+		*phase = time.Since(*start)
+	}
+}
+
+// finish returns the timing of the request, ended now.
+func (t *timingTrace) finish() Timing {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.timing.Total = time.Since(t.start)
+	return t.timing
+}
@@ -0,0 +1,112 @@
+package scrape
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// Trend is how the cardinality of a metric family changed since a previous scrape.
+type Trend string
+
+const (
+	TrendNew       Trend = "new"
+	TrendGrew      Trend = "grew"
+	TrendShrank    Trend = "shrank"
+	TrendUnchanged Trend = "unchanged"
+)
+
+// FamilyTrend is the change of a metric family since a previous scrape.
+type FamilyTrend struct {
+	Trend Trend
+	// Previous is the cardinality of the family in the previous scrape, 0 for new families.
+	Previous int
+	// Delta is the current cardinality minus the previous one.
+	Delta int
+}
+
+// CompareFamilies returns the change of every family of cur since prev. Families of prev missing from cur are
+// returned by name in gone, sorted.
+func CompareFamilies(prev, cur SeriesMap) (trends map[string]FamilyTrend, gone []string) {
+	trends = make(map[string]FamilyTrend, len(cur))
+	for name, set := range cur {
+		cardinality := set.Cardinality()
+		before, ok := prev[name]
+		if !ok {
+			trends[name] = FamilyTrend{Trend: TrendNew, Delta: cardinality}
+			continue
+		}
+		t := FamilyTrend{Trend: TrendUnchanged, Previous: before.Cardinality()}
+		t.Delta = cardinality - t.Previous
+		switch {
+		case t.Delta > 0:
+			t.Trend = TrendGrew
+		case t.Delta < 0:
+			t.Trend = TrendShrank
+		}
+		trends[name] = t
+	}
+	for name := range prev {
+		if _, ok := cur[name]; !ok {
+			gone = append(gone, name)
+		}
+	}
+	slices.Sort(gone)
+	return trends, gone
+}
+
+// LabelValueChange is a label value carried by the series of a family in only one of two scrapes.
+type LabelValueChange struct {
+	Label string
+	Value string
+	// Previous and Current are the number of series carrying the value in the previous and the current scrape,
+	// one of them is 0.
+	Previous int
+	Current  int
+}
+
+// Appeared reports whether the value is new since the previous scrape, as opposed to gone.
+func (c LabelValueChange) Appeared() bool {
+	return c.Previous == 0
+}
+
+// CompareLabelValues returns the label values that appeared or disappeared from the series of a family between
+// prev and cur, sorted by label and value. The metric name is not compared.
+func CompareLabelValues(prev, cur SeriesSet) []LabelValueChange {
+	type labelValue struct{ label, value string }
+	values := make(map[labelValue]*LabelValueChange)
+	count := func(set SeriesSet, current bool) {
+		for _, s := range set {
+			s.Labels.Range(func(l labels.Label) {
+				if l.Name == labels.MetricName {
+					return
+				}
+				c, ok := values[labelValue{l.Name, l.Value}]
+				if !ok {
+					c = &LabelValueChange{Label: l.Name, Value: l.Value}
+					values[labelValue{l.Name, l.Value}] = c
+				}
+				if current {
+					c.Current++
+				} else {
+					c.Previous++
+				}
+			})
+		}
+	}
+	count(prev, false)
+	count(cur, true)
+
+	var changes []LabelValueChange
+	for _, c := range values {
+		if c.Previous == 0 || c.Current == 0 {
+			changes = append(changes, *c)
+		}
+	}
+	slices.SortFunc(changes, func(a, b LabelValueChange) int {
+		return cmp.Or(strings.Compare(a.Label, b.Label), strings.Compare(a.Value, b.Value))
+	})
+	return changes
+}
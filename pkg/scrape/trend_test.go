@@ -0,0 +1,69 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestCompareFamilies(t *testing.T) {
+	t.Parallel()
+	prev := scrape.SeriesMap{
+		"up":             replicaSet("up", 3),
+		"requests_total": replicaSet("requests_total", 10),
+		"errors_total":   replicaSet("errors_total", 5),
+		"old_metric":     replicaSet("old_metric", 1),
+	}
+	cur := scrape.SeriesMap{
+		"up":             replicaSet("up", 3),
+		"requests_total": replicaSet("requests_total", 12),
+		"errors_total":   replicaSet("errors_total", 2),
+		"new_metric":     replicaSet("new_metric", 4),
+	}
+
+	trends, gone := scrape.CompareFamilies(prev, cur)
+	require.Equal(t, map[string]scrape.FamilyTrend{
+		"up":             {Trend: scrape.TrendUnchanged, Previous: 3},
+		"requests_total": {Trend: scrape.TrendGrew, Previous: 10, Delta: 2},
+		"errors_total":   {Trend: scrape.TrendShrank, Previous: 5, Delta: -3},
+		"new_metric":     {Trend: scrape.TrendNew, Delta: 4},
+	}, trends)
+	require.Equal(t, []string{"old_metric"}, gone)
+}
+
+func TestCompareLabelValues(t *testing.T) {
+	t.Parallel()
+	set := func(lsets ...labels.Labels) scrape.SeriesSet {
+		s := make(scrape.SeriesSet, len(lsets))
+		for _, lset := range lsets {
+			s[lset.Hash()] = scrape.Series{Name: "requests_total", Labels: lset}
+		}
+		return s
+	}
+	prev := set(
+		labels.FromStrings("__name__", "requests_total", "path", "/", "pod", "api-1"),
+		labels.FromStrings("__name__", "requests_total", "path", "/login", "pod", "api-1"),
+		labels.FromStrings("__name__", "requests_total", "path", "/", "pod", "api-2"),
+	)
+	cur := set(
+		labels.FromStrings("__name__", "requests_total", "path", "/", "pod", "api-1"),
+		labels.FromStrings("__name__", "requests_total", "path", "/", "pod", "api-3"),
+		labels.FromStrings("__name__", "requests_total", "path", "/users/1", "pod", "api-3"),
+		labels.FromStrings("__name__", "requests_total", "path", "/users/2", "pod", "api-3"),
+	)
+
+	changes := scrape.CompareLabelValues(prev, cur)
+	require.Equal(t, []scrape.LabelValueChange{
+		{Label: "path", Value: "/login", Previous: 1},
+		{Label: "path", Value: "/users/1", Current: 1},
+		{Label: "path", Value: "/users/2", Current: 1},
+		{Label: "pod", Value: "api-2", Previous: 1},
+		{Label: "pod", Value: "api-3", Current: 3},
+	}, changes)
+	require.False(t, changes[0].Appeared())
+	require.True(t, changes[1].Appeared())
+	require.Empty(t, scrape.CompareLabelValues(prev, prev))
+}
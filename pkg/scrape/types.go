@@ -0,0 +1,106 @@
+package scrape
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MixedTypesRule is the lint rule reporting metric families whose series were parsed with different types.
+const MixedTypesRule = "mixed-types"
+
+// MixedTypeFamily is a metric family whose series were parsed with different types, e.g. a counter also exposed
+// untyped, or a histogram exposed both as classic and native histogram series. Prometheus handles such
+// families inconsistently across versions and exposition formats.
+type MixedTypeFamily struct {
+	Name string
+	// Types counts the series of the family of every type, "unknown" standing for the series without type.
+	Types map[string]int
+}
+
+// TypesString lists the types of the family with their number of series, e.g. "counter: 3, unknown: 1".
+func (f MixedTypeFamily) TypesString() string {
+	parts := make([]string, 0, len(f.Types))
+	for _, t := range slices.Sorted(maps.Keys(f.Types)) {
+		parts = append(parts, fmt.Sprintf("%s: %d", t, f.Types[t]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// histogramSuffixes are the suffixes of the series of classic histograms and summaries.
+var histogramSuffixes = []string{"_bucket", "_sum", "_count"}
+
+// MixedTypes returns the metric families whose series were parsed with different types, in name order. The
+// series of classic histograms and summaries are grouped with their family, so that e.g. the _bucket series
+// of a histogram also exposed as a native histogram are flagged.
+func (s SeriesMap) MixedTypes() []MixedTypeFamily {
+	// The families of histograms and summaries, whose series carry suffixes.
+	bases := make(map[string]struct{})
+	for name, set := range s {
+		for _, t := range set.Types() {
+			switch t {
+			case "native_histogram", "summary":
+				bases[name] = struct{}{}
+			}
+			if t != "histogram" && t != "summary" {
+				continue
+			}
+			for _, sfx := range histogramSuffixes {
+				if base, ok := strings.CutSuffix(name, sfx); ok {
+					bases[base] = struct{}{}
+				}
+			}
+		}
+	}
+
+	types := make(map[string]map[string]int)
+	for name, set := range s {
+		family := name
+		for _, sfx := range histogramSuffixes {
+			if base, ok := strings.CutSuffix(name, sfx); ok {
+				if _, ok := bases[base]; ok {
+					family = base
+				}
+				break
+			}
+		}
+		if types[family] == nil {
+			types[family] = make(map[string]int)
+		}
+		for _, v := range set {
+			t := v.Type
+			if t == "" {
+				t = "unknown"
+			}
+			types[family][t]++
+		}
+	}
+
+	var mixed []MixedTypeFamily
+	for _, name := range slices.Sorted(maps.Keys(types)) {
+		if len(types[name]) > 1 {
+			mixed = append(mixed, MixedTypeFamily{Name: name, Types: types[name]})
+		}
+	}
+	return mixed
+}
+
+// CheckMixedTypes reports the metric families whose series were parsed with different types, as warnings of
+// the lint check. There is one result per mixed family, in name order.
+func CheckMixedTypes(sm SeriesMap) []CheckResult {
+	mixed := sm.MixedTypes()
+	results := make([]CheckResult, 0, len(mixed))
+	for _, f := range mixed {
+		results = append(results, CheckResult{
+			Check:  LintCheck,
+			Metric: f.Name,
+			Problems: []Problem{{
+				Rule:     MixedTypesRule,
+				Severity: SeverityWarning,
+				Text:     fmt.Sprintf("series parsed with different types (%s)", f.TypesString()),
+			}},
+		})
+	}
+	return results
+}
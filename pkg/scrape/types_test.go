@@ -0,0 +1,50 @@
+package scrape_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pedro-stanaka/prom-scrape-analyzer/pkg/scrape"
+)
+
+func TestSeriesMap_MixedTypes(t *testing.T) {
+	t.Parallel()
+	series := func(typ, name string, lbls ...string) (uint64, scrape.Series) {
+		lset := labels.FromStrings(append([]string{labels.MetricName, name}, lbls...)...)
+		return lset.Hash(), scrape.Series{Name: name, Labels: lset, Type: typ}
+	}
+	sm := scrape.SeriesMap{}
+	add := func(typ, name string, lbls ...string) {
+		if sm[name] == nil {
+			sm[name] = scrape.SeriesSet{}
+		}
+		hash, s := series(typ, name, lbls...)
+		sm[name][hash] = s
+	}
+	add("counter", "requests_total", "code", "200")
+	add("counter", "requests_total", "code", "500")
+	add("", "requests_total", "code", "404")
+	add("native_histogram", "latency_seconds")
+	add("histogram", "latency_seconds_bucket", "le", "+Inf")
+	add("histogram", "latency_seconds_sum")
+	add("histogram", "size_bytes_bucket", "le", "+Inf")
+	add("histogram", "size_bytes_count")
+	add("counter", "events_count")
+	add("gauge", "up")
+
+	mixed := sm.MixedTypes()
+	require.Equal(t, []scrape.MixedTypeFamily{
+		{Name: "latency_seconds", Types: map[string]int{"histogram": 2, "native_histogram": 1}},
+		{Name: "requests_total", Types: map[string]int{"counter": 2, "unknown": 1}},
+	}, mixed)
+	require.Equal(t, "counter: 2, unknown: 1", mixed[1].TypesString())
+
+	results := scrape.CheckMixedTypes(sm)
+	require.Len(t, results, 2)
+	require.Equal(t, scrape.LintCheck, results[1].Check)
+	require.Equal(t, "requests_total", results[1].Metric)
+	require.Equal(t, scrape.MixedTypesRule, results[1].Problems[0].Rule)
+	require.False(t, results[1].Failed())
+}